@@ -0,0 +1,74 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"log"
+	"os"
+
+	"github.com/stocky/backend/internal/audit"
+)
+
+func newAuditService(env *environment) (*audit.Service, error) {
+	a := env.cfg.Audit
+	return audit.NewService(env.store, a.CheckpointFile, a.SigningKey, a.PublicKey)
+}
+
+// runVerifyChain walks the reward and ledger hash chains and checks them
+// against the signed checkpoints. It exits 1 on the first broken link or a
+// bad checkpoint.
+func runVerifyChain(ctx context.Context, env *environment, args []string) int {
+	fs := flag.NewFlagSet("verify-chain", flag.ExitOnError)
+	checkpoints := fs.String("checkpoints", env.cfg.Audit.CheckpointFile, "checkpoint file to verify against")
+	_ = fs.Parse(args)
+	env.cfg.Audit.CheckpointFile = *checkpoints
+
+	svc, err := newAuditService(env)
+	if err != nil {
+		log.Printf("audit: %v", err)
+		return 2
+	}
+	report, err := svc.Verify(ctx)
+	if err != nil {
+		log.Printf("verify chain: %v", err)
+		return 1
+	}
+
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(report); err != nil {
+		log.Printf("encode report: %v", err)
+		return 1
+	}
+	if !report.OK {
+		return 1
+	}
+	return 0
+}
+
+// runCheckpoint signs the current chain heads and appends them to the
+// checkpoint file.
+func runCheckpoint(ctx context.Context, env *environment, args []string) int {
+	fs := flag.NewFlagSet("checkpoint", flag.ExitOnError)
+	_ = fs.Parse(args)
+
+	svc, err := newAuditService(env)
+	if err != nil {
+		log.Printf("audit: %v", err)
+		return 2
+	}
+	cp, err := svc.Checkpoint(ctx)
+	if err != nil {
+		log.Printf("checkpoint: %v", err)
+		return 1
+	}
+
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(cp); err != nil {
+		log.Printf("encode checkpoint: %v", err)
+		return 1
+	}
+	return 0
+}
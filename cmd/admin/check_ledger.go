@@ -0,0 +1,35 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"log"
+	"os"
+
+	"github.com/stocky/backend/internal/service"
+)
+
+// runCheckLedger records and prints a trial balance as JSON. It exits 1 when
+// any ledger invariant is violated so it can gate scripts and cron alerts.
+func runCheckLedger(ctx context.Context, env *environment, args []string) int {
+	fs := flag.NewFlagSet("check-ledger", flag.ExitOnError)
+	_ = fs.Parse(args)
+
+	report, err := service.NewLedgerService(env.store).CheckLedger(ctx)
+	if err != nil {
+		log.Printf("check ledger: %v", err)
+		return 1
+	}
+
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(report); err != nil {
+		log.Printf("encode report: %v", err)
+		return 1
+	}
+	if !report.Balanced {
+		return 1
+	}
+	return 0
+}
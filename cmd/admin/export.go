@@ -0,0 +1,96 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/stocky/backend/internal/export"
+	"github.com/stocky/backend/internal/service"
+)
+
+// runExportLedger writes the journal for a date range in every format plus a
+// manifest.json into a directory, and prints the manifest.
+func runExportLedger(ctx context.Context, env *environment, args []string) int {
+	fs := flag.NewFlagSet("export-ledger", flag.ExitOnError)
+	fromRaw := fs.String("from", "", "first day or timestamp to export, inclusive (required)")
+	toRaw := fs.String("to", "", "day or timestamp to stop at, exclusive (required)")
+	dir := fs.String("dir", "export", "directory to write the files to")
+	company := fs.String("company", "", "Tally company to import into")
+	_ = fs.Parse(args)
+
+	query := service.ExportQuery{Company: *company}
+	var err error
+	if query.From, err = parseTime("from", *fromRaw); err == nil {
+		query.To, err = parseTime("to", *toRaw)
+	}
+	if err != nil {
+		log.Printf("export ledger: %v", err)
+		return 2
+	}
+
+	journal, err := service.NewLedgerService(env.store).Export(ctx, query)
+	if err != nil {
+		log.Printf("export ledger: %v", err)
+		return 1
+	}
+	manifest, err := journal.Manifest()
+	if err != nil {
+		log.Printf("export ledger: %v", err)
+		return 1
+	}
+	if err := os.MkdirAll(*dir, 0o755); err != nil {
+		log.Printf("export ledger: %v", err)
+		return 1
+	}
+	for _, format := range export.Formats {
+		if err := writeExport(filepath.Join(*dir, export.FileName(format)), journal, format); err != nil {
+			log.Printf("export ledger: %v", err)
+			return 1
+		}
+	}
+	raw, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		log.Printf("encode manifest: %v", err)
+		return 1
+	}
+	raw = append(raw, '\n')
+	if err := os.WriteFile(filepath.Join(*dir, "manifest.json"), raw, 0o644); err != nil {
+		log.Printf("export ledger: %v", err)
+		return 1
+	}
+	_, _ = os.Stdout.Write(raw)
+	return 0
+}
+
+func writeExport(path string, journal *export.Journal, format string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := journal.Write(f, format); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// parseTime accepts the same RFC 3339 timestamps and YYYY-MM-DD dates as the
+// HTTP API.
+func parseTime(name, raw string) (time.Time, error) {
+	if raw == "" {
+		return time.Time{}, fmt.Errorf("-%s is required", name)
+	}
+	if t, err := time.Parse(time.RFC3339, raw); err == nil {
+		return t, nil
+	}
+	if t, err := time.Parse(time.DateOnly, raw); err == nil {
+		return t, nil
+	}
+	return time.Time{}, fmt.Errorf("-%s must be an RFC 3339 timestamp or YYYY-MM-DD date", name)
+}
@@ -0,0 +1,87 @@
+// Command admin runs maintenance tasks against the backend selected by
+// DATABASE_URL.
+//
+//	go run ./cmd/admin rebuild-positions [-user UUID] [-symbol SYM] [-holdings] [-dry-run]
+//	go run ./cmd/admin check-ledger
+//	go run ./cmd/admin verify-chain [-checkpoints FILE]
+//	go run ./cmd/admin checkpoint
+//	go run ./cmd/admin close-period -actor NAME [-period YYYY-MM]
+//	go run ./cmd/admin export-ledger -from DATE -to DATE [-dir DIR] [-company NAME]
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/stocky/backend/internal/config"
+	"github.com/stocky/backend/internal/db"
+	"github.com/stocky/backend/internal/repository"
+)
+
+type command struct {
+	name    string
+	summary string
+	run     func(ctx context.Context, env *environment, args []string) int
+}
+
+// environment carries the dependencies shared by every command.
+type environment struct {
+	cfg   *config.Config
+	store repository.Store
+}
+
+var commands = []command{
+	{name: "rebuild-positions", summary: "replay reward history into user_positions (and daily_holdings)", run: runRebuildPositions},
+	{name: "check-ledger", summary: "verify ledger balances and stock units, record a trial balance", run: runCheckLedger},
+	{name: "verify-chain", summary: "walk the reward and ledger hash chains and report the first broken link", run: runVerifyChain},
+	{name: "checkpoint", summary: "sign the current hash chain heads into the checkpoint file", run: runCheckpoint},
+	{name: "close-period", summary: "snapshot closing balances and lock a month (default: last month)", run: runClosePeriod},
+	{name: "export-ledger", summary: "write ledger postings for a date range as CSV and Tally XML vouchers with a manifest", run: runExportLedger},
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "usage: admin <command> [flags]")
+	fmt.Fprintln(os.Stderr, "\ncommands:")
+	for _, cmd := range commands {
+		fmt.Fprintf(os.Stderr, "  %-20s %s\n", cmd.name, cmd.summary)
+	}
+}
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+		os.Exit(2)
+	}
+
+	var selected *command
+	for i := range commands {
+		if commands[i].name == os.Args[1] {
+			selected = &commands[i]
+		}
+	}
+	if selected == nil {
+		usage()
+		os.Exit(2)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+
+	cfg, err := config.Load()
+	if err != nil {
+		log.Fatalf("config: %v", err)
+	}
+
+	store, closeStore, err := db.Open(ctx, cfg.DatabaseURL)
+	if err != nil {
+		log.Fatalf("db connect: %v", err)
+	}
+
+	code := selected.run(ctx, &environment{cfg: cfg, store: store}, os.Args[2:])
+	closeStore(context.Background())
+	stop()
+	os.Exit(code)
+}
@@ -0,0 +1,38 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"log"
+	"os"
+	"time"
+
+	"github.com/stocky/backend/internal/repository"
+	"github.com/stocky/backend/internal/service"
+)
+
+// runClosePeriod closes a month, by default the one before the current, so
+// it can run from a scheduler on the first of each month.
+func runClosePeriod(ctx context.Context, env *environment, args []string) int {
+	start, _, _ := repository.PeriodBounds(repository.PeriodOf(time.Now()))
+	fs := flag.NewFlagSet("close-period", flag.ExitOnError)
+	period := fs.String("period", repository.PeriodOf(start.Add(-time.Nanosecond)), "period to close (YYYY-MM)")
+	actor := fs.String("actor", "", "who is closing the period (required)")
+	_ = fs.Parse(args)
+
+	svc := service.NewPeriodService(env.store, env.cfg.Ledger)
+	closed, err := svc.Close(ctx, *period, *actor)
+	if err != nil {
+		log.Printf("close period: %v", err)
+		return 1
+	}
+
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(closed); err != nil {
+		log.Printf("encode period: %v", err)
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"log"
+	"os"
+
+	"github.com/google/uuid"
+
+	"github.com/stocky/backend/internal/service"
+)
+
+// runRebuildPositions prints the rebuild report as JSON. In dry-run mode it
+// exits 1 when any projection has drifted so it can gate scripts.
+func runRebuildPositions(ctx context.Context, env *environment, args []string) int {
+	fs := flag.NewFlagSet("rebuild-positions", flag.ExitOnError)
+	user := fs.String("user", "", "only rebuild this user id")
+	symbol := fs.String("symbol", "", "only rebuild this symbol")
+	holdings := fs.Bool("holdings", false, "also rebuild daily_holdings from price history")
+	dryRun := fs.Bool("dry-run", false, "report differences without writing")
+	_ = fs.Parse(args)
+
+	opts := service.RebuildOptions{Symbol: *symbol, DryRun: *dryRun, Holdings: *holdings}
+	if *user != "" {
+		userID, err := uuid.Parse(*user)
+		if err != nil {
+			log.Printf("invalid -user: %v", err)
+			return 2
+		}
+		opts.UserID = userID
+	}
+
+	report, err := service.NewProjectionService(env.store, env.cfg.Fees).Rebuild(ctx, opts)
+	if err != nil {
+		log.Printf("rebuild: %v", err)
+		return 1
+	}
+
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(report); err != nil {
+		log.Printf("encode report: %v", err)
+		return 1
+	}
+	if report.DryRun && report.Drifted() {
+		return 1
+	}
+	return 0
+}
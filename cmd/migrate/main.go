@@ -0,0 +1,84 @@
+// Command migrate applies and inspects schema migrations for the backend
+// selected by DATABASE_URL.
+//
+//	go run ./cmd/migrate status
+//	go run ./cmd/migrate up
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/stocky/backend/internal/config"
+	"github.com/stocky/backend/internal/db"
+	"github.com/stocky/backend/internal/migrate"
+)
+
+func main() {
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "usage: migrate [up|status]\n")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	command := flag.Arg(0)
+	if command == "" {
+		command = "status"
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	cfg, err := config.Load()
+	if err != nil {
+		log.Fatalf("config: %v", err)
+	}
+
+	store, closeStore, err := db.Open(ctx, cfg.DatabaseURL)
+	if err != nil {
+		log.Fatalf("db connect: %v", err)
+	}
+	defer closeStore(context.Background())
+
+	migrator, err := migrate.ForStore(store)
+	if err != nil {
+		log.Fatalf("migrate: %v", err)
+	}
+
+	switch command {
+	case "status":
+		statuses, err := migrator.Status(ctx)
+		if err != nil {
+			log.Fatalf("status: %v", err)
+		}
+		for _, st := range statuses {
+			state := "pending"
+			if st.Applied() {
+				state = "applied " + st.AppliedAt.UTC().Format("2006-01-02T15:04:05Z")
+			}
+			fmt.Printf("%03d_%-32s %s\n", st.Version, st.Name, state)
+		}
+		if len(migrate.Pending(statuses)) > 0 {
+			os.Exit(1)
+		}
+	case "up":
+		applied, err := migrator.Up(ctx)
+		for _, st := range applied {
+			fmt.Printf("applied %03d_%s\n", st.Version, st.Name)
+		}
+		if err != nil {
+			log.Fatalf("up: %v", err)
+		}
+		if len(applied) == 0 {
+			fmt.Println("schema is up to date")
+		}
+	default:
+		flag.Usage()
+		os.Exit(2)
+	}
+}
@@ -7,12 +7,13 @@ import (
 	"syscall"
 	"time"
 
+	"github.com/stocky/backend/internal/audit"
 	"github.com/stocky/backend/internal/config"
 	"github.com/stocky/backend/internal/db"
 	apihttp "github.com/stocky/backend/internal/http"
 	"github.com/stocky/backend/internal/jobs"
+	"github.com/stocky/backend/internal/migrate"
 	"github.com/stocky/backend/internal/price"
-	"github.com/stocky/backend/internal/repository"
 	"github.com/stocky/backend/internal/server"
 	"github.com/stocky/backend/internal/service"
 )
@@ -26,26 +27,87 @@ func main() {
 		log.Fatalf("config: %v", err)
 	}
 
-	client, err := db.NewPool(ctx, cfg.DatabaseURL)
+	store, closeStore, err := db.Open(ctx, cfg.DatabaseURL)
 	if err != nil {
 		log.Fatalf("db connect: %v", err)
 	}
-	defer client.Disconnect(ctx)
+	defer closeStore(context.Background())
 
-	store := repository.New(client)
+	if cfg.RequireMigrations {
+		migrator, err := migrate.ForStore(store)
+		if err != nil {
+			log.Fatalf("migrate: %v", err)
+		}
+		if err := migrate.EnsureCurrent(ctx, migrator); err != nil {
+			log.Fatalf("migrate: %v", err)
+		}
+	}
 
-	priceFetcher := price.NewRandomFetcher(cfg.Price.RandomFloorPrice, cfg.Price.RandomCeilPrice)
-	priceSvc := price.NewService(store, priceFetcher)
-	rewardSvc := service.NewRewardService(store, priceSvc, cfg.Fees)
+	priceFetcher, err := price.NewFetcher(cfg.Price)
+	if err != nil {
+		log.Fatalf("price: %v", err)
+	}
+	priceSvc := price.NewService(store, priceFetcher, cfg.Price)
+	treasurySvc := service.NewTreasuryService(store, cfg.Treasury)
+	periodSvc := service.NewPeriodService(store, cfg.Ledger)
+	rewardSvc := service.NewRewardService(store, priceSvc, treasurySvc, periodSvc, cfg.Fees, cfg.Rewards, cfg.Price)
 	statsSvc := service.NewStatsService(store, priceSvc)
 	portfolioSvc := service.NewPortfolioService(store, priceSvc)
+	projectionSvc := service.NewProjectionService(store, cfg.Fees)
+	ledgerSvc := service.NewLedgerService(store)
+	actionSvc := service.NewCorporateActionService(store, projectionSvc)
+	dividendSvc := service.NewDividendService(store, projectionSvc, cfg.Corporate)
 
-	handler := apihttp.NewHandler(rewardSvc, statsSvc, portfolioSvc)
+	switch {
+	case cfg.AdminAuthDisabled:
+		log.Printf("ADMIN_AUTH_DISABLED is set; admin endpoints are unauthenticated")
+	case len(cfg.AdminPrincipals()) == 0:
+		log.Printf("neither ADMIN_TOKEN nor ADMIN_TOKENS is set; admin endpoints are disabled")
+	}
+	handler := apihttp.NewHandler(apihttp.Services{
+		Reward:     rewardSvc,
+		Stats:      statsSvc,
+		Portfolio:  portfolioSvc,
+		Projection: projectionSvc,
+		Ledger:     ledgerSvc,
+		Treasury:   treasurySvc,
+		Periods:    periodSvc,
+		Corporate:  actionSvc,
+		Dividends:  dividendSvc,
+	}, cfg.AdminPrincipals(), cfg.AdminAuthDisabled)
 	httpServer := server.New(cfg.HTTPPort, handler.Router())
 
 	priceJob := jobs.NewPriceSyncJob(cfg.Price.JobInterval, priceSvc, store)
 	go priceJob.Start(ctx)
 
+	auditSvc, err := audit.NewService(store, cfg.Audit.CheckpointFile, cfg.Audit.SigningKey, cfg.Audit.PublicKey)
+	if err != nil {
+		log.Fatalf("audit: %v", err)
+	}
+	switch {
+	case cfg.Audit.CheckpointInterval <= 0:
+	case !auditSvc.CanSign():
+		log.Printf("AUDIT_SIGNING_KEY is not set; hash chain checkpoints are disabled")
+	default:
+		checkpointJob := jobs.NewAuditCheckpointJob(cfg.Audit.CheckpointInterval, auditSvc)
+		go checkpointJob.Start(ctx)
+	}
+
+	if cfg.Ledger.CheckInterval > 0 {
+		ledgerJob := jobs.NewLedgerCheckJob(cfg.Ledger.CheckInterval, ledgerSvc)
+		go ledgerJob.Start(ctx)
+	}
+
+	if cfg.Corporate.ActionInterval > 0 {
+		actionJob := jobs.NewCorporateActionJob(cfg.Corporate.ActionInterval, actionSvc)
+		go actionJob.Start(ctx)
+	}
+
+	if cfg.Corporate.DividendInterval > 0 {
+		dividendJob := jobs.NewDividendJob(cfg.Corporate.DividendInterval, dividendSvc)
+		go dividendJob.Start(ctx)
+	}
+
 	go func() {
 		if err := httpServer.Start(); err != nil {
 			log.Printf("http server stopped: %v", err)
@@ -0,0 +1,269 @@
+// Package audit verifies the reward and ledger hash chains and keeps signed
+// checkpoints of their heads in a local file, so a rewrite of the database
+// that recomputes every hash is still caught against an earlier checkpoint.
+package audit
+
+import (
+	"bufio"
+	"context"
+	"crypto/ed25519"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/stocky/backend/internal/models"
+	"github.com/stocky/backend/internal/repository"
+)
+
+// Chains lists the chains that are verified and checkpointed.
+var Chains = []string{repository.ChainRewards, repository.ChainLedger}
+
+// Head is a chain's newest link at checkpoint time.
+type Head struct {
+	Chain string `json:"chain"`
+	Seq   int64  `json:"seq"`
+	Hash  string `json:"hash"`
+}
+
+// Checkpoint is one signed line of the checkpoint file.
+type Checkpoint struct {
+	CreatedAt time.Time `json:"createdAt"`
+	Heads     []Head    `json:"heads"`
+	PublicKey string    `json:"publicKey"`
+	Signature string    `json:"signature"`
+}
+
+// payload is the signed content: every field except the signature.
+func (c Checkpoint) payload() []byte {
+	var b strings.Builder
+	b.WriteString(c.CreatedAt.UTC().Format(time.RFC3339Nano))
+	for _, head := range c.Heads {
+		fmt.Fprintf(&b, "\n%s %d %s", head.Chain, head.Seq, head.Hash)
+	}
+	return []byte(b.String())
+}
+
+// ChainResult is the verification outcome of one chain.
+type ChainResult struct {
+	Chain     string                 `json:"chain"`
+	Records   int                    `json:"records"`
+	Unchained int                    `json:"unchained"`
+	Head      Head                   `json:"head"`
+	Break     *repository.ChainBreak `json:"break,omitempty"`
+}
+
+// Report is the outcome of Verify. OK is false when any chain is broken or
+// any checkpoint is invalid or contradicted.
+type Report struct {
+	VerifiedAt       time.Time     `json:"verifiedAt"`
+	OK               bool          `json:"ok"`
+	Chains           []ChainResult `json:"chains"`
+	Checkpoints      int           `json:"checkpoints"`
+	CheckpointErrors []string      `json:"checkpointErrors,omitempty"`
+}
+
+// Service verifies chains and writes checkpoints to path. key signs new
+// checkpoints; publicKey, when set, is the only key checkpoints may be
+// signed with.
+type Service struct {
+	repo      repository.Store
+	path      string
+	key       ed25519.PrivateKey
+	publicKey ed25519.PublicKey
+}
+
+// NewService builds the audit service. signingKey is a hex-encoded 32-byte
+// Ed25519 seed and may be empty, in which case Checkpoint fails. publicKey
+// is hex-encoded and defaults to the signing key's public half.
+func NewService(repo repository.Store, path, signingKey, publicKey string) (*Service, error) {
+	s := &Service{repo: repo, path: path}
+	if signingKey != "" {
+		seed, err := hex.DecodeString(signingKey)
+		if err != nil || len(seed) != ed25519.SeedSize {
+			return nil, fmt.Errorf("signing key must be %d hex-encoded bytes", ed25519.SeedSize)
+		}
+		s.key = ed25519.NewKeyFromSeed(seed)
+		s.publicKey = s.key.Public().(ed25519.PublicKey)
+	}
+	if publicKey != "" {
+		raw, err := hex.DecodeString(publicKey)
+		if err != nil || len(raw) != ed25519.PublicKeySize {
+			return nil, fmt.Errorf("public key must be %d hex-encoded bytes", ed25519.PublicKeySize)
+		}
+		if s.publicKey != nil && !s.publicKey.Equal(ed25519.PublicKey(raw)) {
+			return nil, errors.New("public key does not match the signing key")
+		}
+		s.publicKey = raw
+	}
+	return s, nil
+}
+
+// CanSign reports whether a signing key is configured.
+func (s *Service) CanSign() bool {
+	return s.key != nil
+}
+
+// Checkpoint signs the current chain heads and appends them to the
+// checkpoint file.
+func (s *Service) Checkpoint(ctx context.Context) (*Checkpoint, error) {
+	if s.key == nil {
+		return nil, errors.New("no signing key configured")
+	}
+	cp := Checkpoint{CreatedAt: time.Now().UTC(), PublicKey: hex.EncodeToString(s.publicKey)}
+	for _, chain := range Chains {
+		link, err := s.repo.ChainHead(ctx, chain)
+		if err != nil {
+			return nil, err
+		}
+		cp.Heads = append(cp.Heads, Head{Chain: chain, Seq: link.Seq, Hash: link.Hash})
+	}
+	cp.Signature = hex.EncodeToString(ed25519.Sign(s.key, cp.payload()))
+
+	line, err := json.Marshal(cp)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(filepath.Dir(s.path), 0o755); err != nil {
+		return nil, err
+	}
+	f, err := os.OpenFile(s.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := f.Write(append(line, '\n')); err != nil {
+		f.Close()
+		return nil, err
+	}
+	if err := f.Close(); err != nil {
+		return nil, err
+	}
+	return &cp, nil
+}
+
+// Verify walks every chain from the first chained record and checks each
+// link, and that every checkpointed head is still in the chain. Only the
+// first broken link per chain is reported.
+func (s *Service) Verify(ctx context.Context) (*Report, error) {
+	report := &Report{VerifiedAt: time.Now().UTC(), OK: true}
+
+	checkpoints, errs := s.loadCheckpoints()
+	report.Checkpoints = len(checkpoints)
+	report.CheckpointErrors = errs
+	expected := make(map[string]map[int64]string)
+	for _, cp := range checkpoints {
+		for _, head := range cp.Heads {
+			if head.Seq == 0 {
+				continue
+			}
+			if expected[head.Chain] == nil {
+				expected[head.Chain] = make(map[int64]string)
+			}
+			expected[head.Chain][head.Seq] = head.Hash
+		}
+	}
+
+	for _, chain := range Chains {
+		legacy, err := s.repo.LegacyRecords(ctx, chain)
+		if err != nil {
+			return nil, fmt.Errorf("walk %s: %w", chain, err)
+		}
+		verifier := &repository.ChainVerifier{Chain: chain, Legacy: legacy, Expected: expected[chain]}
+		err = s.repo.WalkChain(ctx, chain, verifier.Visit)
+		result := ChainResult{
+			Chain:     chain,
+			Records:   verifier.Records,
+			Unchained: verifier.Unchained,
+			Head:      Head{Chain: chain, Seq: verifier.Head.Seq, Hash: verifier.Head.Hash},
+		}
+		var brk *repository.ChainBreak
+		switch {
+		case errors.As(err, &brk):
+			result.Break = brk
+		case err != nil:
+			return nil, fmt.Errorf("walk %s: %w", chain, err)
+		default:
+			result.Break = truncation(chain, verifier.Head, expected[chain])
+		}
+		if result.Break != nil {
+			report.OK = false
+		}
+		report.Chains = append(report.Chains, result)
+	}
+	if len(report.CheckpointErrors) > 0 {
+		report.OK = false
+	}
+	return report, nil
+}
+
+// truncation reports a checkpointed sequence number beyond the end of the
+// chain, which means records were deleted from its tail.
+func truncation(chain string, head models.ChainLink, expected map[int64]string) *repository.ChainBreak {
+	for seq := range expected {
+		if seq > head.Seq {
+			return &repository.ChainBreak{
+				Chain:  chain,
+				Seq:    head.Seq + 1,
+				Reason: fmt.Sprintf("chain ends at seq %d but a checkpoint covers seq %d", head.Seq, seq),
+			}
+		}
+	}
+	return nil
+}
+
+// loadCheckpoints reads the checkpoint file, keeping only checkpoints whose
+// signature verifies. A missing file means no checkpoints yet.
+func (s *Service) loadCheckpoints() ([]Checkpoint, []string) {
+	f, err := os.Open(s.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, []string{err.Error()}
+	}
+	defer f.Close()
+
+	var (
+		checkpoints []Checkpoint
+		errs        []string
+	)
+	scanner := bufio.NewScanner(f)
+	for line := 1; scanner.Scan(); line++ {
+		if strings.TrimSpace(scanner.Text()) == "" {
+			continue
+		}
+		var cp Checkpoint
+		if err := json.Unmarshal(scanner.Bytes(), &cp); err != nil {
+			errs = append(errs, fmt.Sprintf("%s:%d: %v", s.path, line, err))
+			continue
+		}
+		if err := s.checkSignature(cp); err != nil {
+			errs = append(errs, fmt.Sprintf("%s:%d: %v", s.path, line, err))
+			continue
+		}
+		checkpoints = append(checkpoints, cp)
+	}
+	if err := scanner.Err(); err != nil {
+		errs = append(errs, err.Error())
+	}
+	return checkpoints, errs
+}
+
+func (s *Service) checkSignature(cp Checkpoint) error {
+	key, err := hex.DecodeString(cp.PublicKey)
+	if err != nil || len(key) != ed25519.PublicKeySize {
+		return errors.New("malformed public key")
+	}
+	if s.publicKey != nil && !s.publicKey.Equal(ed25519.PublicKey(key)) {
+		return errors.New("signed with an unexpected key")
+	}
+	sig, err := hex.DecodeString(cp.Signature)
+	if err != nil || !ed25519.Verify(key, cp.payload(), sig) {
+		return errors.New("invalid signature")
+	}
+	return nil
+}
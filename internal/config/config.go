@@ -5,28 +5,188 @@ import (
 	"fmt"
 	"os"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/joho/godotenv"
+	"github.com/shopspring/decimal"
+
+	"github.com/stocky/backend/internal/fees"
 )
 
 // Config captures application level settings loaded from the environment.
 type Config struct {
 	HTTPPort    string
 	DatabaseURL string
-	Fees        FeeConfig
-	Price       PriceConfig
+	// RequireMigrations makes the server refuse to boot while schema
+	// migrations are pending.
+	RequireMigrations bool
+	// AdminToken and AdminTokens protect the admin endpoints. AdminToken
+	// authenticates as "admin"; AdminTokens maps further tokens to the
+	// name recorded for them in audit trails. Without any token the
+	// endpoints answer 503, unless AdminAuthDisabled opens them for local
+	// development.
+	AdminToken        string
+	AdminTokens       map[string]string
+	AdminAuthDisabled bool
+	Fees              FeeConfig
+	Price             PriceConfig
+	Rewards           RewardConfig
+	Ledger            LedgerConfig
+	Audit             AuditConfig
+	Treasury          TreasuryConfig
+	Corporate         CorporateConfig
 }
 
 type FeeConfig struct {
 	BrokerageBps int
 	TaxBps       int
+	// CapitalizeFees adds brokerage and taxes to the position's cost basis
+	// instead of treating them purely as company expense.
+	CapitalizeFees bool
+	// ScheduleFile is a JSON file of per-exchange, dated fee schedules. When
+	// empty, BrokerageBps and TaxBps are used as a flat schedule.
+	ScheduleFile string
+	// Schedule is the engine built from ScheduleFile or the flat rates.
+	Schedule *fees.Engine
+}
+
+type RewardConfig struct {
+	// BatchLimit caps the number of items accepted by POST /rewards/batch.
+	BatchLimit int
+	// ShareRounding is how shares bought with an INR amount are rounded to
+	// ShareDecimals places: "down" (default), "up" or "half_up".
+	ShareRounding string
+	ShareDecimals int32
+	// AmountIncludesFees treats an INR amount as the total cash out, so
+	// brokerage and tax come out of it; otherwise it buys shares and fees
+	// are added on top.
+	AmountIncludesFees bool
+}
+
+type LedgerConfig struct {
+	// CheckInterval is how often the server runs the ledger invariant
+	// checker; zero disables the schedule.
+	CheckInterval time.Duration
+	// LockedPeriodPolicy decides what happens to a reward dated inside a
+	// closed period: "reject" (default) refuses it, "repost" books it in the
+	// open period with a memo naming the original date.
+	LockedPeriodPolicy string
+}
+
+// Locked period policies for LedgerConfig.LockedPeriodPolicy.
+const (
+	LockedReject = "reject"
+	LockedRepost = "repost"
+)
+
+type AuditConfig struct {
+	// CheckpointFile is the local file signed chain checkpoints are appended
+	// to.
+	CheckpointFile string
+	// CheckpointInterval is how often the server writes a checkpoint; zero
+	// disables it. Checkpoints also need SigningKey.
+	CheckpointInterval time.Duration
+	// SigningKey is a hex-encoded Ed25519 seed used to sign checkpoints.
+	SigningKey string
+	// PublicKey is the hex-encoded key checkpoints must be signed with; it
+	// defaults to the public half of SigningKey.
+	PublicKey string
+}
+
+type TreasuryConfig struct {
+	// OverdraftPolicy decides whether a reward may take the cash account
+	// below zero: "allow" (default) books it anyway, "reject" refuses it.
+	OverdraftPolicy string
+	// LowBalanceInr raises an alert when a reward takes the cash balance
+	// below it; zero disables alerts.
+	LowBalanceInr decimal.Decimal
 }
 
+type CorporateConfig struct {
+	// ActionInterval is how often the server applies corporate actions
+	// whose ex-date has arrived; zero disables the schedule.
+	ActionInterval time.Duration
+	// DividendInterval is how often the server pays dividends whose pay
+	// date has arrived; zero disables the schedule.
+	DividendInterval time.Duration
+	// DefaultTDSRate is the TDS percentage used when an announcement does
+	// not give one.
+	DefaultTDSRate decimal.Decimal
+}
+
+// Overdraft policies for TreasuryConfig.OverdraftPolicy.
+const (
+	OverdraftAllow  = "allow"
+	OverdraftReject = "reject"
+)
+
+// Share rounding modes for RewardConfig.ShareRounding.
+const (
+	RoundDown   = "down"
+	RoundUp     = "up"
+	RoundHalfUp = "half_up"
+)
+
 type PriceConfig struct {
-	JobInterval      time.Duration
+	JobInterval time.Duration
+	// Provider selects the quote source: "random" (default) draws prices
+	// between RandomFloorPrice and RandomCeilPrice, "http" calls the
+	// endpoint described by HTTP.
+	Provider         string
 	RandomFloorPrice float64
 	RandomCeilPrice  float64
+	HTTP             HTTPPriceConfig
+	// FetchConcurrency caps how many quotes are fetched at once when a
+	// provider without batch support is asked for many symbols.
+	FetchConcurrency int
+	// MaxQuoteAge is the oldest quote a reward may be booked at before a
+	// synchronous refresh is attempted; zero disables the check.
+	MaxQuoteAge time.Duration
+	// StalePolicy decides what happens when that refresh fails: "reject"
+	// (default) refuses the reward, "flag" books it with priceStale set.
+	StalePolicy string
+}
+
+// Stale quote policies for PriceConfig.StalePolicy.
+const (
+	StaleReject = "reject"
+	StaleFlag   = "flag"
+)
+
+// Quote providers for PriceConfig.Provider.
+const (
+	ProviderRandom = "random"
+	ProviderHTTP   = "http"
+)
+
+// HTTPPriceConfig describes a JSON quote endpoint.
+type HTTPPriceConfig struct {
+	// URLTemplate is the quote URL with {symbol} where the symbol goes.
+	URLTemplate string
+	// AuthHeader and AuthValue are sent with every request when AuthHeader
+	// is set, e.g. "Authorization" and "Bearer <key>".
+	AuthHeader string
+	AuthValue  string
+	// PricePath and TimePath are dot-separated paths into the response,
+	// e.g. "data.lastPrice"; array elements are addressed by index. Without
+	// TimePath a quote is stamped with the time it was received.
+	PricePath string
+	TimePath  string
+	// Timeout bounds each attempt; a failed attempt is retried up to
+	// Retries times, waiting Backoff and then doubling it.
+	Timeout time.Duration
+	Retries int
+	Backoff time.Duration
+	// BatchURLTemplate, when set, is a URL with {symbols} where a
+	// comma-separated list of up to BatchSize symbols goes. BatchItemsPath
+	// locates the quotes in its response: an object keyed by symbol, or a
+	// list whose items name their symbol at SymbolPath. PricePath and
+	// TimePath are then read from each item.
+	BatchURLTemplate string
+	BatchItemsPath   string
+	SymbolPath       string
+	BatchSize        int
 }
 
 // Load parses environment variables into Config and falls back to sensible defaults
@@ -35,16 +195,63 @@ func Load() (*Config, error) {
 	_ = godotenv.Load(".env")
 
 	cfg := &Config{
-		HTTPPort:    getEnv("PORT", "8080"),
-		DatabaseURL: os.Getenv("DATABASE_URL"),
+		HTTPPort:          getEnv("PORT", "8080"),
+		DatabaseURL:       os.Getenv("DATABASE_URL"),
+		RequireMigrations: getBool("REQUIRE_MIGRATIONS", false),
+		AdminToken:        os.Getenv("ADMIN_TOKEN"),
+		AdminAuthDisabled: getBool("ADMIN_AUTH_DISABLED", false),
 		Fees: FeeConfig{
-			BrokerageBps: getInt("BROKERAGE_BPS", 40), // 0.40%
-			TaxBps:       getInt("TAX_BPS", 35),       // 0.35%
+			BrokerageBps:   getInt("BROKERAGE_BPS", 40), // 0.40%
+			TaxBps:         getInt("TAX_BPS", 35),       // 0.35%
+			CapitalizeFees: getBool("CAPITALIZE_FEES", false),
+			ScheduleFile:   os.Getenv("FEE_SCHEDULE_FILE"),
 		},
 		Price: PriceConfig{
 			JobInterval:      getDuration("PRICE_JOB_INTERVAL", time.Hour),
+			Provider:         getEnv("PRICE_PROVIDER", ProviderRandom),
 			RandomFloorPrice: getFloat("PRICE_RANDOM_FLOOR", 1200.0),
 			RandomCeilPrice:  getFloat("PRICE_RANDOM_CEIL", 3200.0),
+			HTTP: HTTPPriceConfig{
+				URLTemplate:      os.Getenv("PRICE_HTTP_URL"),
+				AuthHeader:       os.Getenv("PRICE_HTTP_AUTH_HEADER"),
+				AuthValue:        os.Getenv("PRICE_HTTP_AUTH_VALUE"),
+				PricePath:        os.Getenv("PRICE_HTTP_PRICE_PATH"),
+				TimePath:         os.Getenv("PRICE_HTTP_TIME_PATH"),
+				Timeout:          getDuration("PRICE_HTTP_TIMEOUT", 5*time.Second),
+				Retries:          getInt("PRICE_HTTP_RETRIES", 2),
+				Backoff:          getDuration("PRICE_HTTP_BACKOFF", 250*time.Millisecond),
+				BatchURLTemplate: os.Getenv("PRICE_HTTP_BATCH_URL"),
+				BatchItemsPath:   os.Getenv("PRICE_HTTP_BATCH_ITEMS_PATH"),
+				SymbolPath:       os.Getenv("PRICE_HTTP_SYMBOL_PATH"),
+				BatchSize:        getInt("PRICE_HTTP_BATCH_SIZE", 50),
+			},
+			FetchConcurrency: getInt("PRICE_FETCH_CONCURRENCY", 8),
+			MaxQuoteAge:      getDuration("PRICE_MAX_AGE", 0),
+			StalePolicy:      getEnv("PRICE_STALE_POLICY", StaleReject),
+		},
+		Rewards: RewardConfig{
+			BatchLimit:         getInt("REWARD_BATCH_LIMIT", 500),
+			ShareRounding:      getEnv("REWARD_SHARE_ROUNDING", RoundDown),
+			ShareDecimals:      int32(getInt("REWARD_SHARE_DECIMALS", 6)),
+			AmountIncludesFees: getBool("REWARD_AMOUNT_INCLUDES_FEES", false),
+		},
+		Ledger: LedgerConfig{
+			CheckInterval:      getDuration("LEDGER_CHECK_INTERVAL", 24*time.Hour),
+			LockedPeriodPolicy: getEnv("LEDGER_LOCKED_PERIOD_POLICY", LockedReject),
+		},
+		Audit: AuditConfig{
+			CheckpointFile:     getEnv("AUDIT_CHECKPOINT_FILE", "data/audit_checkpoints.jsonl"),
+			CheckpointInterval: getDuration("AUDIT_CHECKPOINT_INTERVAL", time.Hour),
+			SigningKey:         os.Getenv("AUDIT_SIGNING_KEY"),
+			PublicKey:          os.Getenv("AUDIT_PUBLIC_KEY"),
+		},
+		Treasury: TreasuryConfig{
+			OverdraftPolicy: getEnv("TREASURY_OVERDRAFT_POLICY", OverdraftAllow),
+		},
+		Corporate: CorporateConfig{
+			ActionInterval:   getDuration("CORPORATE_ACTION_INTERVAL", time.Hour),
+			DividendInterval: getDuration("DIVIDEND_INTERVAL", time.Hour),
+			DefaultTDSRate:   decimal.NewFromInt(10),
 		},
 	}
 
@@ -52,6 +259,33 @@ func Load() (*Config, error) {
 		return nil, errors.New("DATABASE_URL is required")
 	}
 
+	if cfg.Fees.ScheduleFile != "" {
+		engine, err := fees.Load(cfg.Fees.ScheduleFile)
+		if err != nil {
+			return nil, fmt.Errorf("FEE_SCHEDULE_FILE: %w", err)
+		}
+		cfg.Fees.Schedule = engine
+	} else {
+		if cfg.Fees.BrokerageBps < 0 || cfg.Fees.TaxBps < 0 {
+			return nil, errors.New("BROKERAGE_BPS and TAX_BPS must not be negative")
+		}
+		cfg.Fees.Schedule = fees.Flat(cfg.Fees.BrokerageBps, cfg.Fees.TaxBps)
+	}
+
+	tokens, err := parseAdminTokens(os.Getenv("ADMIN_TOKENS"))
+	if err != nil {
+		return nil, err
+	}
+	cfg.AdminTokens = tokens
+	if cfg.AdminToken != "" {
+		if _, ok := tokens[cfg.AdminToken]; ok {
+			return nil, errors.New("ADMIN_TOKEN is also listed in ADMIN_TOKENS")
+		}
+	}
+	if cfg.AdminAuthDisabled && len(cfg.AdminPrincipals()) > 0 {
+		return nil, errors.New("ADMIN_AUTH_DISABLED cannot be combined with ADMIN_TOKEN or ADMIN_TOKENS")
+	}
+
 	if cfg.Price.RandomFloorPrice <= 0 || cfg.Price.RandomCeilPrice <= 0 {
 		return nil, errors.New("invalid random price bounds configured")
 	}
@@ -60,9 +294,109 @@ func Load() (*Config, error) {
 		return nil, errors.New("PRICE_RANDOM_CEIL must be greater than PRICE_RANDOM_FLOOR")
 	}
 
+	if cfg.Price.StalePolicy != StaleReject && cfg.Price.StalePolicy != StaleFlag {
+		return nil, fmt.Errorf("PRICE_STALE_POLICY must be %q or %q", StaleReject, StaleFlag)
+	}
+
+	if cfg.Price.FetchConcurrency <= 0 {
+		return nil, errors.New("PRICE_FETCH_CONCURRENCY must be positive")
+	}
+
+	switch cfg.Price.Provider {
+	case ProviderRandom:
+	case ProviderHTTP:
+		if err := cfg.Price.HTTP.validate(); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("PRICE_PROVIDER must be %q or %q", ProviderRandom, ProviderHTTP)
+	}
+
+	if cfg.Rewards.BatchLimit <= 0 {
+		return nil, errors.New("REWARD_BATCH_LIMIT must be positive")
+	}
+
+	switch cfg.Rewards.ShareRounding {
+	case RoundDown, RoundUp, RoundHalfUp:
+	default:
+		return nil, fmt.Errorf("REWARD_SHARE_ROUNDING must be %q, %q or %q", RoundDown, RoundUp, RoundHalfUp)
+	}
+
+	// Quantities are stored as NUMERIC(18,6).
+	if cfg.Rewards.ShareDecimals < 0 || cfg.Rewards.ShareDecimals > 6 {
+		return nil, errors.New("REWARD_SHARE_DECIMALS must be between 0 and 6")
+	}
+
+	if cfg.Audit.CheckpointInterval < 0 {
+		return nil, errors.New("AUDIT_CHECKPOINT_INTERVAL must not be negative")
+	}
+
+	if cfg.Ledger.CheckInterval < 0 {
+		return nil, errors.New("LEDGER_CHECK_INTERVAL must not be negative")
+	}
+	if cfg.Ledger.LockedPeriodPolicy != LockedReject && cfg.Ledger.LockedPeriodPolicy != LockedRepost {
+		return nil, fmt.Errorf("LEDGER_LOCKED_PERIOD_POLICY must be %q or %q", LockedReject, LockedRepost)
+	}
+
+	if cfg.Treasury.OverdraftPolicy != OverdraftAllow && cfg.Treasury.OverdraftPolicy != OverdraftReject {
+		return nil, fmt.Errorf("TREASURY_OVERDRAFT_POLICY must be %q or %q", OverdraftAllow, OverdraftReject)
+	}
+
+	if cfg.Corporate.ActionInterval < 0 {
+		return nil, errors.New("CORPORATE_ACTION_INTERVAL must not be negative")
+	}
+	if cfg.Corporate.DividendInterval < 0 {
+		return nil, errors.New("DIVIDEND_INTERVAL must not be negative")
+	}
+	if raw := os.Getenv("DIVIDEND_TDS_RATE_PCT"); raw != "" {
+		rate, err := decimal.NewFromString(raw)
+		if err != nil || rate.IsNegative() || rate.GreaterThan(decimal.NewFromInt(100)) {
+			return nil, errors.New("DIVIDEND_TDS_RATE_PCT must be a percentage between 0 and 100")
+		}
+		cfg.Corporate.DefaultTDSRate = rate
+	}
+
+	if raw := os.Getenv("TREASURY_LOW_BALANCE_INR"); raw != "" {
+		threshold, err := decimal.NewFromString(raw)
+		if err != nil || threshold.IsNegative() {
+			return nil, errors.New("TREASURY_LOW_BALANCE_INR must be a non-negative amount")
+		}
+		cfg.Treasury.LowBalanceInr = threshold
+	}
+
 	return cfg, nil
 }
 
+func (c HTTPPriceConfig) validate() error {
+	if !strings.Contains(c.URLTemplate, "{symbol}") {
+		return errors.New("PRICE_HTTP_URL must contain {symbol}")
+	}
+	if c.PricePath == "" {
+		return errors.New("PRICE_HTTP_PRICE_PATH is required")
+	}
+	if c.AuthValue != "" && c.AuthHeader == "" {
+		return errors.New("PRICE_HTTP_AUTH_VALUE needs PRICE_HTTP_AUTH_HEADER")
+	}
+	if c.Timeout <= 0 {
+		return errors.New("PRICE_HTTP_TIMEOUT must be positive")
+	}
+	if c.Retries < 0 {
+		return errors.New("PRICE_HTTP_RETRIES must not be negative")
+	}
+	if c.Backoff < 0 {
+		return errors.New("PRICE_HTTP_BACKOFF must not be negative")
+	}
+	if c.BatchURLTemplate != "" {
+		if !strings.Contains(c.BatchURLTemplate, "{symbols}") {
+			return errors.New("PRICE_HTTP_BATCH_URL must contain {symbols}")
+		}
+		if c.BatchSize <= 0 {
+			return errors.New("PRICE_HTTP_BATCH_SIZE must be positive")
+		}
+	}
+	return nil
+}
+
 func getEnv(key, fallback string) string {
 	if value := os.Getenv(key); value != "" {
 		return value
@@ -82,6 +416,53 @@ func getInt(key string, fallback int) int {
 	return parsed
 }
 
+// AdminPrincipals maps every configured admin token to the name it
+// authenticates as.
+func (c *Config) AdminPrincipals() map[string]string {
+	principals := make(map[string]string, len(c.AdminTokens)+1)
+	for token, name := range c.AdminTokens {
+		principals[token] = name
+	}
+	if c.AdminToken != "" {
+		principals[c.AdminToken] = "admin"
+	}
+	return principals
+}
+
+// parseAdminTokens reads ADMIN_TOKENS, a comma-separated list of name:token
+// pairs, into a token-to-name map.
+func parseAdminTokens(raw string) (map[string]string, error) {
+	tokens := make(map[string]string)
+	for _, pair := range strings.Split(raw, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+		name, token, ok := strings.Cut(pair, ":")
+		name, token = strings.TrimSpace(name), strings.TrimSpace(token)
+		if !ok || name == "" || token == "" {
+			return nil, errors.New("ADMIN_TOKENS must be a comma-separated list of name:token pairs")
+		}
+		if _, dup := tokens[token]; dup {
+			return nil, fmt.Errorf("ADMIN_TOKENS lists the token for %q more than once", name)
+		}
+		tokens[token] = name
+	}
+	return tokens, nil
+}
+
+func getBool(key string, fallback bool) bool {
+	val := os.Getenv(key)
+	if val == "" {
+		return fallback
+	}
+	parsed, err := strconv.ParseBool(val)
+	if err != nil {
+		return fallback
+	}
+	return parsed
+}
+
 func getFloat(key string, fallback float64) float64 {
 	val := os.Getenv(key)
 	if val == "" {
@@ -116,5 +497,8 @@ func bpsToPercent(bps int) float64 {
 }
 
 func (cfg FeeConfig) String() string {
-	return fmt.Sprintf("brokerage=%dbps tax=%dbps", cfg.BrokerageBps, cfg.TaxBps)
+	if cfg.ScheduleFile != "" {
+		return fmt.Sprintf("schedule=%s capitalize=%t", cfg.ScheduleFile, cfg.CapitalizeFees)
+	}
+	return fmt.Sprintf("brokerage=%dbps tax=%dbps capitalize=%t", cfg.BrokerageBps, cfg.TaxBps, cfg.CapitalizeFees)
 }
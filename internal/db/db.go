@@ -4,8 +4,11 @@ import (
 	"context"
 	"time"
 
+	"github.com/jackc/pgx/v5/pgxpool"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/stocky/backend/internal/repository"
 )
 
 // NewPool creates a MongoDB client connection.
@@ -13,7 +16,8 @@ func NewPool(ctx context.Context, connURL string) (*mongo.Client, error) {
 	opts := options.Client().
 		ApplyURI(connURL).
 		SetMaxPoolSize(8).
-		SetMinPoolSize(1)
+		SetMinPoolSize(1).
+		SetRegistry(repository.Registry())
 
 	ctx, cancel := context.WithTimeout(ctx, 15*time.Second)
 	defer cancel()
@@ -35,3 +39,26 @@ func NewPool(ctx context.Context, connURL string) (*mongo.Client, error) {
 func GetDatabase(client *mongo.Client) *mongo.Database {
 	return client.Database("stocky")
 }
+
+// NewPostgresPool creates a pgx connection pool and verifies connectivity.
+func NewPostgresPool(ctx context.Context, connURL string) (*pgxpool.Pool, error) {
+	cfg, err := pgxpool.ParseConfig(connURL)
+	if err != nil {
+		return nil, err
+	}
+	cfg.MaxConns = 8
+	cfg.MinConns = 1
+
+	ctx, cancel := context.WithTimeout(ctx, 15*time.Second)
+	defer cancel()
+
+	pool, err := pgxpool.NewWithConfig(ctx, cfg)
+	if err != nil {
+		return nil, err
+	}
+	if err := pool.Ping(ctx); err != nil {
+		pool.Close()
+		return nil, err
+	}
+	return pool, nil
+}
@@ -0,0 +1,46 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+
+	"github.com/stocky/backend/internal/repository"
+	"github.com/stocky/backend/internal/repository/memory"
+	"github.com/stocky/backend/internal/repository/postgres"
+)
+
+// CloseFunc releases the resources held by a store.
+type CloseFunc func(ctx context.Context) error
+
+// Open selects the storage backend from the DATABASE_URL scheme:
+// mongodb:// and mongodb+srv:// connect to MongoDB, postgres:// and
+// postgresql:// connect to PostgreSQL, memory:// keeps all state in process.
+func Open(ctx context.Context, connURL string) (repository.Store, CloseFunc, error) {
+	parsed, err := url.Parse(connURL)
+	if err != nil {
+		return nil, nil, fmt.Errorf("parse database url: %w", err)
+	}
+
+	switch parsed.Scheme {
+	case "mongodb", "mongodb+srv":
+		client, err := NewPool(ctx, connURL)
+		if err != nil {
+			return nil, nil, err
+		}
+		return repository.New(client), client.Disconnect, nil
+	case "postgres", "postgresql":
+		pool, err := NewPostgresPool(ctx, connURL)
+		if err != nil {
+			return nil, nil, err
+		}
+		return postgres.New(pool), func(context.Context) error {
+			pool.Close()
+			return nil
+		}, nil
+	case "memory":
+		return memory.New(), func(context.Context) error { return nil }, nil
+	default:
+		return nil, nil, fmt.Errorf("unsupported database scheme %q", parsed.Scheme)
+	}
+}
@@ -0,0 +1,49 @@
+package export
+
+import (
+	"encoding/csv"
+	"io"
+	"strconv"
+	"time"
+)
+
+var csvHeader = []string{
+	"voucher_no", "date", "event_id", "narration", "line", "account_code", "account_type",
+	"symbol", "debit_inr", "credit_inr", "stock_units", "memo", "posting_id", "created_at",
+}
+
+// WriteCSV writes one row per posting, voucher by voucher. Dates are IST
+// calendar dates; created_at is the exact UTC posting time.
+func (j *Journal) WriteCSV(w io.Writer) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(csvHeader); err != nil {
+		return err
+	}
+	for _, voucher := range j.Vouchers {
+		number := strconv.Itoa(voucher.Number)
+		date := voucher.Date.In(ist).Format(time.DateOnly)
+		for i, line := range voucher.Lines {
+			err := cw.Write([]string{
+				number,
+				date,
+				voucher.EventID.String(),
+				voucher.Narration,
+				strconv.Itoa(i + 1),
+				line.AccountCode,
+				line.AccountType,
+				line.Symbol,
+				amount(line.DebitInr),
+				amount(line.CreditInr),
+				line.StockUnits.String(),
+				line.Memo,
+				line.ID,
+				line.CreatedAt.UTC().Format(time.RFC3339Nano),
+			})
+			if err != nil {
+				return err
+			}
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
@@ -0,0 +1,189 @@
+// Package export renders ledger postings as double-entry journal vouchers
+// for accounting systems: CSV for spreadsheets and ERP loaders, and XML that
+// Tally imports. Output depends only on the postings, so exporting the same
+// range twice yields identical files, and the manifest carries the totals
+// and checksums an importer can verify against.
+package export
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+
+	"github.com/stocky/backend/internal/models"
+)
+
+// Export formats.
+const (
+	FormatCSV   = "csv"
+	FormatTally = "tally"
+)
+
+// Formats lists every format, in the order the manifest reports them.
+var Formats = []string{FormatCSV, FormatTally}
+
+// ist is the zone voucher dates are written in.
+var ist = time.FixedZone("IST", 5*3600+1800)
+
+// Voucher is the postings of one reward, adjustment or funding. Number is
+// its position in the export, starting at 1.
+type Voucher struct {
+	Number    int
+	EventID   uuid.UUID
+	Date      time.Time
+	Narration string
+	Lines     []models.LedgerPosting
+}
+
+// Journal is the vouchers of postings created in [From, To). Company names
+// the Tally company to import into and may be empty.
+type Journal struct {
+	From     time.Time
+	To       time.Time
+	Company  string
+	Vouchers []Voucher
+}
+
+// NewJournal groups postings, which must be ordered by created_at and id,
+// into one voucher per event. Vouchers are ordered by their first posting
+// and keep their lines in posting order; the narration is the memo of the
+// first line.
+func NewJournal(from, to time.Time, company string, postings []models.LedgerPosting) *Journal {
+	j := &Journal{From: from, To: to, Company: company}
+	index := make(map[uuid.UUID]int)
+	for _, posting := range postings {
+		i, ok := index[posting.EventID]
+		if !ok {
+			i = len(j.Vouchers)
+			index[posting.EventID] = i
+			j.Vouchers = append(j.Vouchers, Voucher{
+				Number:    i + 1,
+				EventID:   posting.EventID,
+				Date:      posting.CreatedAt,
+				Narration: posting.Memo,
+			})
+		}
+		j.Vouchers[i].Lines = append(j.Vouchers[i].Lines, posting)
+	}
+	return j
+}
+
+// FileName is the name a format is written under.
+func FileName(format string) string {
+	switch format {
+	case FormatTally:
+		return "journal.xml"
+	default:
+		return "journal." + format
+	}
+}
+
+// Write renders the journal in format.
+func (j *Journal) Write(w io.Writer, format string) error {
+	switch format {
+	case FormatCSV:
+		return j.WriteCSV(w)
+	case FormatTally:
+		return j.WriteTally(w)
+	default:
+		return fmt.Errorf("unknown export format %q", format)
+	}
+}
+
+// Manifest summarises an export so an import can be checked against it.
+type Manifest struct {
+	From               time.Time       `json:"from"`
+	To                 time.Time       `json:"to"`
+	Company            string          `json:"company,omitempty"`
+	Vouchers           int             `json:"vouchers"`
+	Lines              int             `json:"lines"`
+	TotalDebitInr      decimal.Decimal `json:"totalDebitInr"`
+	TotalCreditInr     decimal.Decimal `json:"totalCreditInr"`
+	Balanced           bool            `json:"balanced"`
+	UnbalancedVouchers []uuid.UUID     `json:"unbalancedVouchers"`
+	Accounts           []AccountTotal  `json:"accounts"`
+	Files              []File          `json:"files"`
+}
+
+// AccountTotal is what the export posts to one account.
+type AccountTotal struct {
+	AccountCode string          `json:"accountCode"`
+	DebitInr    decimal.Decimal `json:"debitInr"`
+	CreditInr   decimal.Decimal `json:"creditInr"`
+	Lines       int             `json:"lines"`
+}
+
+// File is one rendered format with its size and SHA-256.
+type File struct {
+	Name   string `json:"name"`
+	Format string `json:"format"`
+	Bytes  int    `json:"bytes"`
+	SHA256 string `json:"sha256"`
+}
+
+// Manifest totals the journal and checksums every format. Balanced is false
+// when total debits and credits differ or any voucher does not balance.
+func (j *Journal) Manifest() (*Manifest, error) {
+	m := &Manifest{
+		From:               j.From,
+		To:                 j.To,
+		Company:            j.Company,
+		Vouchers:           len(j.Vouchers),
+		UnbalancedVouchers: []uuid.UUID{},
+		Accounts:           []AccountTotal{},
+	}
+	accounts := make(map[string]*AccountTotal)
+	for _, voucher := range j.Vouchers {
+		debit, credit := decimal.Zero, decimal.Zero
+		for _, line := range voucher.Lines {
+			debit = debit.Add(line.DebitInr)
+			credit = credit.Add(line.CreditInr)
+			total, ok := accounts[line.AccountCode]
+			if !ok {
+				total = &AccountTotal{AccountCode: line.AccountCode}
+				accounts[line.AccountCode] = total
+			}
+			total.DebitInr = total.DebitInr.Add(line.DebitInr)
+			total.CreditInr = total.CreditInr.Add(line.CreditInr)
+			total.Lines++
+			m.Lines++
+		}
+		if !debit.Equal(credit) {
+			m.UnbalancedVouchers = append(m.UnbalancedVouchers, voucher.EventID)
+		}
+		m.TotalDebitInr = m.TotalDebitInr.Add(debit)
+		m.TotalCreditInr = m.TotalCreditInr.Add(credit)
+	}
+	for _, total := range accounts {
+		m.Accounts = append(m.Accounts, *total)
+	}
+	sort.Slice(m.Accounts, func(i, k int) bool { return m.Accounts[i].AccountCode < m.Accounts[k].AccountCode })
+	m.Balanced = m.TotalDebitInr.Equal(m.TotalCreditInr) && len(m.UnbalancedVouchers) == 0
+
+	for _, format := range Formats {
+		var buf bytes.Buffer
+		if err := j.Write(&buf, format); err != nil {
+			return nil, err
+		}
+		sum := sha256.Sum256(buf.Bytes())
+		m.Files = append(m.Files, File{
+			Name:   FileName(format),
+			Format: format,
+			Bytes:  buf.Len(),
+			SHA256: hex.EncodeToString(sum[:]),
+		})
+	}
+	return m, nil
+}
+
+// amount renders an INR amount at ledger precision.
+func amount(v decimal.Decimal) string {
+	return v.StringFixed(4)
+}
@@ -0,0 +1,105 @@
+package export
+
+import (
+	"encoding/xml"
+	"io"
+	"strconv"
+)
+
+// The Tally import envelope. Only the elements a journal voucher needs are
+// modelled; ledgers are expected to exist in Tally under the account codes.
+type tallyEnvelope struct {
+	XMLName xml.Name    `xml:"ENVELOPE"`
+	Header  tallyHeader `xml:"HEADER"`
+	Body    tallyBody   `xml:"BODY"`
+}
+
+type tallyHeader struct {
+	Request string `xml:"TALLYREQUEST"`
+}
+
+type tallyBody struct {
+	Import tallyImport `xml:"IMPORTDATA"`
+}
+
+type tallyImport struct {
+	Desc     tallyRequestDesc `xml:"REQUESTDESC"`
+	Messages []tallyMessage   `xml:"REQUESTDATA>TALLYMESSAGE"`
+}
+
+type tallyRequestDesc struct {
+	Report  string `xml:"REPORTNAME"`
+	Company string `xml:"STATICVARIABLES>SVCURRENTCOMPANY,omitempty"`
+}
+
+type tallyMessage struct {
+	Voucher tallyVoucher `xml:"VOUCHER"`
+}
+
+type tallyVoucher struct {
+	VoucherType string             `xml:"VCHTYPE,attr"`
+	Action      string             `xml:"ACTION,attr"`
+	Date        string             `xml:"DATE"`
+	GUID        string             `xml:"GUID"`
+	TypeName    string             `xml:"VOUCHERTYPENAME"`
+	Number      string             `xml:"VOUCHERNUMBER"`
+	Reference   string             `xml:"REFERENCE"`
+	Narration   string             `xml:"NARRATION"`
+	Entries     []tallyLedgerEntry `xml:"ALLLEDGERENTRIES.LIST"`
+}
+
+type tallyLedgerEntry struct {
+	Ledger   string `xml:"LEDGERNAME"`
+	Positive string `xml:"ISDEEMEDPOSITIVE"`
+	Amount   string `xml:"AMOUNT"`
+}
+
+// WriteTally writes a Tally "Import Data" envelope with one Journal voucher
+// per event. Following Tally's sign convention, debits are negative amounts
+// with ISDEEMEDPOSITIVE Yes and credits positive with No. Amounts keep four
+// decimals, so the INR currency in Tally must allow four decimal places for
+// vouchers to balance exactly.
+func (j *Journal) WriteTally(w io.Writer) error {
+	envelope := tallyEnvelope{
+		Header: tallyHeader{Request: "Import Data"},
+		Body: tallyBody{Import: tallyImport{
+			Desc: tallyRequestDesc{Report: "Vouchers", Company: j.Company},
+		}},
+	}
+	for _, voucher := range j.Vouchers {
+		v := tallyVoucher{
+			VoucherType: "Journal",
+			Action:      "Create",
+			Date:        voucher.Date.In(ist).Format("20060102"),
+			GUID:        voucher.EventID.String(),
+			TypeName:    "Journal",
+			Number:      strconv.Itoa(voucher.Number),
+			Reference:   voucher.EventID.String(),
+			Narration:   voucher.Narration,
+		}
+		for _, line := range voucher.Lines {
+			net := line.CreditInr.Sub(line.DebitInr)
+			positive := "No"
+			if net.IsNegative() {
+				positive = "Yes"
+			}
+			v.Entries = append(v.Entries, tallyLedgerEntry{
+				Ledger:   line.AccountCode,
+				Positive: positive,
+				Amount:   amount(net),
+			})
+		}
+		envelope.Body.Import.Messages = append(envelope.Body.Import.Messages, tallyMessage{Voucher: v})
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(envelope); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
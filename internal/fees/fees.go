@@ -0,0 +1,215 @@
+// Package fees computes the brokerage and statutory charges on an equity
+// purchase from dated, per-exchange schedules.
+package fees
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/shopspring/decimal"
+
+	"github.com/stocky/backend/internal/models"
+)
+
+// ErrNoSchedule is returned when no schedule covers the exchange and date.
+var ErrNoSchedule = errors.New("no fee schedule in effect")
+
+var hundred = decimal.NewFromInt(100)
+
+// Schedule is the set of rates in force on one exchange from EffectiveFrom
+// until the next schedule for that exchange. Rates are percentages of the
+// trade value, except GSTPct which applies to brokerage, exchange charges
+// and SEBI fees.
+type Schedule struct {
+	ID             string          `json:"id"`
+	Exchange       string          `json:"exchange"`
+	EffectiveFrom  time.Time       `json:"-"`
+	BrokeragePct   decimal.Decimal `json:"brokeragePct"`
+	BrokerageMin   decimal.Decimal `json:"brokerageMinInr"`
+	BrokerageMax   decimal.Decimal `json:"brokerageMaxInr"`
+	GSTPct         decimal.Decimal `json:"gstPct"`
+	STTPct         decimal.Decimal `json:"sttPct"`
+	ExchangeTxnPct decimal.Decimal `json:"exchangeTxnPct"`
+	SEBIPct        decimal.Decimal `json:"sebiPct"`
+	StampDutyPct   decimal.Decimal `json:"stampDutyPct"`
+}
+
+// Compute itemises the charges on a purchase worth value INR. Each
+// component is rounded to paisa-hundredths like every other INR amount.
+func (s Schedule) Compute(value decimal.Decimal) models.FeeBreakdown {
+	brokerage := pct(value, s.BrokeragePct)
+	if brokerage.LessThan(s.BrokerageMin) {
+		brokerage = s.BrokerageMin
+	}
+	if s.BrokerageMax.IsPositive() && brokerage.GreaterThan(s.BrokerageMax) {
+		brokerage = s.BrokerageMax
+	}
+	exchange := pct(value, s.ExchangeTxnPct)
+	sebi := pct(value, s.SEBIPct)
+
+	return models.FeeBreakdown{
+		Exchange:        s.Exchange,
+		ScheduleID:      s.ID,
+		Brokerage:       brokerage,
+		GST:             pct(brokerage.Add(exchange).Add(sebi), s.GSTPct),
+		STT:             pct(value, s.STTPct),
+		ExchangeCharges: exchange,
+		SEBIFees:        sebi,
+		StampDuty:       pct(value, s.StampDutyPct),
+	}
+}
+
+// validate rejects negative rates, a negative minimum or cap, and a minimum
+// above a positive cap.
+func (s Schedule) validate() error {
+	rates := []struct {
+		name string
+		rate decimal.Decimal
+	}{
+		{"brokeragePct", s.BrokeragePct},
+		{"brokerageMinInr", s.BrokerageMin},
+		{"brokerageMaxInr", s.BrokerageMax},
+		{"gstPct", s.GSTPct},
+		{"sttPct", s.STTPct},
+		{"exchangeTxnPct", s.ExchangeTxnPct},
+		{"sebiPct", s.SEBIPct},
+		{"stampDutyPct", s.StampDutyPct},
+	}
+	for _, r := range rates {
+		if r.rate.IsNegative() {
+			return fmt.Errorf("%s must not be negative", r.name)
+		}
+	}
+	if s.BrokerageMax.IsPositive() && s.BrokerageMin.GreaterThan(s.BrokerageMax) {
+		return errors.New("brokerageMinInr is above brokerageMaxInr")
+	}
+	return nil
+}
+
+func pct(value, rate decimal.Decimal) decimal.Decimal {
+	if rate.IsZero() {
+		return decimal.Zero
+	}
+	return value.Mul(rate).Div(hundred).Round(4)
+}
+
+// Engine selects the schedule in effect for an exchange and date.
+type Engine struct {
+	// byExchange holds each exchange's schedules, newest first.
+	byExchange map[string][]Schedule
+}
+
+// NewEngine validates schedules and indexes them by exchange. A schedule is
+// in force until the next one for its exchange, so two schedules for the
+// same exchange and effective date would overlap and are rejected.
+func NewEngine(schedules []Schedule) (*Engine, error) {
+	e := &Engine{byExchange: make(map[string][]Schedule)}
+	for _, s := range schedules {
+		s.Exchange = strings.ToUpper(s.Exchange)
+		if s.Exchange == "" {
+			return nil, fmt.Errorf("schedule %q: exchange is required", s.ID)
+		}
+		if err := s.validate(); err != nil {
+			return nil, fmt.Errorf("schedule %q: %w", s.ID, err)
+		}
+		e.byExchange[s.Exchange] = append(e.byExchange[s.Exchange], s)
+	}
+	for exchange, list := range e.byExchange {
+		sort.Slice(list, func(i, j int) bool { return list[i].EffectiveFrom.After(list[j].EffectiveFrom) })
+		for i := 1; i < len(list); i++ {
+			if list[i].EffectiveFrom.Equal(list[i-1].EffectiveFrom) {
+				return nil, fmt.Errorf("schedules %q and %q overlap: both start on %s for %s", list[i].ID, list[i-1].ID,
+					list[i].EffectiveFrom.In(ist).Format(time.DateOnly), exchange)
+			}
+		}
+	}
+	return e, nil
+}
+
+// Flat builds an engine with one NSE and one BSE schedule that charge
+// brokerage and a single statutory rate in basis points. It reproduces the
+// BROKERAGE_BPS / TAX_BPS behaviour; the tax is booked as STT. Callers must
+// reject negative rates first.
+func Flat(brokerageBps, taxBps int) *Engine {
+	bps := func(v int) decimal.Decimal { return decimal.NewFromInt(int64(v)).Div(hundred) }
+	var schedules []Schedule
+	for _, exchange := range []string{"NSE", "BSE"} {
+		schedules = append(schedules, Schedule{
+			ID:           "flat-" + strings.ToLower(exchange),
+			Exchange:     exchange,
+			BrokeragePct: bps(brokerageBps),
+			STTPct:       bps(taxBps),
+		})
+	}
+	engine, _ := NewEngine(schedules)
+	return engine
+}
+
+// Schedule returns the schedule for exchange in effect at the given time.
+func (e *Engine) Schedule(exchange string, at time.Time) (Schedule, error) {
+	for _, s := range e.byExchange[strings.ToUpper(exchange)] {
+		if !s.EffectiveFrom.After(at) {
+			return s, nil
+		}
+	}
+	return Schedule{}, fmt.Errorf("%w for %s on %s", ErrNoSchedule, exchange, at.Format(time.DateOnly))
+}
+
+// Compute itemises the charges on a purchase worth value INR on exchange at
+// the given time.
+func (e *Engine) Compute(exchange string, at time.Time, value decimal.Decimal) (models.FeeBreakdown, error) {
+	s, err := e.Schedule(exchange, at)
+	if err != nil {
+		return models.FeeBreakdown{}, err
+	}
+	return s.Compute(value), nil
+}
+
+// file is the JSON layout of a schedule file. effectiveFrom is a date
+// (YYYY-MM-DD) interpreted in IST, when Indian rate changes take effect.
+type file struct {
+	Schedules []struct {
+		Schedule
+		EffectiveFrom string `json:"effectiveFrom"`
+	} `json:"schedules"`
+}
+
+var ist = time.FixedZone("IST", 5*3600+1800)
+
+// Load reads schedules from a JSON file.
+func Load(path string) (*Engine, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var f file
+	if err := json.Unmarshal(raw, &f); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	if len(f.Schedules) == 0 {
+		return nil, fmt.Errorf("%s: no schedules", path)
+	}
+
+	schedules := make([]Schedule, 0, len(f.Schedules))
+	for _, entry := range f.Schedules {
+		s := entry.Schedule
+		if entry.EffectiveFrom != "" {
+			from, err := time.ParseInLocation(time.DateOnly, entry.EffectiveFrom, ist)
+			if err != nil {
+				return nil, fmt.Errorf("%s: schedule %q: %w", path, s.ID, err)
+			}
+			s.EffectiveFrom = from
+		}
+		schedules = append(schedules, s)
+	}
+	engine, err := NewEngine(schedules)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return engine, nil
+}
@@ -0,0 +1,207 @@
+package fees
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+func d(s string) decimal.Decimal { return decimal.RequireFromString(s) }
+
+func TestScheduleCompute(t *testing.T) {
+	schedule := Schedule{
+		ID:             "nse-2024",
+		Exchange:       "NSE",
+		BrokeragePct:   d("0.03"),
+		BrokerageMin:   d("5"),
+		BrokerageMax:   d("20"),
+		GSTPct:         d("18"),
+		STTPct:         d("0.1"),
+		ExchangeTxnPct: d("0.00325"),
+		SEBIPct:        d("0.0001"),
+		StampDutyPct:   d("0.015"),
+	}
+	tests := []struct {
+		name      string
+		schedule  Schedule
+		value     string
+		brokerage string
+		gst       string
+		stt       string
+		total     string
+	}{
+		{name: "minimum brokerage", schedule: schedule, value: "10000", brokerage: "5", gst: "0.9603", stt: "10", total: "17.7953"},
+		{name: "percentage brokerage", schedule: schedule, value: "50000", brokerage: "15", gst: "3.0015", stt: "50", total: "77.1765"},
+		{name: "capped brokerage", schedule: schedule, value: "100000", brokerage: "20", gst: "4.203", stt: "100", total: "142.553"},
+		{
+			name:      "zero cap is uncapped",
+			schedule:  Schedule{BrokeragePct: d("1")},
+			value:     "100000",
+			brokerage: "1000", gst: "0", stt: "0", total: "1000",
+		},
+		{name: "zero value", schedule: schedule, value: "0", brokerage: "5", gst: "0.9", stt: "0", total: "5.9"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.schedule.Compute(d(tt.value))
+			if !got.Brokerage.Equal(d(tt.brokerage)) {
+				t.Errorf("brokerage = %s, want %s", got.Brokerage, tt.brokerage)
+			}
+			if !got.GST.Equal(d(tt.gst)) {
+				t.Errorf("gst = %s, want %s", got.GST, tt.gst)
+			}
+			if !got.STT.Equal(d(tt.stt)) {
+				t.Errorf("stt = %s, want %s", got.STT, tt.stt)
+			}
+			if !got.Total().Equal(d(tt.total)) {
+				t.Errorf("total = %s, want %s", got.Total(), tt.total)
+			}
+			if got.ScheduleID != tt.schedule.ID || got.Exchange != tt.schedule.Exchange {
+				t.Errorf("breakdown names %s/%s, want %s/%s", got.Exchange, got.ScheduleID, tt.schedule.Exchange, tt.schedule.ID)
+			}
+		})
+	}
+}
+
+func TestEngineSchedule(t *testing.T) {
+	engine, err := NewEngine([]Schedule{
+		{ID: "nse-old", Exchange: "nse", EffectiveFrom: time.Date(2023, 1, 1, 0, 0, 0, 0, ist)},
+		{ID: "nse-new", Exchange: "NSE", EffectiveFrom: time.Date(2024, 10, 1, 0, 0, 0, 0, ist)},
+		{ID: "bse", Exchange: "BSE", EffectiveFrom: time.Date(2024, 1, 1, 0, 0, 0, 0, ist)},
+	})
+	if err != nil {
+		t.Fatalf("NewEngine: %v", err)
+	}
+	tests := []struct {
+		name     string
+		exchange string
+		at       time.Time
+		want     string
+	}{
+		{name: "before the change", exchange: "NSE", at: time.Date(2024, 9, 30, 23, 59, 0, 0, ist), want: "nse-old"},
+		{name: "on the change", exchange: "NSE", at: time.Date(2024, 10, 1, 0, 0, 0, 0, ist), want: "nse-new"},
+		{name: "change measured in IST", exchange: "NSE", at: time.Date(2024, 9, 30, 18, 30, 0, 0, time.UTC), want: "nse-new"},
+		{name: "exchange is case-insensitive", exchange: "bse", at: time.Date(2024, 6, 1, 0, 0, 0, 0, ist), want: "bse"},
+		{name: "before the first schedule", exchange: "BSE", at: time.Date(2023, 6, 1, 0, 0, 0, 0, ist)},
+		{name: "unknown exchange", exchange: "MCX", at: time.Date(2024, 6, 1, 0, 0, 0, 0, ist)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := engine.Schedule(tt.exchange, tt.at)
+			if tt.want == "" {
+				if !errors.Is(err, ErrNoSchedule) {
+					t.Fatalf("Schedule = %q, %v; want ErrNoSchedule", got.ID, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Schedule: %v", err)
+			}
+			if got.ID != tt.want {
+				t.Errorf("Schedule = %q, want %q", got.ID, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewEngineRejects(t *testing.T) {
+	day := time.Date(2024, 4, 1, 0, 0, 0, 0, ist)
+	tests := []struct {
+		name      string
+		schedules []Schedule
+		want      string
+	}{
+		{
+			name:      "missing exchange",
+			schedules: []Schedule{{ID: "a"}},
+			want:      `schedule "a": exchange is required`,
+		},
+		{
+			name:      "negative rate",
+			schedules: []Schedule{{ID: "a", Exchange: "NSE", STTPct: d("-0.1")}},
+			want:      `schedule "a": sttPct must not be negative`,
+		},
+		{
+			name:      "negative minimum",
+			schedules: []Schedule{{ID: "a", Exchange: "NSE", BrokerageMin: d("-1")}},
+			want:      `schedule "a": brokerageMinInr must not be negative`,
+		},
+		{
+			name:      "negative cap",
+			schedules: []Schedule{{ID: "a", Exchange: "NSE", BrokerageMax: d("-1")}},
+			want:      `schedule "a": brokerageMaxInr must not be negative`,
+		},
+		{
+			name:      "minimum above cap",
+			schedules: []Schedule{{ID: "a", Exchange: "NSE", BrokerageMin: d("30"), BrokerageMax: d("20")}},
+			want:      `schedule "a": brokerageMinInr is above brokerageMaxInr`,
+		},
+		{
+			name: "same start date",
+			schedules: []Schedule{
+				{ID: "a", Exchange: "NSE", EffectiveFrom: day},
+				{ID: "b", Exchange: "nse", EffectiveFrom: day},
+			},
+			want: "overlap: both start on 2024-04-01 for NSE",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewEngine(tt.schedules)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Fatalf("NewEngine error = %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestFlat(t *testing.T) {
+	engine := Flat(50, 10)
+	for _, exchange := range []string{"NSE", "BSE"} {
+		got, err := engine.Compute(exchange, time.Now(), d("2000"))
+		if err != nil {
+			t.Fatalf("Compute %s: %v", exchange, err)
+		}
+		if !got.Brokerage.Equal(d("10")) || !got.STT.Equal(d("2")) || !got.Total().Equal(d("12")) {
+			t.Errorf("%s breakdown = %+v, want brokerage 10 and STT 2", exchange, got)
+		}
+	}
+}
+
+func TestLoad(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "fees.json")
+	raw := `{"schedules":[
+		{"id":"nse-2024","exchange":"NSE","effectiveFrom":"2024-10-01","brokeragePct":"0.03","sttPct":"0.1"},
+		{"id":"nse-2023","exchange":"NSE","effectiveFrom":"2023-01-01","brokeragePct":"0.05"}
+	]}`
+	if err := os.WriteFile(path, []byte(raw), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	engine, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	got, err := engine.Schedule("NSE", time.Date(2024, 9, 30, 18, 30, 0, 0, time.UTC))
+	if err != nil || got.ID != "nse-2024" {
+		t.Fatalf("Schedule = %q, %v; want nse-2024 from midnight IST", got.ID, err)
+	}
+
+	for name, raw := range map[string]string{
+		"empty":    `{"schedules":[]}`,
+		"bad date": `{"schedules":[{"id":"a","exchange":"NSE","effectiveFrom":"01/10/2024"}]}`,
+		"negative": `{"schedules":[{"id":"a","exchange":"NSE","gstPct":"-18"}]}`,
+	} {
+		if err := os.WriteFile(path, []byte(raw), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := Load(path); err == nil {
+			t.Errorf("Load %s: want an error", name)
+		}
+	}
+}
@@ -0,0 +1,113 @@
+package http
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/go-chi/render"
+	"github.com/google/uuid"
+
+	"github.com/stocky/backend/internal/service"
+)
+
+// unauthenticatedAdmin is the principal recorded for admin requests when
+// admin auth is disabled.
+const unauthenticatedAdmin = "unauthenticated"
+
+type principalKey struct{}
+
+// requireAdmin checks the bearer token on /admin, /treasury and reward
+// reversal routes and stores the name it authenticates as in the request
+// context. With no token configured the routes fail closed unless admin auth
+// was explicitly disabled.
+func (h *Handler) requireAdmin(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		principal := unauthenticatedAdmin
+		switch {
+		case len(h.admins) > 0:
+			token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+			name, ok := h.matchAdmin(token)
+			if !ok {
+				render.Status(r, http.StatusUnauthorized)
+				render.JSON(w, r, errorResponse("admin token required"))
+				return
+			}
+			principal = name
+		case !h.adminOpen:
+			render.Status(r, http.StatusServiceUnavailable)
+			render.JSON(w, r, errorResponse("admin endpoints are disabled: ADMIN_TOKEN is not set"))
+			return
+		}
+		ctx := context.WithValue(r.Context(), principalKey{}, principal)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// matchAdmin compares token against every configured admin token in
+// constant time and returns the name of the one it matches.
+func (h *Handler) matchAdmin(token string) (string, bool) {
+	var name string
+	matched := false
+	for candidate, owner := range h.admins {
+		if subtle.ConstantTimeCompare([]byte(token), []byte(candidate)) == 1 {
+			name, matched = owner, true
+		}
+	}
+	return name, matched
+}
+
+// adminPrincipal returns the admin that requireAdmin authenticated for ctx.
+func adminPrincipal(ctx context.Context) string {
+	principal, _ := ctx.Value(principalKey{}).(string)
+	return principal
+}
+
+func (h *Handler) handleRebuildPositions(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	var req rebuildRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		render.Status(r, http.StatusBadRequest)
+		render.JSON(w, r, errorResponse("invalid payload"))
+		return
+	}
+	opts, err := req.toOptions()
+	if err != nil {
+		render.Status(r, http.StatusBadRequest)
+		render.JSON(w, r, errorResponse(err.Error()))
+		return
+	}
+
+	report, err := h.projectionSvc.Rebuild(ctx, opts)
+	if err != nil {
+		render.Status(r, statusCodeForErr(err))
+		render.JSON(w, r, errorResponse(err.Error()))
+		return
+	}
+	render.JSON(w, r, report)
+}
+
+type rebuildRequest struct {
+	UserID   string `json:"userId"`
+	Symbol   string `json:"symbol"`
+	DryRun   bool   `json:"dryRun"`
+	Holdings bool   `json:"holdings"`
+}
+
+func (r rebuildRequest) toOptions() (service.RebuildOptions, error) {
+	opts := service.RebuildOptions{Symbol: r.Symbol, DryRun: r.DryRun, Holdings: r.Holdings}
+	if r.UserID != "" {
+		userID, err := uuid.Parse(r.UserID)
+		if err != nil {
+			return opts, errors.New("userId must be a valid UUID")
+		}
+		opts.UserID = userID
+	}
+	if r.Holdings && r.Symbol != "" {
+		return opts, errors.New("holdings cannot be rebuilt for a single symbol")
+	}
+	return opts, nil
+}
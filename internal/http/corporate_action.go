@@ -0,0 +1,80 @@
+package http
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/render"
+	"github.com/google/uuid"
+
+	"github.com/stocky/backend/internal/models"
+	"github.com/stocky/backend/internal/repository"
+	"github.com/stocky/backend/internal/service"
+)
+
+type applyActionsResponse struct {
+	Applied []models.CorporateAction `json:"applied"`
+	Error   string                   `json:"error,omitempty"`
+}
+
+func (h *Handler) handleCreateCorporateAction(w http.ResponseWriter, r *http.Request) {
+	var req service.CorporateActionInput
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		render.Status(r, http.StatusBadRequest)
+		render.JSON(w, r, errorResponse("invalid payload"))
+		return
+	}
+
+	action, err := h.actionSvc.Register(r.Context(), req)
+	if err != nil {
+		render.Status(r, statusCodeForErr(err))
+		render.JSON(w, r, errorResponse(err.Error()))
+		return
+	}
+	render.Status(r, http.StatusCreated)
+	render.JSON(w, r, action)
+}
+
+func (h *Handler) handleListCorporateActions(w http.ResponseWriter, r *http.Request) {
+	filter := repository.CorporateActionFilter{
+		Symbol: r.URL.Query().Get("symbol"),
+		Status: r.URL.Query().Get("status"),
+	}
+	items, err := h.actionSvc.Actions(r.Context(), filter)
+	if err != nil {
+		render.Status(r, statusCodeForErr(err))
+		render.JSON(w, r, errorResponse(err.Error()))
+		return
+	}
+	render.JSON(w, r, items)
+}
+
+func (h *Handler) handleGetCorporateAction(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		render.Status(r, http.StatusBadRequest)
+		render.JSON(w, r, errorResponse("invalid corporate action id"))
+		return
+	}
+	action, err := h.actionSvc.Action(r.Context(), id)
+	if err != nil {
+		render.Status(r, statusCodeForErr(err))
+		render.JSON(w, r, errorResponse(err.Error()))
+		return
+	}
+	render.JSON(w, r, action)
+}
+
+// handleApplyCorporateActions runs the scheduled job now. Actions applied
+// before a failure stay applied and are listed alongside the error.
+func (h *Handler) handleApplyCorporateActions(w http.ResponseWriter, r *http.Request) {
+	applied, err := h.actionSvc.ApplyDue(r.Context(), time.Now().UTC())
+	resp := applyActionsResponse{Applied: applied}
+	if err != nil {
+		resp.Error = err.Error()
+		render.Status(r, statusCodeForErr(err))
+	}
+	render.JSON(w, r, resp)
+}
@@ -0,0 +1,96 @@
+package http
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/render"
+	"github.com/google/uuid"
+
+	"github.com/stocky/backend/internal/models"
+	"github.com/stocky/backend/internal/repository"
+	"github.com/stocky/backend/internal/service"
+)
+
+type payDividendsResponse struct {
+	Paid  []models.Dividend `json:"paid"`
+	Error string            `json:"error,omitempty"`
+}
+
+func (h *Handler) handleAnnounceDividend(w http.ResponseWriter, r *http.Request) {
+	var req service.DividendInput
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		render.Status(r, http.StatusBadRequest)
+		render.JSON(w, r, errorResponse("invalid payload"))
+		return
+	}
+
+	dividend, err := h.dividendSvc.Announce(r.Context(), req)
+	if err != nil {
+		render.Status(r, statusCodeForErr(err))
+		render.JSON(w, r, errorResponse(err.Error()))
+		return
+	}
+	render.Status(r, http.StatusCreated)
+	render.JSON(w, r, dividend)
+}
+
+func (h *Handler) handleListDividends(w http.ResponseWriter, r *http.Request) {
+	filter := repository.DividendFilter{
+		Symbol: r.URL.Query().Get("symbol"),
+		Status: r.URL.Query().Get("status"),
+	}
+	items, err := h.dividendSvc.Dividends(r.Context(), filter)
+	if err != nil {
+		render.Status(r, statusCodeForErr(err))
+		render.JSON(w, r, errorResponse(err.Error()))
+		return
+	}
+	render.JSON(w, r, items)
+}
+
+func (h *Handler) handleGetDividend(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		render.Status(r, http.StatusBadRequest)
+		render.JSON(w, r, errorResponse("invalid dividend id"))
+		return
+	}
+	dividend, err := h.dividendSvc.Dividend(r.Context(), id)
+	if err != nil {
+		render.Status(r, statusCodeForErr(err))
+		render.JSON(w, r, errorResponse(err.Error()))
+		return
+	}
+	render.JSON(w, r, dividend)
+}
+
+// handlePayDividends runs the scheduled job now. Dividends paid before a
+// failure stay paid and are listed alongside the error.
+func (h *Handler) handlePayDividends(w http.ResponseWriter, r *http.Request) {
+	paid, err := h.dividendSvc.PayDue(r.Context(), time.Now().UTC())
+	resp := payDividendsResponse{Paid: paid}
+	if err != nil {
+		resp.Error = err.Error()
+		render.Status(r, statusCodeForErr(err))
+	}
+	render.JSON(w, r, resp)
+}
+
+func (h *Handler) handleWallet(w http.ResponseWriter, r *http.Request) {
+	userID, err := uuid.Parse(chi.URLParam(r, "userId"))
+	if err != nil {
+		render.Status(r, http.StatusBadRequest)
+		render.JSON(w, r, errorResponse("invalid user id"))
+		return
+	}
+	statement, err := h.dividendSvc.Wallet(r.Context(), userID)
+	if err != nil {
+		render.Status(r, statusCodeForErr(err))
+		render.JSON(w, r, errorResponse(err.Error()))
+		return
+	}
+	render.JSON(w, r, statement)
+}
@@ -12,21 +12,54 @@ import (
 	"github.com/google/uuid"
 	"github.com/shopspring/decimal"
 
+	"github.com/stocky/backend/internal/price"
 	"github.com/stocky/backend/internal/service"
 )
 
+// Services groups the use-cases exposed over HTTP.
+type Services struct {
+	Reward     *service.RewardService
+	Stats      *service.StatsService
+	Portfolio  *service.PortfolioService
+	Projection *service.ProjectionService
+	Ledger     *service.LedgerService
+	Treasury   *service.TreasuryService
+	Periods    *service.PeriodService
+	Corporate  *service.CorporateActionService
+	Dividends  *service.DividendService
+}
+
 // Handler wires all REST endpoints.
 type Handler struct {
-	rewardSvc    *service.RewardService
-	statsSvc     *service.StatsService
-	portfolioSvc *service.PortfolioService
+	rewardSvc     *service.RewardService
+	statsSvc      *service.StatsService
+	portfolioSvc  *service.PortfolioService
+	projectionSvc *service.ProjectionService
+	ledgerSvc     *service.LedgerService
+	treasurySvc   *service.TreasuryService
+	periodSvc     *service.PeriodService
+	actionSvc     *service.CorporateActionService
+	dividendSvc   *service.DividendService
+	admins        map[string]string
+	adminOpen     bool
 }
 
-func NewHandler(reward *service.RewardService, stats *service.StatsService, portfolio *service.PortfolioService) *Handler {
+// NewHandler builds the REST handler. Admin routes require one of the admins
+// tokens as a bearer token and act as the name it maps to; without any they
+// are refused unless adminOpen is set.
+func NewHandler(svcs Services, admins map[string]string, adminOpen bool) *Handler {
 	return &Handler{
-		rewardSvc:    reward,
-		statsSvc:     stats,
-		portfolioSvc: portfolio,
+		rewardSvc:     svcs.Reward,
+		statsSvc:      svcs.Stats,
+		portfolioSvc:  svcs.Portfolio,
+		projectionSvc: svcs.Projection,
+		ledgerSvc:     svcs.Ledger,
+		treasurySvc:   svcs.Treasury,
+		periodSvc:     svcs.Periods,
+		actionSvc:     svcs.Corporate,
+		dividendSvc:   svcs.Dividends,
+		admins:        admins,
+		adminOpen:     adminOpen,
 	}
 }
 
@@ -42,10 +75,50 @@ func (h *Handler) Router() http.Handler {
 
 	r.Route("/", func(r chi.Router) {
 		r.Post("/reward", h.handleReward)
+		r.Post("/rewards/batch", h.handleRewardBatch)
 		r.Get("/today-stocks/{userId}", h.handleTodayRewards)
 		r.Get("/historical-inr/{userId}", h.handleHistoricalINR)
 		r.Get("/stats/{userId}", h.handleStats)
 		r.Get("/portfolio/{userId}", h.handlePortfolio)
+		r.Get("/wallet/{userId}", h.handleWallet)
+
+		r.Group(func(r chi.Router) {
+			r.Use(h.requireAdmin)
+			r.Post("/rewards/{id}/reverse", h.handleReverseReward)
+		})
+	})
+
+	r.Route("/admin", func(r chi.Router) {
+		r.Use(h.requireAdmin)
+		r.Post("/positions/rebuild", h.handleRebuildPositions)
+		r.Get("/ledger/events/{eventId}", h.handleEventPostings)
+		r.Get("/ledger/accounts", h.handleListAccounts)
+		r.Post("/ledger/accounts", h.handleCreateAccount)
+		r.Post("/ledger/accounts/{code}/close", h.handleCloseAccount)
+		r.Get("/ledger/accounts/{code}/entries", h.handleAccountPostings)
+		r.Get("/ledger/balances", h.handleLedgerBalances)
+		r.Get("/ledger/export", h.handleExportLedger)
+		r.Get("/ledger/trial-balance", h.handleLatestTrialBalance)
+		r.Get("/ledger/periods", h.handleListPeriods)
+		r.Get("/ledger/periods/{period}", h.handleGetPeriod)
+		r.Post("/ledger/periods/{period}/close", h.handleClosePeriod)
+		r.Post("/ledger/periods/{period}/reopen", h.handleReopenPeriod)
+		r.Post("/ledger/check", h.handleCheckLedger)
+		r.Get("/corporate-actions", h.handleListCorporateActions)
+		r.Post("/corporate-actions", h.handleCreateCorporateAction)
+		r.Post("/corporate-actions/apply", h.handleApplyCorporateActions)
+		r.Get("/corporate-actions/{id}", h.handleGetCorporateAction)
+		r.Get("/dividends", h.handleListDividends)
+		r.Post("/dividends", h.handleAnnounceDividend)
+		r.Post("/dividends/pay", h.handlePayDividends)
+		r.Get("/dividends/{id}", h.handleGetDividend)
+	})
+
+	r.Route("/treasury", func(r chi.Router) {
+		r.Use(h.requireAdmin)
+		r.Post("/fund", h.handleFundTreasury)
+		r.Get("/balance", h.handleTreasuryBalance)
+		r.Get("/alerts", h.handleTreasuryAlerts)
 	})
 
 	return r
@@ -76,6 +149,61 @@ func (h *Handler) handleReward(w http.ResponseWriter, r *http.Request) {
 	render.JSON(w, r, result)
 }
 
+func (h *Handler) handleRewardBatch(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	var req rewardBatchRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		render.Status(r, http.StatusBadRequest)
+		render.JSON(w, r, errorResponse("invalid payload"))
+		return
+	}
+
+	result, err := h.rewardSvc.RewardBatch(ctx, req.toItems(), req.Atomic)
+	if err != nil {
+		render.Status(r, statusCodeForErr(err))
+		render.JSON(w, r, errorResponse(err.Error()))
+		return
+	}
+
+	// An aborted atomic batch wrote nothing; the body says which item caused
+	// it.
+	if !result.Applied {
+		render.Status(r, http.StatusUnprocessableEntity)
+	}
+	render.JSON(w, r, result)
+}
+
+func (h *Handler) handleReverseReward(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	rewardID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		render.Status(r, http.StatusBadRequest)
+		render.JSON(w, r, errorResponse("invalid reward id"))
+		return
+	}
+	var req reversalRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		render.Status(r, http.StatusBadRequest)
+		render.JSON(w, r, errorResponse("invalid payload"))
+		return
+	}
+	if req.IdempotencyKey == "" {
+		req.IdempotencyKey = r.Header.Get("Idempotency-Key")
+	}
+
+	result, err := h.rewardSvc.ReverseReward(ctx, req.toInput(rewardID))
+	if err != nil {
+		render.Status(r, statusCodeForErr(err))
+		render.JSON(w, r, errorResponse(err.Error()))
+		return
+	}
+
+	if !result.Replayed {
+		render.Status(r, http.StatusCreated)
+	}
+	render.JSON(w, r, result)
+}
+
 func (h *Handler) handleTodayRewards(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 	userID, err := uuid.Parse(chi.URLParam(r, "userId"))
@@ -151,7 +279,9 @@ func (h *Handler) handlePortfolio(w http.ResponseWriter, r *http.Request) {
 type rewardRequest struct {
 	UserID     string          `json:"userId"`
 	Symbol     string          `json:"symbol"`
+	Exchange   string          `json:"exchange"`
 	Shares     decimal.Decimal `json:"shares"`
+	AmountInr  decimal.Decimal `json:"amountInr"`
 	EventID    string          `json:"eventId"`
 	RewardedAt time.Time       `json:"rewardedAt"`
 }
@@ -160,9 +290,15 @@ func (r rewardRequest) validate() error {
 	if r.UserID == "" || r.Symbol == "" || r.EventID == "" {
 		return errors.New("userId, symbol and eventId are required")
 	}
-	if !r.Shares.GreaterThan(decimal.Zero) {
+	if r.Shares.IsZero() == r.AmountInr.IsZero() {
+		return errors.New("exactly one of shares and amountInr is required")
+	}
+	if r.Shares.IsNegative() {
 		return errors.New("shares must be greater than zero")
 	}
+	if r.AmountInr.IsNegative() {
+		return errors.New("amountInr must be greater than zero")
+	}
 	if _, err := uuid.Parse(r.UserID); err != nil {
 		return errors.New("userId must be a valid UUID")
 	}
@@ -174,12 +310,45 @@ func (r rewardRequest) toInput() service.RewardInput {
 	return service.RewardInput{
 		UserID:     userID,
 		Symbol:     r.Symbol,
+		Exchange:   r.Exchange,
 		Shares:     r.Shares,
+		AmountInr:  r.AmountInr,
 		EventID:    r.EventID,
 		RewardedAt: r.RewardedAt,
 	}
 }
 
+type reversalRequest struct {
+	Shares         decimal.Decimal `json:"shares"`
+	FeeTreatment   string          `json:"feeTreatment"`
+	Reason         string          `json:"reason"`
+	IdempotencyKey string          `json:"idempotencyKey"`
+}
+
+func (r reversalRequest) toInput(rewardID uuid.UUID) service.ReversalInput {
+	return service.ReversalInput{
+		RewardID:       rewardID,
+		Shares:         r.Shares,
+		FeeTreatment:   r.FeeTreatment,
+		Reason:         r.Reason,
+		IdempotencyKey: r.IdempotencyKey,
+	}
+}
+
+type rewardBatchRequest struct {
+	// Atomic writes all items in one transaction or none of them.
+	Atomic bool            `json:"atomic"`
+	Items  []rewardRequest `json:"items"`
+}
+
+func (r rewardBatchRequest) toItems() []service.BatchItem {
+	items := make([]service.BatchItem, len(r.Items))
+	for i, req := range r.Items {
+		items[i] = service.BatchItem{Input: req.toInput(), Err: req.validate()}
+	}
+	return items
+}
+
 func errorResponse(msg string) map[string]string {
 	return map[string]string{"error": msg}
 }
@@ -190,6 +359,12 @@ func statusCodeForErr(err error) int {
 		return http.StatusConflict
 	case errors.Is(err, service.ErrNotFound):
 		return http.StatusNotFound
+	case errors.Is(err, service.ErrInvalidInput):
+		return http.StatusBadRequest
+	case errors.Is(err, service.ErrUnprocessable):
+		return http.StatusUnprocessableEntity
+	case errors.Is(err, service.ErrStalePrice), errors.Is(err, price.ErrUnavailable):
+		return http.StatusServiceUnavailable
 	default:
 		return http.StatusInternalServerError
 	}
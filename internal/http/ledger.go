@@ -0,0 +1,233 @@
+package http
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/render"
+	"github.com/google/uuid"
+
+	"github.com/stocky/backend/internal/export"
+	"github.com/stocky/backend/internal/service"
+)
+
+func (h *Handler) handleEventPostings(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	eventID, err := uuid.Parse(chi.URLParam(r, "eventId"))
+	if err != nil {
+		render.Status(r, http.StatusBadRequest)
+		render.JSON(w, r, errorResponse("invalid event id"))
+		return
+	}
+
+	items, err := h.ledgerSvc.EventPostings(ctx, eventID)
+	if err != nil {
+		render.Status(r, statusCodeForErr(err))
+		render.JSON(w, r, errorResponse(err.Error()))
+		return
+	}
+	render.JSON(w, r, items)
+}
+
+func (h *Handler) handleAccountPostings(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	query, err := accountPostingsQuery(r)
+	if err != nil {
+		render.Status(r, http.StatusBadRequest)
+		render.JSON(w, r, errorResponse(err.Error()))
+		return
+	}
+
+	page, err := h.ledgerSvc.AccountPostings(ctx, query)
+	if err != nil {
+		render.Status(r, statusCodeForErr(err))
+		render.JSON(w, r, errorResponse(err.Error()))
+		return
+	}
+	render.JSON(w, r, page)
+}
+
+func (h *Handler) handleLedgerBalances(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	asOf, err := queryTime(r, "asOf")
+	if err != nil {
+		render.Status(r, http.StatusBadRequest)
+		render.JSON(w, r, errorResponse(err.Error()))
+		return
+	}
+
+	sheet, err := h.ledgerSvc.Balances(ctx, asOf, r.URL.Query().Get("account"))
+	if err != nil {
+		render.Status(r, statusCodeForErr(err))
+		render.JSON(w, r, errorResponse(err.Error()))
+		return
+	}
+	render.JSON(w, r, sheet)
+}
+
+func accountPostingsQuery(r *http.Request) (service.AccountPostingsQuery, error) {
+	query := service.AccountPostingsQuery{
+		AccountCode: chi.URLParam(r, "code"),
+		Cursor:      r.URL.Query().Get("cursor"),
+	}
+	var err error
+	if query.From, err = queryTime(r, "from"); err != nil {
+		return query, err
+	}
+	if query.To, err = queryTime(r, "to"); err != nil {
+		return query, err
+	}
+	if raw := r.URL.Query().Get("limit"); raw != "" {
+		if query.Limit, err = strconv.Atoi(raw); err != nil {
+			return query, errors.New("limit must be an integer")
+		}
+	}
+	return query, nil
+}
+
+// queryTime parses an optional RFC 3339 timestamp or YYYY-MM-DD date (UTC
+// midnight) from the query string.
+func queryTime(r *http.Request, name string) (time.Time, error) {
+	raw := r.URL.Query().Get(name)
+	if raw == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.Parse(time.RFC3339, raw); err == nil {
+		return t, nil
+	}
+	if t, err := time.Parse(time.DateOnly, raw); err == nil {
+		return t, nil
+	}
+	return time.Time{}, fmt.Errorf("%s must be an RFC 3339 timestamp or YYYY-MM-DD date", name)
+}
+
+func (h *Handler) handleLatestTrialBalance(w http.ResponseWriter, r *http.Request) {
+	report, err := h.ledgerSvc.LatestCheck(r.Context())
+	if err != nil {
+		render.Status(r, statusCodeForErr(err))
+		render.JSON(w, r, errorResponse(err.Error()))
+		return
+	}
+	render.JSON(w, r, report)
+}
+
+// handleCheckLedger runs the checker now. The report is returned with 200
+// whether or not the ledger balances; callers inspect "balanced".
+func (h *Handler) handleCheckLedger(w http.ResponseWriter, r *http.Request) {
+	report, err := h.ledgerSvc.CheckLedger(r.Context())
+	if err != nil {
+		render.Status(r, statusCodeForErr(err))
+		render.JSON(w, r, errorResponse(err.Error()))
+		return
+	}
+	render.JSON(w, r, report)
+}
+
+type accountRequest struct {
+	Code     string `json:"code"`
+	Type     string `json:"type"`
+	Currency string `json:"currency"`
+	Symbol   string `json:"symbol"`
+}
+
+func (h *Handler) handleListAccounts(w http.ResponseWriter, r *http.Request) {
+	items, err := h.ledgerSvc.Accounts(r.Context())
+	if err != nil {
+		render.Status(r, statusCodeForErr(err))
+		render.JSON(w, r, errorResponse(err.Error()))
+		return
+	}
+	render.JSON(w, r, items)
+}
+
+func (h *Handler) handleCreateAccount(w http.ResponseWriter, r *http.Request) {
+	var req accountRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		render.Status(r, http.StatusBadRequest)
+		render.JSON(w, r, errorResponse("invalid payload"))
+		return
+	}
+
+	account, err := h.ledgerSvc.CreateAccount(r.Context(), service.AccountInput(req))
+	if err != nil {
+		render.Status(r, statusCodeForErr(err))
+		render.JSON(w, r, errorResponse(err.Error()))
+		return
+	}
+	render.Status(r, http.StatusCreated)
+	render.JSON(w, r, account)
+}
+
+func (h *Handler) handleCloseAccount(w http.ResponseWriter, r *http.Request) {
+	account, err := h.ledgerSvc.CloseAccount(r.Context(), chi.URLParam(r, "code"))
+	if err != nil {
+		render.Status(r, statusCodeForErr(err))
+		render.JSON(w, r, errorResponse(err.Error()))
+		return
+	}
+	render.JSON(w, r, account)
+}
+
+// exportContentTypes maps each export format to the type it is served as.
+var exportContentTypes = map[string]string{
+	export.FormatCSV:   "text/csv; charset=utf-8",
+	export.FormatTally: "application/xml; charset=utf-8",
+}
+
+// handleExportLedger serves the journal for a date range as a CSV or Tally
+// XML download, or with format=manifest (the default) the manifest of both.
+func (h *Handler) handleExportLedger(w http.ResponseWriter, r *http.Request) {
+	query := service.ExportQuery{Company: r.URL.Query().Get("company")}
+	var err error
+	if query.From, err = queryTime(r, "from"); err == nil {
+		query.To, err = queryTime(r, "to")
+	}
+	if err != nil {
+		render.Status(r, http.StatusBadRequest)
+		render.JSON(w, r, errorResponse(err.Error()))
+		return
+	}
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		format = "manifest"
+	}
+	contentType, ok := exportContentTypes[format]
+	if !ok && format != "manifest" {
+		render.Status(r, http.StatusBadRequest)
+		render.JSON(w, r, errorResponse("format must be csv, tally or manifest"))
+		return
+	}
+
+	journal, err := h.ledgerSvc.Export(r.Context(), query)
+	if err != nil {
+		render.Status(r, statusCodeForErr(err))
+		render.JSON(w, r, errorResponse(err.Error()))
+		return
+	}
+	if format == "manifest" {
+		manifest, err := journal.Manifest()
+		if err != nil {
+			render.Status(r, statusCodeForErr(err))
+			render.JSON(w, r, errorResponse(err.Error()))
+			return
+		}
+		render.JSON(w, r, manifest)
+		return
+	}
+
+	var buf bytes.Buffer
+	if err := journal.Write(&buf, format); err != nil {
+		render.Status(r, statusCodeForErr(err))
+		render.JSON(w, r, errorResponse(err.Error()))
+		return
+	}
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", export.FileName(format)))
+	_, _ = w.Write(buf.Bytes())
+}
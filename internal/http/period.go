@@ -0,0 +1,64 @@
+package http
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/render"
+)
+
+type reopenRequest struct {
+	Reason string `json:"reason"`
+}
+
+func (h *Handler) handleListPeriods(w http.ResponseWriter, r *http.Request) {
+	items, err := h.periodSvc.Periods(r.Context())
+	if err != nil {
+		render.Status(r, statusCodeForErr(err))
+		render.JSON(w, r, errorResponse(err.Error()))
+		return
+	}
+	render.JSON(w, r, items)
+}
+
+func (h *Handler) handleGetPeriod(w http.ResponseWriter, r *http.Request) {
+	period, err := h.periodSvc.Period(r.Context(), chi.URLParam(r, "period"))
+	if err != nil {
+		render.Status(r, statusCodeForErr(err))
+		render.JSON(w, r, errorResponse(err.Error()))
+		return
+	}
+	render.JSON(w, r, period)
+}
+
+// handleClosePeriod records the authenticated admin as the actor; the body
+// is not read.
+func (h *Handler) handleClosePeriod(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	period, err := h.periodSvc.Close(ctx, chi.URLParam(r, "period"), adminPrincipal(ctx))
+	if err != nil {
+		render.Status(r, statusCodeForErr(err))
+		render.JSON(w, r, errorResponse(err.Error()))
+		return
+	}
+	render.JSON(w, r, period)
+}
+
+func (h *Handler) handleReopenPeriod(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	var req reopenRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		render.Status(r, http.StatusBadRequest)
+		render.JSON(w, r, errorResponse("invalid payload"))
+		return
+	}
+
+	period, err := h.periodSvc.Reopen(ctx, chi.URLParam(r, "period"), adminPrincipal(ctx), req.Reason)
+	if err != nil {
+		render.Status(r, statusCodeForErr(err))
+		render.JSON(w, r, errorResponse(err.Error()))
+		return
+	}
+	render.JSON(w, r, period)
+}
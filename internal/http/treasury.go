@@ -0,0 +1,71 @@
+package http
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/render"
+	"github.com/shopspring/decimal"
+
+	"github.com/stocky/backend/internal/service"
+)
+
+type fundingRequest struct {
+	AmountInr decimal.Decimal `json:"amountInr"`
+	Reference string          `json:"reference"`
+	Memo      string          `json:"memo"`
+}
+
+func (h *Handler) handleFundTreasury(w http.ResponseWriter, r *http.Request) {
+	var req fundingRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		render.Status(r, http.StatusBadRequest)
+		render.JSON(w, r, errorResponse("invalid payload"))
+		return
+	}
+	if req.Reference == "" {
+		req.Reference = r.Header.Get("Idempotency-Key")
+	}
+
+	result, err := h.treasurySvc.Fund(r.Context(), service.FundingInput(req))
+	if err != nil {
+		render.Status(r, statusCodeForErr(err))
+		render.JSON(w, r, errorResponse(err.Error()))
+		return
+	}
+	if !result.Replayed {
+		render.Status(r, http.StatusCreated)
+	}
+	render.JSON(w, r, result)
+}
+
+func (h *Handler) handleTreasuryBalance(w http.ResponseWriter, r *http.Request) {
+	status, err := h.treasurySvc.Status(r.Context())
+	if err != nil {
+		render.Status(r, statusCodeForErr(err))
+		render.JSON(w, r, errorResponse(err.Error()))
+		return
+	}
+	render.JSON(w, r, status)
+}
+
+func (h *Handler) handleTreasuryAlerts(w http.ResponseWriter, r *http.Request) {
+	var limit int
+	if raw := r.URL.Query().Get("limit"); raw != "" {
+		var err error
+		if limit, err = strconv.Atoi(raw); err != nil {
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, errorResponse("limit must be an integer"))
+			return
+		}
+	}
+
+	alerts, err := h.treasurySvc.Alerts(r.Context(), limit)
+	if err != nil {
+		render.Status(r, statusCodeForErr(err))
+		render.JSON(w, r, errorResponse(err.Error()))
+		return
+	}
+	render.JSON(w, r, alerts)
+}
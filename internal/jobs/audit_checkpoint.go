@@ -0,0 +1,42 @@
+package jobs
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/stocky/backend/internal/audit"
+)
+
+// AuditCheckpointJob periodically signs the hash chain heads into the local
+// checkpoint file.
+type AuditCheckpointJob struct {
+	interval time.Duration
+	auditSvc *audit.Service
+}
+
+func NewAuditCheckpointJob(interval time.Duration, auditSvc *audit.Service) *AuditCheckpointJob {
+	return &AuditCheckpointJob{interval: interval, auditSvc: auditSvc}
+}
+
+func (j *AuditCheckpointJob) Start(ctx context.Context) {
+	ticker := time.NewTicker(j.interval)
+	defer ticker.Stop()
+
+	j.run(ctx)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			j.run(ctx)
+		}
+	}
+}
+
+func (j *AuditCheckpointJob) run(ctx context.Context) {
+	if _, err := j.auditSvc.Checkpoint(ctx); err != nil {
+		log.Printf("audit checkpoint: %v", err)
+	}
+}
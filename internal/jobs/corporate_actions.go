@@ -0,0 +1,46 @@
+package jobs
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/stocky/backend/internal/service"
+)
+
+// CorporateActionJob applies splits and bonus issues once their ex-date has
+// arrived.
+type CorporateActionJob struct {
+	interval  time.Duration
+	actionSvc *service.CorporateActionService
+}
+
+func NewCorporateActionJob(interval time.Duration, actionSvc *service.CorporateActionService) *CorporateActionJob {
+	return &CorporateActionJob{interval: interval, actionSvc: actionSvc}
+}
+
+func (j *CorporateActionJob) Start(ctx context.Context) {
+	ticker := time.NewTicker(j.interval)
+	defer ticker.Stop()
+
+	j.run(ctx)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			j.run(ctx)
+		}
+	}
+}
+
+func (j *CorporateActionJob) run(ctx context.Context) {
+	applied, err := j.actionSvc.ApplyDue(ctx, time.Now().UTC())
+	for _, action := range applied {
+		log.Printf("corporate action %s applied: %s shares issued to %d holders", action.ID, action.SharesIssued, action.Holders)
+	}
+	if err != nil {
+		log.Printf("corporate actions: %v", err)
+	}
+}
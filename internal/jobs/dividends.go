@@ -0,0 +1,46 @@
+package jobs
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/stocky/backend/internal/service"
+)
+
+// DividendJob pays announced dividends into user wallets once their pay
+// date has arrived.
+type DividendJob struct {
+	interval    time.Duration
+	dividendSvc *service.DividendService
+}
+
+func NewDividendJob(interval time.Duration, dividendSvc *service.DividendService) *DividendJob {
+	return &DividendJob{interval: interval, dividendSvc: dividendSvc}
+}
+
+func (j *DividendJob) Start(ctx context.Context) {
+	ticker := time.NewTicker(j.interval)
+	defer ticker.Stop()
+
+	j.run(ctx)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			j.run(ctx)
+		}
+	}
+}
+
+func (j *DividendJob) run(ctx context.Context) {
+	paid, err := j.dividendSvc.PayDue(ctx, time.Now().UTC())
+	for _, dividend := range paid {
+		log.Printf("dividend %s paid: %s INR net to %d holders, %s INR TDS", dividend.ID, dividend.NetInr, dividend.Holders, dividend.TDSInr)
+	}
+	if err != nil {
+		log.Printf("dividends: %v", err)
+	}
+}
@@ -0,0 +1,46 @@
+package jobs
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/stocky/backend/internal/service"
+)
+
+// LedgerCheckJob runs the ledger invariant checker on a schedule and logs
+// any violation it finds.
+type LedgerCheckJob struct {
+	interval  time.Duration
+	ledgerSvc *service.LedgerService
+}
+
+func NewLedgerCheckJob(interval time.Duration, ledgerSvc *service.LedgerService) *LedgerCheckJob {
+	return &LedgerCheckJob{interval: interval, ledgerSvc: ledgerSvc}
+}
+
+func (j *LedgerCheckJob) Start(ctx context.Context) {
+	ticker := time.NewTicker(j.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			j.run(ctx)
+		}
+	}
+}
+
+func (j *LedgerCheckJob) run(ctx context.Context) {
+	report, err := j.ledgerSvc.CheckLedger(ctx)
+	if err != nil {
+		log.Printf("ledger check: %v", err)
+		return
+	}
+	if !report.Balanced {
+		log.Printf("ledger check: out of balance: debits %s credits %s, %d unbalanced events, %d stock mismatches",
+			report.TotalDebitInr, report.TotalCreditInr, len(report.UnbalancedEvents), len(report.StockMismatches))
+	}
+}
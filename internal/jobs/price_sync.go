@@ -3,6 +3,7 @@ package jobs
 import (
 	"context"
 	"log"
+	"sort"
 	"time"
 
 	"github.com/google/uuid"
@@ -16,10 +17,10 @@ import (
 type PriceSyncJob struct {
 	interval time.Duration
 	priceSvc *price.Service
-	repo     *repository.Repository
+	repo     repository.Store
 }
 
-func NewPriceSyncJob(interval time.Duration, priceSvc *price.Service, repo *repository.Repository) *PriceSyncJob {
+func NewPriceSyncJob(interval time.Duration, priceSvc *price.Service, repo repository.Store) *PriceSyncJob {
 	return &PriceSyncJob{
 		interval: interval,
 		priceSvc: priceSvc,
@@ -43,18 +44,34 @@ func (j *PriceSyncJob) Start(ctx context.Context) {
 	}
 }
 
+// run refreshes every tracked symbol and snapshots today's holdings. A
+// symbol the provider cannot quote is valued at its last cached quote and
+// recorded as stale on the snapshot of each user holding it, so one bad
+// ticker does not freeze every valuation.
 func (j *PriceSyncJob) run(ctx context.Context) {
-	quotes, err := j.priceSvc.RefreshAll(ctx)
+	results, err := j.priceSvc.RefreshAll(ctx)
 	if err != nil {
 		log.Printf("price sync: %v", err)
 		return
 	}
-	quoteMap := make(map[string]models.PriceQuote, len(quotes))
-	for _, quote := range quotes {
-		quoteMap[quote.Symbol] = quote
+	quoteMap := make(map[string]models.PriceQuote, len(results))
+	var ok, failed, skipped int
+	for _, result := range results {
+		switch result.Status {
+		case price.RefreshOK:
+			ok++
+			quoteMap[result.Symbol] = *result.Quote
+		case price.RefreshSkipped:
+			skipped++
+		default:
+			failed++
+			log.Printf("price sync %s: %v", result.Symbol, result.Err)
+		}
 	}
-
-	if len(quoteMap) == 0 {
+	if failed > 0 || skipped > 0 {
+		log.Printf("price sync: %d refreshed, %d failed, %d skipped", ok, failed, skipped)
+	}
+	if ctx.Err() != nil {
 		return
 	}
 
@@ -67,10 +84,41 @@ func (j *PriceSyncJob) run(ctx context.Context) {
 		return
 	}
 
+	// Symbols that were not refreshed fall back to the cached quote. For a
+	// delisted symbol that is the frozen final price rather than a stale one.
+	var missing []string
+	for _, pos := range positions {
+		if _, ok := quoteMap[pos.Symbol]; !ok {
+			missing = append(missing, pos.Symbol)
+		}
+	}
+	stale := make(map[string]bool)
+	if len(missing) > 0 {
+		cached, err := j.repo.QuotesForSymbols(ctx, missing)
+		if err != nil {
+			log.Printf("valuation: %v", err)
+			return
+		}
+		for symbol, quote := range cached {
+			quoteMap[symbol] = quote
+			stale[symbol] = quote.Source != repository.DelistedQuoteSource
+		}
+	}
+
+	// A symbol with neither a fresh nor a cached quote adds nothing to the
+	// total but is still listed as stale, so the day is not reported as
+	// fully priced.
 	userTotals := make(map[uuid.UUID]decimal.Decimal)
+	userStale := make(map[uuid.UUID][]string)
 	for _, pos := range positions {
 		quote, ok := quoteMap[pos.Symbol]
 		if !ok {
+			if pos.Shares.IsPositive() {
+				if _, ok := userTotals[pos.UserID]; !ok {
+					userTotals[pos.UserID] = decimal.Zero
+				}
+				userStale[pos.UserID] = append(userStale[pos.UserID], pos.Symbol)
+			}
 			continue
 		}
 		value := pos.Shares.Mul(quote.Price).Round(2)
@@ -79,6 +127,9 @@ func (j *PriceSyncJob) run(ctx context.Context) {
 		}
 		current := userTotals[pos.UserID]
 		userTotals[pos.UserID] = current.Add(value)
+		if stale[pos.Symbol] {
+			userStale[pos.UserID] = append(userStale[pos.UserID], pos.Symbol)
+		}
 	}
 
 	if len(userTotals) == 0 {
@@ -87,7 +138,9 @@ func (j *PriceSyncJob) run(ctx context.Context) {
 
 	date := startOfDay(time.Now().UTC())
 	for userID, total := range userTotals {
-		if err := j.repo.UpsertDailyHolding(ctx, userID, date, total); err != nil {
+		symbols := userStale[userID]
+		sort.Strings(symbols)
+		if err := j.repo.UpsertDailyHolding(ctx, userID, date, total, symbols); err != nil {
 			log.Printf("holdings upsert for user %s: %v", userID, err)
 		}
 	}
@@ -0,0 +1,78 @@
+// Package migrate applies and records versioned schema changes for each
+// storage backend: migrations/*.sql for PostgreSQL and the equivalent index
+// and validator definitions for MongoDB.
+package migrate
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/stocky/backend/internal/repository"
+	"github.com/stocky/backend/internal/repository/memory"
+	"github.com/stocky/backend/internal/repository/postgres"
+	"github.com/stocky/backend/migrations"
+)
+
+// Status describes a known migration and whether it has been applied.
+type Status struct {
+	Version   int        `json:"version"`
+	Name      string     `json:"name"`
+	AppliedAt *time.Time `json:"appliedAt,omitempty"`
+}
+
+func (s Status) Applied() bool {
+	return s.AppliedAt != nil
+}
+
+// Migrator applies pending migrations in version order and records each one
+// so it runs exactly once.
+type Migrator interface {
+	Status(ctx context.Context) ([]Status, error)
+	Up(ctx context.Context) ([]Status, error)
+}
+
+// ForStore returns the migrator matching the store's backend.
+func ForStore(store repository.Store) (Migrator, error) {
+	switch s := store.(type) {
+	case *repository.Repository:
+		return NewMongo(s.DB()), nil
+	case *postgres.Repository:
+		return NewPostgres(s.Pool(), migrations.FS)
+	case *memory.Store:
+		return noopMigrator{}, nil
+	default:
+		return nil, fmt.Errorf("no migrator for store %T", store)
+	}
+}
+
+// Pending filters statuses down to the migrations that have not run yet.
+func Pending(statuses []Status) []Status {
+	var pending []Status
+	for _, st := range statuses {
+		if !st.Applied() {
+			pending = append(pending, st)
+		}
+	}
+	return pending
+}
+
+// EnsureCurrent fails when migrations are pending, so a server can refuse to
+// boot against an outdated schema.
+func EnsureCurrent(ctx context.Context, m Migrator) error {
+	statuses, err := m.Status(ctx)
+	if err != nil {
+		return err
+	}
+	pending := Pending(statuses)
+	if len(pending) == 0 {
+		return nil
+	}
+	return fmt.Errorf("%d pending migration(s), first is %03d_%s; run cmd/migrate up", len(pending), pending[0].Version, pending[0].Name)
+}
+
+// noopMigrator backs the in-memory store, which has no schema.
+type noopMigrator struct{}
+
+func (noopMigrator) Status(context.Context) ([]Status, error) { return nil, nil }
+func (noopMigrator) Up(context.Context) ([]Status, error)     { return nil, nil }
@@ -0,0 +1,533 @@
+package migrate
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type mongoMigration struct {
+	version int
+	name    string
+	up      func(ctx context.Context, db *mongo.Database) error
+}
+
+// mongoMigrations mirrors migrations/*.sql for the stocky database. Append
+// new entries with the next version; never edit an applied one.
+var mongoMigrations = []mongoMigration{
+	{version: 1, name: "init", up: mongoInit},
+	{version: 2, name: "decimal128_amounts", up: mongoDecimal128Amounts},
+	{version: 3, name: "position_cost_basis", up: mongoPositionCostBasis},
+	{version: 4, name: "quarantine", up: mongoQuarantine},
+	{version: 5, name: "adjustments", up: mongoAdjustments},
+	{version: 6, name: "ledger_query_indexes", up: mongoLedgerQueryIndexes},
+	{version: 7, name: "trial_balances", up: mongoTrialBalances},
+	{version: 8, name: "hash_chain", up: mongoHashChain},
+	{version: 9, name: "ledger_accounts", up: mongoLedgerAccounts},
+	{version: 10, name: "treasury", up: mongoTreasury},
+	{version: 11, name: "ledger_periods", up: mongoLedgerPeriods},
+	{version: 12, name: "corporate_actions", up: mongoCorporateActions},
+	{version: 13, name: "dividends", up: mongoDividends},
+	{version: 14, name: "symbol_lifecycle", up: mongoSymbolLifecycle},
+	{version: 16, name: "quote_as_of", up: mongoQuoteAsOf},
+	{version: 17, name: "chain_legacy_records", up: mongoChainLegacyRecords},
+}
+
+// MongoMigrator applies mongoMigrations and records them in the
+// schema_migrations collection.
+type MongoMigrator struct {
+	db *mongo.Database
+}
+
+func NewMongo(db *mongo.Database) *MongoMigrator {
+	return &MongoMigrator{db: db}
+}
+
+type appliedDoc struct {
+	Version   int       `bson:"_id"`
+	Name      string    `bson:"name"`
+	AppliedAt time.Time `bson:"applied_at"`
+}
+
+func (m *MongoMigrator) Status(ctx context.Context) ([]Status, error) {
+	cursor, err := m.db.Collection("schema_migrations").Find(ctx, bson.M{})
+	if err != nil {
+		return nil, err
+	}
+	var docs []appliedDoc
+	if err := cursor.All(ctx, &docs); err != nil {
+		return nil, err
+	}
+
+	applied := make(map[int]time.Time, len(docs))
+	for _, doc := range docs {
+		applied[doc.Version] = doc.AppliedAt
+	}
+
+	statuses := make([]Status, 0, len(mongoMigrations))
+	for _, mig := range mongoMigrations {
+		st := Status{Version: mig.version, Name: mig.name}
+		if at, ok := applied[mig.version]; ok {
+			at := at
+			st.AppliedAt = &at
+		}
+		statuses = append(statuses, st)
+	}
+	return statuses, nil
+}
+
+// Up runs pending migrations in order. MongoDB cannot wrap index builds in a
+// transaction, so every migration must be safe to re-run if it fails halfway.
+func (m *MongoMigrator) Up(ctx context.Context) ([]Status, error) {
+	statuses, err := m.Status(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var applied []Status
+	for i, st := range statuses {
+		if st.Applied() {
+			continue
+		}
+		mig := mongoMigrations[i]
+		if err := mig.up(ctx, m.db); err != nil {
+			return applied, fmt.Errorf("migration %03d_%s: %w", mig.version, mig.name, err)
+		}
+		now := time.Now().UTC()
+		_, err := m.db.Collection("schema_migrations").InsertOne(ctx, appliedDoc{
+			Version:   mig.version,
+			Name:      mig.name,
+			AppliedAt: now,
+		})
+		if err != nil {
+			return applied, fmt.Errorf("record migration %03d_%s: %w", mig.version, mig.name, err)
+		}
+		st.AppliedAt = &now
+		applied = append(applied, st)
+	}
+	return applied, nil
+}
+
+func mongoInit(ctx context.Context, db *mongo.Database) error {
+	indexes := map[string][]mongo.IndexModel{
+		"stocks": {
+			{Keys: bson.D{{Key: "symbol", Value: 1}}, Options: options.Index().SetUnique(true)},
+			{Keys: bson.D{{Key: "status", Value: 1}}},
+		},
+		"reward_events": {
+			{Keys: bson.D{{Key: "event_key", Value: 1}}, Options: options.Index().SetUnique(true)},
+			{Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "rewarded_at", Value: 1}}},
+		},
+		"ledger_entries": {
+			{Keys: bson.D{{Key: "event_id", Value: 1}}},
+			{Keys: bson.D{{Key: "account_code", Value: 1}, {Key: "created_at", Value: 1}}},
+		},
+		"user_positions": {
+			{Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "symbol", Value: 1}}, Options: options.Index().SetUnique(true)},
+		},
+		"price_quotes": {
+			{Keys: bson.D{{Key: "symbol", Value: 1}}, Options: options.Index().SetUnique(true)},
+		},
+		"price_history": {
+			{Keys: bson.D{{Key: "symbol", Value: 1}, {Key: "as_of", Value: 1}}, Options: options.Index().SetUnique(true)},
+		},
+		"daily_holdings": {
+			{Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "date", Value: 1}}, Options: options.Index().SetUnique(true)},
+		},
+	}
+	for name, models := range indexes {
+		if _, err := db.Collection(name).Indexes().CreateMany(ctx, models); err != nil {
+			return fmt.Errorf("%s indexes: %w", name, err)
+		}
+	}
+
+	validators := map[string]bson.M{
+		"reward_events": requireFields("_id", "user_id", "symbol", "shares", "granted_price_inr",
+			"total_cash_out_inr", "rewarded_at", "event_key"),
+		"ledger_entries": requireFields("event_id", "account_code", "account_type", "debit_inr", "credit_inr"),
+		"user_positions": requireFields("user_id", "symbol", "net_shares"),
+		"price_quotes":   requireFields("symbol", "price_inr", "source", "fetched_at"),
+		"price_history":  requireFields("symbol", "price_inr", "as_of"),
+		"daily_holdings": requireFields("user_id", "date", "total_value_inr"),
+	}
+	for name, validator := range validators {
+		if err := setValidator(ctx, db, name, validator); err != nil {
+			return fmt.Errorf("%s validator: %w", name, err)
+		}
+	}
+	return nil
+}
+
+func requireFields(fields ...string) bson.M {
+	return bson.M{"$jsonSchema": bson.M{
+		"bsonType": "object",
+		"required": fields,
+	}}
+}
+
+// setValidator installs a validator on an existing collection or creates the
+// collection with it. Validation is "moderate" so legacy documents that
+// predate the rule can still be updated.
+func setValidator(ctx context.Context, db *mongo.Database, collection string, validator bson.M) error {
+	err := db.RunCommand(ctx, bson.D{
+		{Key: "collMod", Value: collection},
+		{Key: "validator", Value: validator},
+		{Key: "validationLevel", Value: "moderate"},
+	}).Err()
+	var cmdErr mongo.CommandError
+	if errors.As(err, &cmdErr) && cmdErr.Name == "NamespaceNotFound" {
+		return db.CreateCollection(ctx, collection, options.CreateCollection().
+			SetValidator(validator).
+			SetValidationLevel("moderate"))
+	}
+	return err
+}
+
+// decimalFields lists every amount that used to be persisted as a string.
+var decimalFields = map[string][]string{
+	"reward_events":  {"shares", "granted_price_inr", "brokerage_inr", "taxes_inr", "total_cash_out_inr"},
+	"ledger_entries": {"debit_inr", "credit_inr", "stock_units"},
+	"user_positions": {"net_shares", "avg_cost_inr"},
+	"price_quotes":   {"price_inr"},
+	"price_history":  {"price_inr"},
+	"daily_holdings": {"total_value_inr"},
+}
+
+// mongoDecimal128Amounts converts string amounts to Decimal128 in place.
+// Values that do not parse are left untouched so they can be inspected.
+func mongoDecimal128Amounts(ctx context.Context, db *mongo.Database) error {
+	for collection, fields := range decimalFields {
+		for _, field := range fields {
+			_, err := db.Collection(collection).UpdateMany(ctx,
+				bson.M{field: bson.M{"$type": "string"}},
+				mongo.Pipeline{
+					{{Key: "$set", Value: bson.M{field: bson.M{"$convert": bson.M{
+						"input":   "$" + field,
+						"to":      "decimal",
+						"onError": "$" + field,
+					}}}}},
+				},
+			)
+			if err != nil {
+				return fmt.Errorf("%s.%s: %w", collection, field, err)
+			}
+		}
+	}
+	return nil
+}
+
+// mongoPositionCostBasis backfills total_cost_inr for positions written before
+// cost tracking; positions that never had an average cost start from zero and
+// should be rebuilt from reward history.
+func mongoPositionCostBasis(ctx context.Context, db *mongo.Database) error {
+	_, err := db.Collection("user_positions").UpdateMany(ctx,
+		bson.M{"total_cost_inr": bson.M{"$exists": false}},
+		mongo.Pipeline{
+			{{Key: "$set", Value: bson.M{"total_cost_inr": bson.M{"$multiply": bson.A{
+				bson.M{"$ifNull": bson.A{"$net_shares", 0}},
+				bson.M{"$ifNull": bson.A{"$avg_cost_inr", 0}},
+			}}}}},
+		},
+	)
+	return err
+}
+
+// mongoQuarantine indexes the collection that records documents the
+// repository could not decode; one entry is kept per source document.
+func mongoQuarantine(ctx context.Context, db *mongo.Database) error {
+	_, err := db.Collection("quarantine").Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "collection", Value: 1}, {Key: "document_id", Value: 1}}, Options: options.Index().SetUnique(true)},
+		{Keys: bson.D{{Key: "detected_at", Value: 1}}},
+	})
+	return err
+}
+
+// mongoAdjustments mirrors 004_adjustments.sql: reversals are looked up by
+// idempotency key and by the reward they reference.
+func mongoAdjustments(ctx context.Context, db *mongo.Database) error {
+	_, err := db.Collection("adjustments").Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "idempotency_key", Value: 1}}, Options: options.Index().SetUnique(true).
+			SetPartialFilterExpression(bson.M{"idempotency_key": bson.M{"$type": "string"}})},
+		{Keys: bson.D{{Key: "reference_event", Value: 1}}},
+		{Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "symbol", Value: 1}, {Key: "created_at", Value: 1}}},
+	})
+	if err != nil {
+		return err
+	}
+	return setValidator(ctx, db, "adjustments", requireFields("_id", "user_id", "symbol", "kind", "shares", "created_at"))
+}
+
+// mongoLedgerQueryIndexes extends the account index with _id so paging
+// through an account's postings by (created_at, _id) stays an index scan.
+func mongoLedgerQueryIndexes(ctx context.Context, db *mongo.Database) error {
+	_, err := db.Collection("ledger_entries").Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "account_code", Value: 1}, {Key: "created_at", Value: 1}, {Key: "_id", Value: 1}},
+	})
+	return err
+}
+
+func mongoTrialBalances(ctx context.Context, db *mongo.Database) error {
+	_, err := db.Collection("trial_balances").Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "checked_at", Value: -1}},
+	})
+	if err != nil {
+		return err
+	}
+	return setValidator(ctx, db, "trial_balances", requireFields("checked_at", "balanced"))
+}
+
+// mongoHashChain indexes chain_seq for verification walks. The index is
+// unique over chained documents only, so a forked chain cannot be written.
+func mongoHashChain(ctx context.Context, db *mongo.Database) error {
+	for _, collection := range []string{"reward_events", "ledger_entries"} {
+		_, err := db.Collection(collection).Indexes().CreateOne(ctx, mongo.IndexModel{
+			Keys: bson.D{{Key: "chain_seq", Value: 1}},
+			Options: options.Index().SetUnique(true).
+				SetPartialFilterExpression(bson.M{"chain_seq": bson.M{"$exists": true}}),
+		})
+		if err != nil {
+			return fmt.Errorf("%s: %w", collection, err)
+		}
+	}
+	return nil
+}
+
+// mongoLedgerAccounts creates the chart of accounts with the codes the SQL
+// migrations seed, then adds every account already used by ledger_entries so
+// existing postings keep a home.
+func mongoLedgerAccounts(ctx context.Context, db *mongo.Database) error {
+	accounts := db.Collection("ledger_accounts")
+	_, err := accounts.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "code", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	})
+	if err != nil {
+		return err
+	}
+	if err := setValidator(ctx, db, "ledger_accounts", requireFields("code", "type", "currency", "created_at")); err != nil {
+		return err
+	}
+
+	now := time.Now().UTC()
+	open := func(code, kind, symbol string) error {
+		fields := bson.M{"code": code, "type": kind, "currency": "INR", "created_at": now}
+		if symbol != "" {
+			fields["symbol"] = symbol
+		}
+		_, err := accounts.UpdateOne(ctx, bson.M{"code": code}, bson.M{"$setOnInsert": fields},
+			options.Update().SetUpsert(true))
+		return err
+	}
+	for _, seed := range []struct{ code, kind string }{
+		{"cash", "asset"},
+		{"brokerage_expense", "expense"},
+		{"tax_expense", "expense"},
+		{"reversal_loss", "expense"},
+		{"gst_expense", "expense"},
+		{"stt_expense", "expense"},
+		{"exchange_charges_expense", "expense"},
+		{"sebi_fees_expense", "expense"},
+		{"stamp_duty_expense", "expense"},
+	} {
+		if err := open(seed.code, seed.kind, ""); err != nil {
+			return fmt.Errorf("seed %s: %w", seed.code, err)
+		}
+	}
+
+	cursor, err := db.Collection("ledger_entries").Aggregate(ctx, []bson.M{
+		{"$group": bson.M{
+			"_id":    "$account_code",
+			"type":   bson.M{"$first": "$account_type"},
+			"symbol": bson.M{"$max": "$symbol"},
+		}},
+	})
+	if err != nil {
+		return err
+	}
+	var used []struct {
+		Code   string `bson:"_id"`
+		Type   string `bson:"type"`
+		Symbol string `bson:"symbol"`
+	}
+	if err := cursor.All(ctx, &used); err != nil {
+		return err
+	}
+	for _, account := range used {
+		if err := open(account.Code, account.Type, account.Symbol); err != nil {
+			return fmt.Errorf("backfill %s: %w", account.Code, err)
+		}
+	}
+	return nil
+}
+
+// mongoTreasury mirrors 010_treasury.sql: fundings are unique per
+// reference, alerts are read newest first, and funding credits the
+// treasury_funding equity account.
+func mongoTreasury(ctx context.Context, db *mongo.Database) error {
+	_, err := db.Collection("treasury_fundings").Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "reference", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	})
+	if err != nil {
+		return err
+	}
+	if err := setValidator(ctx, db, "treasury_fundings", requireFields("_id", "amount_inr", "reference", "created_at")); err != nil {
+		return err
+	}
+	_, err = db.Collection("treasury_alerts").Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "created_at", Value: -1}},
+	})
+	if err != nil {
+		return err
+	}
+	_, err = db.Collection("ledger_accounts").UpdateOne(ctx, bson.M{"code": "treasury_funding"},
+		bson.M{"$setOnInsert": bson.M{"code": "treasury_funding", "type": "equity", "currency": "INR", "created_at": time.Now().UTC()}},
+		options.Update().SetUpsert(true))
+	return err
+}
+
+// mongoLedgerPeriods mirrors 011_ledger_periods.sql. Periods are keyed by
+// their YYYY-MM name, so no extra index is needed.
+func mongoLedgerPeriods(ctx context.Context, db *mongo.Database) error {
+	return setValidator(ctx, db, "ledger_periods", requireFields("_id", "starts_at", "ends_at", "status", "history"))
+}
+
+// mongoCorporateActions mirrors 012_corporate_actions.sql: one action per
+// symbol and ex-date, and pending actions are found by ex-date.
+func mongoCorporateActions(ctx context.Context, db *mongo.Database) error {
+	_, err := db.Collection("corporate_actions").Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "symbol", Value: 1}, {Key: "ex_date", Value: 1}}, Options: options.Index().SetUnique(true)},
+		{Keys: bson.D{{Key: "status", Value: 1}, {Key: "ex_date", Value: 1}}},
+	})
+	if err != nil {
+		return err
+	}
+	return setValidator(ctx, db, "corporate_actions",
+		requireFields("_id", "symbol", "kind", "ratio_new", "ratio_held", "factor", "record_date", "ex_date", "status", "created_at"))
+}
+
+// mongoDividends mirrors 013_dividends.sql: one dividend per symbol and
+// record date, one payment per holder, wallets keyed by user id, and the
+// dividend accounts in the chart.
+func mongoDividends(ctx context.Context, db *mongo.Database) error {
+	_, err := db.Collection("dividends").Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "symbol", Value: 1}, {Key: "record_date", Value: 1}}, Options: options.Index().SetUnique(true)},
+		{Keys: bson.D{{Key: "status", Value: 1}, {Key: "pay_date", Value: 1}}},
+	})
+	if err != nil {
+		return err
+	}
+	if err := setValidator(ctx, db, "dividends",
+		requireFields("_id", "symbol", "amount_per_share_inr", "record_date", "pay_date", "tds_rate_pct", "status", "created_at")); err != nil {
+		return err
+	}
+	_, err = db.Collection("dividend_payments").Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "dividend_id", Value: 1}, {Key: "user_id", Value: 1}}, Options: options.Index().SetUnique(true)},
+		{Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "paid_at", Value: 1}}},
+	})
+	if err != nil {
+		return err
+	}
+	if err := setValidator(ctx, db, "dividend_payments",
+		requireFields("_id", "dividend_id", "user_id", "symbol", "shares", "gross_inr", "tds_inr", "net_inr", "paid_at")); err != nil {
+		return err
+	}
+	if err := setValidator(ctx, db, "user_wallets", requireFields("_id", "balance_inr", "updated_at")); err != nil {
+		return err
+	}
+	for _, account := range []struct{ code, kind string }{
+		{"dividend_receivable", "asset"},
+		{"dividend_payable", "liability"},
+		{"tds_payable", "liability"},
+	} {
+		_, err := db.Collection("ledger_accounts").UpdateOne(ctx, bson.M{"code": account.code},
+			bson.M{"$setOnInsert": bson.M{"code": account.code, "type": account.kind, "currency": "INR", "created_at": time.Now().UTC()}},
+			options.Update().SetUpsert(true))
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// mongoSymbolLifecycle mirrors 014_symbol_lifecycle.sql: aliases keyed by the
+// retired symbol and the accounts for cash-in-lieu and delisting write-offs.
+func mongoSymbolLifecycle(ctx context.Context, db *mongo.Database) error {
+	_, err := db.Collection("symbol_aliases").Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "target", Value: 1}},
+	})
+	if err != nil {
+		return err
+	}
+	if err := setValidator(ctx, db, "symbol_aliases", requireFields("_id", "kind", "action_id", "effective_at")); err != nil {
+		return err
+	}
+	for _, account := range []struct{ code, kind string }{
+		{"corporate_action_receivable", "asset"},
+		{"cash_in_lieu_payable", "liability"},
+		{"corporate_action_loss", "expense"},
+	} {
+		_, err := db.Collection("ledger_accounts").UpdateOne(ctx, bson.M{"code": account.code},
+			bson.M{"$setOnInsert": bson.M{"code": account.code, "type": account.kind, "currency": "INR", "created_at": time.Now().UTC()}},
+			options.Update().SetUpsert(true))
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// mongoQuoteAsOf mirrors 016_quote_as_of.sql: cached quotes get the
+// provider's timestamp in as_of, which until now was stored in fetched_at.
+// 015 only touched daily_holdings columns, which MongoDB does not need.
+func mongoQuoteAsOf(ctx context.Context, db *mongo.Database) error {
+	_, err := db.Collection("price_quotes").UpdateMany(ctx,
+		bson.M{"as_of": bson.M{"$exists": false}},
+		mongo.Pipeline{{{Key: "$set", Value: bson.M{"as_of": "$fetched_at"}}}},
+	)
+	if err != nil {
+		return err
+	}
+	return setValidator(ctx, db, "price_quotes", requireFields("symbol", "price_inr", "source", "as_of", "fetched_at"))
+}
+
+// mongoChainLegacyRecords mirrors 017_chain_legacy_records.sql: it counts the
+// documents of each chain that predate 008_hash_chain, leaving out any
+// unchained document created after the first chained one.
+func mongoChainLegacyRecords(ctx context.Context, db *mongo.Database) error {
+	for _, collection := range []string{"reward_events", "ledger_entries"} {
+		unchained := bson.M{"chain_seq": bson.M{"$exists": false}}
+		var first struct {
+			CreatedAt time.Time `bson:"created_at"`
+		}
+		err := db.Collection(collection).FindOne(ctx,
+			bson.M{"chain_seq": bson.M{"$exists": true}},
+			options.FindOne().SetSort(bson.D{{Key: "created_at", Value: 1}}).SetProjection(bson.M{"created_at": 1}),
+		).Decode(&first)
+		switch {
+		case err == nil:
+			// Documents from before created_at was written are legacy too.
+			unchained["$or"] = bson.A{
+				bson.M{"created_at": bson.M{"$lt": first.CreatedAt}},
+				bson.M{"created_at": bson.M{"$exists": false}},
+			}
+		case !errors.Is(err, mongo.ErrNoDocuments):
+			return fmt.Errorf("%s: %w", collection, err)
+		}
+		legacy, err := db.Collection(collection).CountDocuments(ctx, unchained)
+		if err != nil {
+			return fmt.Errorf("%s: %w", collection, err)
+		}
+		_, err = db.Collection("chain_heads").UpdateOne(ctx, bson.M{"_id": collection},
+			bson.M{"$set": bson.M{"legacy_records": legacy}}, options.Update().SetUpsert(true))
+		if err != nil {
+			return fmt.Errorf("%s: %w", collection, err)
+		}
+	}
+	return nil
+}
@@ -8,17 +8,37 @@ import (
 )
 
 type RewardEvent struct {
-	ID             uuid.UUID       `json:"id"`
-	UserID         uuid.UUID       `json:"userId"`
-	Symbol         string          `json:"symbol"`
-	Shares         decimal.Decimal `json:"shares"`
-	GrantedPrice   decimal.Decimal `json:"grantedPrice"`
-	BrokerageInr   decimal.Decimal `json:"brokerageInr"`
-	TaxesInr       decimal.Decimal `json:"taxesInr"`
-	TotalCashOut   decimal.Decimal `json:"totalCashOutInr"`
-	RewardedAt     time.Time       `json:"rewardedAt"`
-	CreatedAt      time.Time       `json:"createdAt"`
-	EventKey       string          `json:"eventKey"`
+	ID           uuid.UUID       `json:"id"`
+	UserID       uuid.UUID       `json:"userId"`
+	Symbol       string          `json:"symbol"`
+	Shares       decimal.Decimal `json:"shares"`
+	GrantedPrice decimal.Decimal `json:"grantedPrice"`
+	BrokerageInr decimal.Decimal `json:"brokerageInr"`
+	TaxesInr     decimal.Decimal `json:"taxesInr"`
+	TotalCashOut decimal.Decimal `json:"totalCashOutInr"`
+	RewardedAt   time.Time       `json:"rewardedAt"`
+	CreatedAt    time.Time       `json:"createdAt"`
+	EventKey     string          `json:"eventKey"`
+	// Fees itemises BrokerageInr and TaxesInr; it is nil for rewards booked
+	// before fee schedules were introduced.
+	Fees *FeeBreakdown `json:"fees,omitempty"`
+	// CostBasis is the amount added to the position's cost; it is kept for
+	// replays and not exposed over the API.
+	CostBasis decimal.Decimal `json:"-"`
+	// PriceStale is set on the response, and not stored, when the reward was
+	// booked at a quote older than the configured maximum age.
+	PriceStale bool `json:"priceStale,omitempty"`
+	// Chain is the reward's link in the tamper-evident hash chain.
+	Chain ChainLink `json:"-"`
+}
+
+// ChainLink is a record's position in a hash chain: its sequence number, the
+// previous record's hash and its own hash. A zero Seq means the record
+// predates chaining.
+type ChainLink struct {
+	Seq      int64
+	PrevHash string
+	Hash     string
 }
 
 type TodayReward struct {
@@ -30,6 +50,9 @@ type TodayReward struct {
 type DailyINR struct {
 	Date         time.Time       `json:"date"`
 	TotalValueIn decimal.Decimal `json:"totalInr"`
+	// StaleSymbols lists the holdings the provider could not refresh: valued
+	// at a cached quote, or at zero when there was none.
+	StaleSymbols []string `json:"staleSymbols,omitempty"`
 }
 
 type TodayTotals struct {
@@ -41,6 +64,9 @@ type StatsSummary struct {
 	TotalsToday    []TodayTotals   `json:"totalsToday"`
 	PortfolioValue decimal.Decimal `json:"portfolioInr"`
 	PriceAsOf      time.Time       `json:"priceAsOf"`
+	// UnpricedSymbols lists held symbols left out of PortfolioValue because
+	// no quote could be fetched for them.
+	UnpricedSymbols []string `json:"unpricedSymbols,omitempty"`
 }
 
 type PortfolioPosition struct {
@@ -51,11 +77,254 @@ type PortfolioPosition struct {
 	CurrentValue      decimal.Decimal `json:"currentValueInr"`
 	UnrealizedPnl     decimal.Decimal `json:"unrealizedPnlInr"`
 	LastPriceSnapshot time.Time       `json:"priceAsOf"`
+	// Delisted marks a position valued at the price frozen on delisting.
+	Delisted bool `json:"delisted,omitempty"`
+	// Unpriced marks a position no quote could be fetched for; its price,
+	// value and P&L are zero.
+	Unpriced bool `json:"unpriced,omitempty"`
+	// Dividends lists the cash dividends paid on the symbol, oldest first.
+	Dividends []DividendPayment `json:"dividends,omitempty"`
 }
 
 type PriceQuote struct {
-	Symbol    string          `json:"symbol"`
-	Price     decimal.Decimal `json:"price"`
-	Source    string          `json:"source"`
-	FetchedAt time.Time       `json:"fetchedAt"`
+	Symbol string          `json:"symbol"`
+	Price  decimal.Decimal `json:"price"`
+	Source string          `json:"source"`
+	// AsOf is the provider's timestamp for the price, or FetchedAt when it
+	// sends none. Price history is keyed on it.
+	AsOf time.Time `json:"asOf"`
+	// FetchedAt is when the quote was last fetched; staleness is measured
+	// from it.
+	FetchedAt time.Time `json:"fetchedAt"`
+}
+
+// Adjustment is a correction to a position recorded outside the reward flow,
+// such as a reversal. Shares and CostInr are signed changes to the position.
+type Adjustment struct {
+	ID             uuid.UUID       `json:"id"`
+	UserID         uuid.UUID       `json:"userId"`
+	Symbol         string          `json:"symbol"`
+	Kind           string          `json:"kind"`
+	Shares         decimal.Decimal `json:"shares"`
+	CostInr        decimal.Decimal `json:"costInr"`
+	CashInr        decimal.Decimal `json:"cashInr"`
+	FeeTreatment   string          `json:"feeTreatment,omitempty"`
+	Reason         string          `json:"reason"`
+	ReferenceEvent *uuid.UUID      `json:"referenceEvent,omitempty"`
+	IdempotencyKey string          `json:"idempotencyKey"`
+	CreatedAt      time.Time       `json:"createdAt"`
+}
+
+// CorporateAction is a split, bonus issue, rename, merger or delisting of a
+// symbol. RatioNew shares are issued for every RatioHeld held (for a split
+// the new shares replace the held ones, for a rename or merger they are
+// shares of NewSymbol), so Factor is the multiplier applied to positions on
+// ExDate. CashPrice is the cash-in-lieu paid per NewSymbol share for the
+// fractions of a merger, or the cash-out paid per share on a delisting.
+type CorporateAction struct {
+	ID           uuid.UUID       `json:"id"`
+	Symbol       string          `json:"symbol"`
+	Kind         string          `json:"kind"`
+	NewSymbol    string          `json:"newSymbol,omitempty"`
+	RatioNew     int64           `json:"ratioNew"`
+	RatioHeld    int64           `json:"ratioHeld"`
+	Factor       decimal.Decimal `json:"factor"`
+	CashPrice    decimal.Decimal `json:"cashPriceInr"`
+	RecordDate   time.Time       `json:"recordDate"`
+	ExDate       time.Time       `json:"exDate"`
+	Status       string          `json:"status"`
+	Note         string          `json:"note,omitempty"`
+	CreatedAt    time.Time       `json:"createdAt"`
+	AppliedAt    *time.Time      `json:"appliedAt,omitempty"`
+	Holders      int             `json:"holders"`
+	SharesIssued decimal.Decimal `json:"sharesIssued"`
+	CashPaid     decimal.Decimal `json:"cashPaidInr"`
+	// FrozenPrice is the last price a delisted symbol is valued at.
+	FrozenPrice decimal.Decimal `json:"frozenPriceInr,omitempty"`
+}
+
+// SymbolAlias records that Symbol stopped trading because of a corporate
+// action. Target is the symbol it became, empty for a delisting.
+type SymbolAlias struct {
+	Symbol      string    `json:"symbol"`
+	Target      string    `json:"target,omitempty"`
+	Kind        string    `json:"kind"`
+	ActionID    uuid.UUID `json:"actionId"`
+	EffectiveAt time.Time `json:"effectiveAt"`
+}
+
+// Dividend is a cash dividend announced on a symbol. Holders as of
+// RecordDate are paid AmountPerShare on PayDate, less TDSRate percent of tax
+// deducted at source. The totals are filled in when it is paid.
+type Dividend struct {
+	ID             uuid.UUID       `json:"id"`
+	Symbol         string          `json:"symbol"`
+	AmountPerShare decimal.Decimal `json:"amountPerShareInr"`
+	RecordDate     time.Time       `json:"recordDate"`
+	PayDate        time.Time       `json:"payDate"`
+	TDSRate        decimal.Decimal `json:"tdsRatePct"`
+	Status         string          `json:"status"`
+	Note           string          `json:"note,omitempty"`
+	CreatedAt      time.Time       `json:"createdAt"`
+	PaidAt         *time.Time      `json:"paidAt,omitempty"`
+	Holders        int             `json:"holders"`
+	GrossInr       decimal.Decimal `json:"grossInr"`
+	TDSInr         decimal.Decimal `json:"tdsInr"`
+	NetInr         decimal.Decimal `json:"netInr"`
+}
+
+// DividendPayment is one holder's share of a dividend. NetInr is credited to
+// the user's wallet.
+type DividendPayment struct {
+	ID             uuid.UUID       `json:"id"`
+	DividendID     uuid.UUID       `json:"dividendId"`
+	UserID         uuid.UUID       `json:"userId"`
+	Symbol         string          `json:"symbol"`
+	Shares         decimal.Decimal `json:"shares"`
+	AmountPerShare decimal.Decimal `json:"amountPerShareInr"`
+	GrossInr       decimal.Decimal `json:"grossInr"`
+	TDSInr         decimal.Decimal `json:"tdsInr"`
+	NetInr         decimal.Decimal `json:"netInr"`
+	RecordDate     time.Time       `json:"recordDate"`
+	PaidAt         time.Time       `json:"paidAt"`
+}
+
+// Wallet is a user's INR cash balance.
+type Wallet struct {
+	UserID     uuid.UUID       `json:"userId"`
+	BalanceInr decimal.Decimal `json:"balanceInr"`
+	UpdatedAt  *time.Time      `json:"updatedAt,omitempty"`
+}
+
+// FeeBreakdown itemises the charges on a purchase as computed by the fee
+// schedule in force for the exchange on the reward date.
+type FeeBreakdown struct {
+	Exchange        string          `json:"exchange"`
+	ScheduleID      string          `json:"scheduleId"`
+	Brokerage       decimal.Decimal `json:"brokerageInr"`
+	GST             decimal.Decimal `json:"gstInr"`
+	STT             decimal.Decimal `json:"sttInr"`
+	ExchangeCharges decimal.Decimal `json:"exchangeChargesInr"`
+	SEBIFees        decimal.Decimal `json:"sebiFeesInr"`
+	StampDuty       decimal.Decimal `json:"stampDutyInr"`
+}
+
+// Statutory returns every charge other than brokerage.
+func (f FeeBreakdown) Statutory() decimal.Decimal {
+	return f.GST.Add(f.STT).Add(f.ExchangeCharges).Add(f.SEBIFees).Add(f.StampDuty)
+}
+
+// Total returns brokerage plus statutory charges.
+func (f FeeBreakdown) Total() decimal.Decimal {
+	return f.Brokerage.Add(f.Statutory())
+}
+
+// LedgerPosting is one ledger_entries row as read back for finance. ID is
+// backend specific and only meaningful as a pagination tie-breaker.
+type LedgerPosting struct {
+	ID          string          `json:"id"`
+	EventID     uuid.UUID       `json:"eventId"`
+	AccountCode string          `json:"accountCode"`
+	AccountType string          `json:"accountType"`
+	Symbol      string          `json:"symbol,omitempty"`
+	DebitInr    decimal.Decimal `json:"debitInr"`
+	CreditInr   decimal.Decimal `json:"creditInr"`
+	StockUnits  decimal.Decimal `json:"stockUnits"`
+	Memo        string          `json:"memo"`
+	CreatedAt   time.Time       `json:"createdAt"`
+}
+
+// LedgerAccount is one entry in the chart of accounts. Postings are only
+// accepted for accounts that exist and are not closed.
+type LedgerAccount struct {
+	Code      string     `json:"code"`
+	Type      string     `json:"type"`
+	Currency  string     `json:"currency"`
+	Symbol    string     `json:"symbol,omitempty"`
+	CreatedAt time.Time  `json:"createdAt"`
+	ClosedAt  *time.Time `json:"closedAt,omitempty"`
+}
+
+// TreasuryFunding is cash paid into the company's reward account.
+// Reference is the caller's unique reference for the transfer.
+type TreasuryFunding struct {
+	ID        uuid.UUID       `json:"id"`
+	AmountInr decimal.Decimal `json:"amountInr"`
+	Reference string          `json:"reference"`
+	Memo      string          `json:"memo,omitempty"`
+	CreatedAt time.Time       `json:"createdAt"`
+}
+
+// TreasuryAlert records the cash balance dropping under the low-balance
+// threshold. EventID is the reward that took it there.
+type TreasuryAlert struct {
+	ID           uuid.UUID       `json:"id"`
+	EventID      uuid.UUID       `json:"eventId"`
+	BalanceInr   decimal.Decimal `json:"balanceInr"`
+	ThresholdInr decimal.Decimal `json:"thresholdInr"`
+	CreatedAt    time.Time       `json:"createdAt"`
+}
+
+// AccountBalance totals an account's postings up to a point in time.
+// BalanceInr is debits minus credits, so asset and expense accounts are
+// positive in their normal state.
+type AccountBalance struct {
+	AccountCode string          `json:"accountCode"`
+	AccountType string          `json:"accountType"`
+	Symbol      string          `json:"symbol,omitempty"`
+	DebitInr    decimal.Decimal `json:"debitInr"`
+	CreditInr   decimal.Decimal `json:"creditInr"`
+	BalanceInr  decimal.Decimal `json:"balanceInr"`
+	StockUnits  decimal.Decimal `json:"stockUnits"`
+	Postings    int             `json:"postings"`
+}
+
+// LedgerPeriod is one calendar month of the books. Closing it snapshots the
+// account balances at its end and locks it against rewards dated inside it.
+type LedgerPeriod struct {
+	Period   string           `json:"period"`
+	StartsAt time.Time        `json:"startsAt"`
+	EndsAt   time.Time        `json:"endsAt"`
+	Status   string           `json:"status"`
+	ClosedAt *time.Time       `json:"closedAt,omitempty"`
+	ClosedBy string           `json:"closedBy,omitempty"`
+	Balances []AccountBalance `json:"balances"`
+	History  []PeriodEvent    `json:"history"`
+}
+
+// PeriodEvent is an audited close or reopen of a period.
+type PeriodEvent struct {
+	Action string    `json:"action"`
+	Actor  string    `json:"actor"`
+	Reason string    `json:"reason,omitempty"`
+	At     time.Time `json:"at"`
+}
+
+// EventImbalance is a reward or adjustment whose postings do not balance.
+type EventImbalance struct {
+	EventID       uuid.UUID       `json:"eventId"`
+	DebitInr      decimal.Decimal `json:"debitInr"`
+	CreditInr     decimal.Decimal `json:"creditInr"`
+	DifferenceInr decimal.Decimal `json:"differenceInr"`
+}
+
+// StockMismatch is a symbol whose stock inventory units differ from the sum
+// of user positions.
+type StockMismatch struct {
+	Symbol          string          `json:"symbol"`
+	LedgerUnits     decimal.Decimal `json:"ledgerUnits"`
+	PositionUnits   decimal.Decimal `json:"positionUnits"`
+	DifferenceUnits decimal.Decimal `json:"differenceUnits"`
+}
+
+// TrialBalance is the result of one ledger invariant check: account totals
+// plus every violation found. Balanced is true only when there are none.
+type TrialBalance struct {
+	CheckedAt        time.Time        `json:"checkedAt"`
+	Balanced         bool             `json:"balanced"`
+	TotalDebitInr    decimal.Decimal  `json:"totalDebitInr"`
+	TotalCreditInr   decimal.Decimal  `json:"totalCreditInr"`
+	Accounts         []AccountBalance `json:"accounts"`
+	UnbalancedEvents []EventImbalance `json:"unbalancedEvents"`
+	StockMismatches  []StockMismatch  `json:"stockMismatches"`
 }
@@ -2,17 +2,79 @@ package price
 
 import (
 	"context"
+	"fmt"
 	"hash/crc32"
 	"math/rand"
+	"sort"
+	"strings"
 	"sync"
 	"time"
 
 	"github.com/shopspring/decimal"
+
+	"github.com/stocky/backend/internal/config"
 )
 
 // Fetcher abstracts the external market data provider.
 type Fetcher interface {
-	Fetch(ctx context.Context, symbol string) (decimal.Decimal, error)
+	Fetch(ctx context.Context, symbol string) (Quote, error)
+}
+
+// BatchFetcher is implemented by providers that can quote many symbols in
+// one call. When some symbols fail FetchBatch returns the quotes it got with
+// a FetchErrors naming the rest; any other error fails every symbol.
+type BatchFetcher interface {
+	Fetcher
+	FetchBatch(ctx context.Context, symbols []string) (map[string]Quote, error)
+}
+
+// FetchErrors reports, per symbol, why a multi-symbol fetch could not quote
+// it. It is returned alongside the quotes that were fetched.
+type FetchErrors map[string]error
+
+func (e FetchErrors) Error() string {
+	symbols := make([]string, 0, len(e))
+	for symbol := range e {
+		symbols = append(symbols, symbol)
+	}
+	sort.Strings(symbols)
+	parts := make([]string, 0, len(symbols))
+	for _, symbol := range symbols {
+		parts = append(parts, fmt.Sprintf("%s: %v", symbol, e[symbol]))
+	}
+	return fmt.Sprintf("%d symbols not quoted: %s", len(e), strings.Join(parts, "; "))
+}
+
+func (e FetchErrors) Unwrap() []error {
+	errs := make([]error, 0, len(e))
+	for _, err := range e {
+		errs = append(errs, err)
+	}
+	return errs
+}
+
+// Quote is a price as reported by a Fetcher. AsOf is the provider's own
+// timestamp; a zero AsOf means the quote is as of the moment it arrived.
+type Quote struct {
+	Price  decimal.Decimal
+	AsOf   time.Time
+	Source string
+}
+
+// NewFetcher builds the Fetcher selected by PRICE_PROVIDER.
+func NewFetcher(pc config.PriceConfig) (Fetcher, error) {
+	switch pc.Provider {
+	case config.ProviderRandom:
+		return NewRandomFetcher(pc.RandomFloorPrice, pc.RandomCeilPrice), nil
+	case config.ProviderHTTP:
+		fetcher := NewHTTPFetcher(pc.HTTP, nil)
+		if pc.HTTP.BatchURLTemplate != "" {
+			return &HTTPBatchFetcher{HTTPFetcher: fetcher}, nil
+		}
+		return fetcher, nil
+	default:
+		return nil, fmt.Errorf("unknown price provider %q", pc.Provider)
+	}
 }
 
 type RandomFetcher struct {
@@ -31,7 +93,7 @@ func NewRandomFetcher(min, max float64) *RandomFetcher {
 	}
 }
 
-func (f *RandomFetcher) Fetch(_ context.Context, symbol string) (decimal.Decimal, error) {
+func (f *RandomFetcher) Fetch(_ context.Context, symbol string) (Quote, error) {
 	f.mu.Lock()
 	defer f.mu.Unlock()
 
@@ -39,5 +101,5 @@ func (f *RandomFetcher) Fetch(_ context.Context, symbol string) (decimal.Decimal
 	value := f.min + f.rnd.Float64()*span
 	offset := float64(crc32.ChecksumIEEE([]byte(symbol))%200) / 20.0
 	value += offset
-	return decimal.NewFromFloat(value).Round(2), nil
+	return Quote{Price: decimal.NewFromFloat(value).Round(2), Source: "mock-random"}, nil
 }
@@ -0,0 +1,302 @@
+package price
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/shopspring/decimal"
+
+	"github.com/stocky/backend/internal/config"
+)
+
+// ErrBadQuote is returned for a provider response that cannot be used as a
+// quote: an unexpected status, a malformed body or a missing, non-positive
+// or future-dated value.
+var ErrBadQuote = errors.New("invalid quote response")
+
+// HTTPSource is the PriceQuote.Source of quotes from an HTTPFetcher.
+const HTTPSource = "http"
+
+const (
+	// maxQuoteBody caps how much of a response is read.
+	maxQuoteBody = 1 << 20
+	// maxClockSkew is how far in the future a provider timestamp may be
+	// before the quote is rejected.
+	maxClockSkew = time.Minute
+)
+
+// HTTPFetcher reads quotes from a JSON endpoint, one request per symbol.
+// Network errors, 429 and 5xx responses are retried with exponential
+// backoff; any other failure is returned at once.
+type HTTPFetcher struct {
+	cfg    config.HTTPPriceConfig
+	client *http.Client
+}
+
+// NewHTTPFetcher returns a fetcher for cfg. A nil client uses
+// http.DefaultClient; each attempt is bounded by cfg.Timeout either way.
+func NewHTTPFetcher(cfg config.HTTPPriceConfig, client *http.Client) *HTTPFetcher {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return &HTTPFetcher{cfg: cfg, client: client}
+}
+
+func (f *HTTPFetcher) Fetch(ctx context.Context, symbol string) (Quote, error) {
+	endpoint := strings.ReplaceAll(f.cfg.URLTemplate, "{symbol}", url.PathEscape(symbol))
+	doc, receivedAt, err := f.get(ctx, endpoint)
+	if err != nil {
+		return Quote{}, fmt.Errorf("%s: %w", symbol, err)
+	}
+	quote, err := f.quoteAt(doc, receivedAt)
+	if err != nil {
+		return Quote{}, fmt.Errorf("%s: %w", symbol, err)
+	}
+	return quote, nil
+}
+
+// get fetches and decodes endpoint, retrying as described on HTTPFetcher.
+func (f *HTTPFetcher) get(ctx context.Context, endpoint string) (any, time.Time, error) {
+	backoff := f.cfg.Backoff
+	for attempt := 0; ; attempt++ {
+		doc, receivedAt, retry, err := f.getOnce(ctx, endpoint)
+		if err == nil {
+			return doc, receivedAt, nil
+		}
+		if !retry || attempt >= f.cfg.Retries {
+			return nil, time.Time{}, err
+		}
+		select {
+		case <-ctx.Done():
+			return nil, time.Time{}, fmt.Errorf("%w (last error: %v)", ctx.Err(), err)
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+	}
+}
+
+// getOnce makes one attempt and reports whether a failure is worth
+// retrying.
+func (f *HTTPFetcher) getOnce(ctx context.Context, endpoint string) (any, time.Time, bool, error) {
+	ctx, cancel := context.WithTimeout(ctx, f.cfg.Timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return nil, time.Time{}, false, err
+	}
+	req.Header.Set("Accept", "application/json")
+	if f.cfg.AuthHeader != "" {
+		req.Header.Set(f.cfg.AuthHeader, f.cfg.AuthValue)
+	}
+
+	resp, err := f.client.Do(req)
+	if err != nil {
+		return nil, time.Time{}, true, err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxQuoteBody))
+	if err != nil {
+		return nil, time.Time{}, true, err
+	}
+	receivedAt := time.Now().UTC()
+
+	if resp.StatusCode != http.StatusOK {
+		retry := resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500
+		return nil, time.Time{}, retry, fmt.Errorf("%w: status %d", ErrBadQuote, resp.StatusCode)
+	}
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+	var doc any
+	if err := decoder.Decode(&doc); err != nil {
+		return nil, time.Time{}, false, fmt.Errorf("%w: %v", ErrBadQuote, err)
+	}
+	return doc, receivedAt, false, nil
+}
+
+// quoteAt reads the price and timestamp out of a decoded quote object.
+func (f *HTTPFetcher) quoteAt(doc any, receivedAt time.Time) (Quote, error) {
+	raw, err := lookupPath(doc, f.cfg.PricePath)
+	if err != nil {
+		return Quote{}, err
+	}
+	price, err := decimalValue(raw)
+	if err != nil {
+		return Quote{}, fmt.Errorf("%w: %s: %v", ErrBadQuote, f.cfg.PricePath, err)
+	}
+	if !price.IsPositive() {
+		return Quote{}, fmt.Errorf("%w: %s is %s, want a positive price", ErrBadQuote, f.cfg.PricePath, price)
+	}
+
+	quote := Quote{Price: price.Round(4), AsOf: receivedAt, Source: HTTPSource}
+	if f.cfg.TimePath == "" {
+		return quote, nil
+	}
+	raw, err = lookupPath(doc, f.cfg.TimePath)
+	if err != nil {
+		return Quote{}, err
+	}
+	asOf, err := timeValue(raw)
+	if err != nil {
+		return Quote{}, fmt.Errorf("%w: %s: %v", ErrBadQuote, f.cfg.TimePath, err)
+	}
+	if asOf.After(receivedAt.Add(maxClockSkew)) {
+		return Quote{}, fmt.Errorf("%w: %s %s is in the future", ErrBadQuote, f.cfg.TimePath, asOf.Format(time.RFC3339))
+	}
+	quote.AsOf = asOf
+	return quote, nil
+}
+
+// HTTPBatchFetcher is an HTTPFetcher whose provider also serves many symbols
+// per request. Symbols are sent in chunks of BatchSize; a chunk that fails
+// outright fails each of its symbols, and the rest carry on.
+type HTTPBatchFetcher struct {
+	*HTTPFetcher
+}
+
+func (f *HTTPBatchFetcher) FetchBatch(ctx context.Context, symbols []string) (map[string]Quote, error) {
+	quotes := make(map[string]Quote, len(symbols))
+	errs := make(FetchErrors)
+	for start := 0; start < len(symbols); start += f.cfg.BatchSize {
+		chunk := symbols[start:min(start+f.cfg.BatchSize, len(symbols))]
+		if err := f.fetchChunk(ctx, chunk, quotes, errs); err != nil {
+			for _, symbol := range chunk {
+				errs[symbol] = err
+			}
+		}
+	}
+	if len(errs) > 0 {
+		return quotes, errs
+	}
+	return quotes, nil
+}
+
+// fetchChunk requests one chunk and files each item under the requested
+// symbol it names. Items for symbols that were not asked for are ignored;
+// requested symbols missing from the response are left for the caller.
+func (f *HTTPBatchFetcher) fetchChunk(ctx context.Context, chunk []string, quotes map[string]Quote, errs FetchErrors) error {
+	escaped := make([]string, len(chunk))
+	requested := make(map[string]string, len(chunk))
+	for i, symbol := range chunk {
+		escaped[i] = url.QueryEscape(symbol)
+		requested[strings.ToUpper(symbol)] = symbol
+	}
+	endpoint := strings.ReplaceAll(f.cfg.BatchURLTemplate, "{symbols}", strings.Join(escaped, ","))
+	doc, receivedAt, err := f.get(ctx, endpoint)
+	if err != nil {
+		return err
+	}
+	if f.cfg.BatchItemsPath != "" {
+		if doc, err = lookupPath(doc, f.cfg.BatchItemsPath); err != nil {
+			return err
+		}
+	}
+
+	file := func(name string, item any) {
+		symbol, ok := requested[strings.ToUpper(strings.TrimSpace(name))]
+		if !ok {
+			return
+		}
+		quote, err := f.quoteAt(item, receivedAt)
+		if err != nil {
+			errs[symbol] = err
+			return
+		}
+		quotes[symbol] = quote
+	}
+	switch items := doc.(type) {
+	case map[string]any:
+		for name, item := range items {
+			file(name, item)
+		}
+	case []any:
+		if f.cfg.SymbolPath == "" {
+			return fmt.Errorf("%w: batch items are a list but no symbol path is configured", ErrBadQuote)
+		}
+		for _, item := range items {
+			raw, err := lookupPath(item, f.cfg.SymbolPath)
+			if err != nil {
+				continue
+			}
+			if name, ok := raw.(string); ok {
+				file(name, item)
+			}
+		}
+	default:
+		return fmt.Errorf("%w: batch items are neither an object nor a list", ErrBadQuote)
+	}
+	return nil
+}
+
+// lookupPath walks a dot-separated path through decoded JSON; numeric
+// segments index arrays.
+func lookupPath(doc any, path string) (any, error) {
+	current := doc
+	for _, segment := range strings.Split(path, ".") {
+		switch node := current.(type) {
+		case map[string]any:
+			value, ok := node[segment]
+			if !ok {
+				return nil, fmt.Errorf("%w: %s not found", ErrBadQuote, path)
+			}
+			current = value
+		case []any:
+			index, err := strconv.Atoi(segment)
+			if err != nil || index < 0 || index >= len(node) {
+				return nil, fmt.Errorf("%w: %s not found", ErrBadQuote, path)
+			}
+			current = node[index]
+		default:
+			return nil, fmt.Errorf("%w: %s not found", ErrBadQuote, path)
+		}
+	}
+	if current == nil {
+		return nil, fmt.Errorf("%w: %s is null", ErrBadQuote, path)
+	}
+	return current, nil
+}
+
+// decimalValue accepts a JSON number or a numeric string.
+func decimalValue(raw any) (decimal.Decimal, error) {
+	switch value := raw.(type) {
+	case json.Number:
+		return decimal.NewFromString(value.String())
+	case string:
+		return decimal.NewFromString(strings.TrimSpace(value))
+	default:
+		return decimal.Zero, fmt.Errorf("%v is not a number", raw)
+	}
+}
+
+// timeValue accepts an RFC 3339 string or a Unix time in seconds or, when
+// too large to be seconds, milliseconds.
+func timeValue(raw any) (time.Time, error) {
+	switch value := raw.(type) {
+	case string:
+		parsed, err := time.Parse(time.RFC3339Nano, strings.TrimSpace(value))
+		if err != nil {
+			return time.Time{}, fmt.Errorf("%q is not an RFC 3339 time", value)
+		}
+		return parsed.UTC(), nil
+	case json.Number:
+		unix, err := value.Int64()
+		if err != nil || unix <= 0 {
+			return time.Time{}, fmt.Errorf("%s is not a Unix time", value)
+		}
+		if unix > 1e11 {
+			return time.UnixMilli(unix).UTC(), nil
+		}
+		return time.Unix(unix, 0).UTC(), nil
+	default:
+		return time.Time{}, fmt.Errorf("%v is not a time", raw)
+	}
+}
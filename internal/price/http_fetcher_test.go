@@ -0,0 +1,442 @@
+package price
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+
+	"github.com/stocky/backend/internal/config"
+)
+
+// provider is a scripted quote endpoint. Each request is answered by the
+// next status in statuses (200 once they run out) and recorded with the
+// time it arrived.
+type provider struct {
+	mu       sync.Mutex
+	statuses []int
+	body     func(r *http.Request) string
+	paths    []string
+	arrived  []time.Time
+	headers  []http.Header
+}
+
+func (p *provider) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	p.mu.Lock()
+	p.paths = append(p.paths, r.URL.RequestURI())
+	p.arrived = append(p.arrived, time.Now())
+	p.headers = append(p.headers, r.Header.Clone())
+	status := http.StatusOK
+	if len(p.statuses) > 0 {
+		status, p.statuses = p.statuses[0], p.statuses[1:]
+	}
+	p.mu.Unlock()
+
+	w.WriteHeader(status)
+	if status == http.StatusOK {
+		fmt.Fprint(w, p.body(r))
+	}
+}
+
+func (p *provider) requests() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return len(p.paths)
+}
+
+func staticBody(body string) func(*http.Request) string {
+	return func(*http.Request) string { return body }
+}
+
+func newTestFetcher(t *testing.T, p *provider, cfg config.HTTPPriceConfig) *HTTPFetcher {
+	t.Helper()
+	server := httptest.NewServer(p)
+	t.Cleanup(server.Close)
+	cfg.URLTemplate = server.URL + "/q/{symbol}"
+	cfg.BatchURLTemplate = server.URL + "/batch?s={symbols}"
+	if cfg.PricePath == "" {
+		cfg.PricePath = "data.lastPrice"
+	}
+	if cfg.Timeout == 0 {
+		cfg.Timeout = time.Second
+	}
+	return NewHTTPFetcher(cfg, server.Client())
+}
+
+func TestHTTPFetcherRetries(t *testing.T) {
+	tests := []struct {
+		name     string
+		statuses []int
+		body     string
+		retries  int
+		wantErr  bool
+		attempts int
+	}{
+		{name: "first attempt succeeds", retries: 2, attempts: 1},
+		{name: "5xx is retried", statuses: []int{503}, retries: 2, attempts: 2},
+		{name: "429 is retried", statuses: []int{429, 429}, retries: 2, attempts: 3},
+		{name: "retries run out", statuses: []int{500, 502, 503}, retries: 2, wantErr: true, attempts: 3},
+		{name: "no retries configured", statuses: []int{503}, wantErr: true, attempts: 1},
+		{name: "404 is not retried", statuses: []int{404}, retries: 2, wantErr: true, attempts: 1},
+		{name: "malformed body is not retried", body: `{"data":`, retries: 2, wantErr: true, attempts: 1},
+		{name: "missing price is not retried", body: `{"data":{}}`, retries: 2, wantErr: true, attempts: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body := tt.body
+			if body == "" {
+				body = `{"data":{"lastPrice":2451.35}}`
+			}
+			p := &provider{statuses: tt.statuses, body: staticBody(body)}
+			f := newTestFetcher(t, p, config.HTTPPriceConfig{Retries: tt.retries, Backoff: time.Millisecond})
+
+			quote, err := f.Fetch(context.Background(), "RELIANCE")
+			if tt.wantErr {
+				if !errors.Is(err, ErrBadQuote) {
+					t.Fatalf("err = %v, want ErrBadQuote", err)
+				}
+			} else {
+				if err != nil {
+					t.Fatalf("Fetch: %v", err)
+				}
+				if want := decimal.RequireFromString("2451.35"); !quote.Price.Equal(want) {
+					t.Errorf("price = %s, want %s", quote.Price, want)
+				}
+			}
+			if got := p.requests(); got != tt.attempts {
+				t.Errorf("attempts = %d, want %d", got, tt.attempts)
+			}
+		})
+	}
+}
+
+func TestHTTPFetcherBackoffDoubles(t *testing.T) {
+	const backoff = 20 * time.Millisecond
+	p := &provider{statuses: []int{503, 503, 503}, body: staticBody(`{"data":{"lastPrice":10}}`)}
+	f := newTestFetcher(t, p, config.HTTPPriceConfig{Retries: 3, Backoff: backoff})
+
+	if _, err := f.Fetch(context.Background(), "INFY"); err != nil {
+		t.Fatalf("Fetch: %v", err)
+	}
+	if len(p.arrived) != 4 {
+		t.Fatalf("attempts = %d, want 4", len(p.arrived))
+	}
+	for i := 1; i < len(p.arrived); i++ {
+		want := backoff << (i - 1)
+		if gap := p.arrived[i].Sub(p.arrived[i-1]); gap < want {
+			t.Errorf("gap before attempt %d = %s, want at least %s", i+1, gap, want)
+		}
+	}
+}
+
+func TestHTTPFetcherBackoffStopsOnCancel(t *testing.T) {
+	p := &provider{statuses: []int{503, 503}, body: staticBody(`{"data":{"lastPrice":10}}`)}
+	f := newTestFetcher(t, p, config.HTTPPriceConfig{Retries: 2, Backoff: time.Hour})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	_, err := f.Fetch(ctx, "INFY")
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("err = %v, want context.DeadlineExceeded", err)
+	}
+	if got := p.requests(); got != 1 {
+		t.Errorf("attempts = %d, want 1", got)
+	}
+}
+
+func TestHTTPFetcherTimestamps(t *testing.T) {
+	future := time.Now().Add(time.Hour).UTC().Format(time.RFC3339)
+	tests := []struct {
+		name     string
+		timePath string
+		body     string
+		wantAsOf time.Time
+		wantErr  bool
+	}{
+		{
+			name: "no time path uses the receive time",
+			body: `{"data":{"lastPrice":"101.5"}}`,
+		},
+		{
+			name:     "RFC 3339 string",
+			timePath: "data.ts",
+			body:     `{"data":{"lastPrice":101.5,"ts":"2024-05-10T09:15:00+05:30"}}`,
+			wantAsOf: time.Date(2024, 5, 10, 3, 45, 0, 0, time.UTC),
+		},
+		{
+			name:     "Unix seconds",
+			timePath: "data.ts",
+			body:     `{"data":{"lastPrice":101.5,"ts":1715312700}}`,
+			wantAsOf: time.Unix(1715312700, 0).UTC(),
+		},
+		{
+			name:     "Unix milliseconds",
+			timePath: "data.ts",
+			body:     `{"data":{"lastPrice":101.5,"ts":1715312700123}}`,
+			wantAsOf: time.UnixMilli(1715312700123).UTC(),
+		},
+		{
+			name:     "future timestamp",
+			timePath: "data.ts",
+			body:     `{"data":{"lastPrice":101.5,"ts":"` + future + `"}}`,
+			wantErr:  true,
+		},
+		{
+			name:     "missing timestamp",
+			timePath: "data.ts",
+			body:     `{"data":{"lastPrice":101.5}}`,
+			wantErr:  true,
+		},
+		{
+			name:    "non-positive price",
+			body:    `{"data":{"lastPrice":"-1"}}`,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := &provider{body: staticBody(tt.body)}
+			f := newTestFetcher(t, p, config.HTTPPriceConfig{TimePath: tt.timePath})
+
+			before := time.Now().UTC()
+			quote, err := f.Fetch(context.Background(), "TCS")
+			if tt.wantErr {
+				if !errors.Is(err, ErrBadQuote) {
+					t.Fatalf("err = %v, want ErrBadQuote", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Fetch: %v", err)
+			}
+			if quote.Source != HTTPSource {
+				t.Errorf("source = %q, want %q", quote.Source, HTTPSource)
+			}
+			if tt.wantAsOf.IsZero() {
+				if quote.AsOf.Before(before) || quote.AsOf.After(time.Now().UTC()) {
+					t.Errorf("asOf = %s, want the receive time", quote.AsOf)
+				}
+				return
+			}
+			if !quote.AsOf.Equal(tt.wantAsOf) {
+				t.Errorf("asOf = %s, want %s", quote.AsOf, tt.wantAsOf)
+			}
+		})
+	}
+}
+
+func TestHTTPFetcherRequest(t *testing.T) {
+	p := &provider{body: staticBody(`{"data":{"lastPrice":10}}`)}
+	f := newTestFetcher(t, p, config.HTTPPriceConfig{AuthHeader: "X-Api-Key", AuthValue: "secret"})
+
+	if _, err := f.Fetch(context.Background(), "M&M"); err != nil {
+		t.Fatalf("Fetch: %v", err)
+	}
+	if want := "/q/M&M"; p.paths[0] != want {
+		t.Errorf("path = %q, want %q", p.paths[0], want)
+	}
+	if got := p.headers[0].Get("X-Api-Key"); got != "secret" {
+		t.Errorf("X-Api-Key = %q, want secret", got)
+	}
+	if got := p.headers[0].Get("Accept"); got != "application/json" {
+		t.Errorf("Accept = %q, want application/json", got)
+	}
+}
+
+// batchBody answers a batch request with an object keyed by the lower-cased
+// symbol, skipping MISSING and pricing BAD at zero.
+func batchBody(r *http.Request) string {
+	items := make(map[string]any)
+	for _, symbol := range strings.Split(r.URL.Query().Get("s"), ",") {
+		switch symbol {
+		case "MISSING":
+		case "BAD":
+			items[strings.ToLower(symbol)] = map[string]any{"lastPrice": 0}
+		default:
+			items[strings.ToLower(symbol)] = map[string]any{"lastPrice": 100}
+		}
+	}
+	items["UNASKED"] = map[string]any{"lastPrice": 1}
+	raw, _ := json.Marshal(map[string]any{"quotes": items})
+	return string(raw)
+}
+
+func TestHTTPBatchFetcherChunks(t *testing.T) {
+	p := &provider{body: batchBody}
+	f := &HTTPBatchFetcher{newTestFetcher(t, p, config.HTTPPriceConfig{
+		PricePath:      "lastPrice",
+		BatchItemsPath: "quotes",
+		BatchSize:      2,
+	})}
+
+	symbols := []string{"INFY", "TCS", "MISSING", "BAD", "WIPRO"}
+	quotes, err := f.FetchBatch(context.Background(), symbols)
+
+	wantPaths := []string{"/batch?s=INFY,TCS", "/batch?s=MISSING,BAD", "/batch?s=WIPRO"}
+	if !slices.Equal(p.paths, wantPaths) {
+		t.Errorf("requests = %v, want %v", p.paths, wantPaths)
+	}
+	for _, symbol := range []string{"INFY", "TCS", "WIPRO"} {
+		if !quotes[symbol].Price.Equal(decimal.NewFromInt(100)) {
+			t.Errorf("%s price = %s, want 100", symbol, quotes[symbol].Price)
+		}
+	}
+	if len(quotes) != 3 {
+		t.Errorf("quotes = %v, want only the three priced symbols", quotes)
+	}
+	var failed FetchErrors
+	if !errors.As(err, &failed) {
+		t.Fatalf("err = %v, want FetchErrors", err)
+	}
+	if len(failed) != 1 || !errors.Is(failed["BAD"], ErrBadQuote) {
+		t.Errorf("errors = %v, want only BAD", failed)
+	}
+}
+
+func TestHTTPBatchFetcherFailedChunk(t *testing.T) {
+	p := &provider{statuses: []int{http.StatusOK, http.StatusNotFound}, body: batchBody}
+	f := &HTTPBatchFetcher{newTestFetcher(t, p, config.HTTPPriceConfig{
+		PricePath:      "lastPrice",
+		BatchItemsPath: "quotes",
+		BatchSize:      2,
+		Retries:        1,
+		Backoff:        time.Millisecond,
+	})}
+
+	quotes, err := f.FetchBatch(context.Background(), []string{"A", "B", "C"})
+	var failed FetchErrors
+	if !errors.As(err, &failed) {
+		t.Fatalf("err = %v, want FetchErrors", err)
+	}
+	if len(quotes) != 2 || len(failed) != 1 || !errors.Is(failed["C"], ErrBadQuote) {
+		t.Errorf("quotes = %v, errors = %v; want A and B priced and C failed", quotes, failed)
+	}
+}
+
+func TestHTTPBatchFetcherListItems(t *testing.T) {
+	body := `{"quotes":[{"ticker":"infy","data":{"lastPrice":"1500"}},{"data":{"lastPrice":"1"}},{"ticker":"tcs","data":{"lastPrice":"3900"}}]}`
+	tests := []struct {
+		name       string
+		symbolPath string
+		wantErr    bool
+	}{
+		{name: "symbol path names each item", symbolPath: "ticker"},
+		{name: "list without a symbol path", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := &provider{body: staticBody(body)}
+			f := &HTTPBatchFetcher{newTestFetcher(t, p, config.HTTPPriceConfig{
+				BatchItemsPath: "quotes",
+				SymbolPath:     tt.symbolPath,
+				BatchSize:      10,
+			})}
+
+			quotes, err := f.FetchBatch(context.Background(), []string{"INFY", "TCS"})
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("quotes = %v, want an error", quotes)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("FetchBatch: %v", err)
+			}
+			if !quotes["INFY"].Price.Equal(decimal.NewFromInt(1500)) || !quotes["TCS"].Price.Equal(decimal.NewFromInt(3900)) {
+				t.Errorf("quotes = %v", quotes)
+			}
+		})
+	}
+}
+
+func decodeJSON(t *testing.T, raw string) any {
+	t.Helper()
+	decoder := json.NewDecoder(bytes.NewReader([]byte(raw)))
+	decoder.UseNumber()
+	var doc any
+	if err := decoder.Decode(&doc); err != nil {
+		t.Fatalf("decode %s: %v", raw, err)
+	}
+	return doc
+}
+
+func TestLookupPath(t *testing.T) {
+	doc := `{"data":{"lastPrice":12.5,"levels":[{"p":"1"},{"p":"2"}],"empty":null,"name":"x"}}`
+	tests := []struct {
+		path    string
+		want    any
+		wantErr bool
+	}{
+		{path: "data.lastPrice", want: json.Number("12.5")},
+		{path: "data.levels.1.p", want: "2"},
+		{path: "data.name", want: "x"},
+		{path: "data.missing", wantErr: true},
+		{path: "data.levels.2.p", wantErr: true},
+		{path: "data.levels.-1.p", wantErr: true},
+		{path: "data.levels.first", wantErr: true},
+		{path: "data.name.inner", wantErr: true},
+		{path: "data.empty", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			got, err := lookupPath(decodeJSON(t, doc), tt.path)
+			if tt.wantErr {
+				if !errors.Is(err, ErrBadQuote) {
+					t.Fatalf("lookupPath = %v, %v; want ErrBadQuote", got, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("lookupPath: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("lookupPath = %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTimeValue(t *testing.T) {
+	tests := []struct {
+		name    string
+		raw     any
+		want    time.Time
+		wantErr bool
+	}{
+		{name: "RFC 3339", raw: "2024-05-10T15:30:00Z", want: time.Date(2024, 5, 10, 15, 30, 0, 0, time.UTC)},
+		{name: "RFC 3339 with offset", raw: " 2024-05-10T21:00:00.5+05:30 ", want: time.Date(2024, 5, 10, 15, 30, 0, 5e8, time.UTC)},
+		{name: "seconds", raw: json.Number("1715355000"), want: time.Unix(1715355000, 0).UTC()},
+		{name: "milliseconds", raw: json.Number("1715355000250"), want: time.UnixMilli(1715355000250).UTC()},
+		{name: "date only", raw: "2024-05-10", wantErr: true},
+		{name: "zero", raw: json.Number("0"), wantErr: true},
+		{name: "fractional seconds", raw: json.Number("1715355000.5"), wantErr: true},
+		{name: "boolean", raw: true, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := timeValue(tt.raw)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("timeValue = %s, want an error", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("timeValue: %v", err)
+			}
+			if !got.Equal(tt.want) {
+				t.Errorf("timeValue = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
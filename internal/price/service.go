@@ -3,23 +3,49 @@ package price
 import (
 	"context"
 	"errors"
+	"fmt"
+	"sync"
 	"time"
 
-	"github.com/shopspring/decimal"
-
+	"github.com/stocky/backend/internal/config"
 	"github.com/stocky/backend/internal/models"
 	"github.com/stocky/backend/internal/repository"
 )
 
+// ErrUnavailable wraps every failure to fetch a quote from the provider.
+var ErrUnavailable = errors.New("price provider unavailable")
+
+// errNotAttempted marks a symbol whose fetch never started because the
+// context ended first.
+var errNotAttempted = errors.New("fetch not attempted")
+
+// Refresh outcomes for RefreshResult.Status.
+const (
+	RefreshOK      = "ok"
+	RefreshFailed  = "failed"
+	RefreshSkipped = "skipped"
+)
+
+// RefreshResult is the outcome of refreshing one symbol: the stored quote
+// when Status is RefreshOK, otherwise the reason it was not refreshed.
+type RefreshResult struct {
+	Symbol string
+	Status string
+	Quote  *models.PriceQuote
+	Err    error
+}
+
 type Service struct {
-	repo    *repository.Repository
-	fetcher Fetcher
+	repo        repository.Store
+	fetcher     Fetcher
+	concurrency int
 }
 
-func NewService(repo *repository.Repository, fetcher Fetcher) *Service {
+func NewService(repo repository.Store, fetcher Fetcher, pc config.PriceConfig) *Service {
 	return &Service{
-		repo:    repo,
-		fetcher: fetcher,
+		repo:        repo,
+		fetcher:     fetcher,
+		concurrency: max(pc.FetchConcurrency, 1),
 	}
 }
 
@@ -28,66 +54,190 @@ func (s *Service) EnsureQuote(ctx context.Context, symbol string) (*models.Price
 	if err == nil {
 		return quote, nil
 	}
-	if !errors.Is(err, repository.ErrQuoteNotFound) {
+	// A quarantined quote is replaced by a fresh one rather than failing the
+	// caller.
+	if !errors.Is(err, repository.ErrQuoteNotFound) && !repository.IsDecodeError(err) {
 		return nil, err
 	}
 	return s.fetchAndPersist(ctx, symbol)
 }
 
-func (s *Service) RefreshAll(ctx context.Context) ([]models.PriceQuote, error) {
+// Refresh fetches a new quote for symbol and stores it, regardless of the
+// cached one.
+func (s *Service) Refresh(ctx context.Context, symbol string) (*models.PriceQuote, error) {
+	return s.fetchAndPersist(ctx, symbol)
+}
+
+// RefreshAll fetches and stores a new quote for every tracked symbol and
+// reports each one in symbol order. A symbol that fails does not stop the
+// others; one that was never tried because ctx ended is skipped. The error
+// is only set when the tracked symbols cannot be listed.
+func (s *Service) RefreshAll(ctx context.Context) ([]RefreshResult, error) {
 	symbols, err := s.repo.ListTrackedSymbols(ctx)
 	if err != nil {
 		return nil, err
 	}
-	var quotes []models.PriceQuote
+	quotes, err := s.fetchMany(ctx, symbols)
+	failed, _ := err.(FetchErrors)
+	results := make([]RefreshResult, 0, len(symbols))
 	for _, symbol := range symbols {
-		quote, err := s.fetchAndPersist(ctx, symbol)
-		if err != nil {
-			return nil, err
+		result := RefreshResult{Symbol: symbol, Status: RefreshOK}
+		if quote, ok := quotes[symbol]; ok {
+			result.Quote = &quote
+		} else {
+			result.Status, result.Err = RefreshFailed, failed[symbol]
+			if errors.Is(result.Err, errNotAttempted) {
+				result.Status = RefreshSkipped
+			}
 		}
-		quotes = append(quotes, *quote)
+		results = append(results, result)
 	}
-	return quotes, nil
+	return results, nil
 }
 
+// QuotesFor returns the cached quote of each symbol, fetching the ones never
+// quoted. A renamed, merged or delisted symbol no longer trades, so it keeps
+// its last cached quote and is left out when it has none. Symbols that
+// cannot be fetched are reported in a FetchErrors alongside the rest.
 func (s *Service) QuotesFor(ctx context.Context, symbols []string) (map[string]models.PriceQuote, error) {
 	result, err := s.repo.QuotesForSymbols(ctx, symbols)
 	if err != nil {
 		return nil, err
 	}
+	var missing []string
+	seen := make(map[string]bool)
 	for _, symbol := range symbols {
-		if _, ok := result[symbol]; ok {
+		if _, ok := result[symbol]; ok || seen[symbol] {
 			continue
 		}
-		quote, err := s.fetchAndPersist(ctx, symbol)
-		if err != nil {
+		seen[symbol] = true
+		_, err := s.repo.SymbolAlias(ctx, symbol)
+		if err == nil {
+			continue
+		}
+		if !errors.Is(err, repository.ErrAliasNotFound) {
 			return nil, err
 		}
-		result[symbol] = *quote
+		missing = append(missing, symbol)
+	}
+	if len(missing) == 0 {
+		return result, nil
 	}
-	return result, nil
+	fetched, err := s.fetchMany(ctx, missing)
+	for symbol, quote := range fetched {
+		result[symbol] = quote
+	}
+	return result, err
+}
+
+// fetchMany fetches and stores quotes for symbols, in one call when the
+// fetcher is a BatchFetcher and otherwise with up to s.concurrency Fetch
+// calls in flight. The error, if any, is a FetchErrors.
+func (s *Service) fetchMany(ctx context.Context, symbols []string) (map[string]models.PriceQuote, error) {
+	quotes := make(map[string]models.PriceQuote, len(symbols))
+	errs := make(FetchErrors)
+	if err := ctx.Err(); err != nil {
+		for _, symbol := range symbols {
+			errs[symbol] = fmt.Errorf("%w: %w", errNotAttempted, err)
+		}
+		return quotes, errs
+	}
+	if batch, ok := s.fetcher.(BatchFetcher); ok {
+		s.fetchBatch(ctx, batch, symbols, quotes, errs)
+	} else {
+		s.fetchEach(ctx, symbols, quotes, errs)
+	}
+	if len(errs) > 0 {
+		return quotes, errs
+	}
+	return quotes, nil
+}
+
+func (s *Service) fetchBatch(ctx context.Context, batch BatchFetcher, symbols []string, quotes map[string]models.PriceQuote, errs FetchErrors) {
+	fetched, err := batch.FetchBatch(ctx, symbols)
+	var failed FetchErrors
+	if err != nil && !errors.As(err, &failed) {
+		for _, symbol := range symbols {
+			errs[symbol] = fmt.Errorf("%w: %w", ErrUnavailable, err)
+		}
+		return
+	}
+	for _, symbol := range symbols {
+		quote, ok := fetched[symbol]
+		if !ok {
+			cause := failed[symbol]
+			if cause == nil {
+				cause = errors.New("missing from batch response")
+			}
+			errs[symbol] = fmt.Errorf("%w: %w", ErrUnavailable, cause)
+			continue
+		}
+		saved, err := s.saveQuote(ctx, symbol, quote)
+		if err != nil {
+			errs[symbol] = err
+			continue
+		}
+		quotes[symbol] = *saved
+	}
+}
+
+func (s *Service) fetchEach(ctx context.Context, symbols []string, quotes map[string]models.PriceQuote, errs FetchErrors) {
+	var (
+		mu      sync.Mutex
+		wg      sync.WaitGroup
+		pending = make(chan string)
+	)
+	for range min(s.concurrency, len(symbols)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for symbol := range pending {
+				if err := ctx.Err(); err != nil {
+					mu.Lock()
+					errs[symbol] = fmt.Errorf("%w: %w", errNotAttempted, err)
+					mu.Unlock()
+					continue
+				}
+				quote, err := s.fetchAndPersist(ctx, symbol)
+				mu.Lock()
+				if err != nil {
+					errs[symbol] = err
+				} else {
+					quotes[symbol] = *quote
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+	for _, symbol := range symbols {
+		pending <- symbol
+	}
+	close(pending)
+	wg.Wait()
 }
 
 func (s *Service) fetchAndPersist(ctx context.Context, symbol string) (*models.PriceQuote, error) {
 	if s.fetcher == nil {
 		return nil, errors.New("no price fetcher configured")
 	}
-	price, err := s.fetcher.Fetch(ctx, symbol)
+	fetched, err := s.fetcher.Fetch(ctx, symbol)
 	if err != nil {
-		return nil, err
+		return nil, fmt.Errorf("%w: %w", ErrUnavailable, err)
 	}
-	return s.saveQuote(ctx, symbol, price)
+	return s.saveQuote(ctx, symbol, fetched)
 }
 
-func (s *Service) saveQuote(ctx context.Context, symbol string, price decimal.Decimal) (*models.PriceQuote, error) {
+func (s *Service) saveQuote(ctx context.Context, symbol string, fetched Quote) (*models.PriceQuote, error) {
+	now := time.Now().UTC()
 	quote := models.PriceQuote{
 		Symbol:    symbol,
-		Price:     price,
-		Source:    "mock-random",
-		FetchedAt: time.Now().UTC(),
+		Price:     fetched.Price,
+		Source:    fetched.Source,
+		AsOf:      fetched.AsOf.UTC(),
+		FetchedAt: now,
 	}
-	if err := s.repo.UpsertQuote(ctx, symbol, price, quote.Source, quote.FetchedAt); err != nil {
-		return nil, err
+	if fetched.AsOf.IsZero() {
+		quote.AsOf = now
 	}
-	return &quote, nil
+	return s.repo.UpsertQuote(ctx, quote)
 }
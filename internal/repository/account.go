@@ -0,0 +1,87 @@
+package repository
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/stocky/backend/internal/models"
+)
+
+var (
+	ErrAccountNotFound = errors.New("ledger account not found")
+	ErrAccountExists   = errors.New("ledger account already exists")
+	ErrAccountClosed   = errors.New("ledger account is closed")
+)
+
+// Ledger account types, as in the ledger_account_type enum.
+const (
+	AccountAsset     = "asset"
+	AccountLiability = "liability"
+	AccountEquity    = "equity"
+	AccountIncome    = "income"
+	AccountExpense   = "expense"
+)
+
+// AccountTypes lists every valid account type.
+var AccountTypes = []string{AccountAsset, AccountLiability, AccountEquity, AccountIncome, AccountExpense}
+
+// DefaultCurrency is the currency of the accounts the system opens itself.
+const DefaultCurrency = "INR"
+
+// DefaultAccounts is the chart of accounts every store starts with; the SQL
+// and MongoDB migrations seed the same codes.
+func DefaultAccounts(now time.Time) []models.LedgerAccount {
+	codes := []struct{ code, kind string }{
+		{"cash", AccountAsset},
+		{"brokerage_expense", AccountExpense},
+		{"tax_expense", AccountExpense},
+		{"reversal_loss", AccountExpense},
+		{"gst_expense", AccountExpense},
+		{"stt_expense", AccountExpense},
+		{"exchange_charges_expense", AccountExpense},
+		{"sebi_fees_expense", AccountExpense},
+		{"stamp_duty_expense", AccountExpense},
+		{TreasuryFundingAccount, AccountEquity},
+		{DividendReceivableAccount, AccountAsset},
+		{DividendPayableAccount, AccountLiability},
+		{TDSPayableAccount, AccountLiability},
+		{ActionReceivableAccount, AccountAsset},
+		{CashInLieuPayableAccount, AccountLiability},
+		{ActionLossAccount, AccountExpense},
+	}
+	accounts := make([]models.LedgerAccount, 0, len(codes))
+	for _, c := range codes {
+		accounts = append(accounts, models.LedgerAccount{Code: c.code, Type: c.kind, Currency: DefaultCurrency, CreatedAt: now})
+	}
+	return accounts
+}
+
+// InventoryAccount is the stock inventory account for symbol.
+func InventoryAccount(symbol string, now time.Time) models.LedgerAccount {
+	return models.LedgerAccount{
+		Code:      StockAccount(symbol),
+		Type:      AccountAsset,
+		Currency:  DefaultCurrency,
+		Symbol:    symbol,
+		CreatedAt: now,
+	}
+}
+
+// PostingAccount checks entry against account, the chart entry for its code
+// or nil when there is none. Postings to unknown or closed accounts are
+// rejected, except that a symbol's first inventory posting opens its stock
+// inventory account: that account is returned for the caller to create.
+func PostingAccount(entry LedgerEntry, account *models.LedgerAccount, now time.Time) (*models.LedgerAccount, error) {
+	if account == nil {
+		if entry.Symbol != "" && entry.AccountCode == StockAccount(entry.Symbol) {
+			created := InventoryAccount(entry.Symbol, now)
+			return &created, nil
+		}
+		return nil, fmt.Errorf("%w: %s", ErrAccountNotFound, entry.AccountCode)
+	}
+	if account.ClosedAt != nil {
+		return nil, fmt.Errorf("%w: %s", ErrAccountClosed, entry.AccountCode)
+	}
+	return nil, nil
+}
@@ -0,0 +1,96 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/stocky/backend/internal/models"
+)
+
+func (r *Repository) ListAccounts(ctx context.Context) ([]models.LedgerAccount, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "code", Value: 1}})
+	cursor, err := r.db.Collection("ledger_accounts").Find(ctx, bson.M{}, opts)
+	if err != nil {
+		return nil, err
+	}
+	var items []models.LedgerAccount
+	err = decodeEach(ctx, r, "ledger_accounts", cursor, func(doc ledgerAccountDoc) error {
+		items = append(items, doc.toModel())
+		return nil
+	})
+	return items, err
+}
+
+func (r *Repository) CreateAccount(ctx context.Context, account models.LedgerAccount) error {
+	_, err := r.db.Collection("ledger_accounts").InsertOne(ctx, newLedgerAccountDoc(account))
+	if mongo.IsDuplicateKeyError(err) {
+		return ErrAccountExists
+	}
+	return err
+}
+
+func (r *Repository) CloseAccount(ctx context.Context, code string, at time.Time) (*models.LedgerAccount, error) {
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+	result := r.db.Collection("ledger_accounts").FindOneAndUpdate(ctx,
+		bson.M{"code": code, "closed_at": bson.M{"$exists": false}},
+		bson.M{"$set": bson.M{"closed_at": at}}, opts)
+	doc, err := decodeOne[ledgerAccountDoc](ctx, r, "ledger_accounts", result)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		if _, err := r.findAccount(ctx, code); err != nil {
+			return nil, err
+		}
+		return nil, ErrAccountClosed
+	}
+	if err != nil {
+		return nil, err
+	}
+	account := doc.toModel()
+	return &account, nil
+}
+
+// findAccount returns the account with code, or ErrAccountNotFound.
+func (r *Repository) findAccount(ctx context.Context, code string) (*models.LedgerAccount, error) {
+	doc, err := decodeOne[ledgerAccountDoc](ctx, r, "ledger_accounts",
+		r.db.Collection("ledger_accounts").FindOne(ctx, bson.M{"code": code}))
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, ErrAccountNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	account := doc.toModel()
+	return &account, nil
+}
+
+// checkPostings rejects entries that post to unknown or closed accounts and
+// opens the stock inventory accounts they post to for the first time. It
+// must run inside the transaction that writes the entries.
+func (r *Repository) checkPostings(sessionCtx mongo.SessionContext, entries []LedgerEntry) error {
+	checked := make(map[string]bool, len(entries))
+	for _, entry := range entries {
+		if checked[entry.AccountCode] {
+			continue
+		}
+		checked[entry.AccountCode] = true
+
+		account, err := r.findAccount(sessionCtx, entry.AccountCode)
+		if err != nil && !errors.Is(err, ErrAccountNotFound) {
+			return err
+		}
+		created, err := PostingAccount(entry, account, entry.CreatedAt)
+		if err != nil {
+			return err
+		}
+		if created != nil {
+			if _, err := r.db.Collection("ledger_accounts").InsertOne(sessionCtx, newLedgerAccountDoc(*created)); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
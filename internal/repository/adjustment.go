@@ -0,0 +1,146 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/stocky/backend/internal/models"
+)
+
+func (r *Repository) ReverseReward(ctx context.Context, params ReversalParams) (*models.Adjustment, error) {
+	actions, err := r.ListCorporateActions(ctx, CorporateActionFilter{Status: ActionApplied})
+	if err != nil {
+		return nil, err
+	}
+	session, err := r.client.StartSession()
+	if err != nil {
+		return nil, err
+	}
+	defer session.EndSession(ctx)
+
+	result, err := session.WithTransaction(ctx, func(sessionCtx mongo.SessionContext) (interface{}, error) {
+		return r.reverseReward(sessionCtx, actions, params)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result.(*models.Adjustment), nil
+}
+
+func (r *Repository) reverseReward(sessionCtx mongo.SessionContext, actions []models.CorporateAction, params ReversalParams) (*models.Adjustment, error) {
+	adjustments := r.db.Collection("adjustments")
+	count, err := adjustments.CountDocuments(sessionCtx, bson.M{"idempotency_key": params.IdempotencyKey})
+	if err != nil {
+		return nil, err
+	}
+	if count > 0 {
+		return nil, ErrDuplicateAdjustment
+	}
+
+	rewardDoc, err := decodeOne[rewardEventDoc](sessionCtx, r, "reward_events",
+		r.db.Collection("reward_events").FindOne(sessionCtx, bson.M{"_id": params.RewardID.String()}))
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, ErrRewardNotFound
+		}
+		return nil, err
+	}
+	reward, err := rewardDoc.toModel()
+	if err != nil {
+		return nil, err
+	}
+
+	cursor, err := adjustments.Find(sessionCtx, bson.M{
+		"kind":            AdjustmentReversal,
+		"reference_event": reward.ID.String(),
+	})
+	if err != nil {
+		return nil, err
+	}
+	var earlier []models.Adjustment
+	err = decodeAll(sessionCtx, r, "adjustments", cursor, func(doc adjustmentDoc) error {
+		adjustment, err := doc.toModel()
+		if err != nil {
+			return err
+		}
+		earlier = append(earlier, adjustment)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	adjustment, postings, err := PlanReversal(reward, actions, earlier, params, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	state, err := r.loadPosition(sessionCtx, adjustment.UserID, adjustment.Symbol)
+	if err != nil {
+		return nil, err
+	}
+	next, err := state.Adjust(adjustment.Shares, adjustment.CostInr)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := adjustments.InsertOne(sessionCtx, newAdjustmentDoc(adjustment)); err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return nil, ErrDuplicateAdjustment
+		}
+		return nil, err
+	}
+
+	if err := r.insertLedgerEntries(sessionCtx, postings); err != nil {
+		return nil, err
+	}
+
+	if err := r.savePosition(sessionCtx, adjustment.UserID, adjustment.Symbol, next); err != nil {
+		return nil, err
+	}
+	return &adjustment, nil
+}
+
+func (r *Repository) AdjustmentByKey(ctx context.Context, key string) (*models.Adjustment, error) {
+	doc, err := decodeOne[adjustmentDoc](ctx, r, "adjustments",
+		r.db.Collection("adjustments").FindOne(ctx, bson.M{"idempotency_key": key}))
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, ErrAdjustmentNotFound
+		}
+		return nil, err
+	}
+	adjustment, err := doc.toModel()
+	if err != nil {
+		return nil, err
+	}
+	return &adjustment, nil
+}
+
+func (r *Repository) ListAdjustments(ctx context.Context, filter ProjectionFilter) ([]models.Adjustment, error) {
+	query := projectionQuery(filter)
+	if !filter.Until.IsZero() {
+		query["created_at"] = bson.M{"$lte": filter.Until}
+	}
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: 1}})
+	cursor, err := r.db.Collection("adjustments").Find(ctx, query, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	var items []models.Adjustment
+	err = decodeAll(ctx, r, "adjustments", cursor, func(doc adjustmentDoc) error {
+		adjustment, err := doc.toModel()
+		if err != nil {
+			return err
+		}
+		items = append(items, adjustment)
+		return nil
+	})
+	return items, err
+}
@@ -0,0 +1,167 @@
+package repository
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/shopspring/decimal"
+
+	"github.com/stocky/backend/internal/models"
+)
+
+// Hash chains. Every reward event and every ledger entry carries the SHA-256
+// of its canonical content and of the previous record's hash in the same
+// chain, so editing or deleting a stored record breaks every later link.
+const (
+	ChainRewards = "reward_events"
+	ChainLedger  = "ledger_entries"
+)
+
+// GenesisHash is the previous hash of the first record in a chain.
+var GenesisHash = strings.Repeat("0", 64)
+
+// NextLinks chains contents onto head, returning one link per content in
+// order. A zero head starts a new chain.
+func NextLinks(head models.ChainLink, contents ...string) []models.ChainLink {
+	prev := head.Hash
+	if prev == "" {
+		prev = GenesisHash
+	}
+	links := make([]models.ChainLink, 0, len(contents))
+	seq := head.Seq
+	for _, content := range contents {
+		seq++
+		link := models.ChainLink{Seq: seq, PrevHash: prev, Hash: ChainHash(prev, content)}
+		links = append(links, link)
+		prev = link.Hash
+	}
+	return links
+}
+
+// ChainHash is the hash of a record given its predecessor's hash.
+func ChainHash(prevHash, content string) string {
+	sum := sha256.Sum256([]byte(prevHash + "\n" + content))
+	return hex.EncodeToString(sum[:])
+}
+
+// RewardContent is the canonical form of a reward event that is hashed.
+// Amounts use the storage scale and times are truncated to milliseconds, the
+// coarsest precision of any backend, so the content read back from storage
+// matches what was hashed on write.
+func RewardContent(reward models.RewardEvent) string {
+	fields := []string{
+		"reward",
+		reward.ID.String(),
+		reward.UserID.String(),
+		strings.ToUpper(reward.Symbol),
+		units(reward.Shares),
+		money(reward.GrantedPrice),
+		money(reward.BrokerageInr),
+		money(reward.TaxesInr),
+		money(reward.TotalCashOut),
+		money(reward.CostBasis),
+		canonicalTime(reward.RewardedAt),
+		reward.EventKey,
+		canonicalTime(reward.CreatedAt),
+	}
+	if f := reward.Fees; f != nil {
+		fields = append(fields, f.Exchange, f.ScheduleID, money(f.Brokerage), money(f.GST), money(f.STT),
+			money(f.ExchangeCharges), money(f.SEBIFees), money(f.StampDuty))
+	}
+	return strings.Join(fields, "|")
+}
+
+// LedgerContent is the canonical form of a ledger entry that is hashed.
+func LedgerContent(entry LedgerEntry) string {
+	return strings.Join([]string{
+		"ledger",
+		entry.EventID.String(),
+		entry.AccountCode,
+		entry.AccountType,
+		entry.Symbol,
+		money(entry.Debit),
+		money(entry.Credit),
+		units(entry.StockUnits),
+		entry.Memo,
+		canonicalTime(entry.CreatedAt),
+	}, "|")
+}
+
+// ChainRecord is a stored record as read back for verification.
+type ChainRecord struct {
+	ID      string
+	Content string
+	models.ChainLink
+}
+
+// ChainBreak describes the first link of a chain that does not verify.
+type ChainBreak struct {
+	Chain  string `json:"chain"`
+	Seq    int64  `json:"seq"`
+	ID     string `json:"id"`
+	Reason string `json:"reason"`
+}
+
+func (b *ChainBreak) Error() string {
+	return fmt.Sprintf("%s chain broken at seq %d (%s): %s", b.Chain, b.Seq, b.ID, b.Reason)
+}
+
+// ChainVerifier checks records visited in sequence order. Records written
+// before chaining was introduced have no sequence number and are skipped, up
+// to the Legacy count recorded when chaining began; any other unchained
+// record was inserted around the chain and breaks it.
+type ChainVerifier struct {
+	Chain     string
+	Head      models.ChainLink
+	Legacy    int
+	Records   int
+	Unchained int
+	// Expected maps sequence numbers to hashes recorded in checkpoints.
+	Expected map[int64]string
+}
+
+// Visit verifies the next record; the returned *ChainBreak stops the walk.
+func (v *ChainVerifier) Visit(record ChainRecord) error {
+	brk := func(reason string) error {
+		return &ChainBreak{Chain: v.Chain, Seq: record.Seq, ID: record.ID, Reason: reason}
+	}
+	if record.Seq == 0 {
+		v.Unchained++
+		switch {
+		case v.Head.Seq > 0:
+			return brk(fmt.Sprintf("unchained record after seq %d", v.Head.Seq))
+		case v.Unchained > v.Legacy:
+			return brk(fmt.Sprintf("unchained record beyond the %d written before chaining began", v.Legacy))
+		}
+		return nil
+	}
+	prev := v.Head.Hash
+	if prev == "" {
+		prev = GenesisHash
+	}
+	switch {
+	case record.Seq != v.Head.Seq+1:
+		return brk(fmt.Sprintf("expected seq %d", v.Head.Seq+1))
+	case record.PrevHash != prev:
+		return brk("prev_hash does not match the previous record")
+	case record.Hash != ChainHash(record.PrevHash, record.Content):
+		return brk("hash does not match the record content")
+	}
+	if want, ok := v.Expected[record.Seq]; ok && want != record.Hash {
+		return brk("hash differs from a signed checkpoint")
+	}
+	v.Head = record.ChainLink
+	v.Records++
+	return nil
+}
+
+func money(d decimal.Decimal) string { return d.StringFixed(4) }
+
+func units(d decimal.Decimal) string { return d.StringFixed(6) }
+
+func canonicalTime(t time.Time) string {
+	return t.UTC().Truncate(time.Millisecond).Format("2006-01-02T15:04:05.000Z")
+}
@@ -0,0 +1,143 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/stocky/backend/internal/models"
+)
+
+// chainHeadDoc is the newest link of a chain, kept in chain_heads so
+// appending needs no scan. Concurrent appends write the same document, so one
+// of them hits a write conflict and its transaction is retried.
+// LegacyRecords is set once, by migration 017, and never rewritten.
+type chainHeadDoc struct {
+	Chain         string `bson:"_id"`
+	LegacyRecords int    `bson:"legacy_records,omitempty"`
+	chainDoc      `bson:",inline"`
+}
+
+// extendChain links contents onto the named chain and advances its head. It
+// must run inside the transaction that writes the linked records.
+func (r *Repository) extendChain(sessionCtx mongo.SessionContext, chain string, contents ...string) ([]models.ChainLink, error) {
+	head, err := r.ChainHead(sessionCtx, chain)
+	if err != nil {
+		return nil, err
+	}
+	links := NextLinks(head, contents...)
+	if len(links) == 0 {
+		return links, nil
+	}
+	_, err = r.db.Collection("chain_heads").UpdateOne(sessionCtx, bson.M{"_id": chain},
+		bson.M{"$set": newChainDoc(links[len(links)-1])}, options.Update().SetUpsert(true))
+	if err != nil {
+		return nil, err
+	}
+	return links, nil
+}
+
+// insertLedgerEntries checks, chains and writes entries inside the caller's
+// transaction.
+func (r *Repository) insertLedgerEntries(sessionCtx mongo.SessionContext, entries []LedgerEntry) error {
+	if err := r.checkPostings(sessionCtx, entries); err != nil {
+		return err
+	}
+	contents := make([]string, len(entries))
+	for i, entry := range entries {
+		contents[i] = LedgerContent(entry)
+	}
+	links, err := r.extendChain(sessionCtx, ChainLedger, contents...)
+	if err != nil {
+		return err
+	}
+	docs := make([]interface{}, 0, len(entries))
+	for i, entry := range entries {
+		entry.Chain = links[i]
+		docs = append(docs, newLedgerEntryDoc(entry))
+	}
+	_, err = r.db.Collection("ledger_entries").InsertMany(sessionCtx, docs)
+	return err
+}
+
+func (r *Repository) ChainHead(ctx context.Context, chain string) (models.ChainLink, error) {
+	doc, err := decodeOne[chainHeadDoc](ctx, r, "chain_heads",
+		r.db.Collection("chain_heads").FindOne(ctx, bson.M{"_id": chain}))
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return models.ChainLink{}, nil
+	}
+	if err != nil {
+		return models.ChainLink{}, err
+	}
+	return doc.link(), nil
+}
+
+func (r *Repository) LegacyRecords(ctx context.Context, chain string) (int, error) {
+	doc, err := decodeOne[chainHeadDoc](ctx, r, "chain_heads",
+		r.db.Collection("chain_heads").FindOne(ctx, bson.M{"_id": chain}))
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	return doc.LegacyRecords, nil
+}
+
+// WalkChain reads documents directly rather than through decodeEach: a
+// document that no longer decodes has been tampered with, so it is reported
+// as a broken link instead of being quarantined and skipped.
+func (r *Repository) WalkChain(ctx context.Context, chain string, visit func(ChainRecord) error) error {
+	if chain != ChainRewards && chain != ChainLedger {
+		return fmt.Errorf("unknown chain %q", chain)
+	}
+	// Legacy documents have no chain_seq and sort first.
+	opts := options.Find().
+		SetSort(bson.D{{Key: "chain_seq", Value: 1}, {Key: "_id", Value: 1}}).
+		SetAllowDiskUse(true)
+	cursor, err := r.db.Collection(chain).Find(ctx, bson.M{}, opts)
+	if err != nil {
+		return err
+	}
+	defer cursor.Close(ctx)
+
+	for cursor.Next(ctx) {
+		record, err := chainRecord(chain, cursor)
+		if err != nil {
+			return err
+		}
+		if err := visit(record); err != nil {
+			return err
+		}
+	}
+	return cursor.Err()
+}
+
+func chainRecord(chain string, cursor *mongo.Cursor) (ChainRecord, error) {
+	id := fmt.Sprint(cursor.Current.Lookup("_id"))
+	if chain == ChainRewards {
+		var doc rewardEventDoc
+		if err := cursor.Decode(&doc); err != nil {
+			return ChainRecord{}, &ChainBreak{Chain: chain, ID: id, Reason: "undecodable: " + err.Error()}
+		}
+		reward, err := doc.toModel()
+		if err != nil {
+			return ChainRecord{}, &ChainBreak{Chain: chain, Seq: doc.ChainSeq, ID: doc.ID, Reason: err.Error()}
+		}
+		return ChainRecord{ID: doc.ID, Content: RewardContent(reward), ChainLink: doc.link()}, nil
+	}
+
+	var doc ledgerEntryDoc
+	if err := cursor.Decode(&doc); err != nil {
+		return ChainRecord{}, &ChainBreak{Chain: chain, ID: id, Reason: "undecodable: " + err.Error()}
+	}
+	entry, err := doc.toEntry()
+	if err != nil {
+		return ChainRecord{}, &ChainBreak{Chain: chain, Seq: doc.ChainSeq, ID: doc.ID.Hex(), Reason: err.Error()}
+	}
+	return ChainRecord{ID: doc.ID.Hex(), Content: LedgerContent(entry), ChainLink: doc.link()}, nil
+}
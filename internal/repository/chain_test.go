@@ -0,0 +1,221 @@
+package repository
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/stocky/backend/internal/models"
+)
+
+// chainOf links contents from genesis into stored records.
+func chainOf(contents ...string) []ChainRecord {
+	links := NextLinks(models.ChainLink{}, contents...)
+	records := make([]ChainRecord, len(contents))
+	for i, link := range links {
+		records[i] = ChainRecord{ID: fmt.Sprintf("r%d", i+1), Content: contents[i], ChainLink: link}
+	}
+	return records
+}
+
+// rehash recomputes every link from index from onwards, as an attacker
+// rewriting the database would.
+func rehash(records []ChainRecord, from int) {
+	for i := from; i < len(records); i++ {
+		prev := GenesisHash
+		if i > 0 {
+			prev = records[i-1].Hash
+		}
+		records[i].PrevHash = prev
+		records[i].Hash = ChainHash(prev, records[i].Content)
+	}
+}
+
+func TestChainVerifier(t *testing.T) {
+	tests := []struct {
+		name      string
+		tamper    func([]ChainRecord) []ChainRecord
+		expected  func([]ChainRecord) map[int64]string
+		legacy    int
+		records   int
+		unchained int
+		breakSeq  int64
+		reason    string
+	}{
+		{name: "intact", records: 4},
+		{
+			name: "records written before chaining",
+			tamper: func(r []ChainRecord) []ChainRecord {
+				legacy := []ChainRecord{{ID: "old1", Content: "x"}, {ID: "old2", Content: "y"}}
+				return append(legacy, r...)
+			},
+			legacy:  2,
+			records: 4, unchained: 2,
+		},
+		{
+			name: "unchained record beyond the legacy count",
+			tamper: func(r []ChainRecord) []ChainRecord {
+				legacy := []ChainRecord{{ID: "old1", Content: "x"}, {ID: "forged", Content: "y"}}
+				return append(legacy, r...)
+			},
+			legacy:    1,
+			unchained: 2,
+			reason:    "unchained record beyond the 1 written before chaining began",
+		},
+		{
+			name:      "unchained record without legacy records",
+			tamper:    func(r []ChainRecord) []ChainRecord { return append([]ChainRecord{{ID: "forged", Content: "y"}}, r...) },
+			unchained: 1,
+			reason:    "unchained record beyond the 0 written before chaining began",
+		},
+		{
+			name:      "unchained record after the chain",
+			tamper:    func(r []ChainRecord) []ChainRecord { return append(r, ChainRecord{ID: "forged", Content: "y"}) },
+			legacy:    1,
+			records:   4,
+			unchained: 1,
+			reason:    "unchained record after seq 4",
+		},
+		{
+			name:     "edited content",
+			tamper:   func(r []ChainRecord) []ChainRecord { r[1].Content = "edited"; return r },
+			records:  1,
+			breakSeq: 2, reason: "hash does not match the record content",
+		},
+		{
+			name: "edited content with its hash recomputed",
+			tamper: func(r []ChainRecord) []ChainRecord {
+				r[1].Content = "edited"
+				r[1].Hash = ChainHash(r[1].PrevHash, "edited")
+				return r
+			},
+			records:  2,
+			breakSeq: 3, reason: "prev_hash does not match the previous record",
+		},
+		{
+			name:     "deleted record",
+			tamper:   func(r []ChainRecord) []ChainRecord { return append(r[:1], r[2:]...) },
+			records:  1,
+			breakSeq: 3, reason: "expected seq 2",
+		},
+		{
+			name:     "missing genesis",
+			tamper:   func(r []ChainRecord) []ChainRecord { return r[1:] },
+			breakSeq: 2, reason: "expected seq 1",
+		},
+		{
+			name:     "reordered records",
+			tamper:   func(r []ChainRecord) []ChainRecord { r[1], r[2] = r[2], r[1]; return r },
+			records:  1,
+			breakSeq: 3, reason: "expected seq 2",
+		},
+		{
+			name: "full rewrite without a checkpoint",
+			tamper: func(r []ChainRecord) []ChainRecord {
+				r[1].Content = "edited"
+				rehash(r, 1)
+				return r
+			},
+			records: 4,
+		},
+		{
+			name:     "full rewrite caught by a checkpoint",
+			expected: func(r []ChainRecord) map[int64]string { return map[int64]string{3: r[2].Hash} },
+			tamper: func(r []ChainRecord) []ChainRecord {
+				r[1].Content = "edited"
+				rehash(r, 1)
+				return r
+			},
+			records:  2,
+			breakSeq: 3, reason: "hash differs from a signed checkpoint",
+		},
+		{
+			name:     "checkpoint matches",
+			expected: func(r []ChainRecord) map[int64]string { return map[int64]string{2: r[1].Hash, 4: r[3].Hash} },
+			records:  4,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			records := chainOf("a", "b", "c", "d")
+			verifier := &ChainVerifier{Chain: ChainLedger, Legacy: tt.legacy}
+			if tt.expected != nil {
+				verifier.Expected = tt.expected(records)
+			}
+			if tt.tamper != nil {
+				records = tt.tamper(records)
+			}
+
+			var err error
+			for _, record := range records {
+				if err = verifier.Visit(record); err != nil {
+					break
+				}
+			}
+			if verifier.Records != tt.records || verifier.Unchained != tt.unchained {
+				t.Errorf("records/unchained = %d/%d, want %d/%d", verifier.Records, verifier.Unchained, tt.records, tt.unchained)
+			}
+			if tt.reason == "" {
+				if err != nil {
+					t.Fatalf("Visit: %v", err)
+				}
+				if last := records[len(records)-1]; verifier.Head != last.ChainLink {
+					t.Errorf("head = %+v, want %+v", verifier.Head, last.ChainLink)
+				}
+				return
+			}
+			var brk *ChainBreak
+			if !errors.As(err, &brk) {
+				t.Fatalf("err = %v, want a *ChainBreak", err)
+			}
+			if brk.Chain != ChainLedger || brk.Seq != tt.breakSeq || brk.Reason != tt.reason {
+				t.Errorf("break = %s seq %d %q, want seq %d %q", brk.Chain, brk.Seq, brk.Reason, tt.breakSeq, tt.reason)
+			}
+		})
+	}
+}
+
+func TestNextLinksContinuesHead(t *testing.T) {
+	whole := NextLinks(models.ChainLink{}, "a", "b", "c")
+	first := NextLinks(models.ChainLink{}, "a")
+	rest := NextLinks(first[0], "b", "c")
+	for i, link := range append(first, rest...) {
+		if link != whole[i] {
+			t.Errorf("link %d = %+v, want %+v", i, link, whole[i])
+		}
+	}
+	if whole[0].PrevHash != GenesisHash || whole[2].Seq != 3 {
+		t.Errorf("links = %+v, want seq 1..3 from genesis", whole)
+	}
+}
+
+func TestRewardContentIsCanonical(t *testing.T) {
+	at := time.Date(2024, 5, 10, 9, 15, 0, 123456789, time.FixedZone("IST", 5*3600+1800))
+	reward := models.RewardEvent{
+		ID:           uuid.New(),
+		UserID:       uuid.New(),
+		Symbol:       "infy",
+		Shares:       dec("1.5"),
+		GrantedPrice: dec("1500.1"),
+		RewardedAt:   at,
+		CreatedAt:    at,
+		EventKey:     "evt-1",
+	}
+	stored := reward
+	stored.Symbol = "INFY"
+	stored.Shares = dec("1.500000")
+	stored.GrantedPrice = dec("1500.1000")
+	stored.RewardedAt = at.UTC().Truncate(time.Millisecond)
+	stored.CreatedAt = at.UTC().Truncate(time.Millisecond)
+	if RewardContent(reward) != RewardContent(stored) {
+		t.Errorf("content differs after a storage round trip:\n%s\n%s", RewardContent(reward), RewardContent(stored))
+	}
+
+	stored.Shares = dec("1.500001")
+	if RewardContent(reward) == RewardContent(stored) {
+		t.Error("content ignores a change in shares")
+	}
+}
@@ -0,0 +1,332 @@
+package repository
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+
+	"github.com/stocky/backend/internal/models"
+)
+
+var (
+	ErrCorporateActionNotFound  = errors.New("corporate action not found")
+	ErrDuplicateCorporateAction = errors.New("symbol already has a corporate action on that ex-date")
+	ErrCorporateActionApplied   = errors.New("corporate action already applied")
+	ErrAliasNotFound            = errors.New("symbol alias not found")
+)
+
+// Corporate action kinds. They double as the kind of the adjustments an
+// action writes.
+const (
+	CorporateSplit     = "split"
+	CorporateBonus     = "bonus"
+	CorporateRename    = "rename"
+	CorporateMerger    = "merger"
+	CorporateDelisting = "delisting"
+)
+
+// CorporateKinds lists every valid action kind.
+var CorporateKinds = []string{CorporateSplit, CorporateBonus, CorporateRename, CorporateMerger, CorporateDelisting}
+
+// Ledger accounts for symbol changes. Cash paid for merger fractions or a
+// delisting is receivable from the acquirer or exchange and payable to the
+// user's wallet; inventory book value that leaves without new shares is
+// expensed.
+const (
+	ActionReceivableAccount  = "corporate_action_receivable"
+	CashInLieuPayableAccount = "cash_in_lieu_payable"
+	ActionLossAccount        = "corporate_action_loss"
+)
+
+// StockInactive is the status of a symbol that no longer trades; the price
+// job stops quoting it.
+const StockInactive = "INACTIVE"
+
+// DelistedQuoteSource marks the cached quote of a delisted symbol, frozen at
+// its last price.
+const DelistedQuoteSource = "delisted"
+
+// Corporate action statuses.
+const (
+	ActionPending = "pending"
+	ActionApplied = "applied"
+)
+
+// CorporateActionFilter narrows a listing; zero values match everything.
+type CorporateActionFilter struct {
+	Symbol string
+	Status string
+}
+
+// CorporateActionApplication is everything ApplyCorporateAction writes in
+// one transaction. Action carries the applied status and totals, and
+// StockFactor is the symbol's cumulative factor after a split or bonus.
+//
+// For a rename, merger or delisting the store also records Alias, marks the
+// old symbol inactive and credits each adjustment's CashInr to the user's
+// wallet; a delisting marks the cached quote, Action.FrozenPrice, as frozen.
+type CorporateActionApplication struct {
+	Action      models.CorporateAction
+	Adjustments []models.Adjustment
+	Postings    []LedgerEntry
+	StockFactor decimal.Decimal
+	Alias       *models.SymbolAlias
+}
+
+// ActionFactor is the multiplier an action applies to a holding. For a
+// rename or merger it converts old shares into new ones; a delisting leaves
+// the share count alone.
+func ActionFactor(kind string, ratioNew, ratioHeld int64) (decimal.Decimal, error) {
+	if ratioNew <= 0 || ratioHeld <= 0 {
+		return decimal.Zero, errors.New("ratio terms must be positive")
+	}
+	newShares, held := decimal.NewFromInt(ratioNew), decimal.NewFromInt(ratioHeld)
+	switch kind {
+	case CorporateSplit, CorporateRename, CorporateMerger:
+		return newShares.Div(held), nil
+	case CorporateBonus:
+		return held.Add(newShares).Div(held), nil
+	case CorporateDelisting:
+		return decimal.NewFromInt(1), nil
+	default:
+		return decimal.Zero, fmt.Errorf("kind must be one of %s", strings.Join(CorporateKinds, ", "))
+	}
+}
+
+// RescalesPrices reports whether kind changes the share count of a symbol
+// that keeps trading, so earlier prices are divided by its factor.
+func RescalesPrices(kind string) bool {
+	return kind == CorporateSplit || kind == CorporateBonus
+}
+
+// EndsSymbol reports whether kind stops the symbol trading: its positions
+// move to NewSymbol or are frozen, and the symbol becomes an alias.
+func EndsSymbol(kind string) bool {
+	return kind == CorporateRename || kind == CorporateMerger || kind == CorporateDelisting
+}
+
+// ActionAdjustmentKey is the idempotency key of the adjustment an action
+// writes for a user, so an action cannot be applied to a position twice.
+func ActionAdjustmentKey(actionID, userID uuid.UUID) string {
+	return "corporate-action:" + actionID.String() + ":" + userID.String()
+}
+
+// PlanCorporateAction builds the adjustment and ledger posting for each
+// holder, given the shares every user held before the ex-date. Adjustments
+// add shares without cost, so the average cost falls by the factor, and are
+// dated on the ex-date so replays apply them in order. Each posting moves
+// stock units only; the INR value of inventory is unchanged.
+func PlanCorporateAction(action models.CorporateAction, held map[uuid.UUID]decimal.Decimal, now time.Time) ([]models.Adjustment, []LedgerEntry) {
+	userIDs := make([]uuid.UUID, 0, len(held))
+	for userID := range held {
+		userIDs = append(userIDs, userID)
+	}
+	sort.Slice(userIDs, func(i, j int) bool { return userIDs[i].String() < userIDs[j].String() })
+
+	reason := ActionDescription(action)
+	var (
+		adjustments []models.Adjustment
+		postings    []LedgerEntry
+	)
+	for _, userID := range userIDs {
+		issued := held[userID].Mul(action.Factor.Sub(decimal.NewFromInt(1))).Round(6)
+		if !issued.IsPositive() {
+			continue
+		}
+		adjustment := models.Adjustment{
+			ID:             uuid.New(),
+			UserID:         userID,
+			Symbol:         action.Symbol,
+			Kind:           action.Kind,
+			Shares:         issued,
+			Reason:         reason,
+			IdempotencyKey: ActionAdjustmentKey(action.ID, userID),
+			CreatedAt:      action.ExDate,
+		}
+		adjustments = append(adjustments, adjustment)
+		postings = append(postings, LedgerEntry{
+			EventID:     adjustment.ID,
+			AccountCode: StockAccount(action.Symbol),
+			AccountType: AccountAsset,
+			Symbol:      action.Symbol,
+			StockUnits:  issued,
+			Memo:        reason + "; inventory value unchanged",
+			CreatedAt:   now,
+		})
+	}
+	return adjustments, postings
+}
+
+// PlanSymbolChange builds the adjustments and ledger postings that move each
+// holder out of a renamed, merged or cashed-out delisted symbol. positions
+// are the current holdings of the old symbol and book is the INR balance of
+// its inventory account, shared out by shares held.
+//
+// Every holder gets an adjustment removing the old position at its cost.
+// For a rename or merger a second adjustment adds Factor new shares per old
+// share, carrying the cost across; when CashPrice is set only whole new
+// shares are issued and the fraction is paid at CashPrice per new share,
+// with its share of cost and book value dropped. For a delisting CashPrice
+// is paid per old share. Cash is recorded on the old adjustment and posted
+// as receivable against cash-in-lieu payable; book value not carried into
+// new inventory is expensed. All of a holder's postings belong to the old
+// adjustment. Adjustments are dated now, after every event that built the
+// positions, so a replay arrives at the same state.
+func PlanSymbolChange(action models.CorporateAction, positions map[uuid.UUID]PositionState, book decimal.Decimal, now time.Time) ([]models.Adjustment, []LedgerEntry) {
+	userIDs := make([]uuid.UUID, 0, len(positions))
+	totalShares := decimal.Zero
+	for userID, state := range positions {
+		if !state.Shares.IsPositive() {
+			continue
+		}
+		userIDs = append(userIDs, userID)
+		totalShares = totalShares.Add(state.Shares)
+	}
+	sort.Slice(userIDs, func(i, j int) bool { return userIDs[i].String() < userIDs[j].String() })
+
+	reason := ActionDescription(action)
+	remainingBook := book
+	var (
+		adjustments []models.Adjustment
+		postings    []LedgerEntry
+	)
+	for i, userID := range userIDs {
+		state := positions[userID]
+		held, cost := state.Shares, state.costBasis()
+		userBook := remainingBook
+		if i < len(userIDs)-1 {
+			userBook = book.Mul(held).Div(totalShares).Round(4)
+		}
+		remainingBook = remainingBook.Sub(userBook)
+
+		entitled, issued, cash := decimal.Zero, decimal.Zero, decimal.Zero
+		switch action.Kind {
+		case CorporateRename, CorporateMerger:
+			entitled = held.Mul(decimal.NewFromInt(action.RatioNew)).Div(decimal.NewFromInt(action.RatioHeld)).Round(6)
+			issued = entitled
+			if action.CashPrice.IsPositive() {
+				issued = entitled.Floor()
+				cash = entitled.Sub(issued).Mul(action.CashPrice).Round(2)
+			}
+		case CorporateDelisting:
+			cash = held.Mul(action.CashPrice).Round(2)
+		}
+		newCost, newBook := decimal.Zero, decimal.Zero
+		if entitled.IsPositive() {
+			newCost = cost.Mul(issued).Div(entitled).Round(4)
+			newBook = userBook.Mul(issued).Div(entitled).Round(4)
+		}
+
+		out := models.Adjustment{
+			ID:             uuid.New(),
+			UserID:         userID,
+			Symbol:         action.Symbol,
+			Kind:           action.Kind,
+			Shares:         held.Neg(),
+			CostInr:        cost.Neg(),
+			CashInr:        cash,
+			Reason:         reason,
+			IdempotencyKey: ActionAdjustmentKey(action.ID, userID),
+			CreatedAt:      now,
+		}
+		adjustments = append(adjustments, out)
+		postings = append(postings, LedgerEntry{
+			EventID:     out.ID,
+			AccountCode: StockAccount(action.Symbol),
+			AccountType: AccountAsset,
+			Symbol:      action.Symbol,
+			Credit:      userBook,
+			StockUnits:  held.Neg(),
+			Memo:        reason,
+			CreatedAt:   now,
+		})
+		if issued.IsPositive() {
+			adjustments = append(adjustments, models.Adjustment{
+				ID:             uuid.New(),
+				UserID:         userID,
+				Symbol:         action.NewSymbol,
+				Kind:           action.Kind,
+				Shares:         issued,
+				CostInr:        newCost,
+				Reason:         reason,
+				IdempotencyKey: ActionAdjustmentKey(action.ID, userID) + ":" + action.NewSymbol,
+				CreatedAt:      now,
+			})
+			postings = append(postings, LedgerEntry{
+				EventID:     out.ID,
+				AccountCode: StockAccount(action.NewSymbol),
+				AccountType: AccountAsset,
+				Symbol:      action.NewSymbol,
+				Debit:       newBook,
+				StockUnits:  issued,
+				Memo:        reason,
+				CreatedAt:   now,
+			})
+		}
+		if loss := userBook.Sub(newBook); loss.IsPositive() {
+			postings = append(postings, LedgerEntry{
+				EventID:     out.ID,
+				AccountCode: ActionLossAccount,
+				AccountType: AccountExpense,
+				Symbol:      action.Symbol,
+				Debit:       loss,
+				Memo:        reason + "; book value not carried into new shares",
+				CreatedAt:   now,
+			})
+		}
+		if cash.IsPositive() {
+			postings = append(postings,
+				LedgerEntry{
+					EventID:     out.ID,
+					AccountCode: ActionReceivableAccount,
+					AccountType: AccountAsset,
+					Symbol:      action.Symbol,
+					Debit:       cash,
+					Memo:        reason,
+					CreatedAt:   now,
+				},
+				LedgerEntry{
+					EventID:     out.ID,
+					AccountCode: CashInLieuPayableAccount,
+					AccountType: AccountLiability,
+					Symbol:      action.Symbol,
+					Credit:      cash,
+					Memo:        reason + "; credited to user wallet",
+					CreatedAt:   now,
+				})
+		}
+	}
+	return adjustments, postings
+}
+
+// ActionDescription reads like "2:1 split of TCS, ex-date 2024-06-03" or
+// "1:1 rename of OLD to NEW, ex-date 2024-06-03".
+func ActionDescription(action models.CorporateAction) string {
+	exDate := action.ExDate.In(ist).Format(time.DateOnly)
+	switch action.Kind {
+	case CorporateRename:
+		return fmt.Sprintf("rename of %s to %s, ex-date %s", action.Symbol, action.NewSymbol, exDate)
+	case CorporateMerger:
+		return fmt.Sprintf("%d:%d merger of %s into %s, ex-date %s", action.RatioNew, action.RatioHeld,
+			action.Symbol, action.NewSymbol, exDate)
+	case CorporateDelisting:
+		return fmt.Sprintf("delisting of %s, ex-date %s", action.Symbol, exDate)
+	}
+	return fmt.Sprintf("%d:%d %s of %s, ex-date %s", action.RatioNew, action.RatioHeld, action.Kind,
+		action.Symbol, exDate)
+}
+
+// ParseActionDate reads a record or ex-date given as YYYY-MM-DD. Exchanges
+// announce dates in IST, so the date starts at midnight IST.
+func ParseActionDate(date string) (time.Time, error) {
+	parsed, err := time.ParseInLocation(time.DateOnly, date, ist)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("date %q must be YYYY-MM-DD", date)
+	}
+	return parsed.UTC(), nil
+}
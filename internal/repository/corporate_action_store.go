@@ -0,0 +1,267 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/stocky/backend/internal/models"
+)
+
+func (r *Repository) CreateCorporateAction(ctx context.Context, action models.CorporateAction) error {
+	_, err := r.db.Collection("corporate_actions").InsertOne(ctx, newCorporateActionDoc(action))
+	if mongo.IsDuplicateKeyError(err) {
+		return ErrDuplicateCorporateAction
+	}
+	return err
+}
+
+func (r *Repository) CorporateAction(ctx context.Context, id uuid.UUID) (*models.CorporateAction, error) {
+	doc, err := decodeOne[corporateActionDoc](ctx, r, "corporate_actions",
+		r.db.Collection("corporate_actions").FindOne(ctx, bson.M{"_id": id.String()}))
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, ErrCorporateActionNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	action, err := doc.toModel()
+	if err != nil {
+		return nil, err
+	}
+	return &action, nil
+}
+
+func (r *Repository) ListCorporateActions(ctx context.Context, filter CorporateActionFilter) ([]models.CorporateAction, error) {
+	query := bson.M{}
+	if filter.Symbol != "" {
+		query["symbol"] = filter.Symbol
+	}
+	if filter.Status != "" {
+		query["status"] = filter.Status
+	}
+	opts := options.Find().SetSort(bson.D{{Key: "ex_date", Value: 1}, {Key: "created_at", Value: 1}})
+	cursor, err := r.db.Collection("corporate_actions").Find(ctx, query, opts)
+	if err != nil {
+		return nil, err
+	}
+	var items []models.CorporateAction
+	err = decodeAll(ctx, r, "corporate_actions", cursor, func(doc corporateActionDoc) error {
+		action, err := doc.toModel()
+		if err != nil {
+			return err
+		}
+		items = append(items, action)
+		return nil
+	})
+	return items, err
+}
+
+func (r *Repository) ApplyCorporateAction(ctx context.Context, application CorporateActionApplication) error {
+	session, err := r.client.StartSession()
+	if err != nil {
+		return err
+	}
+	defer session.EndSession(ctx)
+
+	_, err = session.WithTransaction(ctx, func(sessionCtx mongo.SessionContext) (interface{}, error) {
+		return nil, r.applyCorporateAction(sessionCtx, application)
+	})
+	return err
+}
+
+func (r *Repository) applyCorporateAction(sessionCtx mongo.SessionContext, application CorporateActionApplication) error {
+	action := application.Action
+	doc := newCorporateActionDoc(action)
+	result, err := r.db.Collection("corporate_actions").UpdateOne(sessionCtx,
+		bson.M{"_id": doc.ID, "status": ActionPending},
+		bson.M{"$set": bson.M{
+			"status":           doc.Status,
+			"applied_at":       doc.AppliedAt,
+			"holders":          doc.Holders,
+			"shares_issued":    doc.SharesIssued,
+			"cash_paid_inr":    doc.CashPaid,
+			"frozen_price_inr": doc.FrozenPrice,
+		}})
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		if _, err := r.CorporateAction(sessionCtx, action.ID); err != nil {
+			return err
+		}
+		return ErrCorporateActionApplied
+	}
+
+	for _, adjustment := range application.Adjustments {
+		state, err := r.loadPosition(sessionCtx, adjustment.UserID, adjustment.Symbol)
+		if err != nil {
+			return err
+		}
+		next, err := state.Adjust(adjustment.Shares, adjustment.CostInr)
+		if err != nil {
+			return err
+		}
+		if next.FirstAcquiredAt.IsZero() {
+			next.FirstAcquiredAt = adjustment.CreatedAt
+		}
+		if _, err := r.db.Collection("adjustments").InsertOne(sessionCtx, newAdjustmentDoc(adjustment)); err != nil {
+			if mongo.IsDuplicateKeyError(err) {
+				return ErrDuplicateAdjustment
+			}
+			return err
+		}
+		if err := r.savePosition(sessionCtx, adjustment.UserID, adjustment.Symbol, next); err != nil {
+			return err
+		}
+	}
+	if err := r.insertLedgerEntries(sessionCtx, application.Postings); err != nil {
+		return err
+	}
+
+	if RescalesPrices(action.Kind) {
+		if err := r.rescalePrices(sessionCtx, action, application.StockFactor); err != nil {
+			return err
+		}
+	}
+	if application.Alias != nil {
+		if err := r.retireSymbol(sessionCtx, action, *application.Alias); err != nil {
+			return err
+		}
+	}
+	for _, adjustment := range application.Adjustments {
+		if !adjustment.CashInr.IsPositive() {
+			continue
+		}
+		_, err := r.db.Collection("user_wallets").UpdateOne(sessionCtx,
+			bson.M{"_id": adjustment.UserID.String()},
+			bson.M{
+				"$inc": bson.M{"balance_inr": adjustment.CashInr},
+				"$set": bson.M{"updated_at": action.AppliedAt},
+			},
+			options.Update().SetUpsert(true))
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// rescalePrices divides the symbol's prices from before the ex-date by the
+// action's factor and stores the cumulative factor on the stock.
+func (r *Repository) rescalePrices(sessionCtx mongo.SessionContext, action models.CorporateAction, stockFactor decimal.Decimal) error {
+	// Prices are Decimal128, so the division is exact before rounding to
+	// the stored scale.
+	rescale := bson.A{bson.M{"$set": bson.M{
+		"price_inr": bson.M{"$round": bson.A{bson.M{"$divide": bson.A{"$price_inr", action.Factor}}, 4}},
+	}}}
+	_, err := r.db.Collection("price_history").UpdateMany(sessionCtx,
+		bson.M{"symbol": action.Symbol, "as_of": bson.M{"$lt": action.ExDate}}, rescale)
+	if err != nil {
+		return err
+	}
+	_, err = r.db.Collection("price_quotes").UpdateOne(sessionCtx,
+		bson.M{"symbol": action.Symbol, "as_of": bson.M{"$lt": action.ExDate}}, rescale)
+	if err != nil {
+		return err
+	}
+	_, err = r.db.Collection("stocks").UpdateOne(sessionCtx,
+		bson.M{"symbol": action.Symbol},
+		bson.M{"$set": bson.M{"corporate_action_factor": stockFactor}})
+	return err
+}
+
+// retireSymbol records the alias of a renamed, merged or delisted symbol,
+// stops quoting it and makes sure the symbol it became is tracked.
+func (r *Repository) retireSymbol(sessionCtx mongo.SessionContext, action models.CorporateAction, alias models.SymbolAlias) error {
+	if _, err := r.db.Collection("symbol_aliases").InsertOne(sessionCtx, newSymbolAliasDoc(alias)); err != nil {
+		return err
+	}
+	_, err := r.db.Collection("stocks").UpdateOne(sessionCtx,
+		bson.M{"symbol": alias.Symbol},
+		bson.M{"$set": bson.M{"status": StockInactive}})
+	if err != nil {
+		return err
+	}
+	if alias.Target != "" {
+		_, err := r.db.Collection("stocks").UpdateOne(sessionCtx,
+			bson.M{"symbol": alias.Target},
+			bson.M{"$setOnInsert": stockDoc{
+				Symbol:    alias.Target,
+				Name:      alias.Target,
+				Exchange:  "NSE",
+				Status:    "ACTIVE",
+				CreatedAt: *action.AppliedAt,
+			}},
+			options.Update().SetUpsert(true))
+		if err != nil {
+			return err
+		}
+	}
+	switch action.Kind {
+	case CorporateRename:
+		return r.carryQuote(sessionCtx, alias)
+	case CorporateDelisting:
+		_, err := r.db.Collection("price_quotes").UpdateOne(sessionCtx,
+			bson.M{"symbol": alias.Symbol},
+			bson.M{"$set": bson.M{"source": DelistedQuoteSource}})
+		return err
+	}
+	return nil
+}
+
+// carryQuote gives a renamed symbol's target the old symbol's last quote
+// when it has none, so positions stay priced until the next refresh.
+func (r *Repository) carryQuote(sessionCtx mongo.SessionContext, alias models.SymbolAlias) error {
+	quote, err := decodeOne[quoteDoc](sessionCtx, r, "price_quotes",
+		r.db.Collection("price_quotes").FindOne(sessionCtx, bson.M{"symbol": alias.Symbol}))
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	quote.Symbol = alias.Target
+	result, err := r.db.Collection("price_quotes").UpdateOne(sessionCtx,
+		bson.M{"symbol": alias.Target},
+		bson.M{"$setOnInsert": quote},
+		options.Update().SetUpsert(true))
+	if err != nil || result.UpsertedCount == 0 {
+		return err
+	}
+	// A duplicate key error would abort the transaction, so an existing
+	// history row for the same instant is left alone by upserting instead.
+	_, err = r.db.Collection("price_history").UpdateOne(sessionCtx,
+		bson.M{"symbol": alias.Target, "as_of": quote.AsOf},
+		bson.M{"$setOnInsert": priceHistoryDoc{
+			Symbol:    alias.Target,
+			Price:     quote.Price,
+			AsOf:      quote.AsOf,
+			Source:    quote.Source,
+			CreatedAt: time.Now(),
+		}},
+		options.Update().SetUpsert(true))
+	return err
+}
+
+func (r *Repository) SymbolAlias(ctx context.Context, symbol string) (*models.SymbolAlias, error) {
+	doc, err := decodeOne[symbolAliasDoc](ctx, r, "symbol_aliases",
+		r.db.Collection("symbol_aliases").FindOne(ctx, bson.M{"_id": symbol}))
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, ErrAliasNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	alias, err := doc.toModel()
+	if err != nil {
+		return nil, err
+	}
+	return &alias, nil
+}
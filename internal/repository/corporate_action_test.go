@@ -0,0 +1,299 @@
+package repository
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+
+	"github.com/stocky/backend/internal/models"
+)
+
+// appliedAction is an action applied on its ex-date.
+func appliedAction(kind, symbol, newSymbol string, ratioNew, ratioHeld int64, cash string, exDate time.Time) models.CorporateAction {
+	factor, err := ActionFactor(kind, ratioNew, ratioHeld)
+	if err != nil {
+		panic(err)
+	}
+	appliedAt := exDate
+	return models.CorporateAction{
+		ID:        uuid.New(),
+		Symbol:    symbol,
+		Kind:      kind,
+		NewSymbol: newSymbol,
+		RatioNew:  ratioNew,
+		RatioHeld: ratioHeld,
+		Factor:    factor,
+		CashPrice: dec(cash),
+		ExDate:    exDate,
+		Status:    ActionApplied,
+		AppliedAt: &appliedAt,
+	}
+}
+
+func TestActionFactor(t *testing.T) {
+	tests := []struct {
+		name      string
+		kind      string
+		ratioNew  int64
+		ratioHeld int64
+		want      string
+		wantErr   bool
+	}{
+		{name: "2:1 split", kind: CorporateSplit, ratioNew: 2, ratioHeld: 1, want: "2"},
+		{name: "1:5 consolidation", kind: CorporateSplit, ratioNew: 1, ratioHeld: 5, want: "0.2"},
+		{name: "1:1 bonus", kind: CorporateBonus, ratioNew: 1, ratioHeld: 1, want: "2"},
+		{name: "1:4 bonus", kind: CorporateBonus, ratioNew: 1, ratioHeld: 4, want: "1.25"},
+		{name: "zero ratio", kind: CorporateSplit, ratioNew: 0, ratioHeld: 1, wantErr: true},
+		{name: "negative ratio", kind: CorporateBonus, ratioNew: 1, ratioHeld: -1, wantErr: true},
+		{name: "unknown kind", kind: "dividend", ratioNew: 1, ratioHeld: 1, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ActionFactor(tt.kind, tt.ratioNew, tt.ratioHeld)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("ActionFactor = %s, want an error", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ActionFactor: %v", err)
+			}
+			if !got.Equal(dec(tt.want)) {
+				t.Errorf("ActionFactor = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPlanCorporateAction(t *testing.T) {
+	tests := []struct {
+		name      string
+		kind      string
+		ratioNew  int64
+		ratioHeld int64
+		held      []string
+		issued    []string
+	}{
+		{name: "split", kind: CorporateSplit, ratioNew: 2, ratioHeld: 1, held: []string{"10", "0.5", "0"}, issued: []string{"10", "0.5"}},
+		{name: "bonus rounds to six places", kind: CorporateBonus, ratioNew: 1, ratioHeld: 3, held: []string{"9", "1"}, issued: []string{"3", "0.333333"}},
+		{name: "consolidation issues nothing", kind: CorporateSplit, ratioNew: 1, ratioHeld: 2, held: []string{"10"}},
+	}
+	now := day(6, 3)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			action := appliedAction(tt.kind, "TCS", "", tt.ratioNew, tt.ratioHeld, "0", day(6, 1))
+			held := make(map[uuid.UUID]decimal.Decimal)
+			issuedTo := make(map[uuid.UUID]string)
+			for i, shares := range tt.held {
+				userID := uuid.New()
+				held[userID] = dec(shares)
+				if i < len(tt.issued) {
+					issuedTo[userID] = tt.issued[i]
+				}
+			}
+
+			adjustments, postings := PlanCorporateAction(action, held, now)
+			if len(adjustments) != len(tt.issued) || len(postings) != len(tt.issued) {
+				t.Fatalf("adjustments/postings = %d/%d, want %d each", len(adjustments), len(postings), len(tt.issued))
+			}
+			for i, adjustment := range adjustments {
+				if i > 0 && adjustments[i-1].UserID.String() > adjustment.UserID.String() {
+					t.Errorf("adjustments are not ordered by user ID")
+				}
+				if want := issuedTo[adjustment.UserID]; !adjustment.Shares.Equal(dec(want)) {
+					t.Errorf("issued %s to a holder of %s, want %s", adjustment.Shares, held[adjustment.UserID], want)
+				}
+				if !adjustment.CostInr.IsZero() || !adjustment.CreatedAt.Equal(action.ExDate) {
+					t.Errorf("adjustment cost %s dated %s, want no cost on the ex-date", adjustment.CostInr, adjustment.CreatedAt)
+				}
+				if adjustment.IdempotencyKey != ActionAdjustmentKey(action.ID, adjustment.UserID) {
+					t.Errorf("idempotency key = %q", adjustment.IdempotencyKey)
+				}
+				posting := postings[i]
+				if posting.EventID != adjustment.ID || !posting.StockUnits.Equal(adjustment.Shares) {
+					t.Errorf("posting moves %s units for %s, want %s for %s", posting.StockUnits, posting.EventID, adjustment.Shares, adjustment.ID)
+				}
+				if !posting.Debit.IsZero() || !posting.Credit.IsZero() {
+					t.Errorf("posting moves INR %s/%s, want units only", posting.Debit, posting.Credit)
+				}
+			}
+		})
+	}
+}
+
+// accountTotals nets each account's debits less credits.
+func accountTotals(entries []LedgerEntry) map[string]decimal.Decimal {
+	totals := make(map[string]decimal.Decimal)
+	for _, entry := range entries {
+		totals[entry.AccountCode] = totals[entry.AccountCode].Add(entry.Debit).Sub(entry.Credit)
+	}
+	return totals
+}
+
+func TestPlanSymbolChange(t *testing.T) {
+	tests := []struct {
+		name      string
+		kind      string
+		ratioNew  int64
+		ratioHeld int64
+		cash      string
+		held      string
+		cost      string
+		book      string
+		// issued and newCost describe the NEW adjustment; issued is empty
+		// when none is written.
+		issued   string
+		newCost  string
+		cashPaid string
+		// accounts are the net debits expected on each account.
+		accounts map[string]string
+	}{
+		{
+			name: "rename", kind: CorporateRename, ratioNew: 1, ratioHeld: 1, cash: "0",
+			held: "10", cost: "1000", book: "1200",
+			issued: "10", newCost: "1000", cashPaid: "0",
+			accounts: map[string]string{StockAccount("OLD"): "-1200", StockAccount("NEW"): "1200"},
+		},
+		{
+			name: "share-for-share merger", kind: CorporateMerger, ratioNew: 3, ratioHeld: 2, cash: "0",
+			held: "5", cost: "750", book: "900",
+			issued: "7.5", newCost: "750", cashPaid: "0",
+			accounts: map[string]string{StockAccount("OLD"): "-900", StockAccount("NEW"): "900"},
+		},
+		{
+			name: "merger with cash in lieu", kind: CorporateMerger, ratioNew: 3, ratioHeld: 2, cash: "300",
+			held: "5", cost: "750", book: "900",
+			issued: "7", newCost: "700", cashPaid: "150",
+			accounts: map[string]string{
+				StockAccount("OLD"):      "-900",
+				StockAccount("NEW"):      "840",
+				ActionLossAccount:        "60",
+				ActionReceivableAccount:  "150",
+				CashInLieuPayableAccount: "-150",
+			},
+		},
+		{
+			name: "merger into less than one share", kind: CorporateMerger, ratioNew: 1, ratioHeld: 4, cash: "400",
+			held: "2", cost: "150", book: "160",
+			cashPaid: "200",
+			accounts: map[string]string{
+				StockAccount("OLD"):      "-160",
+				ActionLossAccount:        "160",
+				ActionReceivableAccount:  "200",
+				CashInLieuPayableAccount: "-200",
+			},
+		},
+		{
+			name: "delisting cash-out", kind: CorporateDelisting, ratioNew: 1, ratioHeld: 1, cash: "40",
+			held: "10", cost: "1000", book: "950",
+			cashPaid: "400",
+			accounts: map[string]string{
+				StockAccount("OLD"):      "-950",
+				ActionLossAccount:        "950",
+				ActionReceivableAccount:  "400",
+				CashInLieuPayableAccount: "-400",
+			},
+		},
+	}
+	now := day(6, 1)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			action := appliedAction(tt.kind, "OLD", "NEW", tt.ratioNew, tt.ratioHeld, tt.cash, day(5, 1))
+			if tt.kind == CorporateDelisting {
+				action.NewSymbol = ""
+			}
+			userID := uuid.New()
+			positions := map[uuid.UUID]PositionState{
+				userID:     {Shares: dec(tt.held), TotalCost: dec(tt.cost)},
+				uuid.New(): {Shares: decimal.Zero},
+			}
+			adjustments, postings := PlanSymbolChange(action, positions, dec(tt.book), now)
+
+			wantAdjustments := 1
+			if tt.issued != "" {
+				wantAdjustments = 2
+			}
+			if len(adjustments) != wantAdjustments {
+				t.Fatalf("adjustments = %d, want %d", len(adjustments), wantAdjustments)
+			}
+			out := adjustments[0]
+			if out.UserID != userID || out.Symbol != "OLD" || out.IdempotencyKey != ActionAdjustmentKey(action.ID, userID) {
+				t.Errorf("old adjustment = %+v", out)
+			}
+			if !out.Shares.Equal(dec(tt.held).Neg()) || !out.CostInr.Equal(dec(tt.cost).Neg()) {
+				t.Errorf("old adjustment moves %s shares at %s, want -%s at -%s", out.Shares, out.CostInr, tt.held, tt.cost)
+			}
+			if !out.CashInr.Equal(dec(tt.cashPaid)) {
+				t.Errorf("cash = %s, want %s", out.CashInr, tt.cashPaid)
+			}
+			if tt.issued != "" {
+				in := adjustments[1]
+				if in.Symbol != "NEW" || !in.Shares.Equal(dec(tt.issued)) || !in.CostInr.Equal(dec(tt.newCost)) {
+					t.Errorf("new adjustment = %s %s shares at %s, want NEW %s at %s",
+						in.Symbol, in.Shares, in.CostInr, tt.issued, tt.newCost)
+				}
+				if in.IdempotencyKey == out.IdempotencyKey {
+					t.Errorf("both adjustments share idempotency key %q", in.IdempotencyKey)
+				}
+			}
+
+			checkBalanced(t, postings)
+			totals := accountTotals(postings)
+			if len(totals) != len(tt.accounts) {
+				t.Errorf("accounts = %v, want %v", totals, tt.accounts)
+			}
+			for account, want := range tt.accounts {
+				if !totals[account].Equal(dec(want)) {
+					t.Errorf("%s = %s, want %s", account, totals[account], want)
+				}
+			}
+			for _, entry := range postings {
+				if entry.EventID != out.ID {
+					t.Errorf("posting %s belongs to %s, want the old adjustment %s", entry.AccountCode, entry.EventID, out.ID)
+				}
+			}
+		})
+	}
+}
+
+func TestPlanSymbolChangeSharesBook(t *testing.T) {
+	action := appliedAction(CorporateRename, "OLD", "NEW", 1, 1, "0", day(5, 1))
+	positions := map[uuid.UUID]PositionState{
+		uuid.New(): {Shares: dec("1"), AvgCost: dec("30")},
+		uuid.New(): {Shares: dec("1"), AvgCost: dec("30")},
+		uuid.New(): {Shares: dec("1"), AvgCost: dec("30")},
+	}
+	adjustments, postings := PlanSymbolChange(action, positions, dec("100"), day(6, 1))
+	if len(adjustments) != 6 {
+		t.Fatalf("adjustments = %d, want 6", len(adjustments))
+	}
+	for _, adjustment := range adjustments {
+		if adjustment.Symbol == "NEW" && !adjustment.CostInr.Equal(dec("30")) {
+			t.Errorf("new cost = %s, want 30 carried from the average cost", adjustment.CostInr)
+		}
+	}
+
+	var credits []string
+	for _, entry := range postings {
+		if entry.AccountCode == StockAccount("OLD") {
+			credits = append(credits, entry.Credit.String())
+		}
+	}
+	want := []string{"33.3333", "33.3333", "33.3334"}
+	if len(credits) != len(want) {
+		t.Fatalf("old inventory credits = %v, want %v", credits, want)
+	}
+	for i := range want {
+		if credits[i] != want[i] {
+			t.Errorf("old inventory credits = %v, want %v with the remainder on the last holder", credits, want)
+			break
+		}
+	}
+	checkBalanced(t, postings)
+	if total := accountTotals(postings)[StockAccount("NEW")]; !total.Equal(dec("100")) {
+		t.Errorf("new inventory = %s, want 100", total)
+	}
+}
@@ -0,0 +1,117 @@
+package repository
+
+import (
+	"fmt"
+	"reflect"
+
+	"github.com/shopspring/decimal"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/bsoncodec"
+	"go.mongodb.org/mongo-driver/bson/bsonrw"
+	"go.mongodb.org/mongo-driver/bson/bsontype"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+var decimalType = reflect.TypeOf(decimal.Decimal{})
+
+// decimalCodec stores shopspring decimals as BSON Decimal128 so MongoDB can
+// do arithmetic ($sum, $inc) on amounts. Legacy string, double and integer
+// values are still accepted on decode.
+type decimalCodec struct{}
+
+func (decimalCodec) EncodeValue(_ bsoncodec.EncodeContext, vw bsonrw.ValueWriter, val reflect.Value) error {
+	if !val.IsValid() || val.Type() != decimalType {
+		return bsoncodec.ValueEncoderError{Name: "DecimalEncodeValue", Types: []reflect.Type{decimalType}, Received: val}
+	}
+	d128, err := toDecimal128(val.Interface().(decimal.Decimal))
+	if err != nil {
+		return err
+	}
+	return vw.WriteDecimal128(d128)
+}
+
+func (decimalCodec) DecodeValue(_ bsoncodec.DecodeContext, vr bsonrw.ValueReader, val reflect.Value) error {
+	if !val.CanSet() || val.Type() != decimalType {
+		return bsoncodec.ValueDecoderError{Name: "DecimalDecodeValue", Types: []reflect.Type{decimalType}, Received: val}
+	}
+
+	var (
+		result decimal.Decimal
+		err    error
+	)
+	switch vr.Type() {
+	case bsontype.Decimal128:
+		var d128 primitive.Decimal128
+		if d128, err = vr.ReadDecimal128(); err == nil {
+			result, err = fromDecimal128(d128)
+		}
+	case bsontype.String:
+		var s string
+		if s, err = vr.ReadString(); err == nil {
+			result, err = decimal.NewFromString(s)
+		}
+	case bsontype.Double:
+		var f float64
+		if f, err = vr.ReadDouble(); err == nil {
+			result = decimal.NewFromFloat(f)
+		}
+	case bsontype.Int32:
+		var i int32
+		if i, err = vr.ReadInt32(); err == nil {
+			result = decimal.NewFromInt32(i)
+		}
+	case bsontype.Int64:
+		var i int64
+		if i, err = vr.ReadInt64(); err == nil {
+			result = decimal.NewFromInt(i)
+		}
+	case bsontype.Null:
+		err = vr.ReadNull()
+	case bsontype.Undefined:
+		err = vr.ReadUndefined()
+	default:
+		return fmt.Errorf("cannot decode %v into decimal.Decimal", vr.Type())
+	}
+	if err != nil {
+		return err
+	}
+	val.Set(reflect.ValueOf(result))
+	return nil
+}
+
+// toDecimal128 converts a decimal exactly when it fits in 34 significant
+// digits and rounds away the excess precision otherwise.
+func toDecimal128(d decimal.Decimal) (primitive.Decimal128, error) {
+	if d128, ok := primitive.ParseDecimal128FromBigInt(d.Coefficient(), int(d.Exponent())); ok {
+		return d128, nil
+	}
+	digits := len(d.Coefficient().String())
+	if d.IsNegative() {
+		digits--
+	}
+	places := -d.Exponent() - int32(digits-34)
+	rounded := d.Round(places)
+	d128, ok := primitive.ParseDecimal128FromBigInt(rounded.Coefficient(), int(rounded.Exponent()))
+	if !ok {
+		return primitive.Decimal128{}, fmt.Errorf("decimal %s out of Decimal128 range", d.String())
+	}
+	return d128, nil
+}
+
+// fromDecimal128 converts a BSON Decimal128 back into a decimal.
+func fromDecimal128(d128 primitive.Decimal128) (decimal.Decimal, error) {
+	bi, exp, err := d128.BigInt()
+	if err != nil {
+		return decimal.Zero, err
+	}
+	return decimal.NewFromBigInt(bi, int32(exp)), nil
+}
+
+// Registry returns the BSON registry MongoDB clients must use, with the
+// Decimal128 codec for shopspring decimals registered.
+func Registry() *bsoncodec.Registry {
+	registry := bson.NewRegistry()
+	registry.RegisterTypeEncoder(decimalType, decimalCodec{})
+	registry.RegisterTypeDecoder(decimalType, decimalCodec{})
+	return registry
+}
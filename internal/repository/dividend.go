@@ -0,0 +1,134 @@
+package repository
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+
+	"github.com/stocky/backend/internal/models"
+)
+
+var (
+	ErrDividendNotFound  = errors.New("dividend not found")
+	ErrDuplicateDividend = errors.New("symbol already has a dividend on that record date")
+	ErrDividendPaid      = errors.New("dividend already paid")
+)
+
+// Dividend statuses.
+const (
+	DividendAnnounced = "announced"
+	DividendPaid      = "paid"
+)
+
+// Dividend account codes. The gross dividend is receivable from the issuer;
+// the net is owed to users through their wallets and the TDS to the tax
+// department.
+const (
+	DividendReceivableAccount = "dividend_receivable"
+	DividendPayableAccount    = "dividend_payable"
+	TDSPayableAccount         = "tds_payable"
+)
+
+// DividendFilter narrows a listing; zero values match everything.
+type DividendFilter struct {
+	Symbol string
+	Status string
+}
+
+// DividendPayout is everything PayDividend writes in one transaction.
+// Dividend carries the paid status and totals.
+type DividendPayout struct {
+	Dividend models.Dividend
+	Payments []models.DividendPayment
+	Postings []LedgerEntry
+}
+
+// PlanDividend builds each holder's payment and postings, given the shares
+// every user held on the record date. Amounts are rounded to the paisa, and
+// the TDS is taken from the rounded gross so gross = net + TDS exactly.
+func PlanDividend(dividend models.Dividend, held map[uuid.UUID]decimal.Decimal, now time.Time) ([]models.DividendPayment, []LedgerEntry) {
+	userIDs := make([]uuid.UUID, 0, len(held))
+	for userID := range held {
+		userIDs = append(userIDs, userID)
+	}
+	sort.Slice(userIDs, func(i, j int) bool { return userIDs[i].String() < userIDs[j].String() })
+
+	hundred := decimal.NewFromInt(100)
+	memo := DividendDescription(dividend)
+	var (
+		payments []models.DividendPayment
+		postings []LedgerEntry
+	)
+	for _, userID := range userIDs {
+		shares := held[userID]
+		gross := shares.Mul(dividend.AmountPerShare).Round(2)
+		if !gross.IsPositive() {
+			continue
+		}
+		tds := gross.Mul(dividend.TDSRate).Div(hundred).Round(2)
+		payment := models.DividendPayment{
+			ID:             uuid.New(),
+			DividendID:     dividend.ID,
+			UserID:         userID,
+			Symbol:         dividend.Symbol,
+			Shares:         shares,
+			AmountPerShare: dividend.AmountPerShare,
+			GrossInr:       gross,
+			TDSInr:         tds,
+			NetInr:         gross.Sub(tds),
+			RecordDate:     dividend.RecordDate,
+			PaidAt:         now,
+		}
+		payments = append(payments, payment)
+		postings = append(postings, dividendPostings(payment, memo)...)
+	}
+	return payments, postings
+}
+
+// dividendPostings debits the gross receivable against the net owed to the
+// user and the TDS owed to the government.
+func dividendPostings(payment models.DividendPayment, memo string) []LedgerEntry {
+	entries := []LedgerEntry{
+		{
+			EventID:     payment.ID,
+			AccountCode: DividendReceivableAccount,
+			AccountType: AccountAsset,
+			Symbol:      payment.Symbol,
+			Debit:       payment.GrossInr,
+			Memo:        memo,
+			CreatedAt:   payment.PaidAt,
+		},
+		{
+			EventID:     payment.ID,
+			AccountCode: DividendPayableAccount,
+			AccountType: AccountLiability,
+			Symbol:      payment.Symbol,
+			Credit:      payment.NetInr,
+			Memo:        memo + "; credited to user wallet",
+			CreatedAt:   payment.PaidAt,
+		},
+	}
+	if payment.TDSInr.IsPositive() {
+		entries = append(entries, LedgerEntry{
+			EventID:     payment.ID,
+			AccountCode: TDSPayableAccount,
+			AccountType: AccountLiability,
+			Symbol:      payment.Symbol,
+			Credit:      payment.TDSInr,
+			Memo:        memo + "; TDS deducted",
+			CreatedAt:   payment.PaidAt,
+		})
+	}
+	return entries
+}
+
+// DividendDescription reads like "Dividend of 10 INR per share of INFY,
+// record date 2024-05-31".
+func DividendDescription(dividend models.Dividend) string {
+	return fmt.Sprintf("Dividend of %s INR per share of %s, record date %s", dividend.AmountPerShare,
+		dividend.Symbol, dividend.RecordDate.In(ist).Format(time.DateOnly))
+}
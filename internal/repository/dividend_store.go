@@ -0,0 +1,150 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/stocky/backend/internal/models"
+)
+
+func (r *Repository) CreateDividend(ctx context.Context, dividend models.Dividend) error {
+	_, err := r.db.Collection("dividends").InsertOne(ctx, newDividendDoc(dividend))
+	if mongo.IsDuplicateKeyError(err) {
+		return ErrDuplicateDividend
+	}
+	return err
+}
+
+func (r *Repository) Dividend(ctx context.Context, id uuid.UUID) (*models.Dividend, error) {
+	doc, err := decodeOne[dividendDoc](ctx, r, "dividends",
+		r.db.Collection("dividends").FindOne(ctx, bson.M{"_id": id.String()}))
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, ErrDividendNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	dividend, err := doc.toModel()
+	if err != nil {
+		return nil, err
+	}
+	return &dividend, nil
+}
+
+func (r *Repository) ListDividends(ctx context.Context, filter DividendFilter) ([]models.Dividend, error) {
+	query := bson.M{}
+	if filter.Symbol != "" {
+		query["symbol"] = filter.Symbol
+	}
+	if filter.Status != "" {
+		query["status"] = filter.Status
+	}
+	opts := options.Find().SetSort(bson.D{{Key: "pay_date", Value: 1}, {Key: "created_at", Value: 1}})
+	cursor, err := r.db.Collection("dividends").Find(ctx, query, opts)
+	if err != nil {
+		return nil, err
+	}
+	var items []models.Dividend
+	err = decodeEach(ctx, r, "dividends", cursor, func(doc dividendDoc) error {
+		dividend, err := doc.toModel()
+		if err != nil {
+			return err
+		}
+		items = append(items, dividend)
+		return nil
+	})
+	return items, err
+}
+
+func (r *Repository) PayDividend(ctx context.Context, payout DividendPayout) error {
+	session, err := r.client.StartSession()
+	if err != nil {
+		return err
+	}
+	defer session.EndSession(ctx)
+
+	_, err = session.WithTransaction(ctx, func(sessionCtx mongo.SessionContext) (interface{}, error) {
+		return nil, r.payDividend(sessionCtx, payout)
+	})
+	return err
+}
+
+func (r *Repository) payDividend(sessionCtx mongo.SessionContext, payout DividendPayout) error {
+	doc := newDividendDoc(payout.Dividend)
+	result, err := r.db.Collection("dividends").UpdateOne(sessionCtx,
+		bson.M{"_id": doc.ID, "status": DividendAnnounced},
+		bson.M{"$set": bson.M{
+			"status":    doc.Status,
+			"paid_at":   doc.PaidAt,
+			"holders":   doc.Holders,
+			"gross_inr": doc.GrossInr,
+			"tds_inr":   doc.TDSInr,
+			"net_inr":   doc.NetInr,
+		}})
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		if _, err := r.Dividend(sessionCtx, payout.Dividend.ID); err != nil {
+			return err
+		}
+		return ErrDividendPaid
+	}
+
+	for _, payment := range payout.Payments {
+		if _, err := r.db.Collection("dividend_payments").InsertOne(sessionCtx, newDividendPaymentDoc(payment)); err != nil {
+			return err
+		}
+		_, err := r.db.Collection("user_wallets").UpdateOne(sessionCtx,
+			bson.M{"_id": payment.UserID.String()},
+			bson.M{
+				"$inc": bson.M{"balance_inr": payment.NetInr},
+				"$set": bson.M{"updated_at": payment.PaidAt},
+			},
+			options.Update().SetUpsert(true))
+		if err != nil {
+			return err
+		}
+	}
+	return r.insertLedgerEntries(sessionCtx, payout.Postings)
+}
+
+func (r *Repository) DividendPayments(ctx context.Context, userID uuid.UUID) ([]models.DividendPayment, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "paid_at", Value: 1}})
+	cursor, err := r.db.Collection("dividend_payments").Find(ctx, bson.M{"user_id": userID.String()}, opts)
+	if err != nil {
+		return nil, err
+	}
+	var items []models.DividendPayment
+	err = decodeEach(ctx, r, "dividend_payments", cursor, func(doc dividendPaymentDoc) error {
+		payment, err := doc.toModel()
+		if err != nil {
+			return err
+		}
+		items = append(items, payment)
+		return nil
+	})
+	return items, err
+}
+
+func (r *Repository) Wallet(ctx context.Context, userID uuid.UUID) (*models.Wallet, error) {
+	doc, err := decodeOne[walletDoc](ctx, r, "user_wallets",
+		r.db.Collection("user_wallets").FindOne(ctx, bson.M{"_id": userID.String()}))
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return &models.Wallet{UserID: userID, BalanceInr: decimal.Zero}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	wallet, err := doc.toModel()
+	if err != nil {
+		return nil, err
+	}
+	return &wallet, nil
+}
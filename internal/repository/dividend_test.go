@@ -0,0 +1,101 @@
+package repository
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+
+	"github.com/stocky/backend/internal/models"
+)
+
+func TestPlanDividend(t *testing.T) {
+	tests := []struct {
+		name     string
+		perShare string
+		tdsRate  string
+		shares   string
+		wantPaid bool
+		gross    string
+		tds      string
+		net      string
+	}{
+		{name: "whole shares", perShare: "10", tdsRate: "10", shares: "12", wantPaid: true, gross: "120", tds: "12", net: "108"},
+		{name: "no TDS", perShare: "10", tdsRate: "0", shares: "12", wantPaid: true, gross: "120", tds: "0", net: "120"},
+		{name: "fractional shares round to the paisa", perShare: "7.5", tdsRate: "10", shares: "0.333333", wantPaid: true, gross: "2.5", tds: "0.25", net: "2.25"},
+		{name: "TDS rounds from the rounded gross", perShare: "3.33", tdsRate: "7.5", shares: "3", wantPaid: true, gross: "9.99", tds: "0.75", net: "9.24"},
+		{name: "less than a paisa is skipped", perShare: "1", tdsRate: "10", shares: "0.004"},
+		{name: "no shares is skipped", perShare: "10", tdsRate: "10", shares: "0"},
+	}
+	now := day(6, 7)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dividend := models.Dividend{
+				ID:             uuid.New(),
+				Symbol:         "INFY",
+				AmountPerShare: dec(tt.perShare),
+				TDSRate:        dec(tt.tdsRate),
+				RecordDate:     day(5, 31),
+			}
+			userID := uuid.New()
+			payments, postings := PlanDividend(dividend, map[uuid.UUID]decimal.Decimal{userID: dec(tt.shares)}, now)
+			if !tt.wantPaid {
+				if len(payments) != 0 || len(postings) != 0 {
+					t.Fatalf("payments = %v, postings = %v; want none", payments, postings)
+				}
+				return
+			}
+			if len(payments) != 1 {
+				t.Fatalf("payments = %d, want 1", len(payments))
+			}
+			payment := payments[0]
+			if payment.UserID != userID || payment.DividendID != dividend.ID || !payment.PaidAt.Equal(now) {
+				t.Errorf("payment = %+v, want user %s, dividend %s, paid %s", payment, userID, dividend.ID, now)
+			}
+			if !payment.GrossInr.Equal(dec(tt.gross)) || !payment.TDSInr.Equal(dec(tt.tds)) || !payment.NetInr.Equal(dec(tt.net)) {
+				t.Errorf("gross/tds/net = %s/%s/%s, want %s/%s/%s",
+					payment.GrossInr, payment.TDSInr, payment.NetInr, tt.gross, tt.tds, tt.net)
+			}
+			checkBalanced(t, postings)
+			wantPostings := 3
+			if payment.TDSInr.IsZero() {
+				wantPostings = 2
+			}
+			if len(postings) != wantPostings {
+				t.Errorf("postings = %d, want %d", len(postings), wantPostings)
+			}
+			for _, entry := range postings {
+				if entry.EventID != payment.ID {
+					t.Errorf("posting %s belongs to %s, want %s", entry.AccountCode, entry.EventID, payment.ID)
+				}
+			}
+		})
+	}
+}
+
+func TestPlanDividendHolders(t *testing.T) {
+	dividend := models.Dividend{ID: uuid.New(), Symbol: "TCS", AmountPerShare: dec("28"), TDSRate: dec("10"), RecordDate: day(5, 31)}
+	held := map[uuid.UUID]decimal.Decimal{
+		uuid.New(): dec("5"),
+		uuid.New(): dec("0.5"),
+		uuid.New(): dec("0"),
+	}
+	payments, postings := PlanDividend(dividend, held, day(6, 7))
+	if len(payments) != 2 {
+		t.Fatalf("payments = %d, want 2", len(payments))
+	}
+	if payments[0].UserID.String() > payments[1].UserID.String() {
+		t.Errorf("payments are not ordered by user ID")
+	}
+	total := decimal.Zero
+	for _, payment := range payments {
+		if !payment.Shares.Equal(held[payment.UserID]) {
+			t.Errorf("payment shares = %s, want %s", payment.Shares, held[payment.UserID])
+		}
+		total = total.Add(payment.GrossInr)
+	}
+	if !total.Equal(dec("154")) {
+		t.Errorf("gross total = %s, want 154", total)
+	}
+	checkBalanced(t, postings)
+}
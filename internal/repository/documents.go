@@ -0,0 +1,923 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/stocky/backend/internal/models"
+)
+
+// Typed documents for every collection. Amounts are decimal.Decimal and are
+// written as Decimal128 by the registry codec; legacy string amounts still
+// decode.
+
+type userDoc struct {
+	ID        string    `bson:"_id"`
+	CreatedAt time.Time `bson:"created_at"`
+}
+
+type stockDoc struct {
+	Symbol    string    `bson:"symbol"`
+	Name      string    `bson:"name"`
+	Exchange  string    `bson:"exchange"`
+	Status    string    `bson:"status"`
+	CreatedAt time.Time `bson:"created_at"`
+}
+
+type rewardEventDoc struct {
+	ID           string           `bson:"_id"`
+	UserID       string           `bson:"user_id"`
+	Symbol       string           `bson:"symbol"`
+	Shares       decimal.Decimal  `bson:"shares"`
+	GrantedPrice decimal.Decimal  `bson:"granted_price_inr"`
+	Brokerage    decimal.Decimal  `bson:"brokerage_inr"`
+	Taxes        decimal.Decimal  `bson:"taxes_inr"`
+	TotalCashOut decimal.Decimal  `bson:"total_cash_out_inr"`
+	CostBasis    decimal.Decimal  `bson:"cost_basis_inr"`
+	Fees         *feeBreakdownDoc `bson:"fees,omitempty"`
+	RewardedAt   time.Time        `bson:"rewarded_at"`
+	EventKey     string           `bson:"event_key"`
+	CreatedAt    time.Time        `bson:"created_at"`
+	chainDoc     `bson:",inline"`
+}
+
+// chainDoc is a record's hash chain link; legacy documents have none.
+type chainDoc struct {
+	ChainSeq int64  `bson:"chain_seq,omitempty"`
+	PrevHash string `bson:"prev_hash,omitempty"`
+	Hash     string `bson:"hash,omitempty"`
+}
+
+func newChainDoc(link models.ChainLink) chainDoc {
+	return chainDoc{ChainSeq: link.Seq, PrevHash: link.PrevHash, Hash: link.Hash}
+}
+
+func (d chainDoc) link() models.ChainLink {
+	return models.ChainLink{Seq: d.ChainSeq, PrevHash: d.PrevHash, Hash: d.Hash}
+}
+
+func newRewardEventDoc(reward models.RewardEvent) rewardEventDoc {
+	doc := rewardEventDoc{
+		ID:           reward.ID.String(),
+		UserID:       reward.UserID.String(),
+		Symbol:       reward.Symbol,
+		Shares:       reward.Shares,
+		GrantedPrice: reward.GrantedPrice,
+		Brokerage:    reward.BrokerageInr,
+		Taxes:        reward.TaxesInr,
+		TotalCashOut: reward.TotalCashOut,
+		CostBasis:    reward.CostBasis,
+		RewardedAt:   reward.RewardedAt,
+		EventKey:     reward.EventKey,
+		CreatedAt:    reward.CreatedAt,
+		chainDoc:     newChainDoc(reward.Chain),
+	}
+	if reward.Fees != nil {
+		doc.Fees = newFeeBreakdownDoc(*reward.Fees)
+	}
+	return doc
+}
+
+type feeBreakdownDoc struct {
+	Exchange        string          `bson:"exchange"`
+	ScheduleID      string          `bson:"schedule_id"`
+	Brokerage       decimal.Decimal `bson:"brokerage_inr"`
+	GST             decimal.Decimal `bson:"gst_inr"`
+	STT             decimal.Decimal `bson:"stt_inr"`
+	ExchangeCharges decimal.Decimal `bson:"exchange_charges_inr"`
+	SEBIFees        decimal.Decimal `bson:"sebi_fees_inr"`
+	StampDuty       decimal.Decimal `bson:"stamp_duty_inr"`
+}
+
+func newFeeBreakdownDoc(f models.FeeBreakdown) *feeBreakdownDoc {
+	doc := feeBreakdownDoc(f)
+	return &doc
+}
+
+func (d rewardEventDoc) toModel() (models.RewardEvent, error) {
+	id, err := uuid.Parse(d.ID)
+	if err != nil {
+		return models.RewardEvent{}, fmt.Errorf("_id: %w", err)
+	}
+	userID, err := uuid.Parse(d.UserID)
+	if err != nil {
+		return models.RewardEvent{}, fmt.Errorf("user_id: %w", err)
+	}
+	var fees *models.FeeBreakdown
+	if d.Fees != nil {
+		breakdown := models.FeeBreakdown(*d.Fees)
+		fees = &breakdown
+	}
+	return models.RewardEvent{
+		ID:           id,
+		UserID:       userID,
+		Symbol:       d.Symbol,
+		Shares:       d.Shares,
+		GrantedPrice: d.GrantedPrice,
+		BrokerageInr: d.Brokerage,
+		TaxesInr:     d.Taxes,
+		TotalCashOut: d.TotalCashOut,
+		CostBasis:    d.CostBasis,
+		Fees:         fees,
+		RewardedAt:   d.RewardedAt.UTC(),
+		EventKey:     d.EventKey,
+		CreatedAt:    d.CreatedAt.UTC(),
+		Chain:        d.link(),
+	}, nil
+}
+
+type ledgerEntryDoc struct {
+	ID          primitive.ObjectID `bson:"_id,omitempty"`
+	EventID     string             `bson:"event_id"`
+	AccountCode string             `bson:"account_code"`
+	AccountType string             `bson:"account_type"`
+	Symbol      string             `bson:"symbol,omitempty"`
+	Debit       decimal.Decimal    `bson:"debit_inr"`
+	Credit      decimal.Decimal    `bson:"credit_inr"`
+	StockUnits  decimal.Decimal    `bson:"stock_units,omitempty"`
+	Memo        string             `bson:"memo"`
+	CreatedAt   time.Time          `bson:"created_at"`
+	chainDoc    `bson:",inline"`
+}
+
+func newLedgerEntryDoc(entry LedgerEntry) ledgerEntryDoc {
+	return ledgerEntryDoc{
+		EventID:     entry.EventID.String(),
+		AccountCode: entry.AccountCode,
+		AccountType: entry.AccountType,
+		Symbol:      entry.Symbol,
+		Debit:       entry.Debit,
+		Credit:      entry.Credit,
+		StockUnits:  entry.StockUnits,
+		Memo:        entry.Memo,
+		CreatedAt:   entry.CreatedAt,
+		chainDoc:    newChainDoc(entry.Chain),
+	}
+}
+
+func (d ledgerEntryDoc) toEntry() (LedgerEntry, error) {
+	eventID, err := uuid.Parse(d.EventID)
+	if err != nil {
+		return LedgerEntry{}, fmt.Errorf("event_id: %w", err)
+	}
+	return LedgerEntry{
+		EventID:     eventID,
+		AccountCode: d.AccountCode,
+		AccountType: d.AccountType,
+		Symbol:      d.Symbol,
+		Debit:       d.Debit,
+		Credit:      d.Credit,
+		StockUnits:  d.StockUnits,
+		Memo:        d.Memo,
+		CreatedAt:   d.CreatedAt.UTC(),
+		Chain:       d.link(),
+	}, nil
+}
+
+func (d ledgerEntryDoc) toModel() (models.LedgerPosting, error) {
+	eventID, err := uuid.Parse(d.EventID)
+	if err != nil {
+		return models.LedgerPosting{}, fmt.Errorf("event_id: %w", err)
+	}
+	return models.LedgerPosting{
+		ID:          d.ID.Hex(),
+		EventID:     eventID,
+		AccountCode: d.AccountCode,
+		AccountType: d.AccountType,
+		Symbol:      d.Symbol,
+		DebitInr:    d.Debit,
+		CreditInr:   d.Credit,
+		StockUnits:  d.StockUnits,
+		Memo:        d.Memo,
+		CreatedAt:   d.CreatedAt.UTC(),
+	}, nil
+}
+
+type ledgerAccountDoc struct {
+	Code      string     `bson:"code"`
+	Type      string     `bson:"type"`
+	Currency  string     `bson:"currency"`
+	Symbol    string     `bson:"symbol,omitempty"`
+	CreatedAt time.Time  `bson:"created_at"`
+	ClosedAt  *time.Time `bson:"closed_at,omitempty"`
+}
+
+func newLedgerAccountDoc(account models.LedgerAccount) ledgerAccountDoc {
+	return ledgerAccountDoc(account)
+}
+
+func (d ledgerAccountDoc) toModel() models.LedgerAccount {
+	return models.LedgerAccount(d)
+}
+
+type corporateActionDoc struct {
+	ID           string          `bson:"_id"`
+	Symbol       string          `bson:"symbol"`
+	Kind         string          `bson:"kind"`
+	NewSymbol    string          `bson:"new_symbol,omitempty"`
+	RatioNew     int64           `bson:"ratio_new"`
+	RatioHeld    int64           `bson:"ratio_held"`
+	Factor       decimal.Decimal `bson:"factor"`
+	CashPrice    decimal.Decimal `bson:"cash_price_inr"`
+	RecordDate   time.Time       `bson:"record_date"`
+	ExDate       time.Time       `bson:"ex_date"`
+	Status       string          `bson:"status"`
+	Note         string          `bson:"note,omitempty"`
+	CreatedAt    time.Time       `bson:"created_at"`
+	AppliedAt    *time.Time      `bson:"applied_at,omitempty"`
+	Holders      int             `bson:"holders"`
+	SharesIssued decimal.Decimal `bson:"shares_issued"`
+	CashPaid     decimal.Decimal `bson:"cash_paid_inr"`
+	FrozenPrice  decimal.Decimal `bson:"frozen_price_inr"`
+}
+
+func newCorporateActionDoc(action models.CorporateAction) corporateActionDoc {
+	return corporateActionDoc{
+		ID:           action.ID.String(),
+		Symbol:       action.Symbol,
+		Kind:         action.Kind,
+		NewSymbol:    action.NewSymbol,
+		RatioNew:     action.RatioNew,
+		RatioHeld:    action.RatioHeld,
+		Factor:       action.Factor,
+		CashPrice:    action.CashPrice,
+		RecordDate:   action.RecordDate,
+		ExDate:       action.ExDate,
+		Status:       action.Status,
+		Note:         action.Note,
+		CreatedAt:    action.CreatedAt,
+		AppliedAt:    action.AppliedAt,
+		Holders:      action.Holders,
+		SharesIssued: action.SharesIssued,
+		CashPaid:     action.CashPaid,
+		FrozenPrice:  action.FrozenPrice,
+	}
+}
+
+func (d corporateActionDoc) toModel() (models.CorporateAction, error) {
+	id, err := uuid.Parse(d.ID)
+	if err != nil {
+		return models.CorporateAction{}, fmt.Errorf("_id: %w", err)
+	}
+	action := models.CorporateAction{
+		ID:           id,
+		Symbol:       d.Symbol,
+		Kind:         d.Kind,
+		NewSymbol:    d.NewSymbol,
+		RatioNew:     d.RatioNew,
+		RatioHeld:    d.RatioHeld,
+		Factor:       d.Factor,
+		CashPrice:    d.CashPrice,
+		RecordDate:   d.RecordDate.UTC(),
+		ExDate:       d.ExDate.UTC(),
+		Status:       d.Status,
+		Note:         d.Note,
+		CreatedAt:    d.CreatedAt.UTC(),
+		Holders:      d.Holders,
+		SharesIssued: d.SharesIssued,
+		CashPaid:     d.CashPaid,
+		FrozenPrice:  d.FrozenPrice,
+	}
+	if d.AppliedAt != nil {
+		appliedAt := d.AppliedAt.UTC()
+		action.AppliedAt = &appliedAt
+	}
+	return action, nil
+}
+
+type symbolAliasDoc struct {
+	Symbol      string    `bson:"_id"`
+	Target      string    `bson:"target,omitempty"`
+	Kind        string    `bson:"kind"`
+	ActionID    string    `bson:"action_id"`
+	EffectiveAt time.Time `bson:"effective_at"`
+}
+
+func newSymbolAliasDoc(alias models.SymbolAlias) symbolAliasDoc {
+	return symbolAliasDoc{
+		Symbol:      alias.Symbol,
+		Target:      alias.Target,
+		Kind:        alias.Kind,
+		ActionID:    alias.ActionID.String(),
+		EffectiveAt: alias.EffectiveAt,
+	}
+}
+
+func (d symbolAliasDoc) toModel() (models.SymbolAlias, error) {
+	actionID, err := uuid.Parse(d.ActionID)
+	if err != nil {
+		return models.SymbolAlias{}, fmt.Errorf("action_id: %w", err)
+	}
+	return models.SymbolAlias{
+		Symbol:      d.Symbol,
+		Target:      d.Target,
+		Kind:        d.Kind,
+		ActionID:    actionID,
+		EffectiveAt: d.EffectiveAt.UTC(),
+	}, nil
+}
+
+type dividendDoc struct {
+	ID             string          `bson:"_id"`
+	Symbol         string          `bson:"symbol"`
+	AmountPerShare decimal.Decimal `bson:"amount_per_share_inr"`
+	RecordDate     time.Time       `bson:"record_date"`
+	PayDate        time.Time       `bson:"pay_date"`
+	TDSRate        decimal.Decimal `bson:"tds_rate_pct"`
+	Status         string          `bson:"status"`
+	Note           string          `bson:"note,omitempty"`
+	CreatedAt      time.Time       `bson:"created_at"`
+	PaidAt         *time.Time      `bson:"paid_at,omitempty"`
+	Holders        int             `bson:"holders"`
+	GrossInr       decimal.Decimal `bson:"gross_inr"`
+	TDSInr         decimal.Decimal `bson:"tds_inr"`
+	NetInr         decimal.Decimal `bson:"net_inr"`
+}
+
+func newDividendDoc(dividend models.Dividend) dividendDoc {
+	return dividendDoc{
+		ID:             dividend.ID.String(),
+		Symbol:         dividend.Symbol,
+		AmountPerShare: dividend.AmountPerShare,
+		RecordDate:     dividend.RecordDate,
+		PayDate:        dividend.PayDate,
+		TDSRate:        dividend.TDSRate,
+		Status:         dividend.Status,
+		Note:           dividend.Note,
+		CreatedAt:      dividend.CreatedAt,
+		PaidAt:         dividend.PaidAt,
+		Holders:        dividend.Holders,
+		GrossInr:       dividend.GrossInr,
+		TDSInr:         dividend.TDSInr,
+		NetInr:         dividend.NetInr,
+	}
+}
+
+func (d dividendDoc) toModel() (models.Dividend, error) {
+	id, err := uuid.Parse(d.ID)
+	if err != nil {
+		return models.Dividend{}, fmt.Errorf("_id: %w", err)
+	}
+	dividend := models.Dividend{
+		ID:             id,
+		Symbol:         d.Symbol,
+		AmountPerShare: d.AmountPerShare,
+		RecordDate:     d.RecordDate.UTC(),
+		PayDate:        d.PayDate.UTC(),
+		TDSRate:        d.TDSRate,
+		Status:         d.Status,
+		Note:           d.Note,
+		CreatedAt:      d.CreatedAt.UTC(),
+		Holders:        d.Holders,
+		GrossInr:       d.GrossInr,
+		TDSInr:         d.TDSInr,
+		NetInr:         d.NetInr,
+	}
+	if d.PaidAt != nil {
+		paidAt := d.PaidAt.UTC()
+		dividend.PaidAt = &paidAt
+	}
+	return dividend, nil
+}
+
+type dividendPaymentDoc struct {
+	ID             string          `bson:"_id"`
+	DividendID     string          `bson:"dividend_id"`
+	UserID         string          `bson:"user_id"`
+	Symbol         string          `bson:"symbol"`
+	Shares         decimal.Decimal `bson:"shares"`
+	AmountPerShare decimal.Decimal `bson:"amount_per_share_inr"`
+	GrossInr       decimal.Decimal `bson:"gross_inr"`
+	TDSInr         decimal.Decimal `bson:"tds_inr"`
+	NetInr         decimal.Decimal `bson:"net_inr"`
+	RecordDate     time.Time       `bson:"record_date"`
+	PaidAt         time.Time       `bson:"paid_at"`
+}
+
+func newDividendPaymentDoc(payment models.DividendPayment) dividendPaymentDoc {
+	return dividendPaymentDoc{
+		ID:             payment.ID.String(),
+		DividendID:     payment.DividendID.String(),
+		UserID:         payment.UserID.String(),
+		Symbol:         payment.Symbol,
+		Shares:         payment.Shares,
+		AmountPerShare: payment.AmountPerShare,
+		GrossInr:       payment.GrossInr,
+		TDSInr:         payment.TDSInr,
+		NetInr:         payment.NetInr,
+		RecordDate:     payment.RecordDate,
+		PaidAt:         payment.PaidAt,
+	}
+}
+
+func (d dividendPaymentDoc) toModel() (models.DividendPayment, error) {
+	id, err := uuid.Parse(d.ID)
+	if err != nil {
+		return models.DividendPayment{}, fmt.Errorf("_id: %w", err)
+	}
+	dividendID, err := uuid.Parse(d.DividendID)
+	if err != nil {
+		return models.DividendPayment{}, fmt.Errorf("dividend_id: %w", err)
+	}
+	userID, err := uuid.Parse(d.UserID)
+	if err != nil {
+		return models.DividendPayment{}, fmt.Errorf("user_id: %w", err)
+	}
+	return models.DividendPayment{
+		ID:             id,
+		DividendID:     dividendID,
+		UserID:         userID,
+		Symbol:         d.Symbol,
+		Shares:         d.Shares,
+		AmountPerShare: d.AmountPerShare,
+		GrossInr:       d.GrossInr,
+		TDSInr:         d.TDSInr,
+		NetInr:         d.NetInr,
+		RecordDate:     d.RecordDate.UTC(),
+		PaidAt:         d.PaidAt.UTC(),
+	}, nil
+}
+
+type walletDoc struct {
+	UserID     string          `bson:"_id"`
+	BalanceInr decimal.Decimal `bson:"balance_inr"`
+	UpdatedAt  time.Time       `bson:"updated_at"`
+}
+
+func (d walletDoc) toModel() (models.Wallet, error) {
+	userID, err := uuid.Parse(d.UserID)
+	if err != nil {
+		return models.Wallet{}, fmt.Errorf("_id: %w", err)
+	}
+	updatedAt := d.UpdatedAt.UTC()
+	return models.Wallet{UserID: userID, BalanceInr: d.BalanceInr, UpdatedAt: &updatedAt}, nil
+}
+
+type treasuryFundingDoc struct {
+	ID        string          `bson:"_id"`
+	AmountInr decimal.Decimal `bson:"amount_inr"`
+	Reference string          `bson:"reference"`
+	Memo      string          `bson:"memo,omitempty"`
+	CreatedAt time.Time       `bson:"created_at"`
+}
+
+func newTreasuryFundingDoc(funding models.TreasuryFunding) treasuryFundingDoc {
+	return treasuryFundingDoc{
+		ID:        funding.ID.String(),
+		AmountInr: funding.AmountInr,
+		Reference: funding.Reference,
+		Memo:      funding.Memo,
+		CreatedAt: funding.CreatedAt,
+	}
+}
+
+func (d treasuryFundingDoc) toModel() (models.TreasuryFunding, error) {
+	id, err := uuid.Parse(d.ID)
+	if err != nil {
+		return models.TreasuryFunding{}, fmt.Errorf("_id: %w", err)
+	}
+	return models.TreasuryFunding{
+		ID:        id,
+		AmountInr: d.AmountInr,
+		Reference: d.Reference,
+		Memo:      d.Memo,
+		CreatedAt: d.CreatedAt,
+	}, nil
+}
+
+type treasuryAlertDoc struct {
+	ID           string          `bson:"_id"`
+	EventID      string          `bson:"event_id"`
+	BalanceInr   decimal.Decimal `bson:"balance_inr"`
+	ThresholdInr decimal.Decimal `bson:"threshold_inr"`
+	CreatedAt    time.Time       `bson:"created_at"`
+}
+
+func newTreasuryAlertDoc(alert models.TreasuryAlert) treasuryAlertDoc {
+	return treasuryAlertDoc{
+		ID:           alert.ID.String(),
+		EventID:      alert.EventID.String(),
+		BalanceInr:   alert.BalanceInr,
+		ThresholdInr: alert.ThresholdInr,
+		CreatedAt:    alert.CreatedAt,
+	}
+}
+
+func (d treasuryAlertDoc) toModel() (models.TreasuryAlert, error) {
+	id, err := uuid.Parse(d.ID)
+	if err != nil {
+		return models.TreasuryAlert{}, fmt.Errorf("_id: %w", err)
+	}
+	eventID, err := uuid.Parse(d.EventID)
+	if err != nil {
+		return models.TreasuryAlert{}, fmt.Errorf("event_id: %w", err)
+	}
+	return models.TreasuryAlert{
+		ID:           id,
+		EventID:      eventID,
+		BalanceInr:   d.BalanceInr,
+		ThresholdInr: d.ThresholdInr,
+		CreatedAt:    d.CreatedAt,
+	}, nil
+}
+
+type ledgerPeriodDoc struct {
+	Period   string              `bson:"_id"`
+	StartsAt time.Time           `bson:"starts_at"`
+	EndsAt   time.Time           `bson:"ends_at"`
+	Status   string              `bson:"status"`
+	ClosedAt *time.Time          `bson:"closed_at,omitempty"`
+	ClosedBy string              `bson:"closed_by,omitempty"`
+	Balances []accountBalanceDoc `bson:"balances"`
+	History  []periodEventDoc    `bson:"history"`
+}
+
+type periodEventDoc struct {
+	Action string    `bson:"action"`
+	Actor  string    `bson:"actor"`
+	Reason string    `bson:"reason,omitempty"`
+	At     time.Time `bson:"at"`
+}
+
+func (d ledgerPeriodDoc) toModel() models.LedgerPeriod {
+	period := models.LedgerPeriod{
+		Period:   d.Period,
+		StartsAt: d.StartsAt.UTC(),
+		EndsAt:   d.EndsAt.UTC(),
+		Status:   d.Status,
+		ClosedBy: d.ClosedBy,
+		Balances: []models.AccountBalance{},
+		History:  []models.PeriodEvent{},
+	}
+	if d.ClosedAt != nil {
+		closedAt := d.ClosedAt.UTC()
+		period.ClosedAt = &closedAt
+	}
+	for _, balance := range d.Balances {
+		period.Balances = append(period.Balances, models.AccountBalance(balance))
+	}
+	for _, event := range d.History {
+		event.At = event.At.UTC()
+		period.History = append(period.History, models.PeriodEvent(event))
+	}
+	return period
+}
+
+type trialBalanceDoc struct {
+	ID               primitive.ObjectID  `bson:"_id,omitempty"`
+	CheckedAt        time.Time           `bson:"checked_at"`
+	Balanced         bool                `bson:"balanced"`
+	TotalDebit       decimal.Decimal     `bson:"total_debit_inr"`
+	TotalCredit      decimal.Decimal     `bson:"total_credit_inr"`
+	Accounts         []accountBalanceDoc `bson:"accounts"`
+	UnbalancedEvents []eventImbalanceDoc `bson:"unbalanced_events"`
+	StockMismatches  []stockMismatchDoc  `bson:"stock_mismatches"`
+}
+
+type accountBalanceDoc struct {
+	AccountCode string          `bson:"account_code"`
+	AccountType string          `bson:"account_type"`
+	Symbol      string          `bson:"symbol,omitempty"`
+	DebitInr    decimal.Decimal `bson:"debit_inr"`
+	CreditInr   decimal.Decimal `bson:"credit_inr"`
+	BalanceInr  decimal.Decimal `bson:"balance_inr"`
+	StockUnits  decimal.Decimal `bson:"stock_units"`
+	Postings    int             `bson:"postings"`
+}
+
+type eventImbalanceDoc struct {
+	EventID    string          `bson:"event_id"`
+	Debit      decimal.Decimal `bson:"debit_inr"`
+	Credit     decimal.Decimal `bson:"credit_inr"`
+	Difference decimal.Decimal `bson:"difference_inr"`
+}
+
+type stockMismatchDoc struct {
+	Symbol          string          `bson:"symbol"`
+	LedgerUnits     decimal.Decimal `bson:"ledger_units"`
+	PositionUnits   decimal.Decimal `bson:"position_units"`
+	DifferenceUnits decimal.Decimal `bson:"difference_units"`
+}
+
+func newTrialBalanceDoc(report models.TrialBalance) trialBalanceDoc {
+	doc := trialBalanceDoc{
+		CheckedAt:   report.CheckedAt,
+		Balanced:    report.Balanced,
+		TotalDebit:  report.TotalDebitInr,
+		TotalCredit: report.TotalCreditInr,
+	}
+	for _, account := range report.Accounts {
+		doc.Accounts = append(doc.Accounts, accountBalanceDoc(account))
+	}
+	for _, event := range report.UnbalancedEvents {
+		doc.UnbalancedEvents = append(doc.UnbalancedEvents, eventImbalanceDoc{
+			EventID:    event.EventID.String(),
+			Debit:      event.DebitInr,
+			Credit:     event.CreditInr,
+			Difference: event.DifferenceInr,
+		})
+	}
+	for _, mismatch := range report.StockMismatches {
+		doc.StockMismatches = append(doc.StockMismatches, stockMismatchDoc(mismatch))
+	}
+	return doc
+}
+
+func (d trialBalanceDoc) toModel() (models.TrialBalance, error) {
+	report := models.TrialBalance{
+		CheckedAt:      d.CheckedAt.UTC(),
+		Balanced:       d.Balanced,
+		TotalDebitInr:  d.TotalDebit,
+		TotalCreditInr: d.TotalCredit,
+	}
+	for _, account := range d.Accounts {
+		report.Accounts = append(report.Accounts, models.AccountBalance(account))
+	}
+	for _, event := range d.UnbalancedEvents {
+		eventID, err := uuid.Parse(event.EventID)
+		if err != nil {
+			return report, fmt.Errorf("unbalanced event_id: %w", err)
+		}
+		report.UnbalancedEvents = append(report.UnbalancedEvents, models.EventImbalance{
+			EventID:       eventID,
+			DebitInr:      event.Debit,
+			CreditInr:     event.Credit,
+			DifferenceInr: event.Difference,
+		})
+	}
+	for _, mismatch := range d.StockMismatches {
+		report.StockMismatches = append(report.StockMismatches, models.StockMismatch(mismatch))
+	}
+	return report, nil
+}
+
+type positionDoc struct {
+	UserID          string          `bson:"user_id"`
+	Symbol          string          `bson:"symbol"`
+	NetShares       decimal.Decimal `bson:"net_shares"`
+	AvgCost         decimal.Decimal `bson:"avg_cost_inr"`
+	TotalCost       decimal.Decimal `bson:"total_cost_inr"`
+	FirstAcquiredAt time.Time       `bson:"first_acquired_at,omitempty"`
+	UpdatedAt       time.Time       `bson:"updated_at,omitempty"`
+}
+
+func (d positionDoc) toRecord() (PositionRecord, error) {
+	userID, err := uuid.Parse(d.UserID)
+	if err != nil {
+		return PositionRecord{}, fmt.Errorf("user_id: %w", err)
+	}
+	return PositionRecord{
+		UserID: userID,
+		Symbol: d.Symbol,
+		PositionState: PositionState{
+			Shares:          d.NetShares,
+			AvgCost:         d.AvgCost,
+			TotalCost:       d.TotalCost,
+			FirstAcquiredAt: d.FirstAcquiredAt.UTC(),
+			UpdatedAt:       d.UpdatedAt.UTC(),
+		},
+	}, nil
+}
+
+func newPositionDoc(userID uuid.UUID, symbol string, state PositionState) positionDoc {
+	return positionDoc{
+		UserID:          userID.String(),
+		Symbol:          symbol,
+		NetShares:       state.Shares,
+		AvgCost:         state.AvgCost,
+		TotalCost:       state.TotalCost,
+		FirstAcquiredAt: state.FirstAcquiredAt,
+		UpdatedAt:       state.UpdatedAt,
+	}
+}
+
+type quoteDoc struct {
+	Symbol    string          `bson:"symbol"`
+	Price     decimal.Decimal `bson:"price_inr"`
+	Source    string          `bson:"source"`
+	AsOf      time.Time       `bson:"as_of"`
+	FetchedAt time.Time       `bson:"fetched_at"`
+}
+
+func (d quoteDoc) toModel() models.PriceQuote {
+	return models.PriceQuote{
+		Symbol:    d.Symbol,
+		Price:     d.Price,
+		Source:    d.Source,
+		AsOf:      d.AsOf.UTC(),
+		FetchedAt: d.FetchedAt.UTC(),
+	}
+}
+
+type priceHistoryDoc struct {
+	Symbol    string          `bson:"symbol"`
+	Price     decimal.Decimal `bson:"price_inr"`
+	AsOf      time.Time       `bson:"as_of"`
+	Source    string          `bson:"source"`
+	CreatedAt time.Time       `bson:"created_at"`
+}
+
+type adjustmentDoc struct {
+	ID             string          `bson:"_id"`
+	UserID         string          `bson:"user_id"`
+	Symbol         string          `bson:"symbol"`
+	Kind           string          `bson:"kind"`
+	Shares         decimal.Decimal `bson:"shares"`
+	CostInr        decimal.Decimal `bson:"cost_inr"`
+	CashInr        decimal.Decimal `bson:"cash_inr"`
+	FeeTreatment   string          `bson:"fee_treatment,omitempty"`
+	Reason         string          `bson:"reason"`
+	ReferenceEvent string          `bson:"reference_event,omitempty"`
+	IdempotencyKey string          `bson:"idempotency_key"`
+	CreatedAt      time.Time       `bson:"created_at"`
+}
+
+func newAdjustmentDoc(adj models.Adjustment) adjustmentDoc {
+	doc := adjustmentDoc{
+		ID:             adj.ID.String(),
+		UserID:         adj.UserID.String(),
+		Symbol:         adj.Symbol,
+		Kind:           adj.Kind,
+		Shares:         adj.Shares,
+		CostInr:        adj.CostInr,
+		CashInr:        adj.CashInr,
+		FeeTreatment:   adj.FeeTreatment,
+		Reason:         adj.Reason,
+		IdempotencyKey: adj.IdempotencyKey,
+		CreatedAt:      adj.CreatedAt,
+	}
+	if adj.ReferenceEvent != nil {
+		doc.ReferenceEvent = adj.ReferenceEvent.String()
+	}
+	return doc
+}
+
+func (d adjustmentDoc) toModel() (models.Adjustment, error) {
+	id, err := uuid.Parse(d.ID)
+	if err != nil {
+		return models.Adjustment{}, fmt.Errorf("_id: %w", err)
+	}
+	userID, err := uuid.Parse(d.UserID)
+	if err != nil {
+		return models.Adjustment{}, fmt.Errorf("user_id: %w", err)
+	}
+	adj := models.Adjustment{
+		ID:             id,
+		UserID:         userID,
+		Symbol:         d.Symbol,
+		Kind:           d.Kind,
+		Shares:         d.Shares,
+		CostInr:        d.CostInr,
+		CashInr:        d.CashInr,
+		FeeTreatment:   d.FeeTreatment,
+		Reason:         d.Reason,
+		IdempotencyKey: d.IdempotencyKey,
+		CreatedAt:      d.CreatedAt.UTC(),
+	}
+	if d.ReferenceEvent != "" {
+		ref, err := uuid.Parse(d.ReferenceEvent)
+		if err != nil {
+			return models.Adjustment{}, fmt.Errorf("reference_event: %w", err)
+		}
+		adj.ReferenceEvent = &ref
+	}
+	return adj, nil
+}
+
+type dailyHoldingDoc struct {
+	UserID       string          `bson:"user_id"`
+	Date         time.Time       `bson:"date"`
+	TotalValue   decimal.Decimal `bson:"total_value_inr"`
+	StaleSymbols []string        `bson:"stale_symbols"`
+	UpdatedAt    time.Time       `bson:"updated_at"`
+}
+
+// DecodeError reports a stored document that could not be turned into its
+// typed form.
+type DecodeError struct {
+	Collection string
+	ID         interface{}
+	Err        error
+}
+
+func (e *DecodeError) Error() string {
+	return fmt.Sprintf("decode %s document %v: %v", e.Collection, e.ID, e.Err)
+}
+
+func (e *DecodeError) Unwrap() error {
+	return e.Err
+}
+
+// IsDecodeError reports whether err was caused by a malformed document.
+func IsDecodeError(err error) bool {
+	var decodeErr *DecodeError
+	return errors.As(err, &decodeErr)
+}
+
+type quarantineDoc struct {
+	Collection string      `bson:"collection"`
+	DocumentID interface{} `bson:"document_id"`
+	Error      string      `bson:"error"`
+	Document   bson.Raw    `bson:"document"`
+	DetectedAt time.Time   `bson:"detected_at"`
+}
+
+// quarantine logs a malformed document and records a copy in the quarantine
+// collection so it can be repaired; the original is left in place. Failures
+// to record are only logged because the caller is already on an error path.
+func (r *Repository) quarantine(ctx context.Context, collection string, raw bson.Raw, cause error) *DecodeError {
+	var id interface{}
+	if val, err := raw.LookupErr("_id"); err == nil {
+		id = val
+	}
+	decodeErr := &DecodeError{Collection: collection, ID: id, Err: cause}
+	log.Printf("quarantine: %v", decodeErr)
+
+	doc := quarantineDoc{
+		Collection: collection,
+		DocumentID: id,
+		Error:      cause.Error(),
+		Document:   raw,
+		DetectedAt: time.Now(),
+	}
+	if mongo.SessionFromContext(ctx) != nil {
+		// Write outside the caller's transaction so the record survives its
+		// abort.
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+	}
+	_, err := r.db.Collection("quarantine").UpdateOne(ctx,
+		bson.M{"collection": collection, "document_id": id},
+		bson.M{"$set": doc},
+		options.Update().SetUpsert(true),
+	)
+	if err != nil {
+		log.Printf("quarantine: record %s %v: %v", collection, id, err)
+	}
+	return decodeErr
+}
+
+// decodeEach decodes every document from the cursor into T and hands it to
+// visit. A document that fails to decode, or that visit rejects, is
+// quarantined and skipped so one bad record does not fail the whole read.
+// Only user-facing reads may skip; replay and accounting reads use
+// decodeAll.
+func decodeEach[T any](ctx context.Context, r *Repository, collection string, cursor *mongo.Cursor, visit func(T) error) error {
+	defer cursor.Close(ctx)
+	for cursor.Next(ctx) {
+		var doc T
+		err := cursor.Decode(&doc)
+		if err == nil {
+			err = visit(doc)
+		}
+		if err != nil {
+			r.quarantine(ctx, collection, cursor.Current, err)
+		}
+	}
+	return cursor.Err()
+}
+
+// decodeAll is the strict form of decodeEach for reads whose result must be
+// complete, such as projection replays and ledger totals: the first document
+// that fails to decode or that visit rejects is quarantined and the read
+// fails with its *DecodeError.
+func decodeAll[T any](ctx context.Context, r *Repository, collection string, cursor *mongo.Cursor, visit func(T) error) error {
+	defer cursor.Close(ctx)
+	for cursor.Next(ctx) {
+		var doc T
+		err := cursor.Decode(&doc)
+		if err == nil {
+			err = visit(doc)
+		}
+		if err != nil {
+			return r.quarantine(ctx, collection, cursor.Current, err)
+		}
+	}
+	return cursor.Err()
+}
+
+// decodeOne decodes a single result, quarantining and returning a
+// *DecodeError when the document is malformed. mongo.ErrNoDocuments is
+// passed through.
+func decodeOne[T any](ctx context.Context, r *Repository, collection string, result *mongo.SingleResult) (T, error) {
+	var doc T
+	if err := result.Decode(&doc); err != nil {
+		raw, rawErr := result.Raw()
+		if rawErr != nil {
+			return doc, err
+		}
+		return doc, r.quarantine(ctx, collection, raw, err)
+	}
+	return doc, nil
+}
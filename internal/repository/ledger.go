@@ -0,0 +1,192 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/stocky/backend/internal/models"
+)
+
+func (r *Repository) LedgerByEvent(ctx context.Context, eventID uuid.UUID) ([]models.LedgerPosting, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "_id", Value: 1}})
+	cursor, err := r.db.Collection("ledger_entries").Find(ctx, bson.M{"event_id": eventID.String()}, opts)
+	if err != nil {
+		return nil, err
+	}
+	return decodePostings(ctx, r, cursor)
+}
+
+func (r *Repository) LedgerByAccount(ctx context.Context, query LedgerQuery) ([]models.LedgerPosting, error) {
+	filter := bson.M{}
+	if query.AccountCode != "" {
+		filter["account_code"] = query.AccountCode
+	}
+	createdAt := bson.M{}
+	if !query.From.IsZero() {
+		createdAt["$gte"] = query.From
+	}
+	if !query.To.IsZero() {
+		createdAt["$lt"] = query.To
+	}
+	if len(createdAt) > 0 {
+		filter["created_at"] = createdAt
+	}
+	if query.After != nil {
+		afterID, err := primitive.ObjectIDFromHex(query.After.ID)
+		if err != nil {
+			return nil, ErrInvalidCursor
+		}
+		filter["$or"] = bson.A{
+			bson.M{"created_at": bson.M{"$gt": query.After.CreatedAt}},
+			bson.M{"created_at": query.After.CreatedAt, "_id": bson.M{"$gt": afterID}},
+		}
+	}
+
+	opts := options.Find().
+		SetSort(bson.D{{Key: "created_at", Value: 1}, {Key: "_id", Value: 1}}).
+		SetLimit(int64(query.Limit))
+	cursor, err := r.db.Collection("ledger_entries").Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	return decodePostings(ctx, r, cursor)
+}
+
+func (r *Repository) AccountBalances(ctx context.Context, asOf time.Time, accountCode string) ([]models.AccountBalance, error) {
+	match := bson.M{"created_at": bson.M{"$lte": asOf}}
+	if accountCode != "" {
+		match["account_code"] = accountCode
+	}
+	// Amounts are Decimal128, so the sums are exact.
+	pipeline := []bson.M{
+		{"$match": match},
+		{"$group": bson.M{
+			"_id":      "$account_code",
+			"type":     bson.M{"$first": "$account_type"},
+			"symbol":   bson.M{"$max": "$symbol"},
+			"debit":    bson.M{"$sum": "$debit_inr"},
+			"credit":   bson.M{"$sum": "$credit_inr"},
+			"units":    bson.M{"$sum": "$stock_units"},
+			"postings": bson.M{"$sum": 1},
+		}},
+		{"$sort": bson.M{"_id": 1}},
+	}
+	cursor, err := r.db.Collection("ledger_entries").Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var docs []struct {
+		Code     string          `bson:"_id"`
+		Type     string          `bson:"type"`
+		Symbol   string          `bson:"symbol"`
+		Debit    decimal.Decimal `bson:"debit"`
+		Credit   decimal.Decimal `bson:"credit"`
+		Units    decimal.Decimal `bson:"units"`
+		Postings int             `bson:"postings"`
+	}
+	if err := cursor.All(ctx, &docs); err != nil {
+		return nil, err
+	}
+
+	items := make([]models.AccountBalance, 0, len(docs))
+	for _, doc := range docs {
+		items = append(items, models.AccountBalance{
+			AccountCode: doc.Code,
+			AccountType: doc.Type,
+			Symbol:      doc.Symbol,
+			DebitInr:    doc.Debit,
+			CreditInr:   doc.Credit,
+			BalanceInr:  doc.Debit.Sub(doc.Credit),
+			StockUnits:  doc.Units,
+			Postings:    doc.Postings,
+		})
+	}
+	return items, nil
+}
+
+func decodePostings(ctx context.Context, r *Repository, cursor *mongo.Cursor) ([]models.LedgerPosting, error) {
+	var items []models.LedgerPosting
+	err := decodeAll(ctx, r, "ledger_entries", cursor, func(doc ledgerEntryDoc) error {
+		posting, err := doc.toModel()
+		if err != nil {
+			return err
+		}
+		items = append(items, posting)
+		return nil
+	})
+	return items, err
+}
+
+func (r *Repository) UnbalancedEvents(ctx context.Context) ([]models.EventImbalance, error) {
+	pipeline := []bson.M{
+		{"$group": bson.M{
+			"_id":    "$event_id",
+			"debit":  bson.M{"$sum": "$debit_inr"},
+			"credit": bson.M{"$sum": "$credit_inr"},
+		}},
+		{"$match": bson.M{"$expr": bson.M{"$ne": bson.A{"$debit", "$credit"}}}},
+		{"$sort": bson.M{"_id": 1}},
+	}
+	cursor, err := r.db.Collection("ledger_entries").Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var docs []struct {
+		EventID string          `bson:"_id"`
+		Debit   decimal.Decimal `bson:"debit"`
+		Credit  decimal.Decimal `bson:"credit"`
+	}
+	if err := cursor.All(ctx, &docs); err != nil {
+		return nil, err
+	}
+
+	items := make([]models.EventImbalance, 0, len(docs))
+	for _, doc := range docs {
+		eventID, err := uuid.Parse(doc.EventID)
+		if err != nil {
+			return nil, fmt.Errorf("ledger_entries event_id %q: %w", doc.EventID, err)
+		}
+		items = append(items, models.EventImbalance{
+			EventID:       eventID,
+			DebitInr:      doc.Debit,
+			CreditInr:     doc.Credit,
+			DifferenceInr: doc.Debit.Sub(doc.Credit),
+		})
+	}
+	return items, nil
+}
+
+func (r *Repository) SaveTrialBalance(ctx context.Context, report models.TrialBalance) error {
+	_, err := r.db.Collection("trial_balances").InsertOne(ctx, newTrialBalanceDoc(report))
+	return err
+}
+
+func (r *Repository) LatestTrialBalance(ctx context.Context) (*models.TrialBalance, error) {
+	opts := options.FindOne().SetSort(bson.D{{Key: "checked_at", Value: -1}})
+	result := r.db.Collection("trial_balances").FindOne(ctx, bson.M{}, opts)
+	doc, err := decodeOne[trialBalanceDoc](ctx, r, "trial_balances", result)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, ErrTrialBalanceNotFound
+		}
+		return nil, err
+	}
+	report, err := doc.toModel()
+	if err != nil {
+		return nil, err
+	}
+	return &report, nil
+}
@@ -0,0 +1,79 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/stocky/backend/internal/models"
+	"github.com/stocky/backend/internal/repository"
+)
+
+func (s *Store) ListAccounts(_ context.Context) ([]models.LedgerAccount, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	items := make([]models.LedgerAccount, 0, len(s.accounts))
+	for _, account := range s.accounts {
+		items = append(items, account)
+	}
+	sort.Slice(items, func(i, j int) bool { return items[i].Code < items[j].Code })
+	return items, nil
+}
+
+func (s *Store) CreateAccount(_ context.Context, account models.LedgerAccount) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.accounts[account.Code]; ok {
+		return repository.ErrAccountExists
+	}
+	s.accounts[account.Code] = account
+	return nil
+}
+
+func (s *Store) CloseAccount(_ context.Context, code string, at time.Time) (*models.LedgerAccount, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	account, ok := s.accounts[code]
+	if !ok {
+		return nil, repository.ErrAccountNotFound
+	}
+	if account.ClosedAt != nil {
+		return nil, repository.ErrAccountClosed
+	}
+	account.ClosedAt = &at
+	s.accounts[code] = account
+	return &account, nil
+}
+
+// checkPostings rejects entries that post to unknown or closed accounts
+// without changing anything. The caller must hold the lock.
+func (s *Store) checkPostings(entries []repository.LedgerEntry) error {
+	for _, entry := range entries {
+		var account *models.LedgerAccount
+		if existing, ok := s.accounts[entry.AccountCode]; ok {
+			account = &existing
+		}
+		if _, err := repository.PostingAccount(entry, account, entry.CreatedAt); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// openAccounts creates the stock inventory accounts entries post to for the
+// first time. The caller must hold the write lock and have checked the
+// entries with checkPostings.
+func (s *Store) openAccounts(entries []repository.LedgerEntry) {
+	for _, entry := range entries {
+		if _, ok := s.accounts[entry.AccountCode]; ok {
+			continue
+		}
+		created, _ := repository.PostingAccount(entry, nil, entry.CreatedAt)
+		if created != nil {
+			s.accounts[created.Code] = *created
+		}
+	}
+}
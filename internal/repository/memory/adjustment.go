@@ -0,0 +1,92 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/stocky/backend/internal/models"
+	"github.com/stocky/backend/internal/repository"
+)
+
+func (s *Store) ReverseReward(_ context.Context, params repository.ReversalParams) (*models.Adjustment, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.adjustKey[params.IdempotencyKey]; ok {
+		return nil, repository.ErrDuplicateAdjustment
+	}
+
+	var (
+		reward models.RewardEvent
+		found  bool
+	)
+	for _, candidate := range s.rewards {
+		if candidate.ID == params.RewardID {
+			reward, found = candidate, true
+			break
+		}
+	}
+	if !found {
+		return nil, repository.ErrRewardNotFound
+	}
+
+	var earlier []models.Adjustment
+	for _, adj := range s.adjusts {
+		if adj.Kind == repository.AdjustmentReversal && adj.ReferenceEvent != nil && *adj.ReferenceEvent == reward.ID {
+			earlier = append(earlier, adj)
+		}
+	}
+
+	adjustment, entries, err := repository.PlanReversal(reward, s.actions, earlier, params, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	key := positionKey{userID: adjustment.UserID, symbol: adjustment.Symbol}
+	next, err := s.positions[key].Adjust(adjustment.Shares, adjustment.CostInr)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.checkPostings(entries); err != nil {
+		return nil, err
+	}
+
+	s.positions[key] = next
+	s.appendLedger(entries)
+	s.adjustKey[adjustment.IdempotencyKey] = len(s.adjusts)
+	s.adjusts = append(s.adjusts, adjustment)
+	return &adjustment, nil
+}
+
+func (s *Store) AdjustmentByKey(_ context.Context, key string) (*models.Adjustment, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	idx, ok := s.adjustKey[key]
+	if !ok {
+		return nil, repository.ErrAdjustmentNotFound
+	}
+	adjustment := s.adjusts[idx]
+	return &adjustment, nil
+}
+
+func (s *Store) ListAdjustments(_ context.Context, filter repository.ProjectionFilter) ([]models.Adjustment, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var items []models.Adjustment
+	for _, adj := range s.adjusts {
+		if !matchesProjection(filter, adj.UserID, adj.Symbol) {
+			continue
+		}
+		if !filter.Until.IsZero() && adj.CreatedAt.After(filter.Until) {
+			continue
+		}
+		items = append(items, adj)
+	}
+	sort.SliceStable(items, func(i, j int) bool {
+		return items[i].CreatedAt.Before(items[j].CreatedAt)
+	})
+	return items, nil
+}
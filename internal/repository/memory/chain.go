@@ -0,0 +1,70 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/stocky/backend/internal/models"
+	"github.com/stocky/backend/internal/repository"
+)
+
+// extendChain links contents onto the named chain. The caller must hold the
+// write lock.
+func (s *Store) extendChain(chain string, contents ...string) []models.ChainLink {
+	links := repository.NextLinks(s.chainHeads[chain], contents...)
+	if len(links) > 0 {
+		s.chainHeads[chain] = links[len(links)-1]
+	}
+	return links
+}
+
+// appendLedger chains and stores entries, opening any new stock inventory
+// account. The caller must hold the write lock and have checked the entries
+// with checkPostings.
+func (s *Store) appendLedger(entries []repository.LedgerEntry) {
+	s.openAccounts(entries)
+	contents := make([]string, len(entries))
+	for i, entry := range entries {
+		contents[i] = repository.LedgerContent(entry)
+	}
+	for i, link := range s.extendChain(repository.ChainLedger, contents...) {
+		entries[i].Chain = link
+	}
+	s.ledger = append(s.ledger, entries...)
+}
+
+func (s *Store) WalkChain(_ context.Context, chain string, visit func(repository.ChainRecord) error) error {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	switch chain {
+	case repository.ChainRewards:
+		for _, reward := range s.rewards {
+			record := repository.ChainRecord{ID: reward.ID.String(), Content: repository.RewardContent(reward), ChainLink: reward.Chain}
+			if err := visit(record); err != nil {
+				return err
+			}
+		}
+	case repository.ChainLedger:
+		for i, entry := range s.ledger {
+			record := repository.ChainRecord{ID: fmt.Sprint(i + 1), Content: repository.LedgerContent(entry), ChainLink: entry.Chain}
+			if err := visit(record); err != nil {
+				return err
+			}
+		}
+	default:
+		return fmt.Errorf("unknown chain %q", chain)
+	}
+	return nil
+}
+
+func (s *Store) ChainHead(_ context.Context, chain string) (models.ChainLink, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.chainHeads[chain], nil
+}
+
+// LegacyRecords is always zero: the in-memory store chains every record.
+func (s *Store) LegacyRecords(_ context.Context, _ string) (int, error) {
+	return 0, nil
+}
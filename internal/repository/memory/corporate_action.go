@@ -0,0 +1,172 @@
+package memory
+
+import (
+	"context"
+	"sort"
+
+	"github.com/google/uuid"
+
+	"github.com/stocky/backend/internal/models"
+	"github.com/stocky/backend/internal/repository"
+)
+
+func (s *Store) CreateCorporateAction(_ context.Context, action models.CorporateAction) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, existing := range s.actions {
+		if existing.Symbol == action.Symbol && existing.ExDate.Equal(action.ExDate) {
+			return repository.ErrDuplicateCorporateAction
+		}
+	}
+	s.actions = append(s.actions, action)
+	return nil
+}
+
+func (s *Store) CorporateAction(_ context.Context, id uuid.UUID) (*models.CorporateAction, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for _, action := range s.actions {
+		if action.ID == id {
+			return &action, nil
+		}
+	}
+	return nil, repository.ErrCorporateActionNotFound
+}
+
+func (s *Store) ListCorporateActions(_ context.Context, filter repository.CorporateActionFilter) ([]models.CorporateAction, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var items []models.CorporateAction
+	for _, action := range s.actions {
+		if filter.Symbol != "" && action.Symbol != filter.Symbol {
+			continue
+		}
+		if filter.Status != "" && action.Status != filter.Status {
+			continue
+		}
+		items = append(items, action)
+	}
+	sort.SliceStable(items, func(i, j int) bool { return items[i].ExDate.Before(items[j].ExDate) })
+	return items, nil
+}
+
+func (s *Store) ApplyCorporateAction(_ context.Context, application repository.CorporateActionApplication) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	action := application.Action
+	idx := -1
+	for i, existing := range s.actions {
+		if existing.ID == action.ID {
+			idx = i
+		}
+	}
+	if idx < 0 {
+		return repository.ErrCorporateActionNotFound
+	}
+	if s.actions[idx].Status != repository.ActionPending {
+		return repository.ErrCorporateActionApplied
+	}
+
+	next := make(map[positionKey]repository.PositionState, len(application.Adjustments))
+	for _, adjustment := range application.Adjustments {
+		if _, ok := s.adjustKey[adjustment.IdempotencyKey]; ok {
+			return repository.ErrDuplicateAdjustment
+		}
+		key := positionKey{userID: adjustment.UserID, symbol: adjustment.Symbol}
+		state, err := s.positions[key].Adjust(adjustment.Shares, adjustment.CostInr)
+		if err != nil {
+			return err
+		}
+		if state.FirstAcquiredAt.IsZero() {
+			state.FirstAcquiredAt = adjustment.CreatedAt
+		}
+		next[key] = state
+	}
+	if err := s.checkPostings(application.Postings); err != nil {
+		return err
+	}
+
+	for key, state := range next {
+		s.positions[key] = state
+	}
+	for _, adjustment := range application.Adjustments {
+		s.adjustKey[adjustment.IdempotencyKey] = len(s.adjusts)
+		s.adjusts = append(s.adjusts, adjustment)
+	}
+	s.appendLedger(application.Postings)
+
+	if repository.RescalesPrices(action.Kind) {
+		for key, quote := range s.history {
+			if key.symbol == action.Symbol && quote.AsOf.Before(action.ExDate) {
+				quote.Price = quote.Price.Div(action.Factor).Round(4)
+				s.history[key] = quote
+			}
+		}
+		if quote, ok := s.quotes[action.Symbol]; ok && quote.AsOf.Before(action.ExDate) {
+			quote.Price = quote.Price.Div(action.Factor).Round(4)
+			s.quotes[action.Symbol] = quote
+		}
+		if st, ok := s.stocks[action.Symbol]; ok {
+			st.factor = application.StockFactor
+		}
+	}
+	if alias := application.Alias; alias != nil {
+		s.aliases[alias.Symbol] = *alias
+		if st, ok := s.stocks[alias.Symbol]; ok {
+			st.status = repository.StockInactive
+		}
+		if alias.Target != "" {
+			if _, ok := s.stocks[alias.Target]; !ok {
+				s.stocks[alias.Target] = &stock{
+					symbol:    alias.Target,
+					name:      alias.Target,
+					exchange:  "NSE",
+					status:    "ACTIVE",
+					createdAt: *action.AppliedAt,
+				}
+			}
+		}
+		quote, quoted := s.quotes[alias.Symbol]
+		switch {
+		case !quoted:
+		case action.Kind == repository.CorporateDelisting:
+			quote.Source = repository.DelistedQuoteSource
+			s.quotes[alias.Symbol] = quote
+		case action.Kind == repository.CorporateRename:
+			// The target keeps the last quote until its first refresh.
+			if _, ok := s.quotes[alias.Target]; !ok {
+				quote.Symbol = alias.Target
+				s.quotes[alias.Target] = quote
+				s.history[historyKey{symbol: alias.Target, asOf: quote.AsOf.UnixNano()}] = quote
+			}
+		}
+	}
+	for _, adjustment := range application.Adjustments {
+		if !adjustment.CashInr.IsPositive() {
+			continue
+		}
+		wallet := s.wallets[adjustment.UserID]
+		s.wallets[adjustment.UserID] = models.Wallet{
+			UserID:     adjustment.UserID,
+			BalanceInr: wallet.BalanceInr.Add(adjustment.CashInr),
+			UpdatedAt:  action.AppliedAt,
+		}
+	}
+	s.actions[idx] = action
+	return nil
+}
+
+func (s *Store) SymbolAlias(_ context.Context, symbol string) (*models.SymbolAlias, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	alias, ok := s.aliases[symbol]
+	if !ok {
+		return nil, repository.ErrAliasNotFound
+	}
+	return &alias, nil
+}
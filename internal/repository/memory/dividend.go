@@ -0,0 +1,115 @@
+package memory
+
+import (
+	"context"
+	"sort"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+
+	"github.com/stocky/backend/internal/models"
+	"github.com/stocky/backend/internal/repository"
+)
+
+func (s *Store) CreateDividend(_ context.Context, dividend models.Dividend) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, existing := range s.dividends {
+		if existing.Symbol == dividend.Symbol && existing.RecordDate.Equal(dividend.RecordDate) {
+			return repository.ErrDuplicateDividend
+		}
+	}
+	s.dividends = append(s.dividends, dividend)
+	return nil
+}
+
+func (s *Store) Dividend(_ context.Context, id uuid.UUID) (*models.Dividend, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for _, dividend := range s.dividends {
+		if dividend.ID == id {
+			return &dividend, nil
+		}
+	}
+	return nil, repository.ErrDividendNotFound
+}
+
+func (s *Store) ListDividends(_ context.Context, filter repository.DividendFilter) ([]models.Dividend, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var items []models.Dividend
+	for _, dividend := range s.dividends {
+		if filter.Symbol != "" && dividend.Symbol != filter.Symbol {
+			continue
+		}
+		if filter.Status != "" && dividend.Status != filter.Status {
+			continue
+		}
+		items = append(items, dividend)
+	}
+	sort.SliceStable(items, func(i, j int) bool { return items[i].PayDate.Before(items[j].PayDate) })
+	return items, nil
+}
+
+func (s *Store) PayDividend(_ context.Context, payout repository.DividendPayout) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	dividend := payout.Dividend
+	idx := -1
+	for i, existing := range s.dividends {
+		if existing.ID == dividend.ID {
+			idx = i
+		}
+	}
+	if idx < 0 {
+		return repository.ErrDividendNotFound
+	}
+	if s.dividends[idx].Status != repository.DividendAnnounced {
+		return repository.ErrDividendPaid
+	}
+	if err := s.checkPostings(payout.Postings); err != nil {
+		return err
+	}
+
+	s.appendLedger(payout.Postings)
+	for _, payment := range payout.Payments {
+		s.payments = append(s.payments, payment)
+		wallet := s.wallets[payment.UserID]
+		paidAt := payment.PaidAt
+		s.wallets[payment.UserID] = models.Wallet{
+			UserID:     payment.UserID,
+			BalanceInr: wallet.BalanceInr.Add(payment.NetInr),
+			UpdatedAt:  &paidAt,
+		}
+	}
+	s.dividends[idx] = dividend
+	return nil
+}
+
+func (s *Store) DividendPayments(_ context.Context, userID uuid.UUID) ([]models.DividendPayment, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var items []models.DividendPayment
+	for _, payment := range s.payments {
+		if payment.UserID == userID {
+			items = append(items, payment)
+		}
+	}
+	return items, nil
+}
+
+func (s *Store) Wallet(_ context.Context, userID uuid.UUID) (*models.Wallet, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	wallet, ok := s.wallets[userID]
+	if !ok {
+		wallet = models.Wallet{UserID: userID, BalanceInr: decimal.Zero}
+	}
+	return &wallet, nil
+}
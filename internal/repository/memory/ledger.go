@@ -0,0 +1,158 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/stocky/backend/internal/models"
+	"github.com/stocky/backend/internal/repository"
+)
+
+// Ledger entries are append-only, so a posting's id is its 1-based position
+// in s.ledger.
+
+func (s *Store) LedgerByEvent(_ context.Context, eventID uuid.UUID) ([]models.LedgerPosting, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var items []models.LedgerPosting
+	for i, entry := range s.ledger {
+		if entry.EventID == eventID {
+			items = append(items, posting(i, entry))
+		}
+	}
+	return items, nil
+}
+
+func (s *Store) LedgerByAccount(_ context.Context, query repository.LedgerQuery) ([]models.LedgerPosting, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	afterSeq := 0
+	if query.After != nil {
+		seq, err := strconv.Atoi(query.After.ID)
+		if err != nil || seq < 1 {
+			return nil, repository.ErrInvalidCursor
+		}
+		afterSeq = seq
+	}
+
+	var items []models.LedgerPosting
+	for i, entry := range s.ledger {
+		if query.AccountCode != "" && entry.AccountCode != query.AccountCode {
+			continue
+		}
+		if !query.From.IsZero() && entry.CreatedAt.Before(query.From) {
+			continue
+		}
+		if !query.To.IsZero() && !entry.CreatedAt.Before(query.To) {
+			continue
+		}
+		if query.After != nil {
+			if entry.CreatedAt.Before(query.After.CreatedAt) ||
+				(entry.CreatedAt.Equal(query.After.CreatedAt) && i+1 <= afterSeq) {
+				continue
+			}
+		}
+		items = append(items, posting(i, entry))
+	}
+	sort.SliceStable(items, func(i, j int) bool { return items[i].CreatedAt.Before(items[j].CreatedAt) })
+	if query.Limit > 0 && len(items) > query.Limit {
+		items = items[:query.Limit]
+	}
+	return items, nil
+}
+
+func (s *Store) AccountBalances(_ context.Context, asOf time.Time, accountCode string) ([]models.AccountBalance, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	byCode := make(map[string]*models.AccountBalance)
+	for _, entry := range s.ledger {
+		if entry.CreatedAt.After(asOf) || (accountCode != "" && entry.AccountCode != accountCode) {
+			continue
+		}
+		balance, ok := byCode[entry.AccountCode]
+		if !ok {
+			balance = &models.AccountBalance{AccountCode: entry.AccountCode, AccountType: entry.AccountType}
+			byCode[entry.AccountCode] = balance
+		}
+		if entry.Symbol != "" {
+			balance.Symbol = entry.Symbol
+		}
+		balance.DebitInr = balance.DebitInr.Add(entry.Debit)
+		balance.CreditInr = balance.CreditInr.Add(entry.Credit)
+		balance.StockUnits = balance.StockUnits.Add(entry.StockUnits)
+		balance.Postings++
+	}
+
+	items := make([]models.AccountBalance, 0, len(byCode))
+	for _, balance := range byCode {
+		balance.BalanceInr = balance.DebitInr.Sub(balance.CreditInr)
+		items = append(items, *balance)
+	}
+	sort.Slice(items, func(i, j int) bool { return items[i].AccountCode < items[j].AccountCode })
+	return items, nil
+}
+
+func posting(index int, entry repository.LedgerEntry) models.LedgerPosting {
+	return models.LedgerPosting{
+		ID:          strconv.Itoa(index + 1),
+		EventID:     entry.EventID,
+		AccountCode: entry.AccountCode,
+		AccountType: entry.AccountType,
+		Symbol:      entry.Symbol,
+		DebitInr:    entry.Debit,
+		CreditInr:   entry.Credit,
+		StockUnits:  entry.StockUnits,
+		Memo:        entry.Memo,
+		CreatedAt:   entry.CreatedAt,
+	}
+}
+
+func (s *Store) UnbalancedEvents(_ context.Context) ([]models.EventImbalance, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	byEvent := make(map[uuid.UUID]*models.EventImbalance)
+	for _, entry := range s.ledger {
+		event, ok := byEvent[entry.EventID]
+		if !ok {
+			event = &models.EventImbalance{EventID: entry.EventID}
+			byEvent[entry.EventID] = event
+		}
+		event.DebitInr = event.DebitInr.Add(entry.Debit)
+		event.CreditInr = event.CreditInr.Add(entry.Credit)
+	}
+
+	var items []models.EventImbalance
+	for _, event := range byEvent {
+		if !event.DebitInr.Equal(event.CreditInr) {
+			event.DifferenceInr = event.DebitInr.Sub(event.CreditInr)
+			items = append(items, *event)
+		}
+	}
+	sort.Slice(items, func(i, j int) bool { return items[i].EventID.String() < items[j].EventID.String() })
+	return items, nil
+}
+
+func (s *Store) SaveTrialBalance(_ context.Context, report models.TrialBalance) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.trialBalance = &report
+	return nil
+}
+
+func (s *Store) LatestTrialBalance(_ context.Context) (*models.TrialBalance, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if s.trialBalance == nil {
+		return nil, repository.ErrTrialBalanceNotFound
+	}
+	report := *s.trialBalance
+	return &report, nil
+}
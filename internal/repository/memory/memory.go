@@ -0,0 +1,390 @@
+// Package memory implements repository.Store entirely in process memory. It is
+// intended for local development and for exercising the services without a
+// database; all state is lost when the process exits.
+package memory
+
+import (
+	"context"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+
+	"github.com/stocky/backend/internal/models"
+	"github.com/stocky/backend/internal/repository"
+)
+
+type stock struct {
+	symbol    string
+	name      string
+	exchange  string
+	status    string
+	createdAt time.Time
+	// factor is the cumulative corporate action factor; zero means 1.
+	factor decimal.Decimal
+}
+
+type positionKey struct {
+	userID uuid.UUID
+	symbol string
+}
+
+type historyKey struct {
+	symbol string
+	asOf   int64
+}
+
+type holdingKey struct {
+	userID uuid.UUID
+	date   time.Time
+}
+
+type holding struct {
+	value        decimal.Decimal
+	staleSymbols []string
+	updatedAt    time.Time
+}
+
+// Store is a thread-safe in-memory repository.Store.
+type Store struct {
+	mu sync.RWMutex
+
+	users     map[uuid.UUID]time.Time
+	stocks    map[string]*stock
+	rewards   []models.RewardEvent
+	eventKeys map[string]uuid.UUID
+	ledger    []repository.LedgerEntry
+	adjusts   []models.Adjustment
+	adjustKey map[string]int
+	positions map[positionKey]repository.PositionState
+	quotes    map[string]models.PriceQuote
+	history   map[historyKey]models.PriceQuote
+	holdings  map[holdingKey]holding
+
+	// trialBalance is the latest ledger check; only one is kept.
+	trialBalance *models.TrialBalance
+	chainHeads   map[string]models.ChainLink
+	accounts     map[string]models.LedgerAccount
+
+	fundings    []models.TreasuryFunding
+	fundingRefs map[string]int
+	alerts      []models.TreasuryAlert
+
+	periods map[string]models.LedgerPeriod
+	actions []models.CorporateAction
+	aliases map[string]models.SymbolAlias
+
+	dividends []models.Dividend
+	payments  []models.DividendPayment
+	wallets   map[uuid.UUID]models.Wallet
+}
+
+var _ repository.Store = (*Store)(nil)
+
+func New() *Store {
+	s := &Store{
+		users:     make(map[uuid.UUID]time.Time),
+		stocks:    make(map[string]*stock),
+		eventKeys: make(map[string]uuid.UUID),
+		adjustKey: make(map[string]int),
+		positions: make(map[positionKey]repository.PositionState),
+		quotes:    make(map[string]models.PriceQuote),
+		history:   make(map[historyKey]models.PriceQuote),
+		holdings:  make(map[holdingKey]holding),
+
+		chainHeads: make(map[string]models.ChainLink),
+		accounts:   make(map[string]models.LedgerAccount),
+
+		fundingRefs: make(map[string]int),
+
+		periods: make(map[string]models.LedgerPeriod),
+		aliases: make(map[string]models.SymbolAlias),
+		wallets: make(map[uuid.UUID]models.Wallet),
+	}
+	for _, account := range repository.DefaultAccounts(time.Now()) {
+		s.accounts[account.Code] = account
+	}
+	return s
+}
+
+func (s *Store) CreateReward(_ context.Context, params repository.RewardCreationParams) (*models.RewardEvent, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.eventKeys[params.EventKey]; ok {
+		return nil, repository.ErrDuplicateReward
+	}
+	now := time.Now()
+	if err := s.checkPeriodsOpen(repository.RewardPeriods(params.RewardedAt, now)); err != nil {
+		return nil, err
+	}
+	if params.RequireCash {
+		if err := repository.CheckCash(s.cashBalance(), params.Total); err != nil {
+			return nil, err
+		}
+	}
+	if err := s.checkPostings(repository.RewardPostings(uuid.Nil, params, now)); err != nil {
+		return nil, err
+	}
+	return s.createReward(params, now), nil
+}
+
+func (s *Store) CreateRewards(_ context.Context, params []repository.RewardCreationParams) ([]*models.RewardEvent, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// An item can only fail on a duplicate event key, a closed period, a
+	// cash shortfall or a rejected posting, so checking them all up front
+	// keeps the batch all-or-nothing.
+	now := time.Now()
+	cash := s.cashBalance()
+	seen := make(map[string]bool, len(params))
+	for i, item := range params {
+		if _, ok := s.eventKeys[item.EventKey]; ok || seen[item.EventKey] {
+			return nil, &repository.BatchItemError{Index: i, Err: repository.ErrDuplicateReward}
+		}
+		seen[item.EventKey] = true
+		if err := s.checkPeriodsOpen(repository.RewardPeriods(item.RewardedAt, now)); err != nil {
+			return nil, &repository.BatchItemError{Index: i, Err: err}
+		}
+		if item.RequireCash {
+			if err := repository.CheckCash(cash, item.Total); err != nil {
+				return nil, &repository.BatchItemError{Index: i, Err: err}
+			}
+		}
+		cash = cash.Sub(item.Total)
+		if err := s.checkPostings(repository.RewardPostings(uuid.Nil, item, now)); err != nil {
+			return nil, &repository.BatchItemError{Index: i, Err: err}
+		}
+	}
+
+	rewards := make([]*models.RewardEvent, 0, len(params))
+	for _, item := range params {
+		rewards = append(rewards, s.createReward(item, now))
+	}
+	return rewards, nil
+}
+
+// createReward records a reward whose event key is known to be unused. The
+// caller must hold the write lock.
+func (s *Store) createReward(params repository.RewardCreationParams, now time.Time) *models.RewardEvent {
+
+	symbol := strings.ToUpper(params.Symbol)
+
+	if _, ok := s.users[params.UserID]; !ok {
+		s.users[params.UserID] = now
+	}
+	if _, ok := s.stocks[symbol]; !ok {
+		s.stocks[symbol] = &stock{
+			symbol:    symbol,
+			name:      symbol,
+			exchange:  "NSE",
+			status:    "ACTIVE",
+			createdAt: now,
+		}
+	}
+
+	reward := models.RewardEvent{
+		ID:           uuid.New(),
+		UserID:       params.UserID,
+		Symbol:       symbol,
+		Shares:       params.Shares,
+		GrantedPrice: params.GrantPrice,
+		BrokerageInr: params.Brokerage,
+		TaxesInr:     params.Taxes,
+		TotalCashOut: params.Total,
+		RewardedAt:   params.RewardedAt,
+		EventKey:     params.EventKey,
+		CreatedAt:    now,
+		CostBasis:    params.CostBasis,
+		Fees:         params.Fees,
+	}
+	reward.Chain = s.extendChain(repository.ChainRewards, repository.RewardContent(reward))[0]
+	s.rewards = append(s.rewards, reward)
+	s.eventKeys[params.EventKey] = reward.ID
+	if params.LowBalanceInr.IsPositive() {
+		alert := repository.LowBalanceAlert(reward.ID, s.cashBalance(), params.Total, params.LowBalanceInr, now)
+		if alert != nil {
+			s.alerts = append(s.alerts, *alert)
+		}
+	}
+	s.appendLedger(repository.RewardPostings(reward.ID, params, now))
+
+	key := positionKey{userID: params.UserID, symbol: symbol}
+	s.positions[key] = s.positions[key].Acquire(params.Shares, params.CostBasis, params.RewardedAt)
+
+	return &reward
+}
+
+func (s *Store) ListTodayRewards(_ context.Context, userID uuid.UUID, dayStart, dayEnd time.Time) ([]models.TodayReward, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var items []models.TodayReward
+	for _, reward := range s.rewardsInRange(userID, dayStart, dayEnd) {
+		items = append(items, models.TodayReward{
+			Symbol:     reward.Symbol,
+			Shares:     reward.Shares,
+			RewardedAt: reward.RewardedAt,
+		})
+	}
+	return items, nil
+}
+
+func (s *Store) AggregateShares(_ context.Context, userID uuid.UUID, start, end time.Time) ([]models.TodayTotals, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	totals := make(map[string]decimal.Decimal)
+	var order []string
+	for _, reward := range s.rewardsInRange(userID, start, end) {
+		current, ok := totals[reward.Symbol]
+		if !ok {
+			order = append(order, reward.Symbol)
+		}
+		totals[reward.Symbol] = current.Add(reward.Shares)
+	}
+
+	var items []models.TodayTotals
+	for _, symbol := range order {
+		items = append(items, models.TodayTotals{Symbol: symbol, Shares: totals[symbol]})
+	}
+	return items, nil
+}
+
+// rewardsInRange returns the user's rewards in [start, end) ordered by
+// rewarded_at. Callers must hold the read lock.
+func (s *Store) rewardsInRange(userID uuid.UUID, start, end time.Time) []models.RewardEvent {
+	var matched []models.RewardEvent
+	for _, reward := range s.rewards {
+		if reward.UserID != userID {
+			continue
+		}
+		if reward.RewardedAt.Before(start) || !reward.RewardedAt.Before(end) {
+			continue
+		}
+		matched = append(matched, reward)
+	}
+	sort.SliceStable(matched, func(i, j int) bool {
+		return matched[i].RewardedAt.Before(matched[j].RewardedAt)
+	})
+	return matched
+}
+
+func (s *Store) ListUserPositions(_ context.Context, userID uuid.UUID) ([]repository.UserPosition, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var items []repository.UserPosition
+	for key, pos := range s.positions {
+		if key.userID != userID {
+			continue
+		}
+		items = append(items, repository.UserPosition{
+			Symbol:  key.symbol,
+			Shares:  pos.Shares,
+			AvgCost: pos.AvgCost,
+		})
+	}
+	sort.Slice(items, func(i, j int) bool { return items[i].Symbol < items[j].Symbol })
+	return items, nil
+}
+
+func (s *Store) ListAllPositions(_ context.Context) ([]repository.RawPosition, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	items := make([]repository.RawPosition, 0, len(s.positions))
+	for key, pos := range s.positions {
+		items = append(items, repository.RawPosition{
+			UserID: key.userID,
+			Symbol: key.symbol,
+			Shares: pos.Shares,
+		})
+	}
+	return items, nil
+}
+
+func (s *Store) ListTrackedSymbols(_ context.Context) ([]string, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var symbols []string
+	for symbol, st := range s.stocks {
+		if st.status == "ACTIVE" {
+			symbols = append(symbols, symbol)
+		}
+	}
+	sort.Strings(symbols)
+	return symbols, nil
+}
+
+func (s *Store) UpsertQuote(_ context.Context, quote models.PriceQuote) (*models.PriceQuote, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	quote.Symbol = strings.ToUpper(quote.Symbol)
+	if cached, ok := s.quotes[quote.Symbol]; !ok || !cached.AsOf.After(quote.AsOf) {
+		s.quotes[quote.Symbol] = quote
+	}
+
+	// History is keyed on (symbol, as_of); a replayed snapshot is ignored
+	// just like the duplicate key error is in the MongoDB implementation.
+	key := historyKey{symbol: quote.Symbol, asOf: quote.AsOf.UnixNano()}
+	if _, ok := s.history[key]; !ok {
+		s.history[key] = quote
+	}
+	cached := s.quotes[quote.Symbol]
+	return &cached, nil
+}
+
+func (s *Store) LatestQuote(_ context.Context, symbol string) (*models.PriceQuote, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	quote, ok := s.quotes[strings.ToUpper(symbol)]
+	if !ok {
+		return nil, repository.ErrQuoteNotFound
+	}
+	return &quote, nil
+}
+
+func (s *Store) QuotesForSymbols(_ context.Context, symbols []string) (map[string]models.PriceQuote, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	result := make(map[string]models.PriceQuote, len(symbols))
+	for _, symbol := range symbols {
+		if quote, ok := s.quotes[strings.ToUpper(symbol)]; ok {
+			result[quote.Symbol] = quote
+		}
+	}
+	return result, nil
+}
+
+func (s *Store) HistoricalHoldings(_ context.Context, userID uuid.UUID, before time.Time) ([]models.DailyINR, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var items []models.DailyINR
+	for key, h := range s.holdings {
+		if key.userID != userID || !key.date.Before(before) {
+			continue
+		}
+		items = append(items, models.DailyINR{Date: key.date, TotalValueIn: h.value, StaleSymbols: slices.Clone(h.staleSymbols)})
+	}
+	sort.Slice(items, func(i, j int) bool { return items[i].Date.Before(items[j].Date) })
+	return items, nil
+}
+
+func (s *Store) UpsertDailyHolding(_ context.Context, userID uuid.UUID, date time.Time, value decimal.Decimal, staleSymbols []string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.holdings[holdingKey{userID: userID, date: date}] = holding{value: value, staleSymbols: slices.Clone(staleSymbols), updatedAt: time.Now()}
+	return nil
+}
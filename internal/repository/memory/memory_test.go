@@ -0,0 +1,197 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+
+	"github.com/stocky/backend/internal/models"
+	"github.com/stocky/backend/internal/repository"
+)
+
+func dec(s string) decimal.Decimal { return decimal.RequireFromString(s) }
+
+func rewardParams(userID uuid.UUID, symbol, shares, price, eventKey string) repository.RewardCreationParams {
+	return repository.RewardCreationParams{
+		UserID:     userID,
+		Symbol:     symbol,
+		Shares:     dec(shares),
+		GrantPrice: dec(price),
+		Total:      dec(price).Mul(dec(shares)),
+		CostBasis:  dec(price).Mul(dec(shares)),
+		EventKey:   eventKey,
+		RewardedAt: time.Date(2024, 5, 10, 9, 0, 0, 0, time.UTC),
+	}
+}
+
+func TestCreateRewardEventKeyIsIdempotent(t *testing.T) {
+	ctx := context.Background()
+	s := New()
+	userID := uuid.New()
+
+	first, err := s.CreateReward(ctx, rewardParams(userID, "infy", "2", "1500", "evt-1"))
+	if err != nil {
+		t.Fatalf("CreateReward: %v", err)
+	}
+	if first.Symbol != "INFY" {
+		t.Errorf("symbol = %s, want INFY", first.Symbol)
+	}
+	if _, err := s.CreateReward(ctx, rewardParams(userID, "TCS", "5", "3900", "evt-1")); !errors.Is(err, repository.ErrDuplicateReward) {
+		t.Fatalf("duplicate event key: err = %v, want ErrDuplicateReward", err)
+	}
+
+	positions, err := s.ListUserPositions(ctx, userID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(positions) != 1 || positions[0].Symbol != "INFY" || !positions[0].Shares.Equal(dec("2")) {
+		t.Errorf("positions = %+v, want only the first reward's 2 INFY", positions)
+	}
+	if len(s.rewards) != 1 {
+		t.Errorf("rewards = %d, want 1", len(s.rewards))
+	}
+}
+
+func TestCreateRewardConcurrentDuplicates(t *testing.T) {
+	ctx := context.Background()
+	s := New()
+	userID := uuid.New()
+
+	var (
+		wg      sync.WaitGroup
+		mu      sync.Mutex
+		created int
+	)
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := s.CreateReward(ctx, rewardParams(userID, "INFY", "1", "1500", "evt-race"))
+			if err == nil {
+				mu.Lock()
+				created++
+				mu.Unlock()
+			} else if !errors.Is(err, repository.ErrDuplicateReward) {
+				t.Errorf("CreateReward: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if created != 1 {
+		t.Errorf("created = %d, want 1", created)
+	}
+	positions, _ := s.ListUserPositions(ctx, userID)
+	if len(positions) != 1 || !positions[0].Shares.Equal(dec("1")) {
+		t.Errorf("positions = %+v, want 1 INFY", positions)
+	}
+}
+
+func TestCreateRewardUpdatesPosition(t *testing.T) {
+	ctx := context.Background()
+	s := New()
+	userID := uuid.New()
+
+	for i, reward := range []struct{ shares, price string }{{"2", "100"}, {"6", "120"}} {
+		params := rewardParams(userID, "INFY", reward.shares, reward.price, uuid.NewString())
+		if _, err := s.CreateReward(ctx, params); err != nil {
+			t.Fatalf("reward %d: %v", i, err)
+		}
+	}
+
+	positions, err := s.ListUserPositions(ctx, userID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(positions) != 1 {
+		t.Fatalf("positions = %+v, want one", positions)
+	}
+	if !positions[0].Shares.Equal(dec("8")) || !positions[0].AvgCost.Equal(dec("115")) {
+		t.Errorf("position = %s @ %s, want 8 @ 115", positions[0].Shares, positions[0].AvgCost)
+	}
+	symbols, _ := s.ListTrackedSymbols(ctx)
+	if len(symbols) != 1 || symbols[0] != "INFY" {
+		t.Errorf("tracked symbols = %v, want [INFY]", symbols)
+	}
+}
+
+func quote(symbol, price string, asOf time.Time) models.PriceQuote {
+	return models.PriceQuote{Symbol: symbol, Price: dec(price), Source: "test", FetchedAt: asOf.Add(time.Second), AsOf: asOf}
+}
+
+func TestUpsertQuoteKeepsNewest(t *testing.T) {
+	ctx := context.Background()
+	s := New()
+	first := time.Date(2024, 5, 10, 9, 0, 0, 0, time.UTC)
+	second := first.Add(time.Hour)
+
+	for _, q := range []models.PriceQuote{
+		quote("INFY", "1500", first),
+		quote("INFY", "1510", second),
+		// A replayed snapshot adds nothing to history.
+		quote("INFY", "1510", second),
+	} {
+		if _, err := s.UpsertQuote(ctx, q); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// A late answer for an earlier moment is history, not the latest quote.
+	cached, err := s.UpsertQuote(ctx, quote("INFY", "1490", first.Add(30*time.Minute)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !cached.Price.Equal(dec("1510")) || !cached.AsOf.Equal(second) {
+		t.Errorf("cached = %s as of %s, want 1510 as of %s", cached.Price, cached.AsOf, second)
+	}
+
+	latest, err := s.LatestQuote(ctx, "INFY")
+	if err != nil {
+		t.Fatalf("LatestQuote: %v", err)
+	}
+	if !latest.Price.Equal(dec("1510")) || !latest.AsOf.Equal(second) {
+		t.Errorf("latest = %s as of %s, want 1510 as of %s", latest.Price, latest.AsOf, second)
+	}
+	if len(s.history) != 3 {
+		t.Errorf("history rows = %d, want 3", len(s.history))
+	}
+	if _, err := s.LatestQuote(ctx, "TCS"); !errors.Is(err, repository.ErrQuoteNotFound) {
+		t.Errorf("LatestQuote(TCS): err = %v, want ErrQuoteNotFound", err)
+	}
+
+	quotes, err := s.QuotesForSymbols(ctx, []string{"INFY", "TCS"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(quotes) != 1 || !quotes["INFY"].Price.Equal(dec("1510")) {
+		t.Errorf("quotes = %v, want only INFY", quotes)
+	}
+}
+
+func TestQuoteSymbolsAreUpperCased(t *testing.T) {
+	ctx := context.Background()
+	s := New()
+	asOf := time.Date(2024, 5, 10, 9, 0, 0, 0, time.UTC)
+	if _, err := s.UpsertQuote(ctx, quote("infy", "1500", asOf)); err != nil {
+		t.Fatal(err)
+	}
+
+	latest, err := s.LatestQuote(ctx, "Infy")
+	if err != nil {
+		t.Fatalf("LatestQuote: %v", err)
+	}
+	if latest.Symbol != "INFY" {
+		t.Errorf("symbol = %s, want INFY", latest.Symbol)
+	}
+	quotes, err := s.QuotesForSymbols(ctx, []string{"infy"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(quotes) != 1 || !quotes["INFY"].Price.Equal(dec("1500")) {
+		t.Errorf("quotes = %v, want INFY keyed upper-cased", quotes)
+	}
+}
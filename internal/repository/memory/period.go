@@ -0,0 +1,81 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/stocky/backend/internal/models"
+	"github.com/stocky/backend/internal/repository"
+)
+
+func (s *Store) ClosePeriod(_ context.Context, period models.LedgerPeriod, event models.PeriodEvent) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	existing, ok := s.periods[period.Period]
+	if ok && existing.Status == repository.PeriodClosed {
+		return repository.ErrPeriodClosed
+	}
+	period.History = append(existing.History, event)
+	s.periods[period.Period] = period
+	return nil
+}
+
+func (s *Store) ReopenPeriod(_ context.Context, period string, event models.PeriodEvent) (*models.LedgerPeriod, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	existing, ok := s.periods[period]
+	if !ok {
+		return nil, repository.ErrPeriodNotFound
+	}
+	if existing.Status != repository.PeriodClosed {
+		return nil, repository.ErrPeriodOpen
+	}
+	existing.Status = repository.PeriodOpen
+	existing.History = append(existing.History, event)
+	s.periods[period] = existing
+	return copyPeriod(existing), nil
+}
+
+// checkPeriodsOpen returns ErrPeriodClosed if any of periods is closed. The
+// caller must hold the write lock.
+func (s *Store) checkPeriodsOpen(periods []string) error {
+	for _, period := range periods {
+		if s.periods[period].Status == repository.PeriodClosed {
+			return fmt.Errorf("%w: %s", repository.ErrPeriodClosed, period)
+		}
+	}
+	return nil
+}
+
+func (s *Store) LedgerPeriod(_ context.Context, period string) (*models.LedgerPeriod, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	existing, ok := s.periods[period]
+	if !ok {
+		return nil, repository.ErrPeriodNotFound
+	}
+	return copyPeriod(existing), nil
+}
+
+func (s *Store) ListPeriods(_ context.Context) ([]models.LedgerPeriod, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	items := make([]models.LedgerPeriod, 0, len(s.periods))
+	for _, period := range s.periods {
+		items = append(items, *copyPeriod(period))
+	}
+	sort.Slice(items, func(i, j int) bool { return items[i].Period < items[j].Period })
+	return items, nil
+}
+
+// copyPeriod detaches a stored period from the slices the store appends to.
+func copyPeriod(period models.LedgerPeriod) *models.LedgerPeriod {
+	period.Balances = append([]models.AccountBalance{}, period.Balances...)
+	period.History = append([]models.PeriodEvent{}, period.History...)
+	return &period
+}
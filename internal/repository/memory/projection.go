@@ -0,0 +1,98 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/stocky/backend/internal/models"
+	"github.com/stocky/backend/internal/repository"
+)
+
+func (s *Store) ListRewardEvents(_ context.Context, filter repository.ProjectionFilter) ([]models.RewardEvent, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var items []models.RewardEvent
+	for _, reward := range s.rewards {
+		if !matchesProjection(filter, reward.UserID, reward.Symbol) {
+			continue
+		}
+		if !filter.Until.IsZero() && reward.RewardedAt.After(filter.Until) {
+			continue
+		}
+		items = append(items, reward)
+	}
+	sort.SliceStable(items, func(i, j int) bool {
+		return items[i].RewardedAt.Before(items[j].RewardedAt)
+	})
+	return items, nil
+}
+
+func (s *Store) ListPositionStates(_ context.Context, filter repository.ProjectionFilter) ([]repository.PositionRecord, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var items []repository.PositionRecord
+	for key, pos := range s.positions {
+		if !matchesProjection(filter, key.userID, key.symbol) {
+			continue
+		}
+		items = append(items, repository.PositionRecord{UserID: key.userID, Symbol: key.symbol, PositionState: pos})
+	}
+	return items, nil
+}
+
+func (s *Store) SavePositionStates(_ context.Context, records []repository.PositionRecord) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	for _, record := range records {
+		state := record.PositionState
+		state.UpdatedAt = now
+		s.positions[positionKey{userID: record.UserID, symbol: record.Symbol}] = state
+	}
+	return nil
+}
+
+func (s *Store) DeletePosition(_ context.Context, userID uuid.UUID, symbol string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.positions, positionKey{userID: userID, symbol: strings.ToUpper(symbol)})
+	return nil
+}
+
+func (s *Store) PriceHistory(_ context.Context, symbols []string, until time.Time) ([]models.PriceQuote, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	wanted := make(map[string]bool, len(symbols))
+	for _, symbol := range symbols {
+		wanted[symbol] = true
+	}
+
+	var items []models.PriceQuote
+	for key, quote := range s.history {
+		if !wanted[key.symbol] || quote.AsOf.After(until) {
+			continue
+		}
+		items = append(items, quote)
+	}
+	sort.Slice(items, func(i, j int) bool { return items[i].AsOf.Before(items[j].AsOf) })
+	return items, nil
+}
+
+func matchesProjection(filter repository.ProjectionFilter, userID uuid.UUID, symbol string) bool {
+	if filter.UserID != uuid.Nil && filter.UserID != userID {
+		return false
+	}
+	if filter.Symbol != "" && !strings.EqualFold(filter.Symbol, symbol) {
+		return false
+	}
+	return true
+}
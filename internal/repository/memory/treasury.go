@@ -0,0 +1,67 @@
+package memory
+
+import (
+	"context"
+
+	"github.com/shopspring/decimal"
+
+	"github.com/stocky/backend/internal/models"
+	"github.com/stocky/backend/internal/repository"
+)
+
+func (s *Store) FundTreasury(_ context.Context, funding models.TreasuryFunding) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.fundingRefs[funding.Reference]; ok {
+		return repository.ErrDuplicateFunding
+	}
+	entries := repository.FundingPostings(funding)
+	if err := s.checkPostings(entries); err != nil {
+		return err
+	}
+	s.appendLedger(entries)
+	s.fundingRefs[funding.Reference] = len(s.fundings)
+	s.fundings = append(s.fundings, funding)
+	return nil
+}
+
+func (s *Store) FundingByReference(_ context.Context, reference string) (*models.TreasuryFunding, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	idx, ok := s.fundingRefs[reference]
+	if !ok {
+		return nil, repository.ErrFundingNotFound
+	}
+	funding := s.fundings[idx]
+	return &funding, nil
+}
+
+func (s *Store) CashBalance(_ context.Context) (decimal.Decimal, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.cashBalance(), nil
+}
+
+// cashBalance totals the cash account. The caller must hold the lock.
+func (s *Store) cashBalance() decimal.Decimal {
+	balance := decimal.Zero
+	for _, entry := range s.ledger {
+		if entry.AccountCode == repository.CashAccount {
+			balance = balance.Add(entry.Debit).Sub(entry.Credit)
+		}
+	}
+	return balance
+}
+
+func (s *Store) ListTreasuryAlerts(_ context.Context, limit int) ([]models.TreasuryAlert, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var items []models.TreasuryAlert
+	for i := len(s.alerts) - 1; i >= 0 && len(items) < limit; i-- {
+		items = append(items, s.alerts[i])
+	}
+	return items, nil
+}
@@ -0,0 +1,54 @@
+package repository
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+var (
+	ErrInvalidPeriod  = errors.New("period must be a month in YYYY-MM form")
+	ErrPeriodClosed   = errors.New("ledger period is closed")
+	ErrPeriodOpen     = errors.New("ledger period is open")
+	ErrPeriodNotFound = errors.New("ledger period has not been closed")
+)
+
+// Period statuses.
+const (
+	PeriodOpen   = "open"
+	PeriodClosed = "closed"
+)
+
+// Period history actions.
+const (
+	PeriodActionClose  = "close"
+	PeriodActionReopen = "reopen"
+)
+
+// ist is the zone periods are cut in; Indian books close on IST month ends.
+var ist = time.FixedZone("IST", 5*3600+1800)
+
+// PeriodOf returns the period t falls in.
+func PeriodOf(t time.Time) string {
+	return t.In(ist).Format("2006-01")
+}
+
+// RewardPeriods returns the periods a reward must find open: the one it is
+// dated in and the one its postings are stamped in, which is what a closing
+// snapshot groups by.
+func RewardPeriods(rewardedAt, postedAt time.Time) []string {
+	dated, posted := PeriodOf(rewardedAt), PeriodOf(postedAt)
+	if dated == posted {
+		return []string{dated}
+	}
+	return []string{dated, posted}
+}
+
+// PeriodBounds returns the start and exclusive end of a period.
+func PeriodBounds(period string) (time.Time, time.Time, error) {
+	start, err := time.ParseInLocation("2006-01", period, ist)
+	if err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("%w: %q", ErrInvalidPeriod, period)
+	}
+	return start.UTC(), start.AddDate(0, 1, 0).UTC(), nil
+}
@@ -0,0 +1,104 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/stocky/backend/internal/models"
+)
+
+// ClosePeriod upserts the period only while it is not closed, so closing a
+// closed period collides with the existing _id instead of overwriting its
+// snapshot.
+func (r *Repository) ClosePeriod(ctx context.Context, period models.LedgerPeriod, event models.PeriodEvent) error {
+	balances := make([]accountBalanceDoc, 0, len(period.Balances))
+	for _, balance := range period.Balances {
+		balances = append(balances, accountBalanceDoc(balance))
+	}
+	_, err := r.db.Collection("ledger_periods").UpdateOne(ctx,
+		bson.M{"_id": period.Period, "status": bson.M{"$ne": PeriodClosed}},
+		bson.M{
+			"$set": bson.M{
+				"starts_at": period.StartsAt,
+				"ends_at":   period.EndsAt,
+				"status":    PeriodClosed,
+				"closed_at": period.ClosedAt,
+				"closed_by": period.ClosedBy,
+				"balances":  balances,
+			},
+			"$push": bson.M{"history": periodEventDoc(event)},
+		},
+		options.Update().SetUpsert(true))
+	if mongo.IsDuplicateKeyError(err) {
+		return ErrPeriodClosed
+	}
+	return err
+}
+
+func (r *Repository) ReopenPeriod(ctx context.Context, period string, event models.PeriodEvent) (*models.LedgerPeriod, error) {
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+	result := r.db.Collection("ledger_periods").FindOneAndUpdate(ctx,
+		bson.M{"_id": period, "status": PeriodClosed},
+		bson.M{
+			"$set":  bson.M{"status": PeriodOpen},
+			"$push": bson.M{"history": periodEventDoc(event)},
+		}, opts)
+	doc, err := decodeOne[ledgerPeriodDoc](ctx, r, "ledger_periods", result)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		if _, err := r.LedgerPeriod(ctx, period); err != nil {
+			return nil, err
+		}
+		return nil, ErrPeriodOpen
+	}
+	if err != nil {
+		return nil, err
+	}
+	reopened := doc.toModel()
+	return &reopened, nil
+}
+
+// checkPeriodsOpen returns ErrPeriodClosed if any of periods is closed. Run
+// inside a write's transaction it sees a close committed before the write.
+func (r *Repository) checkPeriodsOpen(ctx context.Context, periods []string) error {
+	doc, err := decodeOne[ledgerPeriodDoc](ctx, r, "ledger_periods", r.db.Collection("ledger_periods").FindOne(ctx,
+		bson.M{"_id": bson.M{"$in": periods}, "status": PeriodClosed}))
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	return fmt.Errorf("%w: %s", ErrPeriodClosed, doc.Period)
+}
+
+func (r *Repository) LedgerPeriod(ctx context.Context, period string) (*models.LedgerPeriod, error) {
+	doc, err := decodeOne[ledgerPeriodDoc](ctx, r, "ledger_periods",
+		r.db.Collection("ledger_periods").FindOne(ctx, bson.M{"_id": period}))
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, ErrPeriodNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	found := doc.toModel()
+	return &found, nil
+}
+
+func (r *Repository) ListPeriods(ctx context.Context) ([]models.LedgerPeriod, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "_id", Value: 1}})
+	cursor, err := r.db.Collection("ledger_periods").Find(ctx, bson.M{}, opts)
+	if err != nil {
+		return nil, err
+	}
+	var items []models.LedgerPeriod
+	err = decodeEach(ctx, r, "ledger_periods", cursor, func(doc ledgerPeriodDoc) error {
+		items = append(items, doc.toModel())
+		return nil
+	})
+	return items, err
+}
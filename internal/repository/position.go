@@ -0,0 +1,71 @@
+package repository
+
+import (
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// PositionState is the cost-tracking state kept per (user, symbol). Every
+// backend applies the same transitions so positions agree across stores.
+type PositionState struct {
+	Shares          decimal.Decimal
+	AvgCost         decimal.Decimal
+	TotalCost       decimal.Decimal
+	FirstAcquiredAt time.Time
+	UpdatedAt       time.Time
+}
+
+// Acquire adds shares bought for cost INR and recomputes the weighted
+// average cost.
+func (p PositionState) Acquire(shares, cost decimal.Decimal, at time.Time) PositionState {
+	totalCost := p.costBasis().Add(cost)
+	newShares := p.Shares.Add(shares)
+
+	next := p
+	next.Shares = newShares
+	next.TotalCost = totalCost
+	if newShares.GreaterThan(decimal.Zero) {
+		next.AvgCost = totalCost.Div(newShares)
+	} else {
+		next.AvgCost = decimal.Zero
+	}
+	if next.FirstAcquiredAt.IsZero() || at.Before(next.FirstAcquiredAt) {
+		next.FirstAcquiredAt = at
+	}
+	next.UpdatedAt = time.Now()
+	return next
+}
+
+// Adjust applies a signed change in shares and cost, keeping the weighted
+// average of what remains. It returns ErrInsufficientShares rather than
+// leaving the position negative.
+func (p PositionState) Adjust(shares, cost decimal.Decimal) (PositionState, error) {
+	newShares := p.Shares.Add(shares)
+	if newShares.IsNegative() {
+		return p, ErrInsufficientShares
+	}
+
+	next := p
+	next.Shares = newShares
+	next.TotalCost = p.costBasis().Add(cost)
+	if newShares.IsZero() || next.TotalCost.IsNegative() {
+		next.TotalCost = decimal.Zero
+	}
+	if newShares.GreaterThan(decimal.Zero) {
+		next.AvgCost = next.TotalCost.Div(newShares)
+	} else {
+		next.AvgCost = decimal.Zero
+	}
+	next.UpdatedAt = time.Now()
+	return next, nil
+}
+
+// costBasis returns the total cost, deriving it from the average for
+// positions written before total cost was tracked.
+func (p PositionState) costBasis() decimal.Decimal {
+	if p.TotalCost.IsZero() && p.Shares.GreaterThan(decimal.Zero) {
+		return p.AvgCost.Mul(p.Shares)
+	}
+	return p.TotalCost
+}
@@ -0,0 +1,127 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgtype"
+
+	"github.com/stocky/backend/internal/models"
+	"github.com/stocky/backend/internal/repository"
+)
+
+const accountColumns = `code, type::text, currency, COALESCE(symbol, ''), created_at, closed_at`
+
+func (r *Repository) ListAccounts(ctx context.Context) ([]models.LedgerAccount, error) {
+	rows, err := r.pool.Query(ctx, `SELECT `+accountColumns+` FROM ledger_accounts ORDER BY code`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var items []models.LedgerAccount
+	for rows.Next() {
+		account, err := scanAccount(rows)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, *account)
+	}
+	return items, rows.Err()
+}
+
+func (r *Repository) CreateAccount(ctx context.Context, account models.LedgerAccount) error {
+	_, err := r.pool.Exec(ctx, `
+		INSERT INTO ledger_accounts (code, type, currency, symbol, created_at)
+		VALUES ($1,$2,$3,$4,$5)
+	`, account.Code, account.Type, account.Currency, nullableString(account.Symbol), account.CreatedAt)
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == uniqueViolation {
+		return repository.ErrAccountExists
+	}
+	return err
+}
+
+func (r *Repository) CloseAccount(ctx context.Context, code string, at time.Time) (*models.LedgerAccount, error) {
+	var result *models.LedgerAccount
+	err := r.inTx(ctx, func(tx pgx.Tx) error {
+		account, err := scanAccount(tx.QueryRow(ctx,
+			`SELECT `+accountColumns+` FROM ledger_accounts WHERE code = $1 FOR UPDATE`, code))
+		if errors.Is(err, pgx.ErrNoRows) {
+			return repository.ErrAccountNotFound
+		}
+		if err != nil {
+			return err
+		}
+		if account.ClosedAt != nil {
+			return repository.ErrAccountClosed
+		}
+		if _, err := tx.Exec(ctx, `UPDATE ledger_accounts SET closed_at = $2 WHERE code = $1`, code, at); err != nil {
+			return err
+		}
+		account.ClosedAt = &at
+		result = account
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// postingAccountID returns the id of the account entry posts to after
+// checking it with PostingAccount, opening a new stock inventory account.
+// The row is share-locked so it cannot be closed before the transaction
+// commits.
+func (r *Repository) postingAccountID(ctx context.Context, tx pgx.Tx, entry repository.LedgerEntry) (int, error) {
+	var (
+		id      int
+		closed  pgtype.Timestamptz
+		account *models.LedgerAccount
+	)
+	err := tx.QueryRow(ctx, `SELECT id, closed_at FROM ledger_accounts WHERE code = $1 FOR SHARE`,
+		entry.AccountCode).Scan(&id, &closed)
+	switch {
+	case err == nil:
+		account = &models.LedgerAccount{Code: entry.AccountCode}
+		if closed.Valid {
+			account.ClosedAt = &closed.Time
+		}
+	case !errors.Is(err, pgx.ErrNoRows):
+		return 0, err
+	}
+
+	created, err := repository.PostingAccount(entry, account, entry.CreatedAt)
+	if err != nil {
+		return 0, err
+	}
+	if created != nil {
+		err = tx.QueryRow(ctx, `
+			INSERT INTO ledger_accounts (code, type, currency, symbol, created_at)
+			VALUES ($1,$2,$3,$4,$5)
+			RETURNING id
+		`, created.Code, created.Type, created.Currency, nullableString(created.Symbol), created.CreatedAt).Scan(&id)
+		if err != nil {
+			return 0, err
+		}
+	}
+	return id, nil
+}
+
+func scanAccount(row pgx.Row) (*models.LedgerAccount, error) {
+	var (
+		account models.LedgerAccount
+		closed  pgtype.Timestamptz
+	)
+	if err := row.Scan(&account.Code, &account.Type, &account.Currency, &account.Symbol,
+		&account.CreatedAt, &closed); err != nil {
+		return nil, err
+	}
+	if closed.Valid {
+		account.ClosedAt = &closed.Time
+	}
+	return &account, nil
+}
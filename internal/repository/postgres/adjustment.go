@@ -0,0 +1,196 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgtype"
+
+	"github.com/stocky/backend/internal/models"
+	"github.com/stocky/backend/internal/repository"
+)
+
+const adjustmentColumns = `id, user_id, symbol, kind, shares, cost_inr, cash_inr, fee_treatment,
+	       reason, reference_event, idempotency_key, created_at`
+
+func (r *Repository) ReverseReward(ctx context.Context, params repository.ReversalParams) (*models.Adjustment, error) {
+	actions, err := r.ListCorporateActions(ctx, repository.CorporateActionFilter{Status: repository.ActionApplied})
+	if err != nil {
+		return nil, err
+	}
+	var result *models.Adjustment
+	err = r.inTx(ctx, func(tx pgx.Tx) error {
+		var exists bool
+		err := tx.QueryRow(ctx, `SELECT EXISTS (SELECT 1 FROM adjustments WHERE idempotency_key = $1)`,
+			params.IdempotencyKey).Scan(&exists)
+		if err != nil {
+			return err
+		}
+		if exists {
+			return repository.ErrDuplicateAdjustment
+		}
+
+		reward, err := r.rewardByID(ctx, tx, params.RewardID)
+		if err != nil {
+			return err
+		}
+
+		earlier, err := r.reversalsOf(ctx, tx, reward.ID)
+		if err != nil {
+			return err
+		}
+
+		adjustment, postings, err := repository.PlanReversal(*reward, actions, earlier, params, time.Now())
+		if err != nil {
+			return err
+		}
+
+		state, err := r.lockPosition(ctx, tx, adjustment.UserID, adjustment.Symbol)
+		if err != nil {
+			return err
+		}
+		next, err := state.Adjust(adjustment.Shares, adjustment.CostInr)
+		if err != nil {
+			return err
+		}
+
+		if err := r.insertAdjustment(ctx, tx, adjustment); err != nil {
+			return err
+		}
+		for _, entry := range postings {
+			if err := r.insertLedgerEntry(ctx, tx, entry); err != nil {
+				return err
+			}
+		}
+		if err := r.savePosition(ctx, tx, adjustment.UserID, adjustment.Symbol, next); err != nil {
+			return err
+		}
+
+		result = &adjustment
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func (r *Repository) rewardByID(ctx context.Context, tx pgx.Tx, id uuid.UUID) (*models.RewardEvent, error) {
+	row := tx.QueryRow(ctx, `SELECT `+rewardColumns+` FROM reward_events WHERE id = $1`, id)
+	reward, err := scanReward(row)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, repository.ErrRewardNotFound
+		}
+		return nil, err
+	}
+	return reward, nil
+}
+
+func (r *Repository) reversalsOf(ctx context.Context, tx pgx.Tx, rewardID uuid.UUID) ([]models.Adjustment, error) {
+	rows, err := tx.Query(ctx, `
+		SELECT `+adjustmentColumns+`
+		FROM adjustments
+		WHERE kind = $1 AND reference_event = $2
+	`, repository.AdjustmentReversal, rewardID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var items []models.Adjustment
+	for rows.Next() {
+		adjustment, err := scanAdjustment(rows)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, *adjustment)
+	}
+	return items, rows.Err()
+}
+
+func (r *Repository) insertAdjustment(ctx context.Context, tx pgx.Tx, adj models.Adjustment) error {
+	var reference any
+	if adj.ReferenceEvent != nil {
+		reference = *adj.ReferenceEvent
+	}
+	_, err := tx.Exec(ctx, `
+		INSERT INTO adjustments (`+adjustmentColumns+`)
+		VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9,$10,$11,$12)
+	`, adj.ID, adj.UserID, adj.Symbol, adj.Kind, decimalToNumeric(adj.Shares),
+		decimalToNumeric(adj.CostInr), decimalToNumeric(adj.CashInr), nullableString(adj.FeeTreatment),
+		adj.Reason, reference, nullableString(adj.IdempotencyKey), adj.CreatedAt)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == uniqueViolation {
+			return repository.ErrDuplicateAdjustment
+		}
+	}
+	return err
+}
+
+func (r *Repository) AdjustmentByKey(ctx context.Context, key string) (*models.Adjustment, error) {
+	row := r.pool.QueryRow(ctx, `SELECT `+adjustmentColumns+` FROM adjustments WHERE idempotency_key = $1`, key)
+	adjustment, err := scanAdjustment(row)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, repository.ErrAdjustmentNotFound
+		}
+		return nil, err
+	}
+	return adjustment, nil
+}
+
+func (r *Repository) ListAdjustments(ctx context.Context, filter repository.ProjectionFilter) ([]models.Adjustment, error) {
+	rows, err := r.pool.Query(ctx, `
+		SELECT `+adjustmentColumns+`
+		FROM adjustments
+		WHERE ($1::uuid IS NULL OR user_id = $1)
+		  AND ($2::text IS NULL OR symbol = $2)
+		  AND ($3::timestamptz IS NULL OR created_at <= $3)
+		ORDER BY created_at
+	`, nullableUUID(filter.UserID), nullableString(strings.ToUpper(filter.Symbol)), nullableTime(filter.Until))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var items []models.Adjustment
+	for rows.Next() {
+		adjustment, err := scanAdjustment(rows)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, *adjustment)
+	}
+	return items, rows.Err()
+}
+
+func scanAdjustment(row pgx.Row) (*models.Adjustment, error) {
+	var (
+		adj                models.Adjustment
+		shares, cost, cash pgtype.Numeric
+		feeTreatment, key  pgtype.Text
+		reference          pgtype.UUID
+	)
+	err := row.Scan(&adj.ID, &adj.UserID, &adj.Symbol, &adj.Kind, &shares, &cost, &cash,
+		&feeTreatment, &adj.Reason, &reference, &key, &adj.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	adj.Shares = numericToDecimal(shares)
+	adj.CostInr = numericToDecimal(cost)
+	adj.CashInr = numericToDecimal(cash)
+	adj.FeeTreatment = feeTreatment.String
+	adj.IdempotencyKey = key.String
+	if reference.Valid {
+		ref := uuid.UUID(reference.Bytes)
+		adj.ReferenceEvent = &ref
+	}
+	return &adj, nil
+}
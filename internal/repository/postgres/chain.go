@@ -0,0 +1,154 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+
+	"github.com/stocky/backend/internal/models"
+	"github.com/stocky/backend/internal/repository"
+)
+
+// chainColumns scans the nullable chain_seq, prev_hash and hash columns;
+// rows written before migration 008 have none.
+type chainColumns struct {
+	seq      pgtype.Int8
+	prevHash pgtype.Text
+	hash     pgtype.Text
+}
+
+func (c chainColumns) link() models.ChainLink {
+	return models.ChainLink{Seq: c.seq.Int64, PrevHash: c.prevHash.String, Hash: c.hash.String}
+}
+
+// extendChain links contents onto the named chain. The head row is locked, so
+// concurrent writers queue behind each other and the loser of a serializable
+// conflict is retried by inTx.
+func (r *Repository) extendChain(ctx context.Context, tx pgx.Tx, chain string, contents ...string) ([]models.ChainLink, error) {
+	var head models.ChainLink
+	err := tx.QueryRow(ctx, `SELECT chain_seq, hash FROM chain_heads WHERE chain = $1 FOR UPDATE`, chain).
+		Scan(&head.Seq, &head.Hash)
+	if err != nil {
+		return nil, fmt.Errorf("chain head %s: %w", chain, err)
+	}
+	links := repository.NextLinks(head, contents...)
+	if len(links) == 0 {
+		return links, nil
+	}
+	last := links[len(links)-1]
+	_, err = tx.Exec(ctx, `UPDATE chain_heads SET chain_seq = $2, hash = $3 WHERE chain = $1`, chain, last.Seq, last.Hash)
+	if err != nil {
+		return nil, err
+	}
+	return links, nil
+}
+
+func (r *Repository) ChainHead(ctx context.Context, chain string) (models.ChainLink, error) {
+	var head models.ChainLink
+	err := r.pool.QueryRow(ctx, `SELECT chain_seq, hash FROM chain_heads WHERE chain = $1`, chain).
+		Scan(&head.Seq, &head.Hash)
+	if err != nil {
+		return models.ChainLink{}, fmt.Errorf("chain head %s: %w", chain, err)
+	}
+	if head.Seq == 0 {
+		return models.ChainLink{}, nil
+	}
+	return head, nil
+}
+
+func (r *Repository) LegacyRecords(ctx context.Context, chain string) (int, error) {
+	var legacy int
+	err := r.pool.QueryRow(ctx, `SELECT legacy_records FROM chain_heads WHERE chain = $1`, chain).Scan(&legacy)
+	if err != nil {
+		return 0, fmt.Errorf("chain head %s: %w", chain, err)
+	}
+	return legacy, nil
+}
+
+func (r *Repository) WalkChain(ctx context.Context, chain string, visit func(repository.ChainRecord) error) error {
+	switch chain {
+	case repository.ChainRewards:
+		return r.walkRewards(ctx, visit)
+	case repository.ChainLedger:
+		return r.walkLedger(ctx, visit)
+	default:
+		return fmt.Errorf("unknown chain %q", chain)
+	}
+}
+
+func (r *Repository) walkRewards(ctx context.Context, visit func(repository.ChainRecord) error) error {
+	rows, err := r.pool.Query(ctx, `
+		SELECT `+rewardColumns+`
+		FROM reward_events
+		ORDER BY chain_seq NULLS FIRST, id
+	`)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		reward, err := scanReward(rows)
+		if err != nil {
+			return err
+		}
+		record := repository.ChainRecord{
+			ID:        reward.ID.String(),
+			Content:   repository.RewardContent(*reward),
+			ChainLink: reward.Chain,
+		}
+		if err := visit(record); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+func (r *Repository) walkLedger(ctx context.Context, visit func(repository.ChainRecord) error) error {
+	rows, err := r.pool.Query(ctx, `
+		SELECT `+postingColumns+`, e.chain_seq, e.prev_hash, e.hash
+		FROM ledger_entries e
+		JOIN ledger_accounts a ON a.id = e.account_id
+		ORDER BY e.chain_seq NULLS FIRST, e.id
+	`)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			item                 models.LedgerPosting
+			id                   int64
+			debit, credit, units pgtype.Numeric
+			chain                chainColumns
+		)
+		err := rows.Scan(&id, &item.EventID, &item.AccountCode, &item.AccountType, &item.Symbol,
+			&debit, &credit, &units, &item.Memo, &item.CreatedAt, &chain.seq, &chain.prevHash, &chain.hash)
+		if err != nil {
+			return err
+		}
+		entry := repository.LedgerEntry{
+			EventID:     item.EventID,
+			AccountCode: item.AccountCode,
+			AccountType: item.AccountType,
+			Symbol:      item.Symbol,
+			Debit:       numericToDecimal(debit),
+			Credit:      numericToDecimal(credit),
+			StockUnits:  numericToDecimal(units),
+			Memo:        item.Memo,
+			CreatedAt:   item.CreatedAt,
+		}
+		record := repository.ChainRecord{
+			ID:        fmt.Sprint(id),
+			Content:   repository.LedgerContent(entry),
+			ChainLink: chain.link(),
+		}
+		if err := visit(record); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
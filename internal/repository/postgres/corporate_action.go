@@ -0,0 +1,254 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgtype"
+
+	"github.com/stocky/backend/internal/models"
+	"github.com/stocky/backend/internal/repository"
+)
+
+const corporateActionColumns = `id, symbol, kind, ratio_new, ratio_held, factor, record_date, ex_date,
+	       status, note, created_at, applied_at, holders, shares_issued, new_symbol, cash_price_inr,
+	       cash_paid_inr, frozen_price_inr`
+
+func (r *Repository) CreateCorporateAction(ctx context.Context, action models.CorporateAction) error {
+	_, err := r.pool.Exec(ctx, `
+		INSERT INTO corporate_actions (`+corporateActionColumns+`)
+		VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9,$10,$11,$12,$13,$14,$15,$16,$17,$18)
+	`, action.ID, action.Symbol, action.Kind, action.RatioNew, action.RatioHeld,
+		decimalToNumeric(action.Factor), action.RecordDate, action.ExDate, action.Status,
+		nullableString(action.Note), action.CreatedAt, action.AppliedAt, action.Holders,
+		decimalToNumeric(action.SharesIssued), nullableString(action.NewSymbol),
+		decimalToNumeric(action.CashPrice), decimalToNumeric(action.CashPaid),
+		decimalToNumeric(action.FrozenPrice))
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == uniqueViolation {
+		return repository.ErrDuplicateCorporateAction
+	}
+	return err
+}
+
+func (r *Repository) CorporateAction(ctx context.Context, id uuid.UUID) (*models.CorporateAction, error) {
+	row := r.pool.QueryRow(ctx, `SELECT `+corporateActionColumns+` FROM corporate_actions WHERE id = $1`, id)
+	action, err := scanCorporateAction(row)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, repository.ErrCorporateActionNotFound
+	}
+	return action, err
+}
+
+func (r *Repository) ListCorporateActions(ctx context.Context, filter repository.CorporateActionFilter) ([]models.CorporateAction, error) {
+	rows, err := r.pool.Query(ctx, `
+		SELECT `+corporateActionColumns+`
+		FROM corporate_actions
+		WHERE ($1::text IS NULL OR symbol = $1)
+		  AND ($2::text IS NULL OR status = $2)
+		ORDER BY ex_date, created_at
+	`, nullableString(filter.Symbol), nullableString(filter.Status))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var items []models.CorporateAction
+	for rows.Next() {
+		action, err := scanCorporateAction(rows)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, *action)
+	}
+	return items, rows.Err()
+}
+
+func (r *Repository) ApplyCorporateAction(ctx context.Context, application repository.CorporateActionApplication) error {
+	action := application.Action
+	return r.inTx(ctx, func(tx pgx.Tx) error {
+		tag, err := tx.Exec(ctx, `
+			UPDATE corporate_actions
+			SET status = $2, applied_at = $3, holders = $4, shares_issued = $5,
+			    cash_paid_inr = $6, frozen_price_inr = $7
+			WHERE id = $1 AND status = $8
+		`, action.ID, action.Status, action.AppliedAt, action.Holders,
+			decimalToNumeric(action.SharesIssued), decimalToNumeric(action.CashPaid),
+			decimalToNumeric(action.FrozenPrice), repository.ActionPending)
+		if err != nil {
+			return err
+		}
+		if tag.RowsAffected() == 0 {
+			var exists bool
+			err := tx.QueryRow(ctx, `SELECT EXISTS (SELECT 1 FROM corporate_actions WHERE id = $1)`,
+				action.ID).Scan(&exists)
+			if err != nil {
+				return err
+			}
+			if !exists {
+				return repository.ErrCorporateActionNotFound
+			}
+			return repository.ErrCorporateActionApplied
+		}
+
+		if alias := application.Alias; alias != nil && alias.Target != "" {
+			if err := r.ensureStock(ctx, tx, alias.Target); err != nil {
+				return err
+			}
+		}
+		for _, adjustment := range application.Adjustments {
+			state, err := r.lockPosition(ctx, tx, adjustment.UserID, adjustment.Symbol)
+			if err != nil {
+				return err
+			}
+			next, err := state.Adjust(adjustment.Shares, adjustment.CostInr)
+			if err != nil {
+				return err
+			}
+			if next.FirstAcquiredAt.IsZero() {
+				next.FirstAcquiredAt = adjustment.CreatedAt
+			}
+			if err := r.insertAdjustment(ctx, tx, adjustment); err != nil {
+				return err
+			}
+			if err := r.savePosition(ctx, tx, adjustment.UserID, adjustment.Symbol, next); err != nil {
+				return err
+			}
+		}
+		for _, entry := range application.Postings {
+			if err := r.insertLedgerEntry(ctx, tx, entry); err != nil {
+				return err
+			}
+		}
+
+		for _, adjustment := range application.Adjustments {
+			if !adjustment.CashInr.IsPositive() {
+				continue
+			}
+			_, err = tx.Exec(ctx, `
+				INSERT INTO user_wallets (user_id, balance_inr, updated_at)
+				VALUES ($1, $2, $3)
+				ON CONFLICT (user_id) DO UPDATE SET
+					balance_inr = user_wallets.balance_inr + EXCLUDED.balance_inr,
+					updated_at = EXCLUDED.updated_at
+			`, adjustment.UserID, decimalToNumeric(adjustment.CashInr), action.AppliedAt)
+			if err != nil {
+				return err
+			}
+		}
+
+		if application.Alias != nil {
+			return r.retireSymbol(ctx, tx, action, *application.Alias)
+		}
+		if !repository.RescalesPrices(action.Kind) {
+			return nil
+		}
+		factor := decimalToNumeric(action.Factor)
+		_, err = tx.Exec(ctx, `
+			UPDATE price_history SET price_inr = ROUND(price_inr / $2, 4)
+			WHERE symbol = $1 AND as_of < $3
+		`, action.Symbol, factor, action.ExDate)
+		if err != nil {
+			return err
+		}
+		_, err = tx.Exec(ctx, `
+			UPDATE price_quotes SET price_inr = ROUND(price_inr / $2, 4)
+			WHERE symbol = $1 AND as_of < $3
+		`, action.Symbol, factor, action.ExDate)
+		if err != nil {
+			return err
+		}
+		_, err = tx.Exec(ctx, `UPDATE stocks SET corporate_action_factor = $2 WHERE symbol = $1`,
+			action.Symbol, decimalToNumeric(application.StockFactor))
+		return err
+	})
+}
+
+// retireSymbol records the alias of a renamed, merged or delisted symbol and
+// stops quoting it. A delisted symbol keeps its cached quote, marked frozen.
+func (r *Repository) retireSymbol(ctx context.Context, tx pgx.Tx, action models.CorporateAction, alias models.SymbolAlias) error {
+	_, err := tx.Exec(ctx, `
+		INSERT INTO symbol_aliases (symbol, target, kind, action_id, effective_at)
+		VALUES ($1, $2, $3, $4, $5)
+	`, alias.Symbol, nullableString(alias.Target), alias.Kind, alias.ActionID, alias.EffectiveAt)
+	if err != nil {
+		return err
+	}
+	_, err = tx.Exec(ctx, `UPDATE stocks SET status = $2 WHERE symbol = $1`, alias.Symbol, repository.StockInactive)
+	if err != nil {
+		return err
+	}
+	switch action.Kind {
+	case repository.CorporateRename:
+		// The target keeps the old symbol's last quote until its first
+		// refresh, so positions stay priced.
+		tag, err := tx.Exec(ctx, `
+			INSERT INTO price_quotes (symbol, price_inr, source, as_of, fetched_at)
+			SELECT $2, price_inr, source, as_of, fetched_at FROM price_quotes WHERE symbol = $1
+			ON CONFLICT (symbol) DO NOTHING
+		`, alias.Symbol, alias.Target)
+		if err != nil || tag.RowsAffected() == 0 {
+			return err
+		}
+		_, err = tx.Exec(ctx, `
+			INSERT INTO price_history (symbol, price_inr, as_of, source)
+			SELECT symbol, price_inr, as_of, source FROM price_quotes WHERE symbol = $1
+			ON CONFLICT (symbol, as_of) DO NOTHING
+		`, alias.Target)
+		return err
+	case repository.CorporateDelisting:
+		_, err = tx.Exec(ctx, `UPDATE price_quotes SET source = $2 WHERE symbol = $1`,
+			alias.Symbol, repository.DelistedQuoteSource)
+	}
+	return err
+}
+
+func (r *Repository) SymbolAlias(ctx context.Context, symbol string) (*models.SymbolAlias, error) {
+	var (
+		alias  models.SymbolAlias
+		target pgtype.Text
+	)
+	err := r.pool.QueryRow(ctx, `
+		SELECT symbol, target, kind, action_id, effective_at FROM symbol_aliases WHERE symbol = $1
+	`, symbol).Scan(&alias.Symbol, &target, &alias.Kind, &alias.ActionID, &alias.EffectiveAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, repository.ErrAliasNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	alias.Target = target.String
+	return &alias, nil
+}
+
+func scanCorporateAction(row pgx.Row) (*models.CorporateAction, error) {
+	var (
+		action              models.CorporateAction
+		factor, issued      pgtype.Numeric
+		cashPrice, cashPaid pgtype.Numeric
+		frozenPrice         pgtype.Numeric
+		note, newSymbol     pgtype.Text
+		appliedAt           pgtype.Timestamptz
+	)
+	err := row.Scan(&action.ID, &action.Symbol, &action.Kind, &action.RatioNew, &action.RatioHeld,
+		&factor, &action.RecordDate, &action.ExDate, &action.Status, &note, &action.CreatedAt,
+		&appliedAt, &action.Holders, &issued, &newSymbol, &cashPrice, &cashPaid, &frozenPrice)
+	if err != nil {
+		return nil, err
+	}
+	action.Factor = numericToDecimal(factor)
+	action.SharesIssued = numericToDecimal(issued)
+	action.CashPrice = numericToDecimal(cashPrice)
+	action.CashPaid = numericToDecimal(cashPaid)
+	action.FrozenPrice = numericToDecimal(frozenPrice)
+	action.NewSymbol = newSymbol.String
+	action.Note = note.String
+	if appliedAt.Valid {
+		at := appliedAt.Time
+		action.AppliedAt = &at
+	}
+	return &action, nil
+}
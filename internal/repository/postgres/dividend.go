@@ -0,0 +1,199 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/shopspring/decimal"
+
+	"github.com/stocky/backend/internal/models"
+	"github.com/stocky/backend/internal/repository"
+)
+
+const dividendColumns = `id, symbol, amount_per_share_inr, record_date, pay_date, tds_rate_pct, status,
+	       note, created_at, paid_at, holders, gross_inr, tds_inr, net_inr`
+
+const dividendPaymentColumns = `id, dividend_id, user_id, symbol, shares, amount_per_share_inr,
+	       gross_inr, tds_inr, net_inr, record_date, paid_at`
+
+func (r *Repository) CreateDividend(ctx context.Context, dividend models.Dividend) error {
+	_, err := r.pool.Exec(ctx, `
+		INSERT INTO dividends (`+dividendColumns+`)
+		VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9,$10,$11,$12,$13,$14)
+	`, dividend.ID, dividend.Symbol, decimalToNumeric(dividend.AmountPerShare), dividend.RecordDate,
+		dividend.PayDate, decimalToNumeric(dividend.TDSRate), dividend.Status, nullableString(dividend.Note),
+		dividend.CreatedAt, dividend.PaidAt, dividend.Holders, decimalToNumeric(dividend.GrossInr),
+		decimalToNumeric(dividend.TDSInr), decimalToNumeric(dividend.NetInr))
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == uniqueViolation {
+		return repository.ErrDuplicateDividend
+	}
+	return err
+}
+
+func (r *Repository) Dividend(ctx context.Context, id uuid.UUID) (*models.Dividend, error) {
+	row := r.pool.QueryRow(ctx, `SELECT `+dividendColumns+` FROM dividends WHERE id = $1`, id)
+	dividend, err := scanDividend(row)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, repository.ErrDividendNotFound
+	}
+	return dividend, err
+}
+
+func (r *Repository) ListDividends(ctx context.Context, filter repository.DividendFilter) ([]models.Dividend, error) {
+	rows, err := r.pool.Query(ctx, `
+		SELECT `+dividendColumns+`
+		FROM dividends
+		WHERE ($1::text IS NULL OR symbol = $1)
+		  AND ($2::text IS NULL OR status = $2)
+		ORDER BY pay_date, created_at
+	`, nullableString(filter.Symbol), nullableString(filter.Status))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var items []models.Dividend
+	for rows.Next() {
+		dividend, err := scanDividend(rows)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, *dividend)
+	}
+	return items, rows.Err()
+}
+
+func (r *Repository) PayDividend(ctx context.Context, payout repository.DividendPayout) error {
+	dividend := payout.Dividend
+	return r.inTx(ctx, func(tx pgx.Tx) error {
+		tag, err := tx.Exec(ctx, `
+			UPDATE dividends
+			SET status = $2, paid_at = $3, holders = $4, gross_inr = $5, tds_inr = $6, net_inr = $7
+			WHERE id = $1 AND status = $8
+		`, dividend.ID, dividend.Status, dividend.PaidAt, dividend.Holders, decimalToNumeric(dividend.GrossInr),
+			decimalToNumeric(dividend.TDSInr), decimalToNumeric(dividend.NetInr), repository.DividendAnnounced)
+		if err != nil {
+			return err
+		}
+		if tag.RowsAffected() == 0 {
+			var exists bool
+			err := tx.QueryRow(ctx, `SELECT EXISTS (SELECT 1 FROM dividends WHERE id = $1)`,
+				dividend.ID).Scan(&exists)
+			if err != nil {
+				return err
+			}
+			if !exists {
+				return repository.ErrDividendNotFound
+			}
+			return repository.ErrDividendPaid
+		}
+
+		for _, payment := range payout.Payments {
+			_, err := tx.Exec(ctx, `
+				INSERT INTO dividend_payments (`+dividendPaymentColumns+`)
+				VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9,$10,$11)
+			`, payment.ID, payment.DividendID, payment.UserID, payment.Symbol, decimalToNumeric(payment.Shares),
+				decimalToNumeric(payment.AmountPerShare), decimalToNumeric(payment.GrossInr),
+				decimalToNumeric(payment.TDSInr), decimalToNumeric(payment.NetInr), payment.RecordDate, payment.PaidAt)
+			if err != nil {
+				return err
+			}
+			_, err = tx.Exec(ctx, `
+				INSERT INTO user_wallets (user_id, balance_inr, updated_at)
+				VALUES ($1, $2, $3)
+				ON CONFLICT (user_id) DO UPDATE SET
+					balance_inr = user_wallets.balance_inr + EXCLUDED.balance_inr,
+					updated_at = EXCLUDED.updated_at
+			`, payment.UserID, decimalToNumeric(payment.NetInr), payment.PaidAt)
+			if err != nil {
+				return err
+			}
+		}
+		for _, entry := range payout.Postings {
+			if err := r.insertLedgerEntry(ctx, tx, entry); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (r *Repository) DividendPayments(ctx context.Context, userID uuid.UUID) ([]models.DividendPayment, error) {
+	rows, err := r.pool.Query(ctx, `
+		SELECT `+dividendPaymentColumns+`
+		FROM dividend_payments
+		WHERE user_id = $1
+		ORDER BY paid_at
+	`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var items []models.DividendPayment
+	for rows.Next() {
+		var (
+			payment                           models.DividendPayment
+			shares, perShare, gross, tds, net pgtype.Numeric
+		)
+		err := rows.Scan(&payment.ID, &payment.DividendID, &payment.UserID, &payment.Symbol, &shares,
+			&perShare, &gross, &tds, &net, &payment.RecordDate, &payment.PaidAt)
+		if err != nil {
+			return nil, err
+		}
+		payment.Shares = numericToDecimal(shares)
+		payment.AmountPerShare = numericToDecimal(perShare)
+		payment.GrossInr = numericToDecimal(gross)
+		payment.TDSInr = numericToDecimal(tds)
+		payment.NetInr = numericToDecimal(net)
+		items = append(items, payment)
+	}
+	return items, rows.Err()
+}
+
+func (r *Repository) Wallet(ctx context.Context, userID uuid.UUID) (*models.Wallet, error) {
+	var (
+		balance   pgtype.Numeric
+		updatedAt pgtype.Timestamptz
+	)
+	err := r.pool.QueryRow(ctx, `SELECT balance_inr, updated_at FROM user_wallets WHERE user_id = $1`,
+		userID).Scan(&balance, &updatedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return &models.Wallet{UserID: userID, BalanceInr: decimal.Zero}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	at := updatedAt.Time
+	return &models.Wallet{UserID: userID, BalanceInr: numericToDecimal(balance), UpdatedAt: &at}, nil
+}
+
+func scanDividend(row pgx.Row) (*models.Dividend, error) {
+	var (
+		dividend                        models.Dividend
+		perShare, rate, gross, tds, net pgtype.Numeric
+		note                            pgtype.Text
+		paidAt                          pgtype.Timestamptz
+	)
+	err := row.Scan(&dividend.ID, &dividend.Symbol, &perShare, &dividend.RecordDate, &dividend.PayDate,
+		&rate, &dividend.Status, &note, &dividend.CreatedAt, &paidAt, &dividend.Holders, &gross, &tds, &net)
+	if err != nil {
+		return nil, err
+	}
+	dividend.AmountPerShare = numericToDecimal(perShare)
+	dividend.TDSRate = numericToDecimal(rate)
+	dividend.GrossInr = numericToDecimal(gross)
+	dividend.TDSInr = numericToDecimal(tds)
+	dividend.NetInr = numericToDecimal(net)
+	dividend.Note = note.String
+	if paidAt.Valid {
+		at := paidAt.Time
+		dividend.PaidAt = &at
+	}
+	return &dividend, nil
+}
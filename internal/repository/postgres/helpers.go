@@ -0,0 +1,66 @@
+package postgres
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/shopspring/decimal"
+
+	"github.com/stocky/backend/internal/models"
+)
+
+// decimalToNumeric converts a decimal into the pgx NUMERIC representation.
+func decimalToNumeric(d decimal.Decimal) pgtype.Numeric {
+	return pgtype.Numeric{Int: d.Coefficient(), Exp: d.Exponent(), Valid: true}
+}
+
+// numericToDecimal converts a scanned NUMERIC back into a decimal. NULL and
+// NaN map to zero.
+func numericToDecimal(n pgtype.Numeric) decimal.Decimal {
+	if !n.Valid || n.NaN || n.Int == nil {
+		return decimal.Zero
+	}
+	return decimal.NewFromBigInt(n.Int, n.Exp)
+}
+
+func nullableString(val string) any {
+	if val == "" {
+		return nil
+	}
+	return val
+}
+
+func nullableTime(val time.Time) any {
+	if val.IsZero() {
+		return nil
+	}
+	return val
+}
+
+func nullableUUID(val uuid.UUID) any {
+	if val == uuid.Nil {
+		return nil
+	}
+	return val
+}
+
+// feesToJSON encodes a fee breakdown for a JSONB column; nil stays NULL.
+func feesToJSON(fees *models.FeeBreakdown) (any, error) {
+	if fees == nil {
+		return nil, nil
+	}
+	return json.Marshal(fees)
+}
+
+func feesFromJSON(raw []byte) (*models.FeeBreakdown, error) {
+	if len(raw) == 0 {
+		return nil, nil
+	}
+	var fees models.FeeBreakdown
+	if err := json.Unmarshal(raw, &fees); err != nil {
+		return nil, err
+	}
+	return &fees, nil
+}
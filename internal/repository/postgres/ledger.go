@@ -0,0 +1,184 @@
+package postgres
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+
+	"github.com/stocky/backend/internal/models"
+	"github.com/stocky/backend/internal/repository"
+)
+
+const postingColumns = `e.id, e.event_id, a.code, a.type::text, COALESCE(a.symbol, ''), e.debit_inr,
+	       e.credit_inr, e.stock_units, COALESCE(e.memo, ''), e.created_at`
+
+func (r *Repository) LedgerByEvent(ctx context.Context, eventID uuid.UUID) ([]models.LedgerPosting, error) {
+	rows, err := r.pool.Query(ctx, `
+		SELECT `+postingColumns+`
+		FROM ledger_entries e
+		JOIN ledger_accounts a ON a.id = e.account_id
+		WHERE e.event_id = $1
+		ORDER BY e.id
+	`, eventID)
+	if err != nil {
+		return nil, err
+	}
+	return collectPostings(rows)
+}
+
+func (r *Repository) LedgerByAccount(ctx context.Context, query repository.LedgerQuery) ([]models.LedgerPosting, error) {
+	var (
+		afterAt any
+		afterID int64
+	)
+	if query.After != nil {
+		id, err := strconv.ParseInt(query.After.ID, 10, 64)
+		if err != nil {
+			return nil, repository.ErrInvalidCursor
+		}
+		afterAt, afterID = query.After.CreatedAt, id
+	}
+
+	rows, err := r.pool.Query(ctx, `
+		SELECT `+postingColumns+`
+		FROM ledger_entries e
+		JOIN ledger_accounts a ON a.id = e.account_id
+		WHERE ($1::text IS NULL OR a.code = $1)
+		  AND ($2::timestamptz IS NULL OR e.created_at >= $2)
+		  AND ($3::timestamptz IS NULL OR e.created_at < $3)
+		  AND ($4::timestamptz IS NULL OR (e.created_at, e.id) > ($4, $5))
+		ORDER BY e.created_at, e.id
+		LIMIT $6
+	`, nullableString(query.AccountCode), nullableTime(query.From), nullableTime(query.To), afterAt, afterID, query.Limit)
+	if err != nil {
+		return nil, err
+	}
+	return collectPostings(rows)
+}
+
+func (r *Repository) AccountBalances(ctx context.Context, asOf time.Time, accountCode string) ([]models.AccountBalance, error) {
+	rows, err := r.pool.Query(ctx, `
+		SELECT a.code, a.type::text, COALESCE(a.symbol, ''),
+		       SUM(e.debit_inr), SUM(e.credit_inr), SUM(e.stock_units), COUNT(*)
+		FROM ledger_entries e
+		JOIN ledger_accounts a ON a.id = e.account_id
+		WHERE e.created_at <= $1
+		  AND ($2::text IS NULL OR a.code = $2)
+		GROUP BY a.code, a.type, a.symbol
+		ORDER BY a.code
+	`, asOf, nullableString(accountCode))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var items []models.AccountBalance
+	for rows.Next() {
+		var (
+			balance              models.AccountBalance
+			debit, credit, units pgtype.Numeric
+		)
+		err := rows.Scan(&balance.AccountCode, &balance.AccountType, &balance.Symbol,
+			&debit, &credit, &units, &balance.Postings)
+		if err != nil {
+			return nil, err
+		}
+		balance.DebitInr = numericToDecimal(debit)
+		balance.CreditInr = numericToDecimal(credit)
+		balance.StockUnits = numericToDecimal(units)
+		balance.BalanceInr = balance.DebitInr.Sub(balance.CreditInr)
+		items = append(items, balance)
+	}
+	return items, rows.Err()
+}
+
+func collectPostings(rows pgx.Rows) ([]models.LedgerPosting, error) {
+	defer rows.Close()
+
+	var items []models.LedgerPosting
+	for rows.Next() {
+		var (
+			item                 models.LedgerPosting
+			id                   int64
+			debit, credit, units pgtype.Numeric
+		)
+		err := rows.Scan(&id, &item.EventID, &item.AccountCode, &item.AccountType, &item.Symbol,
+			&debit, &credit, &units, &item.Memo, &item.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		item.ID = strconv.FormatInt(id, 10)
+		item.DebitInr = numericToDecimal(debit)
+		item.CreditInr = numericToDecimal(credit)
+		item.StockUnits = numericToDecimal(units)
+		items = append(items, item)
+	}
+	return items, rows.Err()
+}
+
+func (r *Repository) UnbalancedEvents(ctx context.Context) ([]models.EventImbalance, error) {
+	rows, err := r.pool.Query(ctx, `
+		SELECT event_id, SUM(debit_inr), SUM(credit_inr)
+		FROM ledger_entries
+		GROUP BY event_id
+		HAVING SUM(debit_inr) <> SUM(credit_inr)
+		ORDER BY event_id
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var items []models.EventImbalance
+	for rows.Next() {
+		var (
+			event         models.EventImbalance
+			debit, credit pgtype.Numeric
+		)
+		if err := rows.Scan(&event.EventID, &debit, &credit); err != nil {
+			return nil, err
+		}
+		event.DebitInr = numericToDecimal(debit)
+		event.CreditInr = numericToDecimal(credit)
+		event.DifferenceInr = event.DebitInr.Sub(event.CreditInr)
+		items = append(items, event)
+	}
+	return items, rows.Err()
+}
+
+func (r *Repository) SaveTrialBalance(ctx context.Context, report models.TrialBalance) error {
+	raw, err := json.Marshal(report)
+	if err != nil {
+		return err
+	}
+	_, err = r.pool.Exec(ctx, `
+		INSERT INTO trial_balances (checked_at, balanced, report)
+		VALUES ($1,$2,$3)
+	`, report.CheckedAt, report.Balanced, raw)
+	return err
+}
+
+func (r *Repository) LatestTrialBalance(ctx context.Context) (*models.TrialBalance, error) {
+	var raw []byte
+	err := r.pool.QueryRow(ctx, `
+		SELECT report FROM trial_balances ORDER BY checked_at DESC, id DESC LIMIT 1
+	`).Scan(&raw)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, repository.ErrTrialBalanceNotFound
+		}
+		return nil, err
+	}
+	var report models.TrialBalance
+	if err := json.Unmarshal(raw, &report); err != nil {
+		return nil, fmt.Errorf("trial_balances report: %w", err)
+	}
+	return &report, nil
+}
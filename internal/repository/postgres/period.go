@@ -0,0 +1,141 @@
+package postgres
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+
+	"github.com/stocky/backend/internal/models"
+	"github.com/stocky/backend/internal/repository"
+)
+
+const periodColumns = `period, starts_at, ends_at, status, closed_at, closed_by, balances, history`
+
+// ClosePeriod inserts the period or updates it while it is not closed; no row
+// is affected when it is closed already.
+func (r *Repository) ClosePeriod(ctx context.Context, period models.LedgerPeriod, event models.PeriodEvent) error {
+	balances, err := json.Marshal(period.Balances)
+	if err != nil {
+		return err
+	}
+	history, err := json.Marshal([]models.PeriodEvent{event})
+	if err != nil {
+		return err
+	}
+	tag, err := r.pool.Exec(ctx, `
+		INSERT INTO ledger_periods (`+periodColumns+`)
+		VALUES ($1,$2,$3,$4,$5,$6,$7,$8)
+		ON CONFLICT (period) DO UPDATE SET
+			status = EXCLUDED.status,
+			closed_at = EXCLUDED.closed_at,
+			closed_by = EXCLUDED.closed_by,
+			balances = EXCLUDED.balances,
+			history = ledger_periods.history || EXCLUDED.history
+		WHERE ledger_periods.status <> $4
+	`, period.Period, period.StartsAt, period.EndsAt, repository.PeriodClosed,
+		period.ClosedAt, nullableString(period.ClosedBy), balances, history)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return repository.ErrPeriodClosed
+	}
+	return nil
+}
+
+func (r *Repository) ReopenPeriod(ctx context.Context, period string, event models.PeriodEvent) (*models.LedgerPeriod, error) {
+	history, err := json.Marshal([]models.PeriodEvent{event})
+	if err != nil {
+		return nil, err
+	}
+	row := r.pool.QueryRow(ctx, `
+		UPDATE ledger_periods
+		SET status = $2, history = history || $3
+		WHERE period = $1 AND status = $4
+		RETURNING `+periodColumns,
+		period, repository.PeriodOpen, history, repository.PeriodClosed)
+	reopened, err := scanPeriod(row)
+	if errors.Is(err, pgx.ErrNoRows) {
+		if _, err := r.LedgerPeriod(ctx, period); err != nil {
+			return nil, err
+		}
+		return nil, repository.ErrPeriodOpen
+	}
+	return reopened, err
+}
+
+// checkPeriodsOpen returns ErrPeriodClosed if any of periods is closed.
+// Transactions are serializable, so a close committed after tx started
+// still orders after tx's write.
+func checkPeriodsOpen(ctx context.Context, tx pgx.Tx, periods []string) error {
+	var closed string
+	err := tx.QueryRow(ctx, `
+		SELECT period FROM ledger_periods
+		WHERE period = ANY($1) AND status = $2
+		ORDER BY period LIMIT 1
+	`, periods, repository.PeriodClosed).Scan(&closed)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	return fmt.Errorf("%w: %s", repository.ErrPeriodClosed, closed)
+}
+
+func (r *Repository) LedgerPeriod(ctx context.Context, period string) (*models.LedgerPeriod, error) {
+	row := r.pool.QueryRow(ctx, `SELECT `+periodColumns+` FROM ledger_periods WHERE period = $1`, period)
+	found, err := scanPeriod(row)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, repository.ErrPeriodNotFound
+	}
+	return found, err
+}
+
+func (r *Repository) ListPeriods(ctx context.Context) ([]models.LedgerPeriod, error) {
+	rows, err := r.pool.Query(ctx, `SELECT `+periodColumns+` FROM ledger_periods ORDER BY period`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var items []models.LedgerPeriod
+	for rows.Next() {
+		period, err := scanPeriod(rows)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, *period)
+	}
+	return items, rows.Err()
+}
+
+func scanPeriod(row pgx.Row) (*models.LedgerPeriod, error) {
+	var (
+		period            models.LedgerPeriod
+		closedAt          pgtype.Timestamptz
+		closedBy          pgtype.Text
+		balances, history []byte
+	)
+	err := row.Scan(&period.Period, &period.StartsAt, &period.EndsAt, &period.Status,
+		&closedAt, &closedBy, &balances, &history)
+	if err != nil {
+		return nil, err
+	}
+	if closedAt.Valid {
+		at := closedAt.Time
+		period.ClosedAt = &at
+	}
+	period.ClosedBy = closedBy.String
+	if err := json.Unmarshal(balances, &period.Balances); err != nil {
+		return nil, fmt.Errorf("ledger_periods balances: %w", err)
+	}
+	if err := json.Unmarshal(history, &period.History); err != nil {
+		return nil, fmt.Errorf("ledger_periods history: %w", err)
+	}
+	return &period, nil
+}
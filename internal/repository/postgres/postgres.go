@@ -0,0 +1,49 @@
+// Package postgres implements repository.Store on PostgreSQL using pgx. The
+// schema lives in migrations/*.sql.
+package postgres
+
+import (
+	"context"
+	"errors"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/stocky/backend/internal/repository"
+)
+
+type Repository struct {
+	pool *pgxpool.Pool
+}
+
+var _ repository.Store = (*Repository)(nil)
+
+func New(pool *pgxpool.Pool) *Repository {
+	return &Repository{pool: pool}
+}
+
+func (r *Repository) Pool() *pgxpool.Pool {
+	return r.pool
+}
+
+const (
+	serializationFailure = "40001"
+	maxTxAttempts        = 5
+)
+
+// inTx runs fn in a serializable transaction, retrying when PostgreSQL
+// aborts it with a serialization failure. Every reward and adjustment
+// advances the same hash chain heads, so concurrent writers routinely
+// conflict; fn must therefore be safe to run more than once.
+func (r *Repository) inTx(ctx context.Context, fn func(pgx.Tx) error) error {
+	var err error
+	for attempt := 0; attempt < maxTxAttempts; attempt++ {
+		err = pgx.BeginTxFunc(ctx, r.pool, pgx.TxOptions{IsoLevel: pgx.Serializable}, fn)
+		var pgErr *pgconn.PgError
+		if !errors.As(err, &pgErr) || pgErr.Code != serializationFailure {
+			return err
+		}
+	}
+	return err
+}
@@ -0,0 +1,123 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+
+	"github.com/stocky/backend/internal/models"
+	"github.com/stocky/backend/internal/repository"
+)
+
+func (r *Repository) ListTrackedSymbols(ctx context.Context) ([]string, error) {
+	rows, err := r.pool.Query(ctx, `
+		SELECT symbol
+		FROM stocks
+		WHERE status = 'ACTIVE'
+		ORDER BY symbol
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var symbols []string
+	for rows.Next() {
+		var symbol string
+		if err := rows.Scan(&symbol); err != nil {
+			return nil, err
+		}
+		symbols = append(symbols, symbol)
+	}
+	return symbols, rows.Err()
+}
+
+// UpsertQuote leaves a cached quote with a later as_of in place.
+func (r *Repository) UpsertQuote(ctx context.Context, quote models.PriceQuote) (*models.PriceQuote, error) {
+	symbol := strings.ToUpper(quote.Symbol)
+	var cached *models.PriceQuote
+	err := pgx.BeginTxFunc(ctx, r.pool, pgx.TxOptions{}, func(tx pgx.Tx) error {
+		// price_quotes and price_history reference stocks, so the quote for a
+		// symbol seen for the first time registers it.
+		if err := r.ensureStock(ctx, tx, symbol); err != nil {
+			return err
+		}
+
+		_, err := tx.Exec(ctx, `
+			INSERT INTO price_quotes (symbol, price_inr, source, as_of, fetched_at)
+			VALUES ($1, $2, $3, $4, $5)
+			ON CONFLICT (symbol)
+			DO UPDATE SET price_inr = EXCLUDED.price_inr,
+			              source = EXCLUDED.source,
+			              as_of = EXCLUDED.as_of,
+			              fetched_at = EXCLUDED.fetched_at
+			WHERE price_quotes.as_of <= EXCLUDED.as_of
+		`, symbol, decimalToNumeric(quote.Price), quote.Source, quote.AsOf, quote.FetchedAt)
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.Exec(ctx, `
+			INSERT INTO price_history (symbol, price_inr, as_of, source)
+			VALUES ($1, $2, $3, $4)
+			ON CONFLICT (symbol, as_of) DO NOTHING
+		`, symbol, decimalToNumeric(quote.Price), quote.AsOf, quote.Source)
+		if err != nil {
+			return err
+		}
+
+		cached, err = scanQuote(tx.QueryRow(ctx, `SELECT `+quoteColumns+` FROM price_quotes WHERE symbol = $1`, symbol))
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return cached, nil
+}
+
+const quoteColumns = `symbol, price_inr, source, as_of, fetched_at`
+
+func (r *Repository) LatestQuote(ctx context.Context, symbol string) (*models.PriceQuote, error) {
+	quote, err := scanQuote(r.pool.QueryRow(ctx, `SELECT `+quoteColumns+` FROM price_quotes WHERE symbol = $1`, strings.ToUpper(symbol)))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, repository.ErrQuoteNotFound
+	}
+	return quote, err
+}
+
+func (r *Repository) QuotesForSymbols(ctx context.Context, symbols []string) (map[string]models.PriceQuote, error) {
+	if len(symbols) == 0 {
+		return map[string]models.PriceQuote{}, nil
+	}
+
+	rows, err := r.pool.Query(ctx, `SELECT `+quoteColumns+` FROM price_quotes WHERE symbol = ANY($1)`, repository.UpperSymbols(symbols))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	result := make(map[string]models.PriceQuote)
+	for rows.Next() {
+		quote, err := scanQuote(rows)
+		if err != nil {
+			return nil, err
+		}
+		result[quote.Symbol] = *quote
+	}
+	return result, rows.Err()
+}
+
+func scanQuote(row pgx.Row) (*models.PriceQuote, error) {
+	var (
+		quote models.PriceQuote
+		price pgtype.Numeric
+	)
+	if err := row.Scan(&quote.Symbol, &price, &quote.Source, &quote.AsOf, &quote.FetchedAt); err != nil {
+		return nil, err
+	}
+	quote.Price = numericToDecimal(price)
+	return &quote, nil
+}
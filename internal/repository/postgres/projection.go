@@ -0,0 +1,135 @@
+package postgres
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+
+	"github.com/stocky/backend/internal/models"
+	"github.com/stocky/backend/internal/repository"
+)
+
+func (r *Repository) ListRewardEvents(ctx context.Context, filter repository.ProjectionFilter) ([]models.RewardEvent, error) {
+	rows, err := r.pool.Query(ctx, `
+		SELECT `+rewardColumns+`
+		FROM reward_events
+		WHERE ($1::uuid IS NULL OR user_id = $1)
+		  AND ($2::text IS NULL OR symbol = $2)
+		  AND ($3::timestamptz IS NULL OR rewarded_at <= $3)
+		ORDER BY rewarded_at, created_at
+	`, nullableUUID(filter.UserID), nullableString(strings.ToUpper(filter.Symbol)), nullableTime(filter.Until))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var items []models.RewardEvent
+	for rows.Next() {
+		reward, err := scanReward(rows)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, *reward)
+	}
+	return items, rows.Err()
+}
+
+func (r *Repository) ListPositionStates(ctx context.Context, filter repository.ProjectionFilter) ([]repository.PositionRecord, error) {
+	rows, err := r.pool.Query(ctx, `
+		SELECT user_id, symbol, net_shares, avg_cost_inr, total_cost_inr, first_acquired_at, updated_at
+		FROM user_positions
+		WHERE ($1::uuid IS NULL OR user_id = $1)
+		  AND ($2::text IS NULL OR symbol = $2)
+	`, nullableUUID(filter.UserID), nullableString(strings.ToUpper(filter.Symbol)))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var items []repository.PositionRecord
+	for rows.Next() {
+		var (
+			record            repository.PositionRecord
+			shares, avg, cost pgtype.Numeric
+			first             pgtype.Timestamptz
+		)
+		if err := rows.Scan(&record.UserID, &record.Symbol, &shares, &avg, &cost, &first, &record.UpdatedAt); err != nil {
+			return nil, err
+		}
+		record.Shares = numericToDecimal(shares)
+		record.AvgCost = numericToDecimal(avg)
+		record.TotalCost = numericToDecimal(cost)
+		if first.Valid {
+			record.FirstAcquiredAt = first.Time
+		}
+		items = append(items, record)
+	}
+	return items, rows.Err()
+}
+
+func (r *Repository) SavePositionStates(ctx context.Context, records []repository.PositionRecord) error {
+	if len(records) == 0 {
+		return nil
+	}
+	return pgx.BeginTxFunc(ctx, r.pool, pgx.TxOptions{}, func(tx pgx.Tx) error {
+		now := time.Now()
+		for _, record := range records {
+			if err := r.ensureUser(ctx, tx, record.UserID); err != nil {
+				return err
+			}
+			if err := r.ensureStock(ctx, tx, record.Symbol); err != nil {
+				return err
+			}
+			state := record.PositionState
+			state.UpdatedAt = now
+			if err := r.savePosition(ctx, tx, record.UserID, record.Symbol, state); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (r *Repository) DeletePosition(ctx context.Context, userID uuid.UUID, symbol string) error {
+	_, err := r.pool.Exec(ctx, `
+		DELETE FROM user_positions
+		WHERE user_id = $1 AND symbol = $2
+	`, userID, strings.ToUpper(symbol))
+	return err
+}
+
+func (r *Repository) PriceHistory(ctx context.Context, symbols []string, until time.Time) ([]models.PriceQuote, error) {
+	if len(symbols) == 0 {
+		return nil, nil
+	}
+
+	rows, err := r.pool.Query(ctx, `
+		SELECT symbol, price_inr, source, as_of
+		FROM price_history
+		WHERE symbol = ANY($1) AND as_of <= $2
+		ORDER BY as_of
+	`, symbols, until)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var items []models.PriceQuote
+	for rows.Next() {
+		var (
+			quote models.PriceQuote
+			price pgtype.Numeric
+		)
+		if err := rows.Scan(&quote.Symbol, &price, &quote.Source, &quote.AsOf); err != nil {
+			return nil, err
+		}
+		quote.Price = numericToDecimal(price)
+		quote.FetchedAt = quote.AsOf
+		items = append(items, quote)
+	}
+	return items, rows.Err()
+}
@@ -0,0 +1,162 @@
+package postgres
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/shopspring/decimal"
+
+	"github.com/stocky/backend/internal/models"
+	"github.com/stocky/backend/internal/repository"
+)
+
+func (r *Repository) ListTodayRewards(ctx context.Context, userID uuid.UUID, dayStart, dayEnd time.Time) ([]models.TodayReward, error) {
+	rows, err := r.pool.Query(ctx, `
+		SELECT symbol, shares, rewarded_at
+		FROM reward_events
+		WHERE user_id = $1 AND rewarded_at >= $2 AND rewarded_at < $3
+		ORDER BY rewarded_at
+	`, userID, dayStart, dayEnd)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var items []models.TodayReward
+	for rows.Next() {
+		var (
+			item   models.TodayReward
+			shares pgtype.Numeric
+		)
+		if err := rows.Scan(&item.Symbol, &shares, &item.RewardedAt); err != nil {
+			return nil, err
+		}
+		item.Shares = numericToDecimal(shares)
+		items = append(items, item)
+	}
+	return items, rows.Err()
+}
+
+func (r *Repository) AggregateShares(ctx context.Context, userID uuid.UUID, start, end time.Time) ([]models.TodayTotals, error) {
+	rows, err := r.pool.Query(ctx, `
+		SELECT symbol, SUM(shares)
+		FROM reward_events
+		WHERE user_id = $1 AND rewarded_at >= $2 AND rewarded_at < $3
+		GROUP BY symbol
+		ORDER BY symbol
+	`, userID, start, end)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var items []models.TodayTotals
+	for rows.Next() {
+		var (
+			item  models.TodayTotals
+			total pgtype.Numeric
+		)
+		if err := rows.Scan(&item.Symbol, &total); err != nil {
+			return nil, err
+		}
+		item.Shares = numericToDecimal(total)
+		items = append(items, item)
+	}
+	return items, rows.Err()
+}
+
+func (r *Repository) HistoricalHoldings(ctx context.Context, userID uuid.UUID, before time.Time) ([]models.DailyINR, error) {
+	rows, err := r.pool.Query(ctx, `
+		SELECT date, total_value_inr, stale_symbols
+		FROM daily_holdings
+		WHERE user_id = $1 AND date < $2
+		ORDER BY date
+	`, userID, before)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var items []models.DailyINR
+	for rows.Next() {
+		var (
+			item  models.DailyINR
+			value pgtype.Numeric
+		)
+		if err := rows.Scan(&item.Date, &value, &item.StaleSymbols); err != nil {
+			return nil, err
+		}
+		item.TotalValueIn = numericToDecimal(value)
+		items = append(items, item)
+	}
+	return items, rows.Err()
+}
+
+func (r *Repository) ListUserPositions(ctx context.Context, userID uuid.UUID) ([]repository.UserPosition, error) {
+	rows, err := r.pool.Query(ctx, `
+		SELECT symbol, net_shares, avg_cost_inr
+		FROM user_positions
+		WHERE user_id = $1
+		ORDER BY symbol
+	`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var items []repository.UserPosition
+	for rows.Next() {
+		var (
+			item   repository.UserPosition
+			shares pgtype.Numeric
+			avg    pgtype.Numeric
+		)
+		if err := rows.Scan(&item.Symbol, &shares, &avg); err != nil {
+			return nil, err
+		}
+		item.Shares = numericToDecimal(shares)
+		item.AvgCost = numericToDecimal(avg)
+		items = append(items, item)
+	}
+	return items, rows.Err()
+}
+
+func (r *Repository) UpsertDailyHolding(ctx context.Context, userID uuid.UUID, date time.Time, value decimal.Decimal, staleSymbols []string) error {
+	if staleSymbols == nil {
+		staleSymbols = []string{}
+	}
+	_, err := r.pool.Exec(ctx, `
+		INSERT INTO daily_holdings (user_id, date, total_value_inr, stale_symbols)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (user_id, date)
+		DO UPDATE SET total_value_inr = EXCLUDED.total_value_inr, stale_symbols = EXCLUDED.stale_symbols
+	`, userID, date, decimalToNumeric(value), staleSymbols)
+	return err
+}
+
+func (r *Repository) ListAllPositions(ctx context.Context) ([]repository.RawPosition, error) {
+	rows, err := r.pool.Query(ctx, `
+		SELECT user_id, symbol, net_shares
+		FROM user_positions
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var items []repository.RawPosition
+	for rows.Next() {
+		var (
+			item   repository.RawPosition
+			shares pgtype.Numeric
+		)
+		if err := rows.Scan(&item.UserID, &item.Symbol, &shares); err != nil {
+			return nil, err
+		}
+		item.Shares = numericToDecimal(shares)
+		items = append(items, item)
+	}
+	return items, rows.Err()
+}
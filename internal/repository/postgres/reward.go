@@ -0,0 +1,280 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/shopspring/decimal"
+
+	"github.com/stocky/backend/internal/models"
+	"github.com/stocky/backend/internal/repository"
+)
+
+const uniqueViolation = "23505"
+
+func (r *Repository) CreateReward(ctx context.Context, params repository.RewardCreationParams) (*models.RewardEvent, error) {
+	var result *models.RewardEvent
+	err := r.inTx(ctx, func(tx pgx.Tx) error {
+		reward, err := r.createReward(ctx, tx, params)
+		if err != nil {
+			return err
+		}
+		result = reward
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func (r *Repository) CreateRewards(ctx context.Context, params []repository.RewardCreationParams) ([]*models.RewardEvent, error) {
+	var result []*models.RewardEvent
+	err := r.inTx(ctx, func(tx pgx.Tx) error {
+		rewards := make([]*models.RewardEvent, 0, len(params))
+		for i, item := range params {
+			reward, err := r.createReward(ctx, tx, item)
+			if err != nil {
+				return &repository.BatchItemError{Index: i, Err: err}
+			}
+			rewards = append(rewards, reward)
+		}
+		result = rewards
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// createReward writes the reward, its ledger entries and the position update
+// within tx.
+func (r *Repository) createReward(ctx context.Context, tx pgx.Tx, params repository.RewardCreationParams) (*models.RewardEvent, error) {
+	if err := r.ensureUser(ctx, tx, params.UserID); err != nil {
+		return nil, err
+	}
+	if err := r.ensureStock(ctx, tx, params.Symbol); err != nil {
+		return nil, err
+	}
+	var cash decimal.Decimal
+	if params.RequireCash || params.LowBalanceInr.IsPositive() {
+		var err error
+		if cash, err = cashBalance(ctx, tx); err != nil {
+			return nil, err
+		}
+	}
+	if params.RequireCash {
+		if err := repository.CheckCash(cash, params.Total); err != nil {
+			return nil, err
+		}
+	}
+
+	reward, err := r.insertReward(ctx, tx, params)
+	if err != nil {
+		return nil, err
+	}
+	// created_at is the transaction's start time, which the postings below
+	// are stamped with too.
+	if err := checkPeriodsOpen(ctx, tx, repository.RewardPeriods(reward.RewardedAt, reward.CreatedAt)); err != nil {
+		return nil, err
+	}
+
+	for _, entry := range repository.RewardPostings(reward.ID, params, reward.CreatedAt) {
+		if err := r.insertLedgerEntry(ctx, tx, entry); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := r.updateUserPosition(ctx, tx, reward, params); err != nil {
+		return nil, err
+	}
+
+	if alert := repository.LowBalanceAlert(reward.ID, cash, params.Total, params.LowBalanceInr, reward.CreatedAt); alert != nil {
+		if err := insertTreasuryAlert(ctx, tx, *alert); err != nil {
+			return nil, err
+		}
+	}
+	return reward, nil
+}
+
+func (r *Repository) ensureUser(ctx context.Context, tx pgx.Tx, userID uuid.UUID) error {
+	_, err := tx.Exec(ctx, `
+		INSERT INTO users (id)
+		VALUES ($1)
+		ON CONFLICT (id) DO NOTHING
+	`, userID)
+	return err
+}
+
+func (r *Repository) ensureStock(ctx context.Context, tx pgx.Tx, symbol string) error {
+	_, err := tx.Exec(ctx, `
+		INSERT INTO stocks (symbol, name, exchange, status)
+		VALUES ($1, $2, 'NSE', 'ACTIVE')
+		ON CONFLICT (symbol) DO NOTHING
+	`, strings.ToUpper(symbol), strings.ToUpper(symbol))
+	return err
+}
+
+const rewardColumns = `id, user_id, symbol, shares, granted_price_inr, brokerage_inr, taxes_inr,
+	       total_cash_out_inr, cost_basis_inr, fees, rewarded_at, created_at, event_key,
+	       chain_seq, prev_hash, hash`
+
+func (r *Repository) insertReward(ctx context.Context, tx pgx.Tx, params repository.RewardCreationParams) (*models.RewardEvent, error) {
+	fees, err := feesToJSON(params.Fees)
+	if err != nil {
+		return nil, err
+	}
+
+	id := uuid.New()
+	row := tx.QueryRow(ctx, `
+		INSERT INTO reward_events (
+			id, user_id, symbol, shares, granted_price_inr, brokerage_inr,
+			taxes_inr, total_cash_out_inr, cost_basis_inr, fees, rewarded_at, event_key
+		)
+		VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9,$10,$11,$12)
+		RETURNING `+rewardColumns+`
+	`, id, params.UserID, strings.ToUpper(params.Symbol), decimalToNumeric(params.Shares),
+		decimalToNumeric(params.GrantPrice), decimalToNumeric(params.Brokerage),
+		decimalToNumeric(params.Taxes), decimalToNumeric(params.Total), decimalToNumeric(params.CostBasis),
+		fees, params.RewardedAt, params.EventKey)
+
+	reward, err := scanReward(row)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == uniqueViolation {
+			return nil, repository.ErrDuplicateReward
+		}
+		return nil, err
+	}
+
+	// The link is computed from the stored row so it hashes the values as
+	// rounded by the column types and the database-assigned created_at.
+	links, err := r.extendChain(ctx, tx, repository.ChainRewards, repository.RewardContent(*reward))
+	if err != nil {
+		return nil, err
+	}
+	reward.Chain = links[0]
+	_, err = tx.Exec(ctx, `UPDATE reward_events SET chain_seq = $2, prev_hash = $3, hash = $4 WHERE id = $1`,
+		reward.ID, reward.Chain.Seq, reward.Chain.PrevHash, reward.Chain.Hash)
+	if err != nil {
+		return nil, err
+	}
+	return reward, nil
+}
+
+func scanReward(row pgx.Row) (*models.RewardEvent, error) {
+	var (
+		reward                         models.RewardEvent
+		shares, price, brk, tax, total pgtype.Numeric
+		basis                          pgtype.Numeric
+		fees                           []byte
+		chain                          chainColumns
+	)
+	err := row.Scan(&reward.ID, &reward.UserID, &reward.Symbol, &shares, &price, &brk, &tax,
+		&total, &basis, &fees, &reward.RewardedAt, &reward.CreatedAt, &reward.EventKey,
+		&chain.seq, &chain.prevHash, &chain.hash)
+	if err != nil {
+		return nil, err
+	}
+	reward.Shares = numericToDecimal(shares)
+	reward.GrantedPrice = numericToDecimal(price)
+	reward.BrokerageInr = numericToDecimal(brk)
+	reward.TaxesInr = numericToDecimal(tax)
+	reward.TotalCashOut = numericToDecimal(total)
+	reward.CostBasis = numericToDecimal(basis)
+	reward.Chain = chain.link()
+	if reward.Fees, err = feesFromJSON(fees); err != nil {
+		return nil, fmt.Errorf("reward %s fees: %w", reward.ID, err)
+	}
+	return &reward, nil
+}
+
+func (r *Repository) insertLedgerEntry(ctx context.Context, tx pgx.Tx, entry repository.LedgerEntry) error {
+	accountID, err := r.postingAccountID(ctx, tx, entry)
+	if err != nil {
+		return err
+	}
+
+	links, err := r.extendChain(ctx, tx, repository.ChainLedger, repository.LedgerContent(entry))
+	if err != nil {
+		return err
+	}
+	link := links[0]
+
+	_, err = tx.Exec(ctx, `
+		INSERT INTO ledger_entries (event_id, account_id, debit_inr, credit_inr, stock_units, memo, created_at,
+		                            chain_seq, prev_hash, hash)
+		VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9,$10)
+	`, entry.EventID, accountID,
+		decimalToNumeric(entry.Debit),
+		decimalToNumeric(entry.Credit),
+		decimalToNumeric(entry.StockUnits),
+		entry.Memo,
+		entry.CreatedAt,
+		link.Seq, link.PrevHash, link.Hash)
+	return err
+}
+
+func (r *Repository) updateUserPosition(ctx context.Context, tx pgx.Tx, reward *models.RewardEvent, params repository.RewardCreationParams) error {
+	state, err := r.lockPosition(ctx, tx, reward.UserID, reward.Symbol)
+	if err != nil {
+		return err
+	}
+	next := state.Acquire(params.Shares, params.CostBasis, params.RewardedAt)
+	return r.savePosition(ctx, tx, reward.UserID, reward.Symbol, next)
+}
+
+// lockPosition reads a position FOR UPDATE; a missing row yields the zero
+// state.
+func (r *Repository) lockPosition(ctx context.Context, tx pgx.Tx, userID uuid.UUID, symbol string) (repository.PositionState, error) {
+	var (
+		shares pgtype.Numeric
+		avg    pgtype.Numeric
+		total  pgtype.Numeric
+		first  pgtype.Timestamptz
+	)
+	err := tx.QueryRow(ctx, `
+		SELECT net_shares, avg_cost_inr, total_cost_inr, first_acquired_at
+		FROM user_positions
+		WHERE user_id = $1 AND symbol = $2
+		FOR UPDATE
+	`, userID, symbol).Scan(&shares, &avg, &total, &first)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return repository.PositionState{}, nil
+		}
+		return repository.PositionState{}, err
+	}
+
+	state := repository.PositionState{
+		Shares:    numericToDecimal(shares),
+		AvgCost:   numericToDecimal(avg),
+		TotalCost: numericToDecimal(total),
+	}
+	if first.Valid {
+		state.FirstAcquiredAt = first.Time
+	}
+	return state, nil
+}
+
+func (r *Repository) savePosition(ctx context.Context, tx pgx.Tx, userID uuid.UUID, symbol string, state repository.PositionState) error {
+	_, err := tx.Exec(ctx, `
+		INSERT INTO user_positions (user_id, symbol, net_shares, avg_cost_inr, total_cost_inr, first_acquired_at, updated_at)
+		VALUES ($1,$2,$3,$4,$5,$6,$7)
+		ON CONFLICT (user_id, symbol)
+		DO UPDATE SET net_shares = EXCLUDED.net_shares,
+		              avg_cost_inr = EXCLUDED.avg_cost_inr,
+		              total_cost_inr = EXCLUDED.total_cost_inr,
+		              first_acquired_at = EXCLUDED.first_acquired_at,
+		              updated_at = EXCLUDED.updated_at
+	`, userID, symbol, decimalToNumeric(state.Shares), decimalToNumeric(state.AvgCost),
+		decimalToNumeric(state.TotalCost), nullableTime(state.FirstAcquiredAt), state.UpdatedAt)
+	return err
+}
@@ -0,0 +1,121 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/shopspring/decimal"
+
+	"github.com/stocky/backend/internal/models"
+	"github.com/stocky/backend/internal/repository"
+)
+
+func (r *Repository) FundTreasury(ctx context.Context, funding models.TreasuryFunding) error {
+	return r.inTx(ctx, func(tx pgx.Tx) error {
+		_, err := tx.Exec(ctx, `
+			INSERT INTO treasury_fundings (id, amount_inr, reference, memo, created_at)
+			VALUES ($1,$2,$3,$4,$5)
+		`, funding.ID, decimalToNumeric(funding.AmountInr), funding.Reference,
+			nullableString(funding.Memo), funding.CreatedAt)
+		if err != nil {
+			var pgErr *pgconn.PgError
+			if errors.As(err, &pgErr) && pgErr.Code == uniqueViolation {
+				return repository.ErrDuplicateFunding
+			}
+			return err
+		}
+		for _, entry := range repository.FundingPostings(funding) {
+			if err := r.insertLedgerEntry(ctx, tx, entry); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (r *Repository) FundingByReference(ctx context.Context, reference string) (*models.TreasuryFunding, error) {
+	var (
+		funding models.TreasuryFunding
+		amount  pgtype.Numeric
+		memo    pgtype.Text
+	)
+	err := r.pool.QueryRow(ctx, `
+		SELECT id, amount_inr, reference, memo, created_at
+		FROM treasury_fundings
+		WHERE reference = $1
+	`, reference).Scan(&funding.ID, &amount, &funding.Reference, &memo, &funding.CreatedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, repository.ErrFundingNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	funding.AmountInr = numericToDecimal(amount)
+	funding.Memo = memo.String
+	return &funding, nil
+}
+
+func (r *Repository) CashBalance(ctx context.Context) (decimal.Decimal, error) {
+	return cashBalance(ctx, r.pool)
+}
+
+// rowQuerier is satisfied by both the pool and a transaction.
+type rowQuerier interface {
+	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+}
+
+// cashBalance totals the cash account. Inside a serializable transaction the
+// read conflicts with concurrent grants, so one of two rewards spending the
+// same balance is retried.
+func cashBalance(ctx context.Context, q rowQuerier) (decimal.Decimal, error) {
+	var balance pgtype.Numeric
+	err := q.QueryRow(ctx, `
+		SELECT COALESCE(SUM(e.debit_inr - e.credit_inr), 0)
+		FROM ledger_entries e
+		JOIN ledger_accounts a ON a.id = e.account_id
+		WHERE a.code = $1
+	`, repository.CashAccount).Scan(&balance)
+	if err != nil {
+		return decimal.Zero, err
+	}
+	return numericToDecimal(balance), nil
+}
+
+func insertTreasuryAlert(ctx context.Context, tx pgx.Tx, alert models.TreasuryAlert) error {
+	_, err := tx.Exec(ctx, `
+		INSERT INTO treasury_alerts (id, event_id, balance_inr, threshold_inr, created_at)
+		VALUES ($1,$2,$3,$4,$5)
+	`, alert.ID, alert.EventID, decimalToNumeric(alert.BalanceInr), decimalToNumeric(alert.ThresholdInr), alert.CreatedAt)
+	return err
+}
+
+func (r *Repository) ListTreasuryAlerts(ctx context.Context, limit int) ([]models.TreasuryAlert, error) {
+	rows, err := r.pool.Query(ctx, `
+		SELECT id, event_id, balance_inr, threshold_inr, created_at
+		FROM treasury_alerts
+		ORDER BY created_at DESC
+		LIMIT $1
+	`, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var items []models.TreasuryAlert
+	for rows.Next() {
+		var (
+			alert              models.TreasuryAlert
+			balance, threshold pgtype.Numeric
+		)
+		if err := rows.Scan(&alert.ID, &alert.EventID, &balance, &threshold, &alert.CreatedAt); err != nil {
+			return nil, err
+		}
+		alert.BalanceInr = numericToDecimal(balance)
+		alert.ThresholdInr = numericToDecimal(threshold)
+		items = append(items, alert)
+	}
+	return items, rows.Err()
+}
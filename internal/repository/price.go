@@ -3,9 +3,9 @@ package repository
 import (
 	"context"
 	"errors"
+	"strings"
 	"time"
 
-	"github.com/shopspring/decimal"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
@@ -22,62 +22,70 @@ func (r *Repository) ListTrackedSymbols(ctx context.Context) ([]string, error) {
 	if err != nil {
 		return nil, err
 	}
-	defer cursor.Close(ctx)
 
 	var symbols []string
-	var docs []bson.M
-	if err = cursor.All(ctx, &docs); err != nil {
-		return nil, err
-	}
-
-	for _, doc := range docs {
-		if symbol, ok := doc["symbol"].(string); ok {
-			symbols = append(symbols, symbol)
+	err = decodeEach(ctx, r, "stocks", cursor, func(doc stockDoc) error {
+		if doc.Symbol == "" {
+			return errors.New("symbol is empty")
 		}
-	}
-	return symbols, nil
+		symbols = append(symbols, doc.Symbol)
+		return nil
+	})
+	return symbols, err
 }
 
-func (r *Repository) UpsertQuote(ctx context.Context, symbol string, price decimal.Decimal, source string, fetchedAt time.Time) error {
+// UpsertQuote only matches a cached quote whose as_of is not later than the
+// new one, or that has no as_of because migration 016 has not backfilled it
+// yet. Otherwise the upsert collides with the unique symbol index and the
+// newer quote stays.
+func (r *Repository) UpsertQuote(ctx context.Context, quote models.PriceQuote) (*models.PriceQuote, error) {
+	quote.Symbol = strings.ToUpper(quote.Symbol)
 	collection := r.db.Collection("price_quotes")
 
 	// Upsert price quote
 	opts := options.Update().SetUpsert(true)
 	_, err := collection.UpdateOne(
 		ctx,
-		bson.M{"symbol": symbol},
-		bson.M{"$set": bson.M{
-			"symbol":     symbol,
-			"price_inr":  price.String(),
-			"source":     source,
-			"fetched_at": fetchedAt,
+		bson.M{"symbol": quote.Symbol, "$or": bson.A{
+			bson.M{"as_of": bson.M{"$lte": quote.AsOf}},
+			bson.M{"as_of": bson.M{"$exists": false}},
+		}},
+		bson.M{"$set": quoteDoc{
+			Symbol:    quote.Symbol,
+			Price:     quote.Price,
+			Source:    quote.Source,
+			AsOf:      quote.AsOf,
+			FetchedAt: quote.FetchedAt,
 		}},
 		opts,
 	)
-	if err != nil {
-		return err
+	superseded := mongo.IsDuplicateKeyError(err)
+	if err != nil && !superseded {
+		return nil, err
 	}
 
 	// Insert price history
 	historyCollection := r.db.Collection("price_history")
-	_, err = historyCollection.InsertOne(ctx, bson.M{
-		"symbol":     symbol,
-		"price_inr":  price.String(),
-		"as_of":      fetchedAt,
-		"source":     source,
-		"created_at": time.Now(),
+	_, err = historyCollection.InsertOne(ctx, priceHistoryDoc{
+		Symbol:    quote.Symbol,
+		Price:     quote.Price,
+		AsOf:      quote.AsOf,
+		Source:    quote.Source,
+		CreatedAt: time.Now(),
 	})
 	if err != nil && !mongo.IsDuplicateKeyError(err) {
-		return err
+		return nil, err
 	}
 
-	return nil
+	if superseded {
+		return r.LatestQuote(ctx, quote.Symbol)
+	}
+	return &quote, nil
 }
 
 func (r *Repository) LatestQuote(ctx context.Context, symbol string) (*models.PriceQuote, error) {
 	collection := r.db.Collection("price_quotes")
-	var result bson.M
-	err := collection.FindOne(ctx, bson.M{"symbol": symbol}).Decode(&result)
+	doc, err := decodeOne[quoteDoc](ctx, r, "price_quotes", collection.FindOne(ctx, bson.M{"symbol": strings.ToUpper(symbol)}))
 	if err != nil {
 		if errors.Is(err, mongo.ErrNoDocuments) {
 			return nil, ErrQuoteNotFound
@@ -85,13 +93,8 @@ func (r *Repository) LatestQuote(ctx context.Context, symbol string) (*models.Pr
 		return nil, err
 	}
 
-	price, _ := decimal.NewFromString(result["price_inr"].(string))
-	return &models.PriceQuote{
-		Symbol:    result["symbol"].(string),
-		Price:     price,
-		Source:    result["source"].(string),
-		FetchedAt: result["fetched_at"].(time.Time),
-	}, nil
+	quote := doc.toModel()
+	return &quote, nil
 }
 
 func (r *Repository) QuotesForSymbols(ctx context.Context, symbols []string) (map[string]models.PriceQuote, error) {
@@ -100,27 +103,15 @@ func (r *Repository) QuotesForSymbols(ctx context.Context, symbols []string) (ma
 	}
 
 	collection := r.db.Collection("price_quotes")
-	cursor, err := collection.Find(ctx, bson.M{"symbol": bson.M{"$in": symbols}})
+	cursor, err := collection.Find(ctx, bson.M{"symbol": bson.M{"$in": UpperSymbols(symbols)}})
 	if err != nil {
 		return nil, err
 	}
-	defer cursor.Close(ctx)
 
 	result := make(map[string]models.PriceQuote)
-	var docs []bson.M
-	if err = cursor.All(ctx, &docs); err != nil {
-		return nil, err
-	}
-
-	for _, doc := range docs {
-		symbol := doc["symbol"].(string)
-		price, _ := decimal.NewFromString(doc["price_inr"].(string))
-		result[symbol] = models.PriceQuote{
-			Symbol:    symbol,
-			Price:     price,
-			Source:    doc["source"].(string),
-			FetchedAt: doc["fetched_at"].(time.Time),
-		}
-	}
-	return result, nil
+	err = decodeEach(ctx, r, "price_quotes", cursor, func(doc quoteDoc) error {
+		result[doc.Symbol] = doc.toModel()
+		return nil
+	})
+	return result, err
 }
@@ -0,0 +1,121 @@
+package repository
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/stocky/backend/internal/models"
+)
+
+func (r *Repository) ListRewardEvents(ctx context.Context, filter ProjectionFilter) ([]models.RewardEvent, error) {
+	query := projectionQuery(filter)
+	if !filter.Until.IsZero() {
+		query["rewarded_at"] = bson.M{"$lte": filter.Until}
+	}
+	opts := options.Find().SetSort(bson.D{{Key: "rewarded_at", Value: 1}, {Key: "created_at", Value: 1}})
+	cursor, err := r.db.Collection("reward_events").Find(ctx, query, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	var items []models.RewardEvent
+	err = decodeAll(ctx, r, "reward_events", cursor, func(doc rewardEventDoc) error {
+		event, err := doc.toModel()
+		if err != nil {
+			return err
+		}
+		items = append(items, event)
+		return nil
+	})
+	return items, err
+}
+
+func (r *Repository) ListPositionStates(ctx context.Context, filter ProjectionFilter) ([]PositionRecord, error) {
+	cursor, err := r.db.Collection("user_positions").Find(ctx, projectionQuery(filter))
+	if err != nil {
+		return nil, err
+	}
+
+	var items []PositionRecord
+	err = decodeAll(ctx, r, "user_positions", cursor, func(doc positionDoc) error {
+		record, err := doc.toRecord()
+		if err != nil {
+			return err
+		}
+		items = append(items, record)
+		return nil
+	})
+	return items, err
+}
+
+func (r *Repository) SavePositionStates(ctx context.Context, records []PositionRecord) error {
+	if len(records) == 0 {
+		return nil
+	}
+
+	writes := make([]mongo.WriteModel, 0, len(records))
+	for _, record := range records {
+		state := record.PositionState
+		state.UpdatedAt = time.Now()
+		filter := bson.M{"user_id": record.UserID.String(), "symbol": record.Symbol}
+		writes = append(writes, mongo.NewUpdateOneModel().
+			SetFilter(filter).
+			SetUpdate(bson.M{"$set": newPositionDoc(record.UserID, record.Symbol, state)}).
+			SetUpsert(true))
+	}
+	_, err := r.db.Collection("user_positions").BulkWrite(ctx, writes)
+	return err
+}
+
+func (r *Repository) DeletePosition(ctx context.Context, userID uuid.UUID, symbol string) error {
+	_, err := r.db.Collection("user_positions").DeleteOne(ctx, bson.M{
+		"user_id": userID.String(),
+		"symbol":  strings.ToUpper(symbol),
+	})
+	return err
+}
+
+func (r *Repository) PriceHistory(ctx context.Context, symbols []string, until time.Time) ([]models.PriceQuote, error) {
+	if len(symbols) == 0 {
+		return nil, nil
+	}
+
+	opts := options.Find().SetSort(bson.D{{Key: "as_of", Value: 1}})
+	cursor, err := r.db.Collection("price_history").Find(ctx, bson.M{
+		"symbol": bson.M{"$in": symbols},
+		"as_of":  bson.M{"$lte": until},
+	}, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	var items []models.PriceQuote
+	err = decodeAll(ctx, r, "price_history", cursor, func(doc priceHistoryDoc) error {
+		items = append(items, models.PriceQuote{
+			Symbol:    doc.Symbol,
+			Price:     doc.Price,
+			Source:    doc.Source,
+			AsOf:      doc.AsOf.UTC(),
+			FetchedAt: doc.AsOf.UTC(),
+		})
+		return nil
+	})
+	return items, err
+}
+
+func projectionQuery(filter ProjectionFilter) bson.M {
+	query := bson.M{}
+	if filter.UserID != uuid.Nil {
+		query["user_id"] = filter.UserID.String()
+	}
+	if filter.Symbol != "" {
+		query["symbol"] = strings.ToUpper(filter.Symbol)
+	}
+	return query
+}
@@ -34,23 +34,17 @@ func (r *Repository) ListTodayRewards(ctx context.Context, userID uuid.UUID, day
 	if err != nil {
 		return nil, err
 	}
-	defer cursor.Close(ctx)
 
 	var items []models.TodayReward
-	var docs []bson.M
-	if err = cursor.All(ctx, &docs); err != nil {
-		return nil, err
-	}
-
-	for _, doc := range docs {
-		shares, _ := decimal.NewFromString(doc["shares"].(string))
+	err = decodeEach(ctx, r, "reward_events", cursor, func(doc rewardEventDoc) error {
 		items = append(items, models.TodayReward{
-			Symbol:     doc["symbol"].(string),
-			Shares:     shares,
-			RewardedAt: doc["rewarded_at"].(time.Time),
+			Symbol:     doc.Symbol,
+			Shares:     doc.Shares,
+			RewardedAt: doc.RewardedAt.UTC(),
 		})
-	}
-	return items, nil
+		return nil
+	})
+	return items, err
 }
 
 func (r *Repository) AggregateShares(ctx context.Context, userID uuid.UUID, start, end time.Time) ([]models.TodayTotals, error) {
@@ -62,10 +56,12 @@ func (r *Repository) AggregateShares(ctx context.Context, userID uuid.UUID, star
 				"rewarded_at": bson.M{"$gte": start, "$lt": end},
 			},
 		},
+		// shares is Decimal128, so the sum is computed server-side without
+		// loss of precision.
 		{
 			"$group": bson.M{
-				"_id":    "$symbol",
-				"total":  bson.M{"$sum": "$shares"},
+				"_id":   "$symbol",
+				"total": bson.M{"$sum": "$shares"},
 			},
 		},
 	}
@@ -76,22 +72,17 @@ func (r *Repository) AggregateShares(ctx context.Context, userID uuid.UUID, star
 	}
 	defer cursor.Close(ctx)
 
-	var items []models.TodayTotals
-	var docs []bson.M
+	var docs []struct {
+		Symbol string          `bson:"_id"`
+		Total  decimal.Decimal `bson:"total"`
+	}
 	if err = cursor.All(ctx, &docs); err != nil {
 		return nil, err
 	}
 
+	var items []models.TodayTotals
 	for _, doc := range docs {
-		totalStr := doc["total"].(string)
-		if shares, ok := doc["total"].(float64); ok {
-			totalStr = decimal.NewFromFloat(shares).String()
-		}
-		total, _ := decimal.NewFromString(totalStr)
-		items = append(items, models.TodayTotals{
-			Symbol: doc["_id"].(string),
-			Shares: total,
-		})
+		items = append(items, models.TodayTotals{Symbol: doc.Symbol, Shares: doc.Total})
 	}
 	return items, nil
 }
@@ -106,22 +97,17 @@ func (r *Repository) HistoricalHoldings(ctx context.Context, userID uuid.UUID, b
 	if err != nil {
 		return nil, err
 	}
-	defer cursor.Close(ctx)
 
 	var items []models.DailyINR
-	var docs []bson.M
-	if err = cursor.All(ctx, &docs); err != nil {
-		return nil, err
-	}
-
-	for _, doc := range docs {
-		value, _ := decimal.NewFromString(doc["total_value_inr"].(string))
+	err = decodeEach(ctx, r, "daily_holdings", cursor, func(doc dailyHoldingDoc) error {
 		items = append(items, models.DailyINR{
-			Date:         doc["date"].(time.Time),
-			TotalValueIn: value,
+			Date:         doc.Date.UTC(),
+			TotalValueIn: doc.TotalValue,
+			StaleSymbols: doc.StaleSymbols,
 		})
-	}
-	return items, nil
+		return nil
+	})
+	return items, err
 }
 
 func (r *Repository) ListUserPositions(ctx context.Context, userID uuid.UUID) ([]UserPosition, error) {
@@ -130,37 +116,34 @@ func (r *Repository) ListUserPositions(ctx context.Context, userID uuid.UUID) ([
 	if err != nil {
 		return nil, err
 	}
-	defer cursor.Close(ctx)
 
 	var items []UserPosition
-	var docs []bson.M
-	if err = cursor.All(ctx, &docs); err != nil {
-		return nil, err
-	}
-
-	for _, doc := range docs {
-		shares, _ := decimal.NewFromString(doc["net_shares"].(string))
-		avg, _ := decimal.NewFromString(doc["avg_cost_inr"].(string))
+	err = decodeEach(ctx, r, "user_positions", cursor, func(doc positionDoc) error {
 		items = append(items, UserPosition{
-			Symbol:  doc["symbol"].(string),
-			Shares:  shares,
-			AvgCost: avg,
+			Symbol:  doc.Symbol,
+			Shares:  doc.NetShares,
+			AvgCost: doc.AvgCost,
 		})
-	}
-	return items, nil
+		return nil
+	})
+	return items, err
 }
 
-func (r *Repository) UpsertDailyHolding(ctx context.Context, userID uuid.UUID, date time.Time, value decimal.Decimal) error {
+func (r *Repository) UpsertDailyHolding(ctx context.Context, userID uuid.UUID, date time.Time, value decimal.Decimal, staleSymbols []string) error {
+	if staleSymbols == nil {
+		staleSymbols = []string{}
+	}
 	collection := r.db.Collection("daily_holdings")
 	opts := options.Update().SetUpsert(true)
 	_, err := collection.UpdateOne(
 		ctx,
 		bson.M{"user_id": userID.String(), "date": date},
-		bson.M{"$set": bson.M{
-			"user_id":          userID.String(),
-			"date":             date,
-			"total_value_inr":  value.String(),
-			"updated_at":       time.Now(),
+		bson.M{"$set": dailyHoldingDoc{
+			UserID:       userID.String(),
+			Date:         date,
+			TotalValue:   value,
+			StaleSymbols: staleSymbols,
+			UpdatedAt:    time.Now(),
 		}},
 		opts,
 	)
@@ -173,22 +156,19 @@ func (r *Repository) ListAllPositions(ctx context.Context) ([]RawPosition, error
 	if err != nil {
 		return nil, err
 	}
-	defer cursor.Close(ctx)
 
 	var items []RawPosition
-	var docs []bson.M
-	if err = cursor.All(ctx, &docs); err != nil {
-		return nil, err
-	}
-
-	for _, doc := range docs {
-		userID, _ := uuid.Parse(doc["user_id"].(string))
-		shares, _ := decimal.NewFromString(doc["net_shares"].(string))
+	err = decodeEach(ctx, r, "user_positions", cursor, func(doc positionDoc) error {
+		record, err := doc.toRecord()
+		if err != nil {
+			return err
+		}
 		items = append(items, RawPosition{
-			UserID: userID,
-			Symbol: doc["symbol"].(string),
-			Shares: shares,
+			UserID: record.UserID,
+			Symbol: record.Symbol,
+			Shares: record.Shares,
 		})
-	}
-	return items, nil
+		return nil
+	})
+	return items, err
 }
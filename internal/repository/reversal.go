@@ -0,0 +1,196 @@
+package repository
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+
+	"github.com/stocky/backend/internal/models"
+)
+
+var (
+	ErrRewardNotFound       = errors.New("reward event not found")
+	ErrDuplicateAdjustment  = errors.New("adjustment idempotency key already used")
+	ErrAdjustmentNotFound   = errors.New("adjustment not found")
+	ErrInsufficientShares   = errors.New("position holds fewer shares than requested")
+	ErrReversalExceedsGrant = errors.New("shares exceed what remains of the reward")
+	// ErrRewardCashedOut is returned for a reward whose shares were paid out
+	// in cash by a merger with cash-in-lieu or a delisting cash-out: no
+	// position is left to take them back from.
+	ErrRewardCashedOut = errors.New("reward's shares were cashed out by a corporate action and cannot be reversed")
+)
+
+// Adjustment kinds.
+const (
+	AdjustmentReversal = "reversal"
+)
+
+// How fees are handled when a reward is reversed.
+const (
+	// FeesReverse credits brokerage and tax back and recovers them in cash.
+	FeesReverse = "reverse"
+	// FeesLoss keeps the cash spent on fees and moves it to a loss account.
+	FeesLoss = "loss"
+)
+
+// ReversalParams describes a reversal of some or all of a reward's shares.
+type ReversalParams struct {
+	RewardID uuid.UUID
+	// Shares to reverse; zero reverses everything not already reversed.
+	Shares         decimal.Decimal
+	FeeTreatment   string
+	Reason         string
+	IdempotencyKey string
+}
+
+// TraceReward follows a reward's shares through the corporate actions
+// applied by asOf, in the order they were applied. It returns the symbol the
+// shares are held under and the factor turning granted shares into held
+// ones. A split or bonus counts when the reward was granted before its
+// ex-date; a rename or merger moves the shares to the new symbol. Actions
+// that paid the shares out in cash return ErrRewardCashedOut.
+func TraceReward(reward models.RewardEvent, actions []models.CorporateAction, asOf time.Time) (string, decimal.Decimal, error) {
+	applied := make([]models.CorporateAction, 0, len(actions))
+	for _, action := range actions {
+		if action.Status == ActionApplied && action.AppliedAt != nil && !action.AppliedAt.After(asOf) {
+			applied = append(applied, action)
+		}
+	}
+	sort.SliceStable(applied, func(i, j int) bool { return applied[i].AppliedAt.Before(*applied[j].AppliedAt) })
+
+	symbol := strings.ToUpper(reward.Symbol)
+	factor := decimal.NewFromInt(1)
+	for _, action := range applied {
+		if action.Symbol != symbol {
+			continue
+		}
+		switch {
+		case RescalesPrices(action.Kind):
+			if reward.RewardedAt.Before(action.ExDate) {
+				factor = factor.Mul(action.Factor)
+			}
+		case action.Kind == CorporateRename, action.Kind == CorporateMerger && action.CashPrice.IsZero():
+			factor = factor.Mul(decimal.NewFromInt(action.RatioNew)).Div(decimal.NewFromInt(action.RatioHeld))
+			symbol = action.NewSymbol
+		case action.Kind == CorporateDelisting && action.CashPrice.IsZero():
+			// The position stays, frozen at the last price.
+		default:
+			return "", decimal.Zero, fmt.Errorf("%w: %s", ErrRewardCashedOut, ActionDescription(action))
+		}
+	}
+	return symbol, factor, nil
+}
+
+// PlanReversal builds the adjustment and offsetting ledger entries for
+// reversing part of reward. Shares are counted in what the reward is worth
+// today after the applied corporate actions, and earlier reversals are
+// converted back to granted shares at the factor in force when they were
+// made. Amounts are taken pro rata from the original grant.
+func PlanReversal(reward models.RewardEvent, actions []models.CorporateAction, earlier []models.Adjustment, params ReversalParams, now time.Time) (models.Adjustment, []LedgerEntry, error) {
+	symbol, factor, err := TraceReward(reward, actions, now)
+	if err != nil {
+		return models.Adjustment{}, nil, err
+	}
+	reversed := decimal.Zero
+	for _, adjustment := range earlier {
+		_, then, err := TraceReward(reward, actions, adjustment.CreatedAt)
+		if err != nil {
+			return models.Adjustment{}, nil, err
+		}
+		reversed = reversed.Add(adjustment.Shares.Neg().Div(then))
+	}
+
+	remaining := reward.Shares.Sub(reversed).Mul(factor).Round(6)
+	shares := params.Shares
+	if shares.IsZero() {
+		shares = remaining
+	}
+	if !remaining.GreaterThan(decimal.Zero) || shares.GreaterThan(remaining) {
+		return models.Adjustment{}, nil, ErrReversalExceedsGrant
+	}
+
+	ratio := shares.Div(reward.Shares.Mul(factor))
+	value := reward.GrantedPrice.Mul(shares).Div(factor).Round(4)
+	cost := reward.CostBasis.Mul(ratio).Round(4)
+
+	fees := feeLines(reward.BrokerageInr, reward.TaxesInr, reward.Fees)
+	feeTotal := decimal.Zero
+	for i := range fees {
+		fees[i].amount = fees[i].amount.Mul(ratio).Round(4)
+		feeTotal = feeTotal.Add(fees[i].amount)
+	}
+
+	cash := value
+	if params.FeeTreatment == FeesReverse {
+		cash = value.Add(feeTotal)
+	}
+
+	referenceEvent := reward.ID
+	adjustment := models.Adjustment{
+		ID:             uuid.New(),
+		UserID:         reward.UserID,
+		Symbol:         symbol,
+		Kind:           AdjustmentReversal,
+		Shares:         shares.Neg(),
+		CostInr:        cost.Neg(),
+		CashInr:        cash,
+		FeeTreatment:   params.FeeTreatment,
+		Reason:         params.Reason,
+		ReferenceEvent: &referenceEvent,
+		IdempotencyKey: params.IdempotencyKey,
+		CreatedAt:      now,
+	}
+	return adjustment, reversalPostings(adjustment, value, fees, feeTotal), nil
+}
+
+// reversalPostings credits stock inventory against a cash debit. Fees are
+// credited back to their expense accounts line by line and, when they are not
+// recovered, reclassified as a loss.
+func reversalPostings(adj models.Adjustment, value decimal.Decimal, fees []feeLine, feeTotal decimal.Decimal) []LedgerEntry {
+	entries := []LedgerEntry{
+		{
+			EventID:     adj.ID,
+			AccountCode: StockAccount(adj.Symbol),
+			AccountType: "asset",
+			Symbol:      adj.Symbol,
+			Credit:      value,
+			StockUnits:  adj.Shares,
+			Memo:        "Reversed stock inventory",
+			CreatedAt:   adj.CreatedAt,
+		},
+		{
+			EventID:     adj.ID,
+			AccountCode: "cash",
+			AccountType: "asset",
+			Debit:       adj.CashInr,
+			Memo:        "Cash recovered from reversal",
+			CreatedAt:   adj.CreatedAt,
+		},
+	}
+	for _, line := range fees {
+		entries = append(entries, LedgerEntry{
+			EventID:     adj.ID,
+			AccountCode: line.account,
+			AccountType: "expense",
+			Credit:      line.amount,
+			Memo:        line.memo + " reversed",
+			CreatedAt:   adj.CreatedAt,
+		})
+	}
+	if adj.FeeTreatment == FeesLoss {
+		entries = append(entries, LedgerEntry{
+			EventID:     adj.ID,
+			AccountCode: "reversal_loss",
+			AccountType: "expense",
+			Debit:       feeTotal,
+			Memo:        "Unrecovered fees on reversal",
+			CreatedAt:   adj.CreatedAt,
+		})
+	}
+	return entries
+}
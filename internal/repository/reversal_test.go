@@ -0,0 +1,241 @@
+package repository
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+
+	"github.com/stocky/backend/internal/models"
+)
+
+func dec(s string) decimal.Decimal { return decimal.RequireFromString(s) }
+
+func day(month time.Month, d int) time.Time {
+	return time.Date(2024, month, d, 0, 0, 0, 0, time.UTC)
+}
+
+// checkBalanced fails the test unless debits equal credits.
+func checkBalanced(t *testing.T, entries []LedgerEntry) {
+	t.Helper()
+	debits, credits := decimal.Zero, decimal.Zero
+	for _, entry := range entries {
+		debits = debits.Add(entry.Debit)
+		credits = credits.Add(entry.Credit)
+	}
+	if !debits.Equal(credits) {
+		t.Errorf("postings unbalanced: debits %s, credits %s", debits, credits)
+	}
+}
+
+func TestTraceReward(t *testing.T) {
+	reward := models.RewardEvent{Symbol: "old", RewardedAt: day(1, 10)}
+	pending := appliedAction(CorporateSplit, "OLD", "", 2, 1, "0", day(2, 1))
+	pending.Status, pending.AppliedAt = ActionPending, nil
+
+	tests := []struct {
+		name       string
+		actions    []models.CorporateAction
+		asOf       time.Time
+		wantSymbol string
+		wantFactor string
+		wantErr    error
+	}{
+		{name: "no actions", wantSymbol: "OLD", wantFactor: "1"},
+		{
+			name:       "split after the grant",
+			actions:    []models.CorporateAction{appliedAction(CorporateSplit, "OLD", "", 2, 1, "0", day(2, 1))},
+			wantSymbol: "OLD", wantFactor: "2",
+		},
+		{
+			name:       "split before the grant",
+			actions:    []models.CorporateAction{appliedAction(CorporateSplit, "OLD", "", 2, 1, "0", day(1, 5))},
+			wantSymbol: "OLD", wantFactor: "1",
+		},
+		{
+			name:       "split applied after asOf",
+			actions:    []models.CorporateAction{appliedAction(CorporateSplit, "OLD", "", 2, 1, "0", day(2, 1))},
+			asOf:       day(1, 31),
+			wantSymbol: "OLD", wantFactor: "1",
+		},
+		{name: "pending split", actions: []models.CorporateAction{pending}, wantSymbol: "OLD", wantFactor: "1"},
+		{
+			name:       "other symbol",
+			actions:    []models.CorporateAction{appliedAction(CorporateSplit, "TCS", "", 2, 1, "0", day(2, 1))},
+			wantSymbol: "OLD", wantFactor: "1",
+		},
+		{
+			name: "split then rename then split",
+			actions: []models.CorporateAction{
+				appliedAction(CorporateSplit, "NEW", "", 5, 1, "0", day(4, 1)),
+				appliedAction(CorporateRename, "OLD", "NEW", 1, 1, "0", day(3, 1)),
+				appliedAction(CorporateSplit, "OLD", "", 2, 1, "0", day(2, 1)),
+			},
+			wantSymbol: "NEW", wantFactor: "10",
+		},
+		{
+			name:       "share-for-share merger",
+			actions:    []models.CorporateAction{appliedAction(CorporateMerger, "OLD", "ACQ", 1, 2, "0", day(2, 1))},
+			wantSymbol: "ACQ", wantFactor: "0.5",
+		},
+		{
+			name:       "delisting without cash",
+			actions:    []models.CorporateAction{appliedAction(CorporateDelisting, "OLD", "", 1, 1, "0", day(2, 1))},
+			wantSymbol: "OLD", wantFactor: "1",
+		},
+		{
+			name:    "merger with cash in lieu",
+			actions: []models.CorporateAction{appliedAction(CorporateMerger, "OLD", "ACQ", 1, 3, "250", day(2, 1))},
+			wantErr: ErrRewardCashedOut,
+		},
+		{
+			name:    "delisting cash-out",
+			actions: []models.CorporateAction{appliedAction(CorporateDelisting, "OLD", "", 1, 1, "40", day(2, 1))},
+			wantErr: ErrRewardCashedOut,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			asOf := tt.asOf
+			if asOf.IsZero() {
+				asOf = day(12, 31)
+			}
+			symbol, factor, err := TraceReward(reward, tt.actions, asOf)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("err = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("TraceReward: %v", err)
+			}
+			if symbol != tt.wantSymbol || !factor.Equal(dec(tt.wantFactor)) {
+				t.Errorf("TraceReward = %s x%s, want %s x%s", symbol, factor, tt.wantSymbol, tt.wantFactor)
+			}
+		})
+	}
+}
+
+func TestPlanReversal(t *testing.T) {
+	reward := models.RewardEvent{
+		ID:           uuid.New(),
+		UserID:       uuid.New(),
+		Symbol:       "INFY",
+		Shares:       dec("10"),
+		GrantedPrice: dec("100"),
+		BrokerageInr: dec("5"),
+		TaxesInr:     dec("2"),
+		CostBasis:    dec("1000"),
+		RewardedAt:   day(1, 10),
+	}
+	split := appliedAction(CorporateSplit, "INFY", "", 2, 1, "0", day(3, 1))
+	bonus := appliedAction(CorporateBonus, "INFY", "", 1, 3, "0", day(3, 1))
+	reversal := func(shares string, at time.Time) models.Adjustment {
+		return models.Adjustment{Kind: AdjustmentReversal, Shares: dec(shares).Neg(), CreatedAt: at}
+	}
+
+	tests := []struct {
+		name      string
+		actions   []models.CorporateAction
+		earlier   []models.Adjustment
+		shares    string
+		fees      string
+		wantErr   error
+		symbol    string
+		wantShare string
+		wantCost  string
+		wantCash  string
+	}{
+		{name: "everything with fees reversed", fees: FeesReverse, symbol: "INFY", wantShare: "10", wantCost: "1000", wantCash: "1007"},
+		{name: "part with fees as a loss", shares: "4", fees: FeesLoss, symbol: "INFY", wantShare: "4", wantCost: "400", wantCash: "400"},
+		{name: "part with fees reversed", shares: "4", fees: FeesReverse, symbol: "INFY", wantShare: "4", wantCost: "400", wantCash: "402.8"},
+		{
+			name: "counted in post-split shares", actions: []models.CorporateAction{split},
+			shares: "5", fees: FeesLoss, symbol: "INFY", wantShare: "5", wantCost: "250", wantCash: "250",
+		},
+		{
+			name: "everything after a split", actions: []models.CorporateAction{split},
+			fees: FeesLoss, symbol: "INFY", wantShare: "20", wantCost: "1000", wantCash: "1000",
+		},
+		{
+			name: "non-integer bonus factor", actions: []models.CorporateAction{bonus},
+			fees: FeesLoss, symbol: "INFY", wantShare: "13.333333", wantCost: "1000", wantCash: "1000",
+		},
+		{
+			name: "after a rename", actions: []models.CorporateAction{appliedAction(CorporateRename, "INFY", "INFOSYS", 1, 1, "0", day(3, 1))},
+			shares: "10", fees: FeesLoss, symbol: "INFOSYS", wantShare: "10", wantCost: "1000", wantCash: "1000",
+		},
+		{
+			name: "earlier reversal before a split", actions: []models.CorporateAction{split},
+			earlier: []models.Adjustment{reversal("4", day(2, 1))},
+			fees:    FeesLoss, symbol: "INFY", wantShare: "12", wantCost: "600", wantCash: "600",
+		},
+		{
+			name: "earlier reversal after a split", actions: []models.CorporateAction{split},
+			earlier: []models.Adjustment{reversal("8", day(4, 1))},
+			fees:    FeesLoss, symbol: "INFY", wantShare: "12", wantCost: "600", wantCash: "600",
+		},
+		{
+			name: "more than remains", actions: []models.CorporateAction{split},
+			earlier: []models.Adjustment{reversal("4", day(2, 1))},
+			shares:  "12.000001", fees: FeesLoss, wantErr: ErrReversalExceedsGrant,
+		},
+		{
+			name: "already fully reversed at a non-integer factor", actions: []models.CorporateAction{bonus},
+			earlier: []models.Adjustment{reversal("13.333333", day(4, 1))},
+			fees:    FeesLoss, wantErr: ErrReversalExceedsGrant,
+		},
+		{
+			name: "cashed out by a merger", actions: []models.CorporateAction{appliedAction(CorporateMerger, "INFY", "ACQ", 1, 3, "250", day(3, 1))},
+			fees: FeesLoss, wantErr: ErrRewardCashedOut,
+		},
+	}
+	now := day(6, 1)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			params := ReversalParams{RewardID: reward.ID, FeeTreatment: tt.fees, Reason: "clawback", IdempotencyKey: "k"}
+			if tt.shares != "" {
+				params.Shares = dec(tt.shares)
+			}
+			adjustment, postings, err := PlanReversal(reward, tt.actions, tt.earlier, params, now)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("err = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("PlanReversal: %v", err)
+			}
+			if adjustment.Symbol != tt.symbol {
+				t.Errorf("symbol = %s, want %s", adjustment.Symbol, tt.symbol)
+			}
+			if !adjustment.Shares.Equal(dec(tt.wantShare).Neg()) {
+				t.Errorf("shares = %s, want -%s", adjustment.Shares, tt.wantShare)
+			}
+			if !adjustment.CostInr.Equal(dec(tt.wantCost).Neg()) {
+				t.Errorf("cost = %s, want -%s", adjustment.CostInr, tt.wantCost)
+			}
+			if !adjustment.CashInr.Equal(dec(tt.wantCash)) {
+				t.Errorf("cash = %s, want %s", adjustment.CashInr, tt.wantCash)
+			}
+			if adjustment.ReferenceEvent == nil || *adjustment.ReferenceEvent != reward.ID {
+				t.Errorf("reference event = %v, want %s", adjustment.ReferenceEvent, reward.ID)
+			}
+			checkBalanced(t, postings)
+			stock := postings[0]
+			if stock.AccountCode != StockAccount(tt.symbol) || !stock.StockUnits.Equal(adjustment.Shares) {
+				t.Errorf("stock posting = %s %s units, want %s %s units",
+					stock.AccountCode, stock.StockUnits, StockAccount(tt.symbol), adjustment.Shares)
+			}
+			for _, entry := range postings {
+				if entry.EventID != adjustment.ID {
+					t.Errorf("posting %s belongs to %s, want %s", entry.AccountCode, entry.EventID, adjustment.ID)
+				}
+			}
+		})
+	}
+}
@@ -20,6 +20,20 @@ var (
 	ErrDuplicateReward = errors.New("reward event already exists")
 )
 
+// BatchItemError identifies the item that aborted a CreateRewards batch.
+type BatchItemError struct {
+	Index int
+	Err   error
+}
+
+func (e *BatchItemError) Error() string {
+	return fmt.Sprintf("batch item %d: %v", e.Index, e.Err)
+}
+
+func (e *BatchItemError) Unwrap() error {
+	return e.Err
+}
+
 type RewardCreationParams struct {
 	UserID     uuid.UUID
 	Symbol     string
@@ -28,8 +42,22 @@ type RewardCreationParams struct {
 	Brokerage  decimal.Decimal
 	Taxes      decimal.Decimal
 	Total      decimal.Decimal
+	// CostBasis is the INR amount added to the position's cost: the grant
+	// value, plus fees when they are capitalised.
+	CostBasis decimal.Decimal
+	// Fees itemises Brokerage and Taxes; when set, each component is booked
+	// to its own expense account.
+	Fees       *models.FeeBreakdown
 	EventKey   string
 	RewardedAt time.Time
+	// RequireCash rejects the reward with ErrInsufficientCash when Total
+	// would take the cash account below zero.
+	RequireCash bool
+	// LowBalanceInr, when positive, records a TreasuryAlert with the reward
+	// if it takes the cash balance from at or above it to below.
+	LowBalanceInr decimal.Decimal
+	// Memo is appended to every ledger memo of the reward.
+	Memo string
 }
 
 func (r *Repository) CreateReward(ctx context.Context, params RewardCreationParams) (*models.RewardEvent, error) {
@@ -39,165 +67,183 @@ func (r *Repository) CreateReward(ctx context.Context, params RewardCreationPara
 	}
 	defer session.EndSession(ctx)
 
-	var result *models.RewardEvent
-	err = mongo.WithSession(ctx, session, func(sessionCtx mongo.SessionContext) error {
-		if err := sessionCtx.StartTransaction(); err != nil {
-			return err
-		}
+	// WithTransaction retries on transient errors, which is how concurrent
+	// grants touching the same position are serialised.
+	result, err := session.WithTransaction(ctx, func(sessionCtx mongo.SessionContext) (interface{}, error) {
+		return r.createReward(sessionCtx, params)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result.(*models.RewardEvent), nil
+}
 
-		// Ensure user exists
-		usersCollection := r.db.Collection("users")
-		_, err = usersCollection.UpdateOne(
-			sessionCtx,
-			bson.M{"_id": params.UserID.String()},
-			bson.M{"$setOnInsert": bson.M{"_id": params.UserID.String(), "created_at": time.Now()}},
-			options.Update().SetUpsert(true),
-		)
-		if err != nil {
-			sessionCtx.AbortTransaction(sessionCtx)
-			return err
-		}
+func (r *Repository) CreateRewards(ctx context.Context, params []RewardCreationParams) ([]*models.RewardEvent, error) {
+	session, err := r.client.StartSession()
+	if err != nil {
+		return nil, err
+	}
+	defer session.EndSession(ctx)
 
-		// Ensure stock exists
-		stocksCollection := r.db.Collection("stocks")
-		_, err = stocksCollection.UpdateOne(
-			sessionCtx,
-			bson.M{"symbol": strings.ToUpper(params.Symbol)},
-			bson.M{"$setOnInsert": bson.M{
-				"symbol":     strings.ToUpper(params.Symbol),
-				"name":       strings.ToUpper(params.Symbol),
-				"exchange":   "NSE",
-				"status":     "ACTIVE",
-				"created_at": time.Now(),
-			}},
-			options.Update().SetUpsert(true),
-		)
-		if err != nil {
-			sessionCtx.AbortTransaction(sessionCtx)
-			return err
+	result, err := session.WithTransaction(ctx, func(sessionCtx mongo.SessionContext) (interface{}, error) {
+		rewards := make([]*models.RewardEvent, 0, len(params))
+		for i, item := range params {
+			reward, err := r.createReward(sessionCtx, item)
+			if err != nil {
+				return nil, &BatchItemError{Index: i, Err: err}
+			}
+			rewards = append(rewards, reward)
 		}
+		return rewards, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result.([]*models.RewardEvent), nil
+}
 
-		// Check for duplicate reward event
-		rewardCollection := r.db.Collection("reward_events")
-		count, err := rewardCollection.CountDocuments(sessionCtx, bson.M{"event_key": params.EventKey})
-		if err != nil {
-			sessionCtx.AbortTransaction(sessionCtx)
-			return err
-		}
-		if count > 0 {
-			sessionCtx.AbortTransaction(sessionCtx)
-			return ErrDuplicateReward
-		}
+// createReward writes the reward, its ledger entries and the position update.
+// It must run inside a transaction.
+func (r *Repository) createReward(sessionCtx mongo.SessionContext, params RewardCreationParams) (*models.RewardEvent, error) {
+	now := time.Now()
+	symbol := strings.ToUpper(params.Symbol)
+
+	// Ensure user exists
+	usersCollection := r.db.Collection("users")
+	_, err := usersCollection.UpdateOne(
+		sessionCtx,
+		bson.M{"_id": params.UserID.String()},
+		bson.M{"$setOnInsert": userDoc{ID: params.UserID.String(), CreatedAt: now}},
+		options.Update().SetUpsert(true),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	// Ensure stock exists
+	stocksCollection := r.db.Collection("stocks")
+	_, err = stocksCollection.UpdateOne(
+		sessionCtx,
+		bson.M{"symbol": symbol},
+		bson.M{"$setOnInsert": stockDoc{
+			Symbol:    symbol,
+			Name:      symbol,
+			Exchange:  "NSE",
+			Status:    "ACTIVE",
+			CreatedAt: now,
+		}},
+		options.Update().SetUpsert(true),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	// Check for duplicate reward event
+	rewardCollection := r.db.Collection("reward_events")
+	count, err := rewardCollection.CountDocuments(sessionCtx, bson.M{"event_key": params.EventKey})
+	if err != nil {
+		return nil, err
+	}
+	if count > 0 {
+		return nil, ErrDuplicateReward
+	}
 
-		// Insert reward event
-		id := uuid.New()
-		reward := bson.M{
-			"_id":                 id.String(),
-			"user_id":             params.UserID.String(),
-			"symbol":              strings.ToUpper(params.Symbol),
-			"shares":              params.Shares.String(),
-			"granted_price_inr":   params.GrantPrice.String(),
-			"brokerage_inr":       params.Brokerage.String(),
-			"taxes_inr":           params.Taxes.String(),
-			"total_cash_out_inr":  params.Total.String(),
-			"rewarded_at":         params.RewardedAt,
-			"event_key":           params.EventKey,
-			"created_at":          time.Now(),
+	if err := r.checkPeriodsOpen(sessionCtx, RewardPeriods(params.RewardedAt, now)); err != nil {
+		return nil, err
+	}
+
+	var cash decimal.Decimal
+	if params.RequireCash || params.LowBalanceInr.IsPositive() {
+		if cash, err = r.lockedCashBalance(sessionCtx); err != nil {
+			return nil, err
 		}
-		_, err = rewardCollection.InsertOne(sessionCtx, reward)
-		if err != nil {
-			sessionCtx.AbortTransaction(sessionCtx)
-			return err
+	}
+	if params.RequireCash {
+		if err := CheckCash(cash, params.Total); err != nil {
+			return nil, err
 		}
+	}
 
-		// Insert ledger entries
-		ledgerCollection := r.db.Collection("ledger_entries")
-		stockAccount := fmt.Sprintf("stock_inventory:%s", strings.ToUpper(params.Symbol))
-
-		entries := []interface{}{
-			bson.M{
-				"event_id":        id.String(),
-				"account_code":    stockAccount,
-				"account_type":    "asset",
-				"symbol":          strings.ToUpper(params.Symbol),
-				"debit_inr":       params.GrantPrice.Mul(params.Shares).String(),
-				"credit_inr":      "0",
-				"stock_units":     params.Shares.String(),
-				"memo":            "Rewarded stock inventory",
-				"created_at":      time.Now(),
-			},
-			bson.M{
-				"event_id":     id.String(),
-				"account_code": "brokerage_expense",
-				"account_type": "expense",
-				"debit_inr":    params.Brokerage.String(),
-				"credit_inr":   "0",
-				"memo":         "Brokerage charges",
-				"created_at":   time.Now(),
-			},
-			bson.M{
-				"event_id":     id.String(),
-				"account_code": "tax_expense",
-				"account_type": "expense",
-				"debit_inr":    params.Taxes.String(),
-				"credit_inr":   "0",
-				"memo":         "Statutory taxes",
-				"created_at":   time.Now(),
-			},
-			bson.M{
-				"event_id":     id.String(),
-				"account_code": "cash",
-				"account_type": "asset",
-				"debit_inr":    "0",
-				"credit_inr":   params.Total.String(),
-				"memo":         "Cash outflow for reward",
-				"created_at":   time.Now(),
-			},
+	// Insert reward event
+	reward := models.RewardEvent{
+		ID:           uuid.New(),
+		UserID:       params.UserID,
+		Symbol:       symbol,
+		Shares:       params.Shares,
+		GrantedPrice: params.GrantPrice,
+		BrokerageInr: params.Brokerage,
+		TaxesInr:     params.Taxes,
+		TotalCashOut: params.Total,
+		RewardedAt:   params.RewardedAt,
+		EventKey:     params.EventKey,
+		CreatedAt:    now,
+		CostBasis:    params.CostBasis,
+		Fees:         params.Fees,
+	}
+	links, err := r.extendChain(sessionCtx, ChainRewards, RewardContent(reward))
+	if err != nil {
+		return nil, err
+	}
+	reward.Chain = links[0]
+	_, err = rewardCollection.InsertOne(sessionCtx, newRewardEventDoc(reward))
+	if err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return nil, ErrDuplicateReward
 		}
+		return nil, err
+	}
 
-		_, err = ledgerCollection.InsertMany(sessionCtx, entries)
-		if err != nil {
-			sessionCtx.AbortTransaction(sessionCtx)
-			return err
-		}
+	// Insert ledger entries
+	if err := r.insertLedgerEntries(sessionCtx, RewardPostings(reward.ID, params, now)); err != nil {
+		return nil, err
+	}
 
-		// Update user position
-		positionsCollection := r.db.Collection("user_positions")
-		filter := bson.M{"user_id": params.UserID.String(), "symbol": strings.ToUpper(params.Symbol)}
-		opts := options.FindOneAndUpdate().SetReturnDocument(options.After).SetUpsert(true)
-
-		var position bson.M
-		err = positionsCollection.FindOneAndUpdate(
-			sessionCtx,
-			filter,
-			bson.M{"$inc": bson.M{"net_shares": params.Shares.String()}},
-			opts,
-		).Decode(&position)
-		if err != nil && err != mongo.ErrNoDocuments {
-			sessionCtx.AbortTransaction(sessionCtx)
-			return err
-		}
+	// Update user position with the weighted average cost
+	if err := r.acquirePosition(sessionCtx, params.UserID, symbol, params.Shares, params.CostBasis, params.RewardedAt); err != nil {
+		return nil, err
+	}
 
-		if err := sessionCtx.CommitTransaction(sessionCtx); err != nil {
-			return err
+	if alert := LowBalanceAlert(reward.ID, cash, params.Total, params.LowBalanceInr, now); alert != nil {
+		if _, err := r.db.Collection("treasury_alerts").InsertOne(sessionCtx, newTreasuryAlertDoc(*alert)); err != nil {
+			return nil, err
 		}
+	}
 
-		// Parse result
-		result = &models.RewardEvent{
-			ID:            id,
-			UserID:        params.UserID,
-			Symbol:        strings.ToUpper(params.Symbol),
-			Shares:        params.Shares,
-			GrantedPrice:  params.GrantPrice,
-			BrokerageInr:  params.Brokerage,
-			TaxesInr:      params.Taxes,
-			TotalCashOut:  params.Total,
-			RewardedAt:    params.RewardedAt,
-			EventKey:      params.EventKey,
-			CreatedAt:     time.Now(),
-		}
-		return nil
-	})
+	return &reward, nil
+}
+
+// acquirePosition reads the current position inside the transaction and
+// writes back the new share count and cost basis. A concurrent writer to the
+// same position causes a write conflict and the transaction is retried.
+func (r *Repository) acquirePosition(sessionCtx mongo.SessionContext, userID uuid.UUID, symbol string, shares, cost decimal.Decimal, at time.Time) error {
+	state, err := r.loadPosition(sessionCtx, userID, symbol)
+	if err != nil {
+		return err
+	}
+	return r.savePosition(sessionCtx, userID, symbol, state.Acquire(shares, cost, at))
+}
+
+// loadPosition reads a position; a missing document yields the zero state.
+func (r *Repository) loadPosition(ctx context.Context, userID uuid.UUID, symbol string) (PositionState, error) {
+	filter := bson.M{"user_id": userID.String(), "symbol": symbol}
+	current, err := decodeOne[positionDoc](ctx, r, "user_positions", r.db.Collection("user_positions").FindOne(ctx, filter))
+	if err != nil && !errors.Is(err, mongo.ErrNoDocuments) {
+		return PositionState{}, err
+	}
+	return PositionState{
+		Shares:          current.NetShares,
+		AvgCost:         current.AvgCost,
+		TotalCost:       current.TotalCost,
+		FirstAcquiredAt: current.FirstAcquiredAt,
+	}, nil
+}
 
-	return result, err
+func (r *Repository) savePosition(ctx context.Context, userID uuid.UUID, symbol string, state PositionState) error {
+	_, err := r.db.Collection("user_positions").UpdateOne(
+		ctx,
+		bson.M{"user_id": userID.String(), "symbol": symbol},
+		bson.M{"$set": newPositionDoc(userID, symbol, state)},
+		options.Update().SetUpsert(true),
+	)
+	return err
 }
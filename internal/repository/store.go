@@ -0,0 +1,381 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+
+	"github.com/stocky/backend/internal/models"
+)
+
+// Store is the persistence contract the services depend on. The MongoDB
+// Repository is the production implementation; memory.Store provides the
+// same semantics without an external database.
+type Store interface {
+	RewardStore
+	AdjustmentStore
+	PositionStore
+	QuoteStore
+	HoldingStore
+	ProjectionStore
+	LedgerStore
+	LedgerCheckStore
+	ChainStore
+	AccountStore
+	TreasuryStore
+	PeriodStore
+	CorporateActionStore
+	DividendStore
+}
+
+// RewardStore persists reward events together with their ledger postings.
+type RewardStore interface {
+	// CreateReward records the reward, its ledger entries and the position
+	// update atomically. It returns ErrDuplicateReward when the event key has
+	// already been used.
+	CreateReward(ctx context.Context, params RewardCreationParams) (*models.RewardEvent, error)
+	// CreateRewards records every reward in a single transaction. If any item
+	// fails nothing is written and the error is a *BatchItemError naming it.
+	CreateRewards(ctx context.Context, params []RewardCreationParams) ([]*models.RewardEvent, error)
+	ListTodayRewards(ctx context.Context, userID uuid.UUID, dayStart, dayEnd time.Time) ([]models.TodayReward, error)
+	AggregateShares(ctx context.Context, userID uuid.UUID, start, end time.Time) ([]models.TodayTotals, error)
+}
+
+// AdjustmentStore records corrections to positions together with their
+// ledger postings.
+type AdjustmentStore interface {
+	// ReverseReward plans the reversal with PlanReversal and writes the
+	// adjustment, its ledger entries and the position decrement atomically.
+	// Applied corporate actions are passed in so the shares are taken from
+	// the position the reward is held in today. It returns
+	// ErrRewardNotFound, ErrReversalExceedsGrant, ErrInsufficientShares,
+	// ErrRewardCashedOut, or ErrDuplicateAdjustment when the idempotency key
+	// has already been used.
+	ReverseReward(ctx context.Context, params ReversalParams) (*models.Adjustment, error)
+	AdjustmentByKey(ctx context.Context, key string) (*models.Adjustment, error)
+}
+
+// PositionStore exposes the running per-user positions.
+type PositionStore interface {
+	ListUserPositions(ctx context.Context, userID uuid.UUID) ([]UserPosition, error)
+	ListAllPositions(ctx context.Context) ([]RawPosition, error)
+}
+
+// QuoteStore caches the latest price per symbol and appends price history.
+type QuoteStore interface {
+	ListTrackedSymbols(ctx context.Context) ([]string, error)
+	// UpsertQuote records quote, under its upper-cased symbol, in price
+	// history and caches it unless the cached quote has a later AsOf. It
+	// returns the quote cached afterwards.
+	UpsertQuote(ctx context.Context, quote models.PriceQuote) (*models.PriceQuote, error)
+	// LatestQuote and QuotesForSymbols look symbols up upper-cased;
+	// QuotesForSymbols keys its result by the stored, upper-cased symbol.
+	LatestQuote(ctx context.Context, symbol string) (*models.PriceQuote, error)
+	QuotesForSymbols(ctx context.Context, symbols []string) (map[string]models.PriceQuote, error)
+}
+
+// HoldingStore keeps the end-of-day INR valuations per user.
+type HoldingStore interface {
+	HistoricalHoldings(ctx context.Context, userID uuid.UUID, before time.Time) ([]models.DailyINR, error)
+	// UpsertDailyHolding replaces the user's valuation for date, including
+	// the symbols it was valued at a stale quote for.
+	UpsertDailyHolding(ctx context.Context, userID uuid.UUID, date time.Time, value decimal.Decimal, staleSymbols []string) error
+}
+
+// ProjectionStore exposes the event history and raw position state needed to
+// rebuild user_positions and daily_holdings by replay.
+type ProjectionStore interface {
+	// ListRewardEvents returns matching rewards ordered by rewarded_at.
+	ListRewardEvents(ctx context.Context, filter ProjectionFilter) ([]models.RewardEvent, error)
+	// ListAdjustments returns matching adjustments ordered by created_at.
+	ListAdjustments(ctx context.Context, filter ProjectionFilter) ([]models.Adjustment, error)
+	ListPositionStates(ctx context.Context, filter ProjectionFilter) ([]PositionRecord, error)
+	SavePositionStates(ctx context.Context, records []PositionRecord) error
+	DeletePosition(ctx context.Context, userID uuid.UUID, symbol string) error
+	// PriceHistory returns snapshots for the symbols up to and including
+	// until, ordered by as_of.
+	PriceHistory(ctx context.Context, symbols []string, until time.Time) ([]models.PriceQuote, error)
+}
+
+// LedgerStore reads postings back out of ledger_entries.
+type LedgerStore interface {
+	// LedgerByEvent returns the postings of one reward or adjustment in the
+	// order they were written.
+	LedgerByEvent(ctx context.Context, eventID uuid.UUID) ([]models.LedgerPosting, error)
+	// LedgerByAccount returns up to query.Limit postings matching query
+	// ordered by created_at and then id, starting after query.After. It
+	// returns ErrInvalidCursor when After does not name a posting id of this
+	// backend.
+	LedgerByAccount(ctx context.Context, query LedgerQuery) ([]models.LedgerPosting, error)
+	// AccountBalances totals postings created at or before asOf per account,
+	// optionally for a single account code, ordered by code.
+	AccountBalances(ctx context.Context, asOf time.Time, accountCode string) ([]models.AccountBalance, error)
+}
+
+// LedgerCheckStore supports the ledger invariant checker and keeps its
+// reports.
+type LedgerCheckStore interface {
+	// UnbalancedEvents returns every event whose debits and credits differ,
+	// ordered by event id.
+	UnbalancedEvents(ctx context.Context) ([]models.EventImbalance, error)
+	SaveTrialBalance(ctx context.Context, report models.TrialBalance) error
+	// LatestTrialBalance returns the most recent report, or
+	// ErrTrialBalanceNotFound before the first check.
+	LatestTrialBalance(ctx context.Context) (*models.TrialBalance, error)
+}
+
+// ChainStore reads the reward and ledger hash chains back for verification.
+type ChainStore interface {
+	// WalkChain visits every record of chain (ChainRewards or ChainLedger),
+	// records written before chaining first and then in sequence order. It
+	// stops at and returns the first error from visit.
+	WalkChain(ctx context.Context, chain string, visit func(ChainRecord) error) error
+	// ChainHead returns the newest link of chain, or a zero link when nothing
+	// has been chained yet.
+	ChainHead(ctx context.Context, chain string) (models.ChainLink, error)
+	// LegacyRecords returns how many records of chain predate chaining, as
+	// counted by the migration that recorded them; only that many unchained
+	// records verify.
+	LegacyRecords(ctx context.Context, chain string) (int, error)
+}
+
+// AccountStore manages the chart of accounts. Every ledger write checks its
+// postings against it with PostingAccount.
+type AccountStore interface {
+	// ListAccounts returns every account, open or closed, ordered by code.
+	ListAccounts(ctx context.Context) ([]models.LedgerAccount, error)
+	// CreateAccount returns ErrAccountExists when the code is taken.
+	CreateAccount(ctx context.Context, account models.LedgerAccount) error
+	// CloseAccount stops further postings to an account. It returns
+	// ErrAccountNotFound, or ErrAccountClosed when it is already closed.
+	CloseAccount(ctx context.Context, code string, at time.Time) (*models.LedgerAccount, error)
+}
+
+// TreasuryStore records funding of the cash account and low-balance alerts.
+type TreasuryStore interface {
+	// FundTreasury writes the funding and its ledger entries atomically. It
+	// returns ErrDuplicateFunding when the reference has already been used.
+	FundTreasury(ctx context.Context, funding models.TreasuryFunding) error
+	// FundingByReference returns ErrFundingNotFound for an unused reference.
+	FundingByReference(ctx context.Context, reference string) (*models.TreasuryFunding, error)
+	// CashBalance returns the cash account's debits minus credits.
+	CashBalance(ctx context.Context) (decimal.Decimal, error)
+	// ListTreasuryAlerts returns up to limit alerts, newest first.
+	ListTreasuryAlerts(ctx context.Context, limit int) ([]models.TreasuryAlert, error)
+}
+
+// PeriodStore keeps the month-end closes of the ledger.
+type PeriodStore interface {
+	// ClosePeriod stores period as closed with its closing balances and
+	// appends event to its history. It returns ErrPeriodClosed when the
+	// period is already closed.
+	ClosePeriod(ctx context.Context, period models.LedgerPeriod, event models.PeriodEvent) error
+	// ReopenPeriod marks a closed period open and appends event to its
+	// history. It returns ErrPeriodNotFound for a period that was never
+	// closed and ErrPeriodOpen when it is not closed now.
+	ReopenPeriod(ctx context.Context, period string, event models.PeriodEvent) (*models.LedgerPeriod, error)
+	// LedgerPeriod returns ErrPeriodNotFound for a period that was never
+	// closed.
+	LedgerPeriod(ctx context.Context, period string) (*models.LedgerPeriod, error)
+	// ListPeriods returns every period that has been closed, ordered by
+	// period.
+	ListPeriods(ctx context.Context) ([]models.LedgerPeriod, error)
+}
+
+// CorporateActionStore keeps splits and bonus issues and applies them.
+type CorporateActionStore interface {
+	// CreateCorporateAction returns ErrDuplicateCorporateAction when the
+	// symbol already has an action on the same ex-date.
+	CreateCorporateAction(ctx context.Context, action models.CorporateAction) error
+	// CorporateAction returns ErrCorporateActionNotFound for an unknown id.
+	CorporateAction(ctx context.Context, id uuid.UUID) (*models.CorporateAction, error)
+	// ListCorporateActions returns matching actions ordered by ex-date and
+	// then creation.
+	ListCorporateActions(ctx context.Context, filter CorporateActionFilter) ([]models.CorporateAction, error)
+	// ApplyCorporateAction atomically writes the adjustments and postings,
+	// applies each adjustment's shares and cost to its position and marks
+	// the action applied. For a split or bonus it divides the symbol's price
+	// history and cached quote from before the ex-date by the action's
+	// factor and stores StockFactor on the stock; for a rename, merger or
+	// delisting it records the alias, deactivates the symbol and credits
+	// cash to wallets. It returns ErrCorporateActionApplied when the action
+	// is no longer pending.
+	ApplyCorporateAction(ctx context.Context, application CorporateActionApplication) error
+	// SymbolAlias returns what a renamed, merged or delisted symbol became,
+	// or ErrAliasNotFound for a symbol that still trades.
+	SymbolAlias(ctx context.Context, symbol string) (*models.SymbolAlias, error)
+}
+
+// DividendStore keeps cash dividends, the payments made to holders and the
+// user wallets they are credited to.
+type DividendStore interface {
+	// CreateDividend returns ErrDuplicateDividend when the symbol already has
+	// a dividend on the same record date.
+	CreateDividend(ctx context.Context, dividend models.Dividend) error
+	// Dividend returns ErrDividendNotFound for an unknown id.
+	Dividend(ctx context.Context, id uuid.UUID) (*models.Dividend, error)
+	// ListDividends returns matching dividends ordered by pay date and then
+	// creation.
+	ListDividends(ctx context.Context, filter DividendFilter) ([]models.Dividend, error)
+	// PayDividend atomically writes the payments and postings, adds each
+	// payment's net amount to its user's wallet and marks the dividend paid.
+	// It returns ErrDividendPaid when the dividend is no longer announced.
+	PayDividend(ctx context.Context, payout DividendPayout) error
+	// DividendPayments returns a user's payments, oldest first.
+	DividendPayments(ctx context.Context, userID uuid.UUID) ([]models.DividendPayment, error)
+	// Wallet returns the user's wallet, with a zero balance when nothing has
+	// been credited yet.
+	Wallet(ctx context.Context, userID uuid.UUID) (*models.Wallet, error)
+}
+
+// LedgerQuery selects a page of postings, of one account or of every
+// account when AccountCode is empty. From is inclusive and To exclusive;
+// zero values leave the range open.
+type LedgerQuery struct {
+	AccountCode string
+	From        time.Time
+	To          time.Time
+	After       *LedgerCursor
+	Limit       int
+}
+
+// LedgerCursor is the position of the last posting of a page.
+type LedgerCursor struct {
+	CreatedAt time.Time
+	ID        string
+}
+
+// ProjectionFilter narrows a replay to one user and/or symbol. Zero values
+// match everything; Until bounds event time when set.
+type ProjectionFilter struct {
+	UserID uuid.UUID
+	Symbol string
+	Until  time.Time
+}
+
+// PositionRecord is a position together with the key it is stored under.
+type PositionRecord struct {
+	UserID uuid.UUID
+	Symbol string
+	PositionState
+}
+
+var _ Store = (*Repository)(nil)
+
+var (
+	// ErrInvalidCursor is returned for a ledger cursor this backend did not
+	// issue.
+	ErrInvalidCursor        = errors.New("invalid ledger cursor")
+	ErrTrialBalanceNotFound = errors.New("no trial balance has been recorded")
+)
+
+// LedgerEntry is a single double-entry posting.
+type LedgerEntry struct {
+	EventID     uuid.UUID
+	AccountCode string
+	AccountType string
+	Symbol      string
+	Debit       decimal.Decimal
+	Credit      decimal.Decimal
+	StockUnits  decimal.Decimal
+	Memo        string
+	CreatedAt   time.Time
+	// Chain is set by the backend when the entry is written.
+	Chain models.ChainLink
+}
+
+// StockAccount returns the inventory account code for a symbol.
+func StockAccount(symbol string) string {
+	return fmt.Sprintf("stock_inventory:%s", strings.ToUpper(symbol))
+}
+
+// UpperSymbols returns symbols upper-cased, the form quotes are stored under.
+func UpperSymbols(symbols []string) []string {
+	upper := make([]string, len(symbols))
+	for i, symbol := range symbols {
+		upper[i] = strings.ToUpper(symbol)
+	}
+	return upper
+}
+
+// RewardPostings builds the ledger entries booked for a reward grant: stock
+// inventory and fees are debited against a single cash credit. params.Memo,
+// when set, is appended to every entry's memo.
+func RewardPostings(eventID uuid.UUID, params RewardCreationParams, now time.Time) []LedgerEntry {
+	symbol := strings.ToUpper(params.Symbol)
+	entries := []LedgerEntry{
+		{
+			EventID:     eventID,
+			AccountCode: StockAccount(symbol),
+			AccountType: "asset",
+			Symbol:      symbol,
+			Debit:       params.GrantPrice.Mul(params.Shares).Round(4),
+			StockUnits:  params.Shares,
+			Memo:        "Rewarded stock inventory",
+			CreatedAt:   now,
+		},
+	}
+	for _, line := range feeLines(params.Brokerage, params.Taxes, params.Fees) {
+		entries = append(entries, LedgerEntry{
+			EventID:     eventID,
+			AccountCode: line.account,
+			AccountType: "expense",
+			Debit:       line.amount,
+			Memo:        line.memo,
+			CreatedAt:   now,
+		})
+	}
+	entries = append(entries, LedgerEntry{
+		EventID:     eventID,
+		AccountCode: "cash",
+		AccountType: "asset",
+		Credit:      params.Total,
+		Memo:        "Cash outflow for reward",
+		CreatedAt:   now,
+	})
+	if params.Memo != "" {
+		for i := range entries {
+			entries[i].Memo += "; " + params.Memo
+		}
+	}
+	return entries
+}
+
+// feeLine is the part of a reward's fees booked to one expense account.
+type feeLine struct {
+	account string
+	memo    string
+	amount  decimal.Decimal
+}
+
+// feeLines splits fees across expense accounts. Rewards without an itemised
+// breakdown keep the original brokerage and tax lines; itemised rewards get
+// one line per non-zero charge.
+func feeLines(brokerage, taxes decimal.Decimal, fees *models.FeeBreakdown) []feeLine {
+	if fees == nil {
+		return []feeLine{
+			{"brokerage_expense", "Brokerage charges", brokerage},
+			{"tax_expense", "Statutory taxes", taxes},
+		}
+	}
+	all := []feeLine{
+		{"brokerage_expense", "Brokerage charges", fees.Brokerage},
+		{"gst_expense", "GST on charges", fees.GST},
+		{"stt_expense", "Securities transaction tax", fees.STT},
+		{"exchange_charges_expense", "Exchange transaction charges", fees.ExchangeCharges},
+		{"sebi_fees_expense", "SEBI turnover fees", fees.SEBIFees},
+		{"stamp_duty_expense", "Stamp duty", fees.StampDuty},
+	}
+	lines := all[:0]
+	for _, line := range all {
+		if !line.amount.IsZero() {
+			lines = append(lines, line)
+		}
+	}
+	return lines
+}
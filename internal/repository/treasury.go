@@ -0,0 +1,76 @@
+package repository
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+
+	"github.com/stocky/backend/internal/models"
+)
+
+var (
+	ErrInsufficientCash = errors.New("cash balance does not cover the reward")
+	ErrDuplicateFunding = errors.New("funding reference already used")
+	ErrFundingNotFound  = errors.New("funding not found")
+)
+
+// Treasury account codes. Rewards spend from CashAccount; funding credits
+// TreasuryFundingAccount, the company's contributed capital.
+const (
+	CashAccount            = "cash"
+	TreasuryFundingAccount = "treasury_funding"
+)
+
+// FundingPostings debits cash against contributed capital.
+func FundingPostings(funding models.TreasuryFunding) []LedgerEntry {
+	memo := "Treasury funding " + funding.Reference
+	return []LedgerEntry{
+		{
+			EventID:     funding.ID,
+			AccountCode: CashAccount,
+			AccountType: AccountAsset,
+			Debit:       funding.AmountInr,
+			Memo:        memo,
+			CreatedAt:   funding.CreatedAt,
+		},
+		{
+			EventID:     funding.ID,
+			AccountCode: TreasuryFundingAccount,
+			AccountType: AccountEquity,
+			Credit:      funding.AmountInr,
+			Memo:        memo,
+			CreatedAt:   funding.CreatedAt,
+		},
+	}
+}
+
+// CheckCash returns ErrInsufficientCash when spending total would take
+// balance below zero.
+func CheckCash(balance, total decimal.Decimal) error {
+	if balance.LessThan(total) {
+		return fmt.Errorf("%w: balance %s INR, reward needs %s INR", ErrInsufficientCash,
+			balance.StringFixed(2), total.StringFixed(2))
+	}
+	return nil
+}
+
+// LowBalanceAlert returns the alert for a reward, eventID, that spends total
+// INR out of balance, or nil unless that takes the balance from at or above
+// threshold to below it. Stores call it inside the reward's transaction, so
+// each crossing raises exactly one alert.
+func LowBalanceAlert(eventID uuid.UUID, balance, total, threshold decimal.Decimal, at time.Time) *models.TreasuryAlert {
+	after := balance.Sub(total)
+	if !threshold.IsPositive() || balance.LessThan(threshold) || !after.LessThan(threshold) {
+		return nil
+	}
+	return &models.TreasuryAlert{
+		ID:           uuid.New(),
+		EventID:      eventID,
+		BalanceInr:   after,
+		ThresholdInr: threshold,
+		CreatedAt:    at.UTC(),
+	}
+}
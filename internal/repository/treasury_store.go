@@ -0,0 +1,104 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"github.com/shopspring/decimal"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/stocky/backend/internal/models"
+)
+
+func (r *Repository) FundTreasury(ctx context.Context, funding models.TreasuryFunding) error {
+	session, err := r.client.StartSession()
+	if err != nil {
+		return err
+	}
+	defer session.EndSession(ctx)
+
+	_, err = session.WithTransaction(ctx, func(sessionCtx mongo.SessionContext) (interface{}, error) {
+		_, err := r.db.Collection("treasury_fundings").InsertOne(sessionCtx, newTreasuryFundingDoc(funding))
+		if mongo.IsDuplicateKeyError(err) {
+			return nil, ErrDuplicateFunding
+		}
+		if err != nil {
+			return nil, err
+		}
+		return nil, r.insertLedgerEntries(sessionCtx, FundingPostings(funding))
+	})
+	return err
+}
+
+func (r *Repository) FundingByReference(ctx context.Context, reference string) (*models.TreasuryFunding, error) {
+	doc, err := decodeOne[treasuryFundingDoc](ctx, r, "treasury_fundings",
+		r.db.Collection("treasury_fundings").FindOne(ctx, bson.M{"reference": reference}))
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, ErrFundingNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	funding, err := doc.toModel()
+	if err != nil {
+		return nil, err
+	}
+	return &funding, nil
+}
+
+func (r *Repository) CashBalance(ctx context.Context) (decimal.Decimal, error) {
+	cursor, err := r.db.Collection("ledger_entries").Aggregate(ctx, []bson.M{
+		{"$match": bson.M{"account_code": CashAccount}},
+		{"$group": bson.M{
+			"_id":     nil,
+			"balance": bson.M{"$sum": bson.M{"$subtract": bson.A{"$debit_inr", "$credit_inr"}}},
+		}},
+	})
+	if err != nil {
+		return decimal.Zero, err
+	}
+	var totals []struct {
+		Balance decimal.Decimal `bson:"balance"`
+	}
+	if err := cursor.All(ctx, &totals); err != nil {
+		return decimal.Zero, err
+	}
+	if len(totals) == 0 {
+		return decimal.Zero, nil
+	}
+	return totals[0].Balance, nil
+}
+
+// lockedCashBalance reads the cash balance inside the caller's transaction.
+// Snapshot reads alone would let two concurrent grants spend the same
+// balance, or both see it cross the low-balance threshold, so the cash
+// account document is touched first: concurrent readers then hit a write
+// conflict and one is retried.
+func (r *Repository) lockedCashBalance(sessionCtx mongo.SessionContext) (decimal.Decimal, error) {
+	_, err := r.db.Collection("ledger_accounts").UpdateOne(sessionCtx, bson.M{"code": CashAccount},
+		bson.M{"$currentDate": bson.M{"balance_checked_at": true}})
+	if err != nil {
+		return decimal.Zero, err
+	}
+	return r.CashBalance(sessionCtx)
+}
+
+func (r *Repository) ListTreasuryAlerts(ctx context.Context, limit int) ([]models.TreasuryAlert, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: -1}}).SetLimit(int64(limit))
+	cursor, err := r.db.Collection("treasury_alerts").Find(ctx, bson.M{}, opts)
+	if err != nil {
+		return nil, err
+	}
+	var items []models.TreasuryAlert
+	err = decodeEach(ctx, r, "treasury_alerts", cursor, func(doc treasuryAlertDoc) error {
+		alert, err := doc.toModel()
+		if err != nil {
+			return err
+		}
+		items = append(items, alert)
+		return nil
+	})
+	return items, err
+}
@@ -0,0 +1,385 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+
+	"github.com/stocky/backend/internal/models"
+	"github.com/stocky/backend/internal/repository"
+)
+
+// CorporateActionInput registers a corporate action. Ratios read as
+// "RatioNew for RatioHeld": a 2:1 split turns one share into two, a 1:1
+// bonus gives one extra share per share held and a 2:3 merger gives two
+// NewSymbol shares for every three held. A rename is always 1:1 and a
+// delisting takes no ratio. CashPrice is paid per NewSymbol share for merger
+// fractions, or per share on a delisting; without it a delisted position is
+// frozen at the last price. Dates are YYYY-MM-DD in IST.
+type CorporateActionInput struct {
+	Symbol     string          `json:"symbol"`
+	Kind       string          `json:"kind"`
+	NewSymbol  string          `json:"newSymbol"`
+	RatioNew   int64           `json:"ratioNew"`
+	RatioHeld  int64           `json:"ratioHeld"`
+	CashPrice  decimal.Decimal `json:"cashPriceInr"`
+	RecordDate string          `json:"recordDate"`
+	ExDate     string          `json:"exDate"`
+	Note       string          `json:"note"`
+}
+
+// CorporateActionService registers splits, bonus issues, renames, mergers
+// and delistings and applies them to positions, the ledger and prices once
+// their ex-date arrives.
+type CorporateActionService struct {
+	repo       repository.Store
+	projection *ProjectionService
+}
+
+func NewCorporateActionService(repo repository.Store, projection *ProjectionService) *CorporateActionService {
+	return &CorporateActionService{repo: repo, projection: projection}
+}
+
+// Register records a pending action. Prices before the ex-date are divided
+// by the factor when the action is applied, so an action may not fall on or
+// before one already applied to the symbol. A symbol that has been renamed,
+// merged or delisted takes no further actions.
+func (s *CorporateActionService) Register(ctx context.Context, input CorporateActionInput) (*models.CorporateAction, error) {
+	symbol := strings.ToUpper(strings.TrimSpace(input.Symbol))
+	if symbol == "" {
+		return nil, fmt.Errorf("%w: symbol is required", ErrInvalidInput)
+	}
+	newSymbol := strings.ToUpper(strings.TrimSpace(input.NewSymbol))
+	if err := validateActionKind(&input, symbol, newSymbol); err != nil {
+		return nil, err
+	}
+	factor, err := repository.ActionFactor(input.Kind, input.RatioNew, input.RatioHeld)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidInput, err)
+	}
+	if repository.RescalesPrices(input.Kind) && factor.LessThanOrEqual(decimal.NewFromInt(1)) {
+		return nil, fmt.Errorf("%w: a consolidation is not supported; the ratio must increase the holding", ErrInvalidInput)
+	}
+	recordDate, err := repository.ParseActionDate(input.RecordDate)
+	if err != nil {
+		return nil, fmt.Errorf("%w: recordDate: %v", ErrInvalidInput, err)
+	}
+	exDate, err := repository.ParseActionDate(input.ExDate)
+	if err != nil {
+		return nil, fmt.Errorf("%w: exDate: %v", ErrInvalidInput, err)
+	}
+	if exDate.After(recordDate) {
+		return nil, fmt.Errorf("%w: exDate must not be after recordDate", ErrInvalidInput)
+	}
+
+	applied, err := s.repo.ListCorporateActions(ctx, repository.CorporateActionFilter{Symbol: symbol, Status: repository.ActionApplied})
+	if err != nil {
+		return nil, err
+	}
+	if n := len(applied); n > 0 && !exDate.After(applied[n-1].ExDate) {
+		return nil, fmt.Errorf("%w: %s already has an action applied with ex-date on or after %s",
+			ErrUnprocessable, symbol, input.ExDate)
+	}
+	if err := s.checkTrading(ctx, symbol); err != nil {
+		return nil, err
+	}
+	if newSymbol != "" {
+		if err := s.checkTrading(ctx, newSymbol); err != nil {
+			return nil, err
+		}
+	}
+
+	action := models.CorporateAction{
+		ID:           uuid.New(),
+		Symbol:       symbol,
+		Kind:         input.Kind,
+		NewSymbol:    newSymbol,
+		RatioNew:     input.RatioNew,
+		RatioHeld:    input.RatioHeld,
+		Factor:       factor,
+		CashPrice:    input.CashPrice,
+		RecordDate:   recordDate,
+		ExDate:       exDate,
+		Status:       repository.ActionPending,
+		Note:         strings.TrimSpace(input.Note),
+		CreatedAt:    time.Now().UTC(),
+		SharesIssued: decimal.Zero,
+		CashPaid:     decimal.Zero,
+		FrozenPrice:  decimal.Zero,
+	}
+	if err := s.repo.CreateCorporateAction(ctx, action); err != nil {
+		if errors.Is(err, repository.ErrDuplicateCorporateAction) {
+			return nil, fmt.Errorf("%w: %v", ErrConflict, err)
+		}
+		return nil, err
+	}
+	return &action, nil
+}
+
+// validateActionKind checks the fields that depend on the kind and fills in
+// the 1:1 ratio of a rename or delisting.
+func validateActionKind(input *CorporateActionInput, symbol, newSymbol string) error {
+	if input.CashPrice.IsNegative() {
+		return fmt.Errorf("%w: cashPriceInr must not be negative", ErrInvalidInput)
+	}
+	switch input.Kind {
+	case repository.CorporateSplit, repository.CorporateBonus:
+		if newSymbol != "" || !input.CashPrice.IsZero() {
+			return fmt.Errorf("%w: a %s takes no newSymbol or cashPriceInr", ErrInvalidInput, input.Kind)
+		}
+		return nil
+	case repository.CorporateRename, repository.CorporateMerger:
+		if newSymbol == "" {
+			return fmt.Errorf("%w: newSymbol is required for a %s", ErrInvalidInput, input.Kind)
+		}
+		if newSymbol == symbol {
+			return fmt.Errorf("%w: newSymbol must differ from symbol", ErrInvalidInput)
+		}
+		if input.Kind == repository.CorporateMerger {
+			return nil
+		}
+		if !input.CashPrice.IsZero() {
+			return fmt.Errorf("%w: a rename takes no cashPriceInr", ErrInvalidInput)
+		}
+	case repository.CorporateDelisting:
+		if newSymbol != "" {
+			return fmt.Errorf("%w: a delisting takes no newSymbol", ErrInvalidInput)
+		}
+	default:
+		return fmt.Errorf("%w: kind must be one of %s", ErrInvalidInput, strings.Join(repository.CorporateKinds, ", "))
+	}
+	if input.RatioNew == 0 && input.RatioHeld == 0 {
+		input.RatioNew, input.RatioHeld = 1, 1
+	}
+	if input.RatioNew != 1 || input.RatioHeld != 1 {
+		return fmt.Errorf("%w: a %s is always 1:1", ErrInvalidInput, input.Kind)
+	}
+	return nil
+}
+
+// checkTrading rejects a symbol that was renamed, merged or delisted, or has
+// such an action pending.
+func (s *CorporateActionService) checkTrading(ctx context.Context, symbol string) error {
+	alias, err := s.repo.SymbolAlias(ctx, symbol)
+	switch {
+	case err == nil:
+		return fmt.Errorf("%w: %s stopped trading on %s (%s)", ErrUnprocessable, symbol,
+			alias.EffectiveAt.Format(time.DateOnly), alias.Kind)
+	case !errors.Is(err, repository.ErrAliasNotFound):
+		return err
+	}
+	pending, err := s.repo.ListCorporateActions(ctx, repository.CorporateActionFilter{Symbol: symbol, Status: repository.ActionPending})
+	if err != nil {
+		return err
+	}
+	for _, action := range pending {
+		if repository.EndsSymbol(action.Kind) {
+			return fmt.Errorf("%w: %s has a pending %s", ErrUnprocessable, symbol, repository.ActionDescription(action))
+		}
+	}
+	return nil
+}
+
+func (s *CorporateActionService) Actions(ctx context.Context, filter repository.CorporateActionFilter) ([]models.CorporateAction, error) {
+	filter.Symbol = strings.ToUpper(strings.TrimSpace(filter.Symbol))
+	switch filter.Status {
+	case "", repository.ActionPending, repository.ActionApplied:
+	default:
+		return nil, fmt.Errorf("%w: status must be %q or %q", ErrInvalidInput, repository.ActionPending, repository.ActionApplied)
+	}
+	actions, err := s.repo.ListCorporateActions(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	if actions == nil {
+		actions = []models.CorporateAction{}
+	}
+	return actions, nil
+}
+
+func (s *CorporateActionService) Action(ctx context.Context, id uuid.UUID) (*models.CorporateAction, error) {
+	action, err := s.repo.CorporateAction(ctx, id)
+	if errors.Is(err, repository.ErrCorporateActionNotFound) {
+		return nil, fmt.Errorf("%w: %v", ErrNotFound, err)
+	}
+	return action, err
+}
+
+// ApplyDue applies every pending action whose ex-date is at or before now,
+// oldest first. It stops at the first failure and returns what was applied
+// before it.
+func (s *CorporateActionService) ApplyDue(ctx context.Context, now time.Time) ([]models.CorporateAction, error) {
+	applied := []models.CorporateAction{}
+	pending, err := s.repo.ListCorporateActions(ctx, repository.CorporateActionFilter{Status: repository.ActionPending})
+	if err != nil {
+		return applied, err
+	}
+	for _, action := range pending {
+		if action.ExDate.After(now) {
+			break
+		}
+		result, err := s.apply(ctx, action, now)
+		if err != nil {
+			return applied, fmt.Errorf("%s: %w", repository.ActionDescription(action), err)
+		}
+		applied = append(applied, *result)
+	}
+	return applied, nil
+}
+
+// apply credits each holder with the new shares in one store transaction,
+// then revalues their daily holdings from the ex-date so the snapshot taken
+// between the ex-date and the application is corrected.
+func (s *CorporateActionService) apply(ctx context.Context, action models.CorporateAction, now time.Time) (*models.CorporateAction, error) {
+	if repository.EndsSymbol(action.Kind) {
+		return s.retire(ctx, action, now)
+	}
+
+	// Entitlement is what each user held when the market closed before the
+	// ex-date; shares granted from the ex-date on already trade adjusted.
+	events, err := s.projection.loadEvents(ctx, repository.ProjectionFilter{
+		Symbol: action.Symbol,
+		Until:  action.ExDate.Add(-time.Nanosecond),
+	})
+	if err != nil {
+		return nil, err
+	}
+	held := make(map[uuid.UUID]decimal.Decimal)
+	for key, state := range replayPositions(events) {
+		if state.Shares.IsPositive() {
+			held[key.userID] = state.Shares
+		}
+	}
+
+	previous, err := s.repo.ListCorporateActions(ctx, repository.CorporateActionFilter{Symbol: action.Symbol, Status: repository.ActionApplied})
+	if err != nil {
+		return nil, err
+	}
+	stockFactor := action.Factor
+	for _, earlier := range previous {
+		if repository.RescalesPrices(earlier.Kind) {
+			stockFactor = stockFactor.Mul(earlier.Factor)
+		}
+	}
+
+	adjustments, postings := repository.PlanCorporateAction(action, held, now)
+	action.Status = repository.ActionApplied
+	action.AppliedAt = &now
+	action.Holders = len(adjustments)
+	action.SharesIssued = decimal.Zero
+	for _, adjustment := range adjustments {
+		action.SharesIssued = action.SharesIssued.Add(adjustment.Shares)
+	}
+
+	return s.write(ctx, repository.CorporateActionApplication{
+		Action:      action,
+		Adjustments: adjustments,
+		Postings:    postings,
+		StockFactor: stockFactor,
+	})
+}
+
+// retire moves every current holder out of a renamed, merged or delisted
+// symbol. Positions are taken as they stand rather than replayed to the
+// ex-date, since nothing can be granted in the symbol once it stops trading
+// and the inventory book value being moved is today's. A delisting without
+// a cash-out keeps the positions and freezes them at the last quote.
+func (s *CorporateActionService) retire(ctx context.Context, action models.CorporateAction, now time.Time) (*models.CorporateAction, error) {
+	alias := models.SymbolAlias{
+		Symbol:      action.Symbol,
+		Target:      action.NewSymbol,
+		Kind:        action.Kind,
+		ActionID:    action.ID,
+		EffectiveAt: action.ExDate,
+	}
+	if action.Kind == repository.CorporateDelisting {
+		quote, err := s.repo.LatestQuote(ctx, action.Symbol)
+		switch {
+		case errors.Is(err, repository.ErrQuoteNotFound) && action.CashPrice.IsZero():
+			return nil, fmt.Errorf("%w: %s has no quote to freeze", ErrUnprocessable, action.Symbol)
+		case err == nil:
+			action.FrozenPrice = quote.Price
+		case !errors.Is(err, repository.ErrQuoteNotFound):
+			return nil, err
+		}
+		if action.CashPrice.IsPositive() {
+			action.FrozenPrice = action.CashPrice
+		}
+	}
+
+	records, err := s.repo.ListPositionStates(ctx, repository.ProjectionFilter{Symbol: action.Symbol})
+	if err != nil {
+		return nil, err
+	}
+	positions := make(map[uuid.UUID]repository.PositionState, len(records))
+	for _, record := range records {
+		if record.Shares.IsPositive() {
+			positions[record.UserID] = record.PositionState
+		}
+	}
+	book := decimal.Zero
+	balances, err := s.repo.AccountBalances(ctx, now, repository.StockAccount(action.Symbol))
+	if err != nil {
+		return nil, err
+	}
+	for _, balance := range balances {
+		book = book.Add(balance.BalanceInr)
+	}
+
+	var (
+		adjustments []models.Adjustment
+		postings    []repository.LedgerEntry
+	)
+	if action.Kind != repository.CorporateDelisting || action.CashPrice.IsPositive() {
+		adjustments, postings = repository.PlanSymbolChange(action, positions, book, now)
+	}
+	action.Status = repository.ActionApplied
+	action.AppliedAt = &now
+	action.Holders = len(positions)
+	action.SharesIssued, action.CashPaid = decimal.Zero, decimal.Zero
+	for _, adjustment := range adjustments {
+		if adjustment.Symbol == action.NewSymbol {
+			action.SharesIssued = action.SharesIssued.Add(adjustment.Shares)
+		}
+		action.CashPaid = action.CashPaid.Add(adjustment.CashInr)
+	}
+	return s.write(ctx, repository.CorporateActionApplication{
+		Action:      action,
+		Adjustments: adjustments,
+		Postings:    postings,
+		Alias:       &alias,
+	})
+}
+
+// write stores an application and revalues the affected users' holdings
+// from the ex-date.
+func (s *CorporateActionService) write(ctx context.Context, application repository.CorporateActionApplication) (*models.CorporateAction, error) {
+	err := s.repo.ApplyCorporateAction(ctx, application)
+	switch {
+	case errors.Is(err, repository.ErrCorporateActionApplied):
+		return nil, fmt.Errorf("%w: %v", ErrConflict, err)
+	case errors.Is(err, repository.ErrCorporateActionNotFound):
+		return nil, fmt.Errorf("%w: %v", ErrNotFound, err)
+	case err != nil:
+		return nil, postingRejected(err)
+	}
+
+	seen := make(map[uuid.UUID]bool, len(application.Adjustments))
+	userIDs := make([]uuid.UUID, 0, len(application.Adjustments))
+	for _, adjustment := range application.Adjustments {
+		if !seen[adjustment.UserID] {
+			seen[adjustment.UserID] = true
+			userIDs = append(userIDs, adjustment.UserID)
+		}
+	}
+	action := application.Action
+	if err := s.projection.RevalueHoldings(ctx, userIDs, action.ExDate); err != nil {
+		return nil, fmt.Errorf("applied, but revaluing holdings failed: %w", err)
+	}
+	return &action, nil
+}
@@ -0,0 +1,216 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+
+	"github.com/stocky/backend/internal/config"
+	"github.com/stocky/backend/internal/models"
+	"github.com/stocky/backend/internal/repository"
+)
+
+// DividendInput announces a cash dividend. Dates are YYYY-MM-DD in IST; a
+// missing TDSRatePct falls back to DIVIDEND_TDS_RATE_PCT.
+type DividendInput struct {
+	Symbol         string           `json:"symbol"`
+	AmountPerShare decimal.Decimal  `json:"amountPerShareInr"`
+	RecordDate     string           `json:"recordDate"`
+	PayDate        string           `json:"payDate"`
+	TDSRatePct     *decimal.Decimal `json:"tdsRatePct"`
+	Note           string           `json:"note"`
+}
+
+// WalletStatement is a user's wallet balance with the dividends and the
+// merger cash-in-lieu or delisting cash-outs credited to it, oldest first.
+type WalletStatement struct {
+	models.Wallet
+	Dividends        []models.DividendPayment `json:"dividends"`
+	CorporateActions []models.Adjustment      `json:"corporateActions"`
+}
+
+// DividendService announces cash dividends and pays them into user wallets
+// once their pay date arrives.
+type DividendService struct {
+	repo       repository.Store
+	projection *ProjectionService
+	cc         config.CorporateConfig
+}
+
+func NewDividendService(repo repository.Store, projection *ProjectionService, cc config.CorporateConfig) *DividendService {
+	return &DividendService{repo: repo, projection: projection, cc: cc}
+}
+
+// Announce records a dividend to be paid on its pay date.
+func (s *DividendService) Announce(ctx context.Context, input DividendInput) (*models.Dividend, error) {
+	symbol := strings.ToUpper(strings.TrimSpace(input.Symbol))
+	if symbol == "" {
+		return nil, fmt.Errorf("%w: symbol is required", ErrInvalidInput)
+	}
+	if !input.AmountPerShare.IsPositive() {
+		return nil, fmt.Errorf("%w: amountPerShareInr must be positive", ErrInvalidInput)
+	}
+	rate := s.cc.DefaultTDSRate
+	if input.TDSRatePct != nil {
+		rate = *input.TDSRatePct
+	}
+	if rate.IsNegative() || rate.GreaterThan(decimal.NewFromInt(100)) {
+		return nil, fmt.Errorf("%w: tdsRatePct must be between 0 and 100", ErrInvalidInput)
+	}
+	recordDate, err := repository.ParseActionDate(input.RecordDate)
+	if err != nil {
+		return nil, fmt.Errorf("%w: recordDate: %v", ErrInvalidInput, err)
+	}
+	payDate, err := repository.ParseActionDate(input.PayDate)
+	if err != nil {
+		return nil, fmt.Errorf("%w: payDate: %v", ErrInvalidInput, err)
+	}
+	if payDate.Before(recordDate) {
+		return nil, fmt.Errorf("%w: payDate must not be before recordDate", ErrInvalidInput)
+	}
+
+	dividend := models.Dividend{
+		ID:             uuid.New(),
+		Symbol:         symbol,
+		AmountPerShare: input.AmountPerShare,
+		RecordDate:     recordDate,
+		PayDate:        payDate,
+		TDSRate:        rate,
+		Status:         repository.DividendAnnounced,
+		Note:           strings.TrimSpace(input.Note),
+		CreatedAt:      time.Now().UTC(),
+		GrossInr:       decimal.Zero,
+		TDSInr:         decimal.Zero,
+		NetInr:         decimal.Zero,
+	}
+	if err := s.repo.CreateDividend(ctx, dividend); err != nil {
+		if errors.Is(err, repository.ErrDuplicateDividend) {
+			return nil, fmt.Errorf("%w: %v", ErrConflict, err)
+		}
+		return nil, err
+	}
+	return &dividend, nil
+}
+
+func (s *DividendService) Dividends(ctx context.Context, filter repository.DividendFilter) ([]models.Dividend, error) {
+	filter.Symbol = strings.ToUpper(strings.TrimSpace(filter.Symbol))
+	switch filter.Status {
+	case "", repository.DividendAnnounced, repository.DividendPaid:
+	default:
+		return nil, fmt.Errorf("%w: status must be %q or %q", ErrInvalidInput, repository.DividendAnnounced, repository.DividendPaid)
+	}
+	dividends, err := s.repo.ListDividends(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	if dividends == nil {
+		dividends = []models.Dividend{}
+	}
+	return dividends, nil
+}
+
+func (s *DividendService) Dividend(ctx context.Context, id uuid.UUID) (*models.Dividend, error) {
+	dividend, err := s.repo.Dividend(ctx, id)
+	if errors.Is(err, repository.ErrDividendNotFound) {
+		return nil, fmt.Errorf("%w: %v", ErrNotFound, err)
+	}
+	return dividend, err
+}
+
+// PayDue pays every announced dividend whose pay date is at or before now
+// and whose record date has ended, oldest first. It stops at the first
+// failure and returns what was paid before it.
+func (s *DividendService) PayDue(ctx context.Context, now time.Time) ([]models.Dividend, error) {
+	paid := []models.Dividend{}
+	announced, err := s.repo.ListDividends(ctx, repository.DividendFilter{Status: repository.DividendAnnounced})
+	if err != nil {
+		return paid, err
+	}
+	for _, dividend := range announced {
+		if dividend.PayDate.After(now) {
+			break
+		}
+		if dividend.RecordDate.Add(24 * time.Hour).After(now) {
+			continue
+		}
+		result, err := s.pay(ctx, dividend, now)
+		if err != nil {
+			return paid, fmt.Errorf("%s: %w", repository.DividendDescription(dividend), err)
+		}
+		paid = append(paid, *result)
+	}
+	return paid, nil
+}
+
+// pay credits every holder of record in one store transaction.
+func (s *DividendService) pay(ctx context.Context, dividend models.Dividend, now time.Time) (*models.Dividend, error) {
+	// Rewards are delivered at once, so a user holds the shares on the
+	// register as soon as they are granted: everything up to the end of the
+	// record date counts, including splits applied before it.
+	events, err := s.projection.loadEvents(ctx, repository.ProjectionFilter{
+		Symbol: dividend.Symbol,
+		Until:  dividend.RecordDate.Add(24*time.Hour - time.Nanosecond),
+	})
+	if err != nil {
+		return nil, err
+	}
+	held := make(map[uuid.UUID]decimal.Decimal)
+	for key, state := range replayPositions(events) {
+		if state.Shares.IsPositive() {
+			held[key.userID] = state.Shares
+		}
+	}
+
+	payments, postings := repository.PlanDividend(dividend, held, now)
+	dividend.Status = repository.DividendPaid
+	dividend.PaidAt = &now
+	dividend.Holders = len(payments)
+	dividend.GrossInr, dividend.TDSInr, dividend.NetInr = decimal.Zero, decimal.Zero, decimal.Zero
+	for _, payment := range payments {
+		dividend.GrossInr = dividend.GrossInr.Add(payment.GrossInr)
+		dividend.TDSInr = dividend.TDSInr.Add(payment.TDSInr)
+		dividend.NetInr = dividend.NetInr.Add(payment.NetInr)
+	}
+
+	err = s.repo.PayDividend(ctx, repository.DividendPayout{Dividend: dividend, Payments: payments, Postings: postings})
+	switch {
+	case errors.Is(err, repository.ErrDividendPaid):
+		return nil, fmt.Errorf("%w: %v", ErrConflict, err)
+	case errors.Is(err, repository.ErrDividendNotFound):
+		return nil, fmt.Errorf("%w: %v", ErrNotFound, err)
+	case err != nil:
+		return nil, postingRejected(err)
+	}
+	return &dividend, nil
+}
+
+// Wallet returns the user's balance and the history of credits to it.
+func (s *DividendService) Wallet(ctx context.Context, userID uuid.UUID) (*WalletStatement, error) {
+	wallet, err := s.repo.Wallet(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	payments, err := s.repo.DividendPayments(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if payments == nil {
+		payments = []models.DividendPayment{}
+	}
+	adjustments, err := s.repo.ListAdjustments(ctx, repository.ProjectionFilter{UserID: userID})
+	if err != nil {
+		return nil, err
+	}
+	cash := []models.Adjustment{}
+	for _, adjustment := range adjustments {
+		if repository.EndsSymbol(adjustment.Kind) && adjustment.CashInr.IsPositive() {
+			cash = append(cash, adjustment)
+		}
+	}
+	return &WalletStatement{Wallet: *wallet, Dividends: payments, CorporateActions: cash}, nil
+}
@@ -5,4 +5,13 @@ import "errors"
 var (
 	ErrConflict = errors.New("resource already exists")
 	ErrNotFound = errors.New("resource not found")
+	// ErrInvalidInput wraps validation failures so callers can tell them
+	// apart from storage errors.
+	ErrInvalidInput = errors.New("invalid input")
+	// ErrUnprocessable marks a valid request that the current state cannot
+	// satisfy.
+	ErrUnprocessable = errors.New("request cannot be applied")
+	// ErrStalePrice means the cached quote is older than the configured
+	// maximum and could not be refreshed.
+	ErrStalePrice = errors.New("price quote is stale")
 )
@@ -0,0 +1,120 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/stocky/backend/internal/models"
+	"github.com/stocky/backend/internal/repository"
+)
+
+// AccountInput describes a ledger account to open. Currency defaults to
+// INR; a stock inventory code implies its symbol.
+type AccountInput struct {
+	Code     string
+	Type     string
+	Currency string
+	Symbol   string
+}
+
+// Accounts returns the chart of accounts.
+func (s *LedgerService) Accounts(ctx context.Context) ([]models.LedgerAccount, error) {
+	items, err := s.repo.ListAccounts(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if items == nil {
+		items = []models.LedgerAccount{}
+	}
+	return items, nil
+}
+
+// CreateAccount opens a new account.
+func (s *LedgerService) CreateAccount(ctx context.Context, input AccountInput) (*models.LedgerAccount, error) {
+	account, err := newAccount(input)
+	if err != nil {
+		return nil, err
+	}
+	account.CreatedAt = time.Now().UTC()
+	err = s.repo.CreateAccount(ctx, account)
+	if errors.Is(err, repository.ErrAccountExists) {
+		return nil, fmt.Errorf("%w: account %s", ErrConflict, account.Code)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &account, nil
+}
+
+func newAccount(input AccountInput) (models.LedgerAccount, error) {
+	account := models.LedgerAccount{
+		Code:     normalizeAccountCode(input.Code),
+		Type:     strings.ToLower(strings.TrimSpace(input.Type)),
+		Currency: strings.ToUpper(strings.TrimSpace(input.Currency)),
+		Symbol:   strings.ToUpper(strings.TrimSpace(input.Symbol)),
+	}
+	if account.Code == "" || strings.ContainsAny(account.Code, " \t\n/") {
+		return account, fmt.Errorf("%w: account code is required and may not contain spaces or slashes", ErrInvalidInput)
+	}
+	if !slices.Contains(repository.AccountTypes, account.Type) {
+		return account, fmt.Errorf("%w: type must be one of %s", ErrInvalidInput, strings.Join(repository.AccountTypes, ", "))
+	}
+	if account.Currency == "" {
+		account.Currency = repository.DefaultCurrency
+	}
+	if len(account.Currency) != 3 {
+		return account, fmt.Errorf("%w: currency must be a three-letter code", ErrInvalidInput)
+	}
+	if symbol, ok := strings.CutPrefix(account.Code, "stock_inventory:"); ok {
+		if account.Symbol != "" && account.Symbol != symbol {
+			return account, fmt.Errorf("%w: %s is the inventory account of %s", ErrInvalidInput, account.Code, symbol)
+		}
+		if account.Type != repository.AccountAsset {
+			return account, fmt.Errorf("%w: stock inventory accounts are assets", ErrInvalidInput)
+		}
+		account.Symbol = symbol
+	}
+	return account, nil
+}
+
+// CloseAccount stops further postings to an account. Only accounts with no
+// INR balance and no stock units can be closed.
+func (s *LedgerService) CloseAccount(ctx context.Context, code string) (*models.LedgerAccount, error) {
+	code = normalizeAccountCode(code)
+	now := time.Now().UTC()
+	balances, err := s.repo.AccountBalances(ctx, now, code)
+	if err != nil {
+		return nil, err
+	}
+	for _, balance := range balances {
+		if !balance.BalanceInr.IsZero() || !balance.StockUnits.IsZero() {
+			return nil, fmt.Errorf("%w: %s has a balance of %s INR and %s units",
+				ErrUnprocessable, code, balance.BalanceInr.StringFixed(4), balance.StockUnits.String())
+		}
+	}
+
+	account, err := s.repo.CloseAccount(ctx, code, now)
+	switch {
+	case errors.Is(err, repository.ErrAccountNotFound):
+		return nil, fmt.Errorf("%w: account %s", ErrNotFound, code)
+	case errors.Is(err, repository.ErrAccountClosed):
+		return nil, fmt.Errorf("%w: %s is already closed", ErrUnprocessable, code)
+	case err != nil:
+		return nil, err
+	}
+	return account, nil
+}
+
+// postingRejected reports a ledger write refused by the chart of accounts or
+// the overdraft policy as ErrUnprocessable and passes other errors through.
+func postingRejected(err error) error {
+	if errors.Is(err, repository.ErrAccountNotFound) || errors.Is(err, repository.ErrAccountClosed) ||
+		errors.Is(err, repository.ErrInsufficientCash) || errors.Is(err, repository.ErrPeriodClosed) {
+		return fmt.Errorf("%w: %v", ErrUnprocessable, err)
+	}
+	return err
+}
@@ -0,0 +1,114 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/shopspring/decimal"
+
+	"github.com/stocky/backend/internal/models"
+	"github.com/stocky/backend/internal/repository"
+)
+
+// CheckLedger verifies the ledger invariants, records the trial balance and
+// returns it:
+//   - every event's debits equal its credits;
+//   - total debits equal total credits;
+//   - each stock_inventory:<SYM> account holds as many units as the users'
+//     positions in SYM add up to.
+//
+// Violations are reported, not returned as errors; an error means the check
+// itself could not run.
+func (s *LedgerService) CheckLedger(ctx context.Context) (*models.TrialBalance, error) {
+	checkedAt := time.Now().UTC()
+	accounts, err := s.repo.AccountBalances(ctx, checkedAt, "")
+	if err != nil {
+		return nil, fmt.Errorf("account balances: %w", err)
+	}
+	unbalanced, err := s.repo.UnbalancedEvents(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("unbalanced events: %w", err)
+	}
+	positions, err := s.repo.ListAllPositions(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("positions: %w", err)
+	}
+
+	report := models.TrialBalance{
+		CheckedAt:        checkedAt,
+		Accounts:         accounts,
+		UnbalancedEvents: unbalanced,
+		StockMismatches:  stockMismatches(accounts, positions),
+	}
+	if report.Accounts == nil {
+		report.Accounts = []models.AccountBalance{}
+	}
+	if report.UnbalancedEvents == nil {
+		report.UnbalancedEvents = []models.EventImbalance{}
+	}
+	for _, account := range accounts {
+		report.TotalDebitInr = report.TotalDebitInr.Add(account.DebitInr)
+		report.TotalCreditInr = report.TotalCreditInr.Add(account.CreditInr)
+	}
+	report.Balanced = report.TotalDebitInr.Equal(report.TotalCreditInr) &&
+		len(report.UnbalancedEvents) == 0 && len(report.StockMismatches) == 0
+
+	if err := s.repo.SaveTrialBalance(ctx, report); err != nil {
+		return nil, fmt.Errorf("save trial balance: %w", err)
+	}
+	return &report, nil
+}
+
+// LatestCheck returns the most recently recorded trial balance.
+func (s *LedgerService) LatestCheck(ctx context.Context) (*models.TrialBalance, error) {
+	report, err := s.repo.LatestTrialBalance(ctx)
+	if errors.Is(err, repository.ErrTrialBalanceNotFound) {
+		return nil, fmt.Errorf("%w: %v", ErrNotFound, err)
+	}
+	return report, err
+}
+
+// stockMismatches compares stock inventory units with position shares per
+// symbol. A symbol present on only one side counts as zero on the other.
+func stockMismatches(accounts []models.AccountBalance, positions []repository.RawPosition) []models.StockMismatch {
+	ledger := make(map[string]decimal.Decimal)
+	for _, account := range accounts {
+		symbol, ok := strings.CutPrefix(account.AccountCode, "stock_inventory:")
+		if !ok {
+			continue
+		}
+		ledger[symbol] = ledger[symbol].Add(account.StockUnits)
+	}
+	held := make(map[string]decimal.Decimal)
+	for _, pos := range positions {
+		symbol := strings.ToUpper(pos.Symbol)
+		held[symbol] = held[symbol].Add(pos.Shares)
+	}
+
+	symbols := make(map[string]struct{}, len(ledger))
+	for symbol := range ledger {
+		symbols[symbol] = struct{}{}
+	}
+	for symbol := range held {
+		symbols[symbol] = struct{}{}
+	}
+
+	mismatches := []models.StockMismatch{}
+	for symbol := range symbols {
+		if ledger[symbol].Equal(held[symbol]) {
+			continue
+		}
+		mismatches = append(mismatches, models.StockMismatch{
+			Symbol:          symbol,
+			LedgerUnits:     ledger[symbol],
+			PositionUnits:   held[symbol],
+			DifferenceUnits: ledger[symbol].Sub(held[symbol]),
+		})
+	}
+	sort.Slice(mismatches, func(i, j int) bool { return mismatches[i].Symbol < mismatches[j].Symbol })
+	return mismatches
+}
@@ -0,0 +1,47 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/stocky/backend/internal/export"
+	"github.com/stocky/backend/internal/models"
+	"github.com/stocky/backend/internal/repository"
+)
+
+// ExportQuery selects the postings to export: From is inclusive, To
+// exclusive, and both are required. Company names the Tally company.
+type ExportQuery struct {
+	From    time.Time
+	To      time.Time
+	Company string
+}
+
+// Export reads every posting created in the range, page by page in posting
+// order, and groups them into journal vouchers.
+func (s *LedgerService) Export(ctx context.Context, query ExportQuery) (*export.Journal, error) {
+	if query.From.IsZero() || query.To.IsZero() {
+		return nil, fmt.Errorf("%w: from and to are required", ErrInvalidInput)
+	}
+	if !query.From.Before(query.To) {
+		return nil, fmt.Errorf("%w: from must be before to", ErrInvalidInput)
+	}
+
+	var postings []models.LedgerPosting
+	repoQuery := repository.LedgerQuery{From: query.From, To: query.To, Limit: MaxLedgerPageSize}
+	for {
+		page, err := s.repo.LedgerByAccount(ctx, repoQuery)
+		if err != nil {
+			return nil, err
+		}
+		postings = append(postings, page...)
+		if len(page) < repoQuery.Limit {
+			break
+		}
+		last := page[len(page)-1]
+		repoQuery.After = &repository.LedgerCursor{CreatedAt: last.CreatedAt, ID: last.ID}
+	}
+	return export.NewJournal(query.From, query.To, strings.TrimSpace(query.Company), postings), nil
+}
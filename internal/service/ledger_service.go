@@ -0,0 +1,161 @@
+package service
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/stocky/backend/internal/models"
+	"github.com/stocky/backend/internal/repository"
+)
+
+// Ledger page sizes.
+const (
+	DefaultLedgerPageSize = 100
+	MaxLedgerPageSize     = 1000
+)
+
+// AccountPostingsQuery selects a page of an account's postings. From is
+// inclusive, To exclusive; Cursor is the NextCursor of the previous page.
+type AccountPostingsQuery struct {
+	AccountCode string
+	From        time.Time
+	To          time.Time
+	Cursor      string
+	Limit       int
+}
+
+// LedgerPage is one page of postings. NextCursor is empty on the last page.
+type LedgerPage struct {
+	AccountCode string                 `json:"accountCode"`
+	Items       []models.LedgerPosting `json:"items"`
+	NextCursor  string                 `json:"nextCursor,omitempty"`
+}
+
+// BalanceSheet lists account balances as of a point in time.
+type BalanceSheet struct {
+	AsOf     time.Time               `json:"asOf"`
+	Accounts []models.AccountBalance `json:"accounts"`
+}
+
+// LedgerService reads the double-entry ledger back for finance.
+type LedgerService struct {
+	repo repository.Store
+}
+
+func NewLedgerService(repo repository.Store) *LedgerService {
+	return &LedgerService{repo: repo}
+}
+
+// EventPostings returns the postings written for a reward or adjustment.
+func (s *LedgerService) EventPostings(ctx context.Context, eventID uuid.UUID) ([]models.LedgerPosting, error) {
+	items, err := s.repo.LedgerByEvent(ctx, eventID)
+	if err != nil {
+		return nil, err
+	}
+	if len(items) == 0 {
+		return nil, fmt.Errorf("%w: no postings for event %s", ErrNotFound, eventID)
+	}
+	return items, nil
+}
+
+// AccountPostings pages through an account's postings in posting order.
+func (s *LedgerService) AccountPostings(ctx context.Context, query AccountPostingsQuery) (*LedgerPage, error) {
+	code := normalizeAccountCode(query.AccountCode)
+	if code == "" {
+		return nil, fmt.Errorf("%w: account code is required", ErrInvalidInput)
+	}
+	if !query.From.IsZero() && !query.To.IsZero() && !query.From.Before(query.To) {
+		return nil, fmt.Errorf("%w: from must be before to", ErrInvalidInput)
+	}
+	limit := query.Limit
+	switch {
+	case limit == 0:
+		limit = DefaultLedgerPageSize
+	case limit < 0 || limit > MaxLedgerPageSize:
+		return nil, fmt.Errorf("%w: limit must be between 1 and %d", ErrInvalidInput, MaxLedgerPageSize)
+	}
+
+	repoQuery := repository.LedgerQuery{AccountCode: code, From: query.From, To: query.To, Limit: limit + 1}
+	if query.Cursor != "" {
+		after, err := decodeLedgerCursor(query.Cursor)
+		if err != nil {
+			return nil, err
+		}
+		repoQuery.After = after
+	}
+
+	items, err := s.repo.LedgerByAccount(ctx, repoQuery)
+	if errors.Is(err, repository.ErrInvalidCursor) {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidInput, err)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	page := &LedgerPage{AccountCode: code, Items: items}
+	if len(items) > limit {
+		page.Items = items[:limit]
+		last := page.Items[limit-1]
+		page.NextCursor = encodeLedgerCursor(repository.LedgerCursor{CreatedAt: last.CreatedAt, ID: last.ID})
+	}
+	if page.Items == nil {
+		page.Items = []models.LedgerPosting{}
+	}
+	return page, nil
+}
+
+// Balances totals every account, or just accountCode, as of asOf; a zero
+// asOf means now.
+func (s *LedgerService) Balances(ctx context.Context, asOf time.Time, accountCode string) (*BalanceSheet, error) {
+	if asOf.IsZero() {
+		asOf = time.Now().UTC()
+	}
+	accounts, err := s.repo.AccountBalances(ctx, asOf, normalizeAccountCode(accountCode))
+	if err != nil {
+		return nil, err
+	}
+	if accounts == nil {
+		accounts = []models.AccountBalance{}
+	}
+	return &BalanceSheet{AsOf: asOf, Accounts: accounts}, nil
+}
+
+// normalizeAccountCode upper-cases the symbol of stock inventory codes so
+// stock_inventory:reliance finds stock_inventory:RELIANCE.
+func normalizeAccountCode(code string) string {
+	code = strings.TrimSpace(code)
+	if symbol, ok := strings.CutPrefix(code, "stock_inventory:"); ok {
+		return repository.StockAccount(symbol)
+	}
+	return code
+}
+
+// Cursors are opaque to clients: base64 of "<created_at>|<id>".
+
+func encodeLedgerCursor(c repository.LedgerCursor) string {
+	raw := c.CreatedAt.UTC().Format(time.RFC3339Nano) + "|" + c.ID
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+func decodeLedgerCursor(cursor string) (*repository.LedgerCursor, error) {
+	invalid := fmt.Errorf("%w: invalid cursor", ErrInvalidInput)
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return nil, invalid
+	}
+	at, id, ok := strings.Cut(string(raw), "|")
+	if !ok || id == "" {
+		return nil, invalid
+	}
+	createdAt, err := time.Parse(time.RFC3339Nano, at)
+	if err != nil {
+		return nil, invalid
+	}
+	return &repository.LedgerCursor{CreatedAt: createdAt, ID: id}, nil
+}
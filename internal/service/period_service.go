@@ -0,0 +1,175 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/stocky/backend/internal/config"
+	"github.com/stocky/backend/internal/models"
+	"github.com/stocky/backend/internal/repository"
+)
+
+// PeriodService closes and reopens monthly ledger periods and decides where
+// rewards dated inside a closed period are booked.
+type PeriodService struct {
+	repo repository.Store
+	lc   config.LedgerConfig
+}
+
+func NewPeriodService(repo repository.Store, lc config.LedgerConfig) *PeriodService {
+	return &PeriodService{repo: repo, lc: lc}
+}
+
+// Close snapshots every account's balance at the end of period and locks it.
+// Only periods that have ended can be closed. Postings are dated when they
+// are written, so the snapshot cannot change once the period has ended.
+func (s *PeriodService) Close(ctx context.Context, period, actor string) (*models.LedgerPeriod, error) {
+	actor = strings.TrimSpace(actor)
+	if actor == "" {
+		return nil, fmt.Errorf("%w: actor is required", ErrInvalidInput)
+	}
+	start, end, err := periodBounds(period)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now().UTC()
+	if end.After(now) {
+		return nil, fmt.Errorf("%w: period %s has not ended", ErrUnprocessable, period)
+	}
+
+	balances, err := s.repo.AccountBalances(ctx, end.Add(-time.Nanosecond), "")
+	if err != nil {
+		return nil, err
+	}
+	if balances == nil {
+		balances = []models.AccountBalance{}
+	}
+	closed := models.LedgerPeriod{
+		Period:   period,
+		StartsAt: start,
+		EndsAt:   end,
+		Status:   repository.PeriodClosed,
+		ClosedAt: &now,
+		ClosedBy: actor,
+		Balances: balances,
+	}
+	event := models.PeriodEvent{Action: repository.PeriodActionClose, Actor: actor, At: now}
+	if err := s.repo.ClosePeriod(ctx, closed, event); err != nil {
+		if errors.Is(err, repository.ErrPeriodClosed) {
+			return nil, fmt.Errorf("%w: period %s is already closed", ErrUnprocessable, period)
+		}
+		return nil, err
+	}
+	return s.repo.LedgerPeriod(ctx, period)
+}
+
+// Reopen unlocks a closed period. The closing snapshot is kept until the
+// period is closed again; actor and reason are recorded in its history.
+func (s *PeriodService) Reopen(ctx context.Context, period, actor, reason string) (*models.LedgerPeriod, error) {
+	actor, reason = strings.TrimSpace(actor), strings.TrimSpace(reason)
+	if actor == "" || reason == "" {
+		return nil, fmt.Errorf("%w: actor and reason are required", ErrInvalidInput)
+	}
+	if _, _, err := periodBounds(period); err != nil {
+		return nil, err
+	}
+	event := models.PeriodEvent{
+		Action: repository.PeriodActionReopen,
+		Actor:  actor,
+		Reason: reason,
+		At:     time.Now().UTC(),
+	}
+	reopened, err := s.repo.ReopenPeriod(ctx, period, event)
+	if errors.Is(err, repository.ErrPeriodNotFound) || errors.Is(err, repository.ErrPeriodOpen) {
+		return nil, fmt.Errorf("%w: period %s is not closed", ErrUnprocessable, period)
+	}
+	return reopened, err
+}
+
+// Period returns a period's status. A period that was never closed is
+// reported open with no balances.
+func (s *PeriodService) Period(ctx context.Context, period string) (*models.LedgerPeriod, error) {
+	start, end, err := periodBounds(period)
+	if err != nil {
+		return nil, err
+	}
+	found, err := s.repo.LedgerPeriod(ctx, period)
+	if errors.Is(err, repository.ErrPeriodNotFound) {
+		return &models.LedgerPeriod{
+			Period:   period,
+			StartsAt: start,
+			EndsAt:   end,
+			Status:   repository.PeriodOpen,
+			Balances: []models.AccountBalance{},
+			History:  []models.PeriodEvent{},
+		}, nil
+	}
+	return found, err
+}
+
+// Periods lists every period that has been closed, including ones reopened
+// since.
+func (s *PeriodService) Periods(ctx context.Context) ([]models.LedgerPeriod, error) {
+	items, err := s.repo.ListPeriods(ctx)
+	if items == nil {
+		items = []models.LedgerPeriod{}
+	}
+	return items, err
+}
+
+// placeReward checks the period a reward is dated in. Inside a closed period
+// it is rejected, or under the repost policy moved to now with a memo naming
+// the original date. The store checks again inside the write transaction, so
+// a period closed after this check still rejects the reward.
+func (s *PeriodService) placeReward(ctx context.Context, input RewardInput) (RewardInput, error) {
+	period := repository.PeriodOf(input.RewardedAt)
+	locked, err := s.locked(ctx, period)
+	if err != nil || !locked {
+		return input, err
+	}
+	if s.lc.LockedPeriodPolicy != config.LockedRepost {
+		return input, fmt.Errorf("%w: rewardedAt %s falls in closed period %s",
+			ErrUnprocessable, input.RewardedAt.Format(time.RFC3339), period)
+	}
+	input.memo = fmt.Sprintf("reposted from closed period %s, originally dated %s",
+		period, input.RewardedAt.Format(time.RFC3339))
+	input.RewardedAt = time.Now().UTC()
+	return input, nil
+}
+
+// checkOpen rejects a write dated at in a closed period. Adjustments are
+// dated when they are written, so this only fails while the current period
+// is closed.
+func (s *PeriodService) checkOpen(ctx context.Context, at time.Time) error {
+	period := repository.PeriodOf(at)
+	locked, err := s.locked(ctx, period)
+	if err != nil {
+		return err
+	}
+	if locked {
+		return fmt.Errorf("%w: period %s is closed", ErrUnprocessable, period)
+	}
+	return nil
+}
+
+func (s *PeriodService) locked(ctx context.Context, period string) (bool, error) {
+	found, err := s.repo.LedgerPeriod(ctx, period)
+	if errors.Is(err, repository.ErrPeriodNotFound) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return found.Status == repository.PeriodClosed, nil
+}
+
+func periodBounds(period string) (time.Time, time.Time, error) {
+	start, end, err := repository.PeriodBounds(period)
+	if err != nil {
+		return start, end, fmt.Errorf("%w: %v", ErrInvalidInput, err)
+	}
+	return start, end, nil
+}
@@ -2,6 +2,7 @@ package service
 
 import (
 	"context"
+	"errors"
 
 	"github.com/google/uuid"
 	"github.com/shopspring/decimal"
@@ -12,11 +13,11 @@ import (
 )
 
 type PortfolioService struct {
-	repo     *repository.Repository
+	repo     repository.Store
 	priceSvc *price.Service
 }
 
-func NewPortfolioService(repo *repository.Repository, priceSvc *price.Service) *PortfolioService {
+func NewPortfolioService(repo repository.Store, priceSvc *price.Service) *PortfolioService {
 	return &PortfolioService{repo: repo, priceSvc: priceSvc}
 }
 
@@ -25,6 +26,14 @@ func (s *PortfolioService) GetPortfolio(ctx context.Context, userID uuid.UUID) (
 	if err != nil {
 		return nil, err
 	}
+	// Positions emptied by reversals are kept in storage but not shown.
+	open := positions[:0]
+	for _, pos := range positions {
+		if pos.Shares.IsPositive() {
+			open = append(open, pos)
+		}
+	}
+	positions = open
 	if len(positions) == 0 {
 		return []models.PortfolioPosition{}, nil
 	}
@@ -33,19 +42,40 @@ func (s *PortfolioService) GetPortfolio(ctx context.Context, userID uuid.UUID) (
 	for _, pos := range positions {
 		symbols = append(symbols, pos.Symbol)
 	}
+	// A symbol the provider cannot quote is listed as unpriced rather than
+	// failing the whole portfolio.
 	quotes, err := s.priceSvc.QuotesFor(ctx, symbols)
+	var failed price.FetchErrors
+	if err != nil && !errors.As(err, &failed) {
+		return nil, err
+	}
+	payments, err := s.repo.DividendPayments(ctx, userID)
 	if err != nil {
 		return nil, err
 	}
+	dividends := make(map[string][]models.DividendPayment)
+	for _, payment := range payments {
+		dividends[payment.Symbol] = append(dividends[payment.Symbol], payment)
+	}
 
 	result := make([]models.PortfolioPosition, 0, len(positions))
 	for _, pos := range positions {
+		avgCost := pos.AvgCost.Round(4)
 		quote, ok := quotes[pos.Symbol]
 		if !ok {
+			result = append(result, models.PortfolioPosition{
+				Symbol:        pos.Symbol,
+				Shares:        pos.Shares,
+				AverageCost:   avgCost,
+				CurrentPrice:  decimal.Zero,
+				CurrentValue:  decimal.Zero,
+				UnrealizedPnl: decimal.Zero,
+				Unpriced:      true,
+				Dividends:     dividends[pos.Symbol],
+			})
 			continue
 		}
 		currentValue := pos.Shares.Mul(quote.Price).Round(2)
-		avgCost := pos.AvgCost.Round(4)
 		unrealized := currentValue.Sub(pos.Shares.Mul(avgCost)).Round(2)
 		result = append(result, models.PortfolioPosition{
 			Symbol:            pos.Symbol,
@@ -54,7 +84,9 @@ func (s *PortfolioService) GetPortfolio(ctx context.Context, userID uuid.UUID) (
 			CurrentPrice:      quote.Price,
 			CurrentValue:      currentValue,
 			UnrealizedPnl:     unrealized,
-			LastPriceSnapshot: quote.FetchedAt,
+			LastPriceSnapshot: quote.AsOf,
+			Delisted:          quote.Source == repository.DelistedQuoteSource,
+			Dividends:         dividends[pos.Symbol],
 		})
 	}
 	return result, nil
@@ -0,0 +1,395 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+
+	"github.com/stocky/backend/internal/config"
+	"github.com/stocky/backend/internal/models"
+	"github.com/stocky/backend/internal/repository"
+)
+
+// RebuildOptions scopes a projection rebuild. Zero UserID and empty Symbol
+// rebuild everything.
+type RebuildOptions struct {
+	UserID uuid.UUID
+	Symbol string
+	// DryRun reports differences without writing anything.
+	DryRun bool
+	// Holdings also recomputes daily_holdings from price history. It values
+	// whole portfolios, so it cannot be combined with a symbol filter.
+	Holdings bool
+}
+
+// PositionDiff describes a position whose stored state differs from the
+// replayed state. A missing side is reported as zero.
+type PositionDiff struct {
+	UserID          uuid.UUID       `json:"userId"`
+	Symbol          string          `json:"symbol"`
+	StoredShares    decimal.Decimal `json:"storedShares"`
+	ReplayedShares  decimal.Decimal `json:"replayedShares"`
+	StoredAvgCost   decimal.Decimal `json:"storedAvgCostInr"`
+	ReplayedAvgCost decimal.Decimal `json:"replayedAvgCostInr"`
+	StoredTotal     decimal.Decimal `json:"storedTotalCostInr"`
+	ReplayedTotal   decimal.Decimal `json:"replayedTotalCostInr"`
+}
+
+// HoldingDiff describes a daily valuation that differs from the replay.
+type HoldingDiff struct {
+	UserID   uuid.UUID       `json:"userId"`
+	Date     time.Time       `json:"date"`
+	Stored   decimal.Decimal `json:"storedInr"`
+	Replayed decimal.Decimal `json:"replayedInr"`
+}
+
+// RebuildReport summarises a rebuild run.
+type RebuildReport struct {
+	DryRun           bool           `json:"dryRun"`
+	EventsReplayed   int            `json:"eventsReplayed"`
+	PositionsChecked int            `json:"positionsChecked"`
+	PositionDiffs    []PositionDiff `json:"positionDiffs"`
+	HoldingDiffs     []HoldingDiff  `json:"holdingDiffs,omitempty"`
+}
+
+// Drifted reports whether any projection differed from the replay.
+func (r *RebuildReport) Drifted() bool {
+	return len(r.PositionDiffs) > 0 || len(r.HoldingDiffs) > 0
+}
+
+type positionKey struct {
+	userID uuid.UUID
+	symbol string
+}
+
+// positionEvent is one step of the replay: a change in shares and cost at a
+// point in time. Rewards acquire; adjustments apply signed deltas.
+type positionEvent struct {
+	userID     uuid.UUID
+	symbol     string
+	at         time.Time
+	shares     decimal.Decimal
+	cost       decimal.Decimal
+	adjustment bool
+}
+
+// ProjectionService recomputes user_positions and daily_holdings from the
+// immutable event history.
+type ProjectionService struct {
+	repo repository.Store
+	fc   config.FeeConfig
+}
+
+func NewProjectionService(repo repository.Store, fc config.FeeConfig) *ProjectionService {
+	return &ProjectionService{repo: repo, fc: fc}
+}
+
+// Rebuild replays history in rewarded_at order and compares the result with
+// the stored projections, writing corrections unless DryRun is set. Rewards
+// granted while a rebuild runs may be overwritten, so run it during a quiet
+// period or follow up with a dry run.
+func (s *ProjectionService) Rebuild(ctx context.Context, opts RebuildOptions) (*RebuildReport, error) {
+	if opts.Holdings && opts.Symbol != "" {
+		return nil, errors.New("holdings can only be rebuilt for whole portfolios; drop the symbol filter")
+	}
+	filter := repository.ProjectionFilter{UserID: opts.UserID, Symbol: strings.ToUpper(opts.Symbol)}
+
+	events, err := s.loadEvents(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	replayed := replayPositions(events)
+
+	stored, err := s.repo.ListPositionStates(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &RebuildReport{DryRun: opts.DryRun, EventsReplayed: len(events), PositionDiffs: []PositionDiff{}}
+	storedByKey := make(map[positionKey]repository.PositionState, len(stored))
+	for _, record := range stored {
+		storedByKey[positionKey{userID: record.UserID, symbol: record.Symbol}] = record.PositionState
+	}
+
+	var (
+		updates []repository.PositionRecord
+		deletes []positionKey
+	)
+	for key, state := range replayed {
+		current, ok := storedByKey[key]
+		if ok && positionsMatch(current, state) {
+			continue
+		}
+		report.PositionDiffs = append(report.PositionDiffs, positionDiff(key, current, state))
+		updates = append(updates, repository.PositionRecord{UserID: key.userID, Symbol: key.symbol, PositionState: state})
+	}
+	for key, current := range storedByKey {
+		if _, ok := replayed[key]; ok {
+			continue
+		}
+		report.PositionDiffs = append(report.PositionDiffs, positionDiff(key, current, repository.PositionState{}))
+		deletes = append(deletes, key)
+	}
+	report.PositionsChecked = len(replayed) + len(deletes)
+	sortPositionDiffs(report.PositionDiffs)
+
+	if !opts.DryRun {
+		if err := s.repo.SavePositionStates(ctx, updates); err != nil {
+			return nil, err
+		}
+		for _, key := range deletes {
+			if err := s.repo.DeletePosition(ctx, key.userID, key.symbol); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if opts.Holdings {
+		diffs, err := s.rebuildHoldings(ctx, events, time.Time{}, opts.DryRun)
+		if err != nil {
+			return nil, err
+		}
+		report.HoldingDiffs = diffs
+	}
+	return report, nil
+}
+
+// loadEvents collects every position-changing record matching the filter in
+// replay order.
+func (s *ProjectionService) loadEvents(ctx context.Context, filter repository.ProjectionFilter) ([]positionEvent, error) {
+	rewards, err := s.repo.ListRewardEvents(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	adjustments, err := s.repo.ListAdjustments(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	events := make([]positionEvent, 0, len(rewards)+len(adjustments))
+	for _, reward := range rewards {
+		events = append(events, positionEvent{
+			userID: reward.UserID,
+			symbol: reward.Symbol,
+			at:     reward.RewardedAt,
+			shares: reward.Shares,
+			cost:   s.rewardCostBasis(reward),
+		})
+	}
+	for _, adj := range adjustments {
+		events = append(events, positionEvent{
+			userID:     adj.UserID,
+			symbol:     adj.Symbol,
+			at:         adj.CreatedAt,
+			shares:     adj.Shares,
+			cost:       adj.CostInr,
+			adjustment: true,
+		})
+	}
+	sort.SliceStable(events, func(i, j int) bool { return events[i].at.Before(events[j].at) })
+	return events, nil
+}
+
+// rewardCostBasis returns the cost a reward added to its position. Events
+// written before the cost basis was persisted are derived from the current
+// fee configuration.
+func (s *ProjectionService) rewardCostBasis(reward models.RewardEvent) decimal.Decimal {
+	if !reward.CostBasis.IsZero() {
+		return reward.CostBasis
+	}
+	cost := reward.GrantedPrice.Mul(reward.Shares).Round(4)
+	if s.fc.CapitalizeFees {
+		cost = cost.Add(reward.BrokerageInr).Add(reward.TaxesInr)
+	}
+	return cost
+}
+
+// replayPositions folds events into positions. An adjustment that would take
+// a position negative, which only edited history can produce, empties it
+// instead.
+func replayPositions(events []positionEvent) map[positionKey]repository.PositionState {
+	positions := make(map[positionKey]repository.PositionState)
+	for _, event := range events {
+		key := positionKey{userID: event.userID, symbol: event.symbol}
+		current := positions[key]
+		if !event.adjustment {
+			positions[key] = current.Acquire(event.shares, event.cost, event.at)
+			continue
+		}
+		next, err := current.Adjust(event.shares, event.cost)
+		if err != nil {
+			next, _ = current.Adjust(current.Shares.Neg(), current.TotalCost.Neg())
+		}
+		positions[key] = next
+	}
+	return positions
+}
+
+// RevalueHoldings recomputes the daily valuations of the given users from
+// the day containing from through today, leaving earlier days untouched.
+func (s *ProjectionService) RevalueHoldings(ctx context.Context, userIDs []uuid.UUID, from time.Time) error {
+	for _, userID := range userIDs {
+		events, err := s.loadEvents(ctx, repository.ProjectionFilter{UserID: userID})
+		if err != nil {
+			return err
+		}
+		if _, err := s.rebuildHoldings(ctx, events, startOfDay(from.UTC()), false); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// rebuildHoldings recomputes end-of-day valuations for every user with
+// events, from the first event day (or from, if later) through today, using
+// the last price snapshot at or before the end of each day.
+func (s *ProjectionService) rebuildHoldings(ctx context.Context, events []positionEvent, from time.Time, dryRun bool) ([]HoldingDiff, error) {
+	diffs := []HoldingDiff{}
+	if len(events) == 0 {
+		return diffs, nil
+	}
+
+	symbolSet := make(map[string]bool)
+	byUser := make(map[uuid.UUID][]positionEvent)
+	for _, event := range events {
+		symbolSet[event.symbol] = true
+		byUser[event.userID] = append(byUser[event.userID], event)
+	}
+	symbols := make([]string, 0, len(symbolSet))
+	for symbol := range symbolSet {
+		symbols = append(symbols, symbol)
+	}
+
+	today := startOfDay(time.Now().UTC())
+	history, err := s.repo.PriceHistory(ctx, symbols, today.Add(24*time.Hour))
+	if err != nil {
+		return nil, err
+	}
+	actions, err := s.repo.ListCorporateActions(ctx, repository.CorporateActionFilter{Status: repository.ActionApplied})
+	if err != nil {
+		return nil, err
+	}
+
+	for userID, userEvents := range byUser {
+		stored, err := s.repo.HistoricalHoldings(ctx, userID, today.Add(24*time.Hour))
+		if err != nil {
+			return nil, err
+		}
+		storedByDay := make(map[time.Time]decimal.Decimal, len(stored))
+		for _, day := range stored {
+			storedByDay[startOfDay(day.Date.UTC())] = day.TotalValueIn
+		}
+
+		for _, day := range replayHoldings(userEvents, history, actions, today) {
+			if day.Date.Before(from) {
+				continue
+			}
+			current, ok := storedByDay[day.Date]
+			if ok && current.Equal(day.TotalValueIn) {
+				continue
+			}
+			diffs = append(diffs, HoldingDiff{UserID: userID, Date: day.Date, Stored: current, Replayed: day.TotalValueIn})
+			if dryRun {
+				continue
+			}
+			if err := s.repo.UpsertDailyHolding(ctx, userID, day.Date, day.TotalValueIn, day.StaleSymbols); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	sort.Slice(diffs, func(i, j int) bool {
+		if diffs[i].UserID != diffs[j].UserID {
+			return diffs[i].UserID.String() < diffs[j].UserID.String()
+		}
+		return diffs[i].Date.Before(diffs[j].Date)
+	})
+	return diffs, nil
+}
+
+// replayHoldings walks one user's events day by day, valuing the shares held
+// at the end of each day. Days before any price is known are skipped, like
+// the price job skips symbols without a quote. Prices before an applied
+// split or bonus are stored divided by its factor, so shares held before the
+// ex-date are multiplied by it to value the day as it was.
+func replayHoldings(events []positionEvent, history []models.PriceQuote, actions []models.CorporateAction, through time.Time) []models.DailyINR {
+	shares := make(map[string]decimal.Decimal)
+	prices := make(map[string]decimal.Decimal)
+
+	var (
+		days      []models.DailyINR
+		eventIdx  int
+		priceIdx  int
+		firstDate = startOfDay(events[0].at.UTC())
+	)
+	for day := firstDate; !day.After(through); day = day.Add(24 * time.Hour) {
+		end := day.Add(24 * time.Hour)
+		for eventIdx < len(events) && events[eventIdx].at.Before(end) {
+			event := events[eventIdx]
+			shares[event.symbol] = shares[event.symbol].Add(event.shares)
+			eventIdx++
+		}
+		for priceIdx < len(history) && history[priceIdx].AsOf.Before(end) {
+			quote := history[priceIdx]
+			prices[quote.Symbol] = quote.Price
+			priceIdx++
+		}
+
+		var (
+			total  decimal.Decimal
+			priced bool
+		)
+		for symbol, held := range shares {
+			price, ok := prices[symbol]
+			if !ok {
+				continue
+			}
+			for _, action := range actions {
+				if action.Symbol == symbol && repository.RescalesPrices(action.Kind) && !action.ExDate.Before(end) {
+					held = held.Mul(action.Factor)
+				}
+			}
+			total = total.Add(held.Mul(price).Round(2))
+			priced = true
+		}
+		if priced {
+			days = append(days, models.DailyINR{Date: day, TotalValueIn: total})
+		}
+	}
+	return days
+}
+
+// positionsMatch compares at the storage precision used by every backend.
+func positionsMatch(a, b repository.PositionState) bool {
+	return a.Shares.Round(6).Equal(b.Shares.Round(6)) &&
+		a.AvgCost.Round(4).Equal(b.AvgCost.Round(4)) &&
+		a.TotalCost.Round(4).Equal(b.TotalCost.Round(4))
+}
+
+func positionDiff(key positionKey, stored, replayed repository.PositionState) PositionDiff {
+	return PositionDiff{
+		UserID:          key.userID,
+		Symbol:          key.symbol,
+		StoredShares:    stored.Shares,
+		ReplayedShares:  replayed.Shares,
+		StoredAvgCost:   stored.AvgCost.Round(4),
+		ReplayedAvgCost: replayed.AvgCost.Round(4),
+		StoredTotal:     stored.TotalCost.Round(4),
+		ReplayedTotal:   replayed.TotalCost.Round(4),
+	}
+}
+
+func sortPositionDiffs(diffs []PositionDiff) {
+	sort.Slice(diffs, func(i, j int) bool {
+		if diffs[i].UserID != diffs[j].UserID {
+			return diffs[i].UserID.String() < diffs[j].UserID.String()
+		}
+		return diffs[i].Symbol < diffs[j].Symbol
+	})
+}
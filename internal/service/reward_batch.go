@@ -0,0 +1,259 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/stocky/backend/internal/models"
+	"github.com/stocky/backend/internal/price"
+	"github.com/stocky/backend/internal/repository"
+)
+
+// Batch item statuses.
+const (
+	BatchCreated   = "created"
+	BatchDuplicate = "duplicate"
+	BatchInvalid   = "invalid"
+	BatchFailed    = "failed"
+)
+
+// BatchItem is one entry of a batch request. Err carries a problem found
+// before the input could be built, such as an unparsable user id.
+type BatchItem struct {
+	Input RewardInput
+	Err   error
+}
+
+// BatchItemResult reports the outcome of one batch entry, in request order.
+type BatchItemResult struct {
+	Index   int                 `json:"index"`
+	EventID string              `json:"eventId,omitempty"`
+	Status  string              `json:"status"`
+	Reward  *models.RewardEvent `json:"reward,omitempty"`
+	Error   string              `json:"error,omitempty"`
+}
+
+// BatchResult summarises a batch. In atomic mode Applied is false when any
+// item failed, and no reward in the batch was written.
+type BatchResult struct {
+	Atomic     bool              `json:"atomic"`
+	Applied    bool              `json:"applied"`
+	Created    int               `json:"created"`
+	Duplicates int               `json:"duplicates"`
+	Invalid    int               `json:"invalid"`
+	Failed     int               `json:"failed"`
+	Items      []BatchItemResult `json:"items"`
+}
+
+func (r *BatchResult) set(index int, status string, reward *models.RewardEvent, err error) {
+	item := &r.Items[index]
+	item.Status = status
+	item.Reward = reward
+	if err != nil {
+		item.Error = err.Error()
+	}
+}
+
+func (r *BatchResult) tally() {
+	r.Created, r.Duplicates, r.Invalid, r.Failed = 0, 0, 0, 0
+	for _, item := range r.Items {
+		switch item.Status {
+		case BatchCreated:
+			r.Created++
+		case BatchDuplicate:
+			r.Duplicates++
+		case BatchInvalid:
+			r.Invalid++
+		default:
+			r.Failed++
+		}
+	}
+}
+
+// RewardBatch grants many rewards with the same validation and fee math as
+// RewardUser, fetching each symbol's quote once. Without atomic every item
+// is written independently; with atomic the batch is written in one
+// transaction and any invalid, unpriced or duplicate item aborts it.
+func (s *RewardService) RewardBatch(ctx context.Context, items []BatchItem, atomic bool) (*BatchResult, error) {
+	if len(items) == 0 {
+		return nil, fmt.Errorf("%w: batch is empty", ErrInvalidInput)
+	}
+	if len(items) > s.rc.BatchLimit {
+		return nil, fmt.Errorf("%w: batch has %d items, limit is %d", ErrInvalidInput, len(items), s.rc.BatchLimit)
+	}
+
+	result := &BatchResult{Atomic: atomic, Items: make([]BatchItemResult, len(items))}
+	inputs := make([]RewardInput, len(items))
+	var symbols []string
+	seen := make(map[string]bool)
+	for i, item := range items {
+		result.Items[i] = BatchItemResult{Index: i, EventID: item.Input.EventID}
+		input, err := item.Input, item.Err
+		if err == nil {
+			input, err = normalizeRewardInput(input)
+		}
+		if err == nil {
+			input.Symbol, err = resolveSymbol(ctx, s.repo, input.Symbol)
+		}
+		if err != nil {
+			result.set(i, BatchInvalid, nil, err)
+			continue
+		}
+		if input, err = s.periods.placeReward(ctx, input); err != nil {
+			result.set(i, BatchInvalid, nil, err)
+			continue
+		}
+		inputs[i] = input
+		if !seen[input.Symbol] {
+			seen[input.Symbol] = true
+			symbols = append(symbols, input.Symbol)
+		}
+	}
+
+	quotes, quoteErrs := s.batchQuotes(ctx, symbols)
+
+	var (
+		pending []int
+		params  []repository.RewardCreationParams
+		stale   []bool
+	)
+	for i, input := range inputs {
+		if result.Items[i].Status != "" {
+			continue
+		}
+		quote, ok := quotes[input.Symbol]
+		if !ok {
+			result.set(i, BatchFailed, nil, fmt.Errorf("price for %s: %w", input.Symbol, quoteErrs[input.Symbol]))
+			continue
+		}
+		input, err := s.resolveShares(input, quote.Price)
+		if err != nil {
+			result.set(i, BatchInvalid, nil, err)
+			continue
+		}
+		p, err := s.rewardParams(input, quote.Price)
+		if err != nil {
+			result.set(i, BatchInvalid, nil, err)
+			continue
+		}
+		pending = append(pending, i)
+		params = append(params, p)
+		stale = append(stale, quote.stale)
+	}
+
+	if atomic {
+		err := s.writeAtomic(ctx, result, pending, params)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		s.writeEach(ctx, result, pending, params)
+		result.Applied = true
+	}
+	for n, i := range pending {
+		if reward := result.Items[i].Reward; reward != nil {
+			reward.PriceStale = stale[n]
+		}
+	}
+	result.tally()
+	return result, nil
+}
+
+// batchQuote is a symbol's quote after the staleness check.
+type batchQuote struct {
+	models.PriceQuote
+	stale bool
+}
+
+// batchQuotes resolves a quote per symbol and applies the staleness check
+// RewardUser uses. A symbol the provider cannot quote only fails its own
+// items; if the bulk lookup fails outright each symbol is retried on its own.
+func (s *RewardService) batchQuotes(ctx context.Context, symbols []string) (map[string]batchQuote, map[string]error) {
+	errs := make(map[string]error)
+	result := make(map[string]batchQuote, len(symbols))
+	if len(symbols) == 0 {
+		return result, errs
+	}
+
+	quotes, err := s.priceSvc.QuotesFor(ctx, symbols)
+	var failed price.FetchErrors
+	switch {
+	case errors.As(err, &failed):
+		for symbol, err := range failed {
+			errs[symbol] = err
+		}
+	case err != nil:
+		quotes = make(map[string]models.PriceQuote, len(symbols))
+		for _, symbol := range symbols {
+			quote, err := s.priceSvc.EnsureQuote(ctx, symbol)
+			if err != nil {
+				errs[symbol] = err
+				continue
+			}
+			quotes[symbol] = *quote
+		}
+	}
+
+	for symbol, quote := range quotes {
+		fresh, stale, err := s.freshQuote(ctx, &quote)
+		if err != nil {
+			errs[symbol] = err
+			continue
+		}
+		result[symbol] = batchQuote{PriceQuote: *fresh, stale: stale}
+	}
+	return result, errs
+}
+
+func (s *RewardService) writeEach(ctx context.Context, result *BatchResult, pending []int, params []repository.RewardCreationParams) {
+	for n, i := range pending {
+		reward, err := s.repo.CreateReward(ctx, params[n])
+		switch {
+		case err == nil:
+			result.set(i, BatchCreated, reward, nil)
+		case errors.Is(err, repository.ErrDuplicateReward):
+			result.set(i, BatchDuplicate, nil, ErrConflict)
+		default:
+			result.set(i, BatchFailed, nil, postingRejected(err))
+		}
+	}
+}
+
+// writeAtomic writes every pending item in one transaction, or nothing if an
+// item was already rejected or the store aborts on one. Errors that cannot be
+// attributed to an item are returned.
+func (s *RewardService) writeAtomic(ctx context.Context, result *BatchResult, pending []int, params []repository.RewardCreationParams) error {
+	notApplied := errors.New("not applied: batch aborted")
+	if len(pending) < len(result.Items) {
+		for _, i := range pending {
+			result.set(i, BatchFailed, nil, notApplied)
+		}
+		return nil
+	}
+
+	rewards, err := s.repo.CreateRewards(ctx, params)
+	var itemErr *repository.BatchItemError
+	if errors.As(err, &itemErr) && itemErr.Index < len(pending) {
+		for n, i := range pending {
+			switch {
+			case n != itemErr.Index:
+				result.set(i, BatchFailed, nil, notApplied)
+			case errors.Is(itemErr.Err, repository.ErrDuplicateReward):
+				result.set(i, BatchDuplicate, nil, ErrConflict)
+			default:
+				result.set(i, BatchFailed, nil, postingRejected(itemErr.Err))
+			}
+		}
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	for n, i := range pending {
+		result.set(i, BatchCreated, rewards[n], nil)
+	}
+	result.Applied = true
+	return nil
+}
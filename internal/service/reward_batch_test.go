@@ -0,0 +1,235 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+
+	"github.com/stocky/backend/internal/config"
+	"github.com/stocky/backend/internal/fees"
+	"github.com/stocky/backend/internal/price"
+	"github.com/stocky/backend/internal/repository"
+	"github.com/stocky/backend/internal/repository/memory"
+)
+
+// fixedFetcher quotes every symbol in prices and fails the rest.
+type fixedFetcher map[string]string
+
+func (f fixedFetcher) Fetch(_ context.Context, symbol string) (price.Quote, error) {
+	p, ok := f[symbol]
+	if !ok {
+		return price.Quote{}, fmt.Errorf("no price for %s", symbol)
+	}
+	return price.Quote{Price: decimal.RequireFromString(p), Source: "test"}, nil
+}
+
+func newBatchService(store repository.Store) *RewardService {
+	prices := price.NewService(store, fixedFetcher{"INFY": "1500", "TCS": "3900"}, config.PriceConfig{})
+	treasury := NewTreasuryService(store, config.TreasuryConfig{OverdraftPolicy: config.OverdraftAllow})
+	periods := NewPeriodService(store, config.LedgerConfig{LockedPeriodPolicy: config.LockedReject})
+	return NewRewardService(store, prices, treasury, periods, config.FeeConfig{Schedule: fees.Flat(0, 0)}, config.RewardConfig{BatchLimit: 10}, config.PriceConfig{})
+}
+
+func batchItem(userID uuid.UUID, symbol, eventID string) BatchItem {
+	return BatchItem{Input: RewardInput{
+		UserID:     userID,
+		Symbol:     symbol,
+		Shares:     decimal.NewFromInt(1),
+		EventID:    eventID,
+		RewardedAt: time.Date(2024, 5, 10, 9, 0, 0, 0, time.UTC),
+	}}
+}
+
+// seedReward books evt-0 before the batch runs.
+func seedReward(t *testing.T, store repository.Store, userID uuid.UUID) {
+	t.Helper()
+	if _, err := store.CreateReward(context.Background(), repository.RewardCreationParams{
+		UserID: userID, Symbol: "INFY", Shares: decimal.NewFromInt(1), GrantPrice: decimal.NewFromInt(1500),
+		EventKey: "evt-0", RewardedAt: time.Date(2024, 5, 9, 9, 0, 0, 0, time.UTC),
+	}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// countRewards counts the user's stored rewards on 9 and 10 May 2024.
+func countRewards(t *testing.T, store repository.Store, userID uuid.UUID) int {
+	t.Helper()
+	rewards, err := store.ListTodayRewards(context.Background(), userID,
+		time.Date(2024, 5, 9, 0, 0, 0, 0, time.UTC), time.Date(2024, 5, 11, 0, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return len(rewards)
+}
+
+func checkItems(t *testing.T, result *BatchResult, items []BatchItem, statuses []string) {
+	t.Helper()
+	if len(result.Items) != len(statuses) {
+		t.Fatalf("items = %d, want %d", len(result.Items), len(statuses))
+	}
+	for i, item := range result.Items {
+		if item.Index != i || item.EventID != items[i].Input.EventID {
+			t.Errorf("item %d reports index %d event %q, want %d %q", i, item.Index, item.EventID, i, items[i].Input.EventID)
+		}
+		if item.Status != statuses[i] {
+			t.Errorf("item %d status = %s (%s), want %s", i, item.Status, item.Error, statuses[i])
+		}
+		if (item.Status == BatchCreated) != (item.Reward != nil) {
+			t.Errorf("item %d: status %s with reward %v", i, item.Status, item.Reward)
+		}
+		if item.Reward != nil && item.Reward.EventKey != item.EventID {
+			t.Errorf("item %d carries the reward for %q, want %q", i, item.Reward.EventKey, item.EventID)
+		}
+	}
+}
+
+func checkTally(t *testing.T, result *BatchResult, created, duplicates, invalid, failed int) {
+	t.Helper()
+	if result.Created != created || result.Duplicates != duplicates || result.Invalid != invalid || result.Failed != failed {
+		t.Errorf("tally created/duplicates/invalid/failed = %d/%d/%d/%d, want %d/%d/%d/%d",
+			result.Created, result.Duplicates, result.Invalid, result.Failed, created, duplicates, invalid, failed)
+	}
+}
+
+func TestRewardBatchEachItem(t *testing.T) {
+	store := memory.New()
+	s := newBatchService(store)
+	userID := uuid.New()
+	seedReward(t, store, userID)
+
+	invalid := batchItem(userID, "INFY", "evt-bad-user")
+	invalid.Err = fmt.Errorf("%w: user id is not a UUID", ErrInvalidInput)
+	items := []BatchItem{
+		batchItem(userID, "infy", "evt-1"),
+		invalid,
+		batchItem(userID, "TCS", "evt-2"),
+		batchItem(userID, "UNPRICED", "evt-3"),
+		batchItem(userID, "INFY", "evt-1"),
+		batchItem(userID, "TCS", "evt-0"),
+		batchItem(uuid.Nil, "TCS", "evt-4"),
+	}
+	result, err := s.RewardBatch(context.Background(), items, false)
+	if err != nil {
+		t.Fatalf("RewardBatch: %v", err)
+	}
+
+	checkItems(t, result, items, []string{
+		BatchCreated, BatchInvalid, BatchCreated, BatchFailed, BatchDuplicate, BatchDuplicate, BatchInvalid,
+	})
+	checkTally(t, result, 2, 2, 2, 1)
+	if !result.Applied || result.Atomic {
+		t.Errorf("applied/atomic = %v/%v, want true/false", result.Applied, result.Atomic)
+	}
+	if !strings.Contains(result.Items[3].Error, "UNPRICED") {
+		t.Errorf("unpriced item error = %q, want it to name the symbol", result.Items[3].Error)
+	}
+	if got := countRewards(t, store, userID); got != 3 {
+		t.Errorf("stored rewards = %d, want the seed and 2 created", got)
+	}
+}
+
+func TestRewardBatchAtomic(t *testing.T) {
+	invalid := func(userID uuid.UUID) BatchItem {
+		item := batchItem(userID, "INFY", "evt-x")
+		item.Input.Shares = decimal.Zero
+		return item
+	}
+	tests := []struct {
+		name     string
+		items    func(uuid.UUID) []BatchItem
+		statuses []string
+		applied  bool
+		stored   int
+		tally    [4]int
+	}{
+		{
+			name: "every item valid",
+			items: func(u uuid.UUID) []BatchItem {
+				return []BatchItem{batchItem(u, "INFY", "evt-1"), batchItem(u, "TCS", "evt-2"), batchItem(u, "INFY", "evt-3")}
+			},
+			statuses: []string{BatchCreated, BatchCreated, BatchCreated},
+			applied:  true, stored: 4, tally: [4]int{3, 0, 0, 0},
+		},
+		{
+			name: "invalid item aborts",
+			items: func(u uuid.UUID) []BatchItem {
+				return []BatchItem{batchItem(u, "INFY", "evt-1"), invalid(u), batchItem(u, "TCS", "evt-2")}
+			},
+			statuses: []string{BatchFailed, BatchInvalid, BatchFailed},
+			stored:   1, tally: [4]int{0, 0, 1, 2},
+		},
+		{
+			name: "unpriced item aborts",
+			items: func(u uuid.UUID) []BatchItem {
+				return []BatchItem{batchItem(u, "INFY", "evt-1"), batchItem(u, "UNPRICED", "evt-2")}
+			},
+			statuses: []string{BatchFailed, BatchFailed},
+			stored:   1, tally: [4]int{0, 0, 0, 2},
+		},
+		{
+			name: "stored event key aborts",
+			items: func(u uuid.UUID) []BatchItem {
+				return []BatchItem{batchItem(u, "INFY", "evt-1"), batchItem(u, "TCS", "evt-2"), batchItem(u, "TCS", "evt-0")}
+			},
+			statuses: []string{BatchFailed, BatchFailed, BatchDuplicate},
+			stored:   1, tally: [4]int{0, 1, 0, 2},
+		},
+		{
+			name: "repeated event key aborts",
+			items: func(u uuid.UUID) []BatchItem {
+				return []BatchItem{batchItem(u, "INFY", "evt-1"), batchItem(u, "TCS", "evt-1"), batchItem(u, "TCS", "evt-2")}
+			},
+			statuses: []string{BatchFailed, BatchDuplicate, BatchFailed},
+			stored:   1, tally: [4]int{0, 1, 0, 2},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := memory.New()
+			s := newBatchService(store)
+			userID := uuid.New()
+			seedReward(t, store, userID)
+
+			items := tt.items(userID)
+			result, err := s.RewardBatch(context.Background(), items, true)
+			if err != nil {
+				t.Fatalf("RewardBatch: %v", err)
+			}
+			checkItems(t, result, items, tt.statuses)
+			checkTally(t, result, tt.tally[0], tt.tally[1], tt.tally[2], tt.tally[3])
+			if result.Applied != tt.applied || !result.Atomic {
+				t.Errorf("applied/atomic = %v/%v, want %v/true", result.Applied, result.Atomic, tt.applied)
+			}
+			if !tt.applied {
+				for i, item := range result.Items {
+					if item.Status == BatchFailed && items[i].Input.Symbol != "UNPRICED" && !strings.Contains(item.Error, "not applied") {
+						t.Errorf("item %d error = %q, want it marked not applied", i, item.Error)
+					}
+				}
+			}
+			if got := countRewards(t, store, userID); got != tt.stored {
+				t.Errorf("stored rewards = %d, want %d", got, tt.stored)
+			}
+		})
+	}
+}
+
+func TestRewardBatchLimits(t *testing.T) {
+	s := newBatchService(memory.New())
+	userID := uuid.New()
+	items := make([]BatchItem, 11)
+	for i := range items {
+		items[i] = batchItem(userID, "INFY", fmt.Sprintf("evt-%d", i))
+	}
+	for name, batch := range map[string][]BatchItem{"empty": nil, "over the limit": items} {
+		if _, err := s.RewardBatch(context.Background(), batch, false); !errors.Is(err, ErrInvalidInput) {
+			t.Errorf("%s batch: err = %v, want ErrInvalidInput", name, err)
+		}
+	}
+}
@@ -0,0 +1,95 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+
+	"github.com/stocky/backend/internal/models"
+	"github.com/stocky/backend/internal/repository"
+)
+
+// ReversalInput asks for some or all of a reward's shares to be taken back.
+type ReversalInput struct {
+	RewardID uuid.UUID
+	// Shares to reverse; zero reverses whatever has not been reversed yet.
+	Shares decimal.Decimal
+	// FeeTreatment is repository.FeesReverse (the default) or
+	// repository.FeesLoss.
+	FeeTreatment   string
+	Reason         string
+	IdempotencyKey string
+}
+
+// ReversalResult is the adjustment written for a reversal. Replayed is set
+// when the idempotency key had already been used for the same reward and
+// the original adjustment is returned instead of a new one.
+type ReversalResult struct {
+	Adjustment *models.Adjustment `json:"adjustment"`
+	Replayed   bool               `json:"replayed"`
+}
+
+// ReverseReward records a reversal adjustment with offsetting ledger entries
+// and decrements the user's position.
+func (s *RewardService) ReverseReward(ctx context.Context, input ReversalInput) (*ReversalResult, error) {
+	if input.RewardID == uuid.Nil {
+		return nil, fmt.Errorf("%w: reward id is required", ErrInvalidInput)
+	}
+	if input.IdempotencyKey == "" {
+		return nil, fmt.Errorf("%w: idempotency key is required", ErrInvalidInput)
+	}
+	if input.Shares.IsNegative() {
+		return nil, fmt.Errorf("%w: shares must not be negative", ErrInvalidInput)
+	}
+	switch input.FeeTreatment {
+	case "":
+		input.FeeTreatment = repository.FeesReverse
+	case repository.FeesReverse, repository.FeesLoss:
+	default:
+		return nil, fmt.Errorf("%w: feeTreatment must be %q or %q", ErrInvalidInput, repository.FeesReverse, repository.FeesLoss)
+	}
+	if input.Reason == "" {
+		input.Reason = "reward reversal"
+	}
+	if err := s.periods.checkOpen(ctx, time.Now()); err != nil {
+		return nil, err
+	}
+
+	adjustment, err := s.repo.ReverseReward(ctx, repository.ReversalParams{
+		RewardID:       input.RewardID,
+		Shares:         input.Shares,
+		FeeTreatment:   input.FeeTreatment,
+		Reason:         input.Reason,
+		IdempotencyKey: input.IdempotencyKey,
+	})
+	switch {
+	case err == nil:
+		return &ReversalResult{Adjustment: adjustment}, nil
+	case errors.Is(err, repository.ErrDuplicateAdjustment):
+		return s.replayReversal(ctx, input)
+	case errors.Is(err, repository.ErrRewardNotFound):
+		return nil, ErrNotFound
+	case errors.Is(err, repository.ErrReversalExceedsGrant), errors.Is(err, repository.ErrInsufficientShares),
+		errors.Is(err, repository.ErrRewardCashedOut):
+		return nil, fmt.Errorf("%w: %v", ErrUnprocessable, err)
+	default:
+		return nil, postingRejected(err)
+	}
+}
+
+// replayReversal returns the adjustment already stored under the key, as
+// long as it was for the same reward.
+func (s *RewardService) replayReversal(ctx context.Context, input ReversalInput) (*ReversalResult, error) {
+	existing, err := s.repo.AdjustmentByKey(ctx, input.IdempotencyKey)
+	if err != nil {
+		return nil, err
+	}
+	if existing.ReferenceEvent == nil || *existing.ReferenceEvent != input.RewardID {
+		return nil, fmt.Errorf("%w: idempotency key was used for a different reward", ErrConflict)
+	}
+	return &ReversalResult{Adjustment: existing, Replayed: true}, nil
+}
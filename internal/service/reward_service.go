@@ -3,6 +3,8 @@ package service
 import (
 	"context"
 	"errors"
+	"fmt"
+	"log"
 	"strings"
 	"time"
 
@@ -10,81 +12,265 @@ import (
 	"github.com/shopspring/decimal"
 
 	"github.com/stocky/backend/internal/config"
+	"github.com/stocky/backend/internal/fees"
 	"github.com/stocky/backend/internal/models"
 	"github.com/stocky/backend/internal/price"
 	"github.com/stocky/backend/internal/repository"
 )
 
+// RewardInput describes a grant. Exactly one of Shares and AmountInr is set;
+// an INR amount is converted to shares at the quote the reward is booked at.
+// Exchange selects the fee schedule and defaults to NSE.
 type RewardInput struct {
 	UserID     uuid.UUID
 	Symbol     string
+	Exchange   string
 	Shares     decimal.Decimal
+	AmountInr  decimal.Decimal
 	EventID    string
 	RewardedAt time.Time
+
+	// memo is appended to the reward's ledger memos when it is reposted out
+	// of a closed period.
+	memo string
 }
 
+// defaultExchange is used when a reward does not name an exchange.
+const defaultExchange = "NSE"
+
 type RewardService struct {
-	repo     *repository.Repository
+	repo     repository.Store
 	priceSvc *price.Service
 	fc       config.FeeConfig
+	rc       config.RewardConfig
+	pc       config.PriceConfig
+	treasury *TreasuryService
+	periods  *PeriodService
 }
 
-func NewRewardService(repo *repository.Repository, priceSvc *price.Service, fc config.FeeConfig) *RewardService {
-	return &RewardService{repo: repo, priceSvc: priceSvc, fc: fc}
+func NewRewardService(repo repository.Store, priceSvc *price.Service, treasury *TreasuryService, periods *PeriodService, fc config.FeeConfig, rc config.RewardConfig, pc config.PriceConfig) *RewardService {
+	return &RewardService{repo: repo, priceSvc: priceSvc, treasury: treasury, periods: periods, fc: fc, rc: rc, pc: pc}
 }
 
 func (s *RewardService) RewardUser(ctx context.Context, input RewardInput) (*models.RewardEvent, error) {
-	if input.UserID == uuid.Nil {
-		return nil, errors.New("user id is required")
+	input, err := normalizeRewardInput(input)
+	if err != nil {
+		return nil, err
 	}
-	if input.Symbol == "" {
-		return nil, errors.New("symbol is required")
+	if input.Symbol, err = resolveSymbol(ctx, s.repo, input.Symbol); err != nil {
+		return nil, err
 	}
-	if input.EventID == "" {
-		return nil, errors.New("event id is required")
+	input, err = s.periods.placeReward(ctx, input)
+	if err != nil {
+		return nil, err
 	}
-	if !input.Shares.GreaterThan(decimal.Zero) {
-		return nil, errors.New("shares must be positive")
+
+	quote, err := s.priceSvc.EnsureQuote(ctx, input.Symbol)
+	if err != nil {
+		return nil, err
 	}
-	if input.RewardedAt.IsZero() {
-		input.RewardedAt = time.Now().UTC()
+	quote, stale, err := s.freshQuote(ctx, quote)
+	if err != nil {
+		return nil, err
 	}
-
-	symbol := strings.ToUpper(input.Symbol)
-	quote, err := s.priceSvc.EnsureQuote(ctx, symbol)
+	input, err = s.resolveShares(input, quote.Price)
 	if err != nil {
 		return nil, err
 	}
-	cost := quote.Price.Mul(input.Shares).Round(4)
-	brokerage := applyBps(cost, s.fc.BrokerageBps)
-	taxes := applyBps(cost, s.fc.TaxBps)
-	total := cost.Add(brokerage).Add(taxes)
 
-	params := repository.RewardCreationParams{
-		UserID:     input.UserID,
-		Symbol:     symbol,
-		Shares:     input.Shares,
-		GrantPrice: quote.Price,
-		Brokerage:  brokerage,
-		Taxes:      taxes,
-		Total:      total,
-		EventKey:   input.EventID,
-		RewardedAt: input.RewardedAt,
+	params, err := s.rewardParams(input, quote.Price)
+	if err != nil {
+		return nil, err
 	}
-
 	reward, err := s.repo.CreateReward(ctx, params)
 	if err != nil {
 		if errors.Is(err, repository.ErrDuplicateReward) {
 			return nil, ErrConflict
 		}
-		return nil, err
+		return nil, postingRejected(err)
 	}
+	reward.PriceStale = stale
 	return reward, nil
 }
 
-func applyBps(amount decimal.Decimal, bps int) decimal.Decimal {
-	if bps == 0 {
-		return decimal.Zero
+// freshQuote returns quote if it is within MaxQuoteAge, otherwise a
+// synchronously refreshed one. If the refresh fails the stale policy either
+// rejects with ErrStalePrice or returns the old quote flagged as stale.
+func (s *RewardService) freshQuote(ctx context.Context, quote *models.PriceQuote) (*models.PriceQuote, bool, error) {
+	if s.pc.MaxQuoteAge <= 0 || time.Since(quote.FetchedAt) <= s.pc.MaxQuoteAge {
+		return quote, false, nil
+	}
+	refreshed, err := s.priceSvc.Refresh(ctx, quote.Symbol)
+	if err == nil {
+		return refreshed, false, nil
+	}
+	if s.pc.StalePolicy == config.StaleFlag {
+		log.Printf("reward: booking %s at stale quote from %s: %v", quote.Symbol, quote.FetchedAt.Format(time.RFC3339), err)
+		return quote, true, nil
+	}
+	return nil, false, fmt.Errorf("%w: %s quote from %s, refresh failed: %v",
+		ErrStalePrice, quote.Symbol, quote.FetchedAt.Format(time.RFC3339), err)
+}
+
+// maxAliasHops bounds how many renames resolveSymbol follows.
+const maxAliasHops = 10
+
+// resolveSymbol follows renames and mergers to the symbol that trades today,
+// so a reward named by an old ticker lands in the position it became. A
+// delisted symbol cannot be granted.
+func resolveSymbol(ctx context.Context, repo repository.Store, symbol string) (string, error) {
+	for hops := 0; hops < maxAliasHops; hops++ {
+		alias, err := repo.SymbolAlias(ctx, symbol)
+		if errors.Is(err, repository.ErrAliasNotFound) {
+			return symbol, nil
+		}
+		if err != nil {
+			return "", err
+		}
+		if alias.Target == "" {
+			return "", fmt.Errorf("%w: %s was delisted on %s", ErrUnprocessable, symbol,
+				alias.EffectiveAt.Format(time.DateOnly))
+		}
+		symbol = alias.Target
+	}
+	return "", fmt.Errorf("%w: %s is renamed more than %d times", ErrUnprocessable, symbol, maxAliasHops)
+}
+
+// normalizeRewardInput validates the input, upper-cases the symbol and
+// exchange and defaults the exchange to NSE and RewardedAt to now.
+func normalizeRewardInput(input RewardInput) (RewardInput, error) {
+	if input.UserID == uuid.Nil {
+		return input, fmt.Errorf("%w: user id is required", ErrInvalidInput)
+	}
+	if input.Symbol == "" {
+		return input, fmt.Errorf("%w: symbol is required", ErrInvalidInput)
+	}
+	if input.EventID == "" {
+		return input, fmt.Errorf("%w: event id is required", ErrInvalidInput)
+	}
+	hasShares, hasAmount := !input.Shares.IsZero(), !input.AmountInr.IsZero()
+	if hasShares == hasAmount {
+		return input, fmt.Errorf("%w: exactly one of shares and amount is required", ErrInvalidInput)
+	}
+	if hasShares && !input.Shares.IsPositive() {
+		return input, fmt.Errorf("%w: shares must be positive", ErrInvalidInput)
+	}
+	if hasAmount && !input.AmountInr.IsPositive() {
+		return input, fmt.Errorf("%w: amount must be positive", ErrInvalidInput)
+	}
+	if input.RewardedAt.IsZero() {
+		input.RewardedAt = time.Now().UTC()
+	}
+	input.Symbol = strings.ToUpper(input.Symbol)
+	input.Exchange = strings.ToUpper(input.Exchange)
+	if input.Exchange == "" {
+		input.Exchange = defaultExchange
+	}
+	return input, nil
+}
+
+// resolveShares converts an INR amount into shares at price, rounding with
+// the configured mode. When the amount includes fees it is divided by the
+// price grossed up by the schedule's effective fee rate on the amount; under
+// the default round-down mode shares are then trimmed until the booked total,
+// with brokerage minimums and caps applied, does not exceed it.
+func (s *RewardService) resolveShares(input RewardInput, price decimal.Decimal) (RewardInput, error) {
+	if input.AmountInr.IsZero() {
+		return input, nil
+	}
+	if !price.IsPositive() {
+		return input, fmt.Errorf("%w: no usable price for %s", ErrUnprocessable, input.Symbol)
+	}
+
+	unitCost := price
+	if s.rc.AmountIncludesFees {
+		charges, err := s.computeFees(input, input.AmountInr)
+		if err != nil {
+			return input, err
+		}
+		rate := charges.Total().DivRound(input.AmountInr, 16)
+		unitCost = price.Mul(decimal.NewFromInt(1).Add(rate))
+	}
+	shares := roundShares(input.AmountInr.DivRound(unitCost, 16), s.rc.ShareRounding, s.rc.ShareDecimals)
+
+	if s.rc.AmountIncludesFees && s.rc.ShareRounding == config.RoundDown {
+		step := decimal.New(1, -s.rc.ShareDecimals)
+		for i := 0; i < maxShareTrims && shares.IsPositive(); i++ {
+			cost := price.Mul(shares).Round(4)
+			charges, err := s.computeFees(input, cost)
+			if err != nil {
+				return input, err
+			}
+			if !cost.Add(charges.Total()).GreaterThan(input.AmountInr) {
+				break
+			}
+			shares = shares.Sub(step)
+		}
+	}
+	if !shares.IsPositive() {
+		return input, fmt.Errorf("%w: amount %s buys no shares of %s at %s", ErrInvalidInput, input.AmountInr, input.Symbol, price)
+	}
+	input.Shares = shares
+	return input, nil
+}
+
+// maxShareTrims bounds the step-down in resolveShares; the gross-up estimate
+// is normally off by at most a step or two, more only when a brokerage
+// minimum dominates a tiny amount.
+const maxShareTrims = 1000
+
+func roundShares(shares decimal.Decimal, mode string, places int32) decimal.Decimal {
+	switch mode {
+	case config.RoundUp:
+		return shares.RoundCeil(places)
+	case config.RoundHalfUp:
+		return shares.Round(places)
+	default:
+		return shares.RoundFloor(places)
+	}
+}
+
+// rewardParams applies the fee schedule to a normalized input priced at
+// price. Brokerage is booked as Brokerage and every statutory charge as Taxes,
+// with the itemised breakdown kept alongside.
+func (s *RewardService) rewardParams(input RewardInput, price decimal.Decimal) (repository.RewardCreationParams, error) {
+	cost := price.Mul(input.Shares).Round(4)
+	charges, err := s.computeFees(input, cost)
+	if err != nil {
+		return repository.RewardCreationParams{}, err
+	}
+	brokerage := charges.Brokerage
+	taxes := charges.Statutory()
+	total := cost.Add(brokerage).Add(taxes)
+	costBasis := cost
+	if s.fc.CapitalizeFees {
+		costBasis = total
+	}
+
+	return repository.RewardCreationParams{
+		UserID:        input.UserID,
+		Symbol:        input.Symbol,
+		Shares:        input.Shares,
+		GrantPrice:    price,
+		Brokerage:     brokerage,
+		Taxes:         taxes,
+		Total:         total,
+		CostBasis:     costBasis,
+		Fees:          &charges,
+		EventKey:      input.EventID,
+		RewardedAt:    input.RewardedAt,
+		RequireCash:   s.treasury.requireCash(),
+		LowBalanceInr: s.treasury.tc.LowBalanceInr,
+		Memo:          input.memo,
+	}, nil
+}
+
+// computeFees prices a purchase worth value under the schedule in force on
+// the input's exchange when the reward was granted.
+func (s *RewardService) computeFees(input RewardInput, value decimal.Decimal) (models.FeeBreakdown, error) {
+	breakdown, err := s.fc.Schedule.Compute(input.Exchange, input.RewardedAt, value)
+	if errors.Is(err, fees.ErrNoSchedule) {
+		return breakdown, fmt.Errorf("%w: %v", ErrInvalidInput, err)
 	}
-	return amount.Mul(decimal.NewFromInt(int64(bps))).Div(decimal.NewFromInt(10000)).Round(4)
+	return breakdown, err
 }
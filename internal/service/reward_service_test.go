@@ -0,0 +1,111 @@
+package service
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+
+	"github.com/stocky/backend/internal/config"
+	"github.com/stocky/backend/internal/fees"
+)
+
+func TestResolveShares(t *testing.T) {
+	flat := fees.Flat(50, 10)
+	minimum, err := fees.NewEngine([]fees.Schedule{
+		{ID: "min", Exchange: "NSE", BrokerageMin: decimal.NewFromInt(20)},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name         string
+		schedule     *fees.Engine
+		rounding     string
+		decimals     int32
+		includesFees bool
+		exchange     string
+		amount       string
+		price        string
+		want         string
+		wantErr      error
+	}{
+		{name: "round down", rounding: config.RoundDown, decimals: 4, amount: "1000", price: "300", want: "3.3333"},
+		{name: "round up", rounding: config.RoundUp, decimals: 4, amount: "1000", price: "300", want: "3.3334"},
+		{name: "round half up", rounding: config.RoundHalfUp, decimals: 0, amount: "1000", price: "400", want: "3"},
+		{name: "whole shares down", rounding: config.RoundDown, decimals: 0, amount: "1000", price: "400", want: "2"},
+		{name: "fees on top are ignored", rounding: config.RoundDown, decimals: 0, amount: "1000", price: "100", want: "10"},
+		{
+			name: "fees come out of the amount", rounding: config.RoundDown, decimals: 0, includesFees: true,
+			amount: "1006", price: "100", want: "10",
+		},
+		{
+			name: "a paisa short of the fees", rounding: config.RoundDown, decimals: 0, includesFees: true,
+			amount: "1005.99", price: "100", want: "9",
+		},
+		{
+			name: "brokerage minimum trims shares", schedule: minimum, rounding: config.RoundDown, decimals: 0, includesFees: true,
+			amount: "100", price: "1", want: "80",
+		},
+		{
+			name: "rounding up is not trimmed", schedule: minimum, rounding: config.RoundUp, decimals: 0, includesFees: true,
+			amount: "100", price: "1", want: "84",
+		},
+		{name: "amount buys nothing", rounding: config.RoundDown, decimals: 0, amount: "99", price: "100", wantErr: ErrInvalidInput},
+		{name: "no price", rounding: config.RoundDown, decimals: 4, amount: "1000", price: "0", wantErr: ErrUnprocessable},
+		{
+			name: "no fee schedule", rounding: config.RoundDown, decimals: 4, includesFees: true, exchange: "MCX",
+			amount: "1000", price: "100", wantErr: ErrInvalidInput,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			schedule := tt.schedule
+			if schedule == nil {
+				schedule = flat
+			}
+			s := &RewardService{
+				fc: config.FeeConfig{Schedule: schedule},
+				rc: config.RewardConfig{ShareRounding: tt.rounding, ShareDecimals: tt.decimals, AmountIncludesFees: tt.includesFees},
+			}
+			exchange := tt.exchange
+			if exchange == "" {
+				exchange = defaultExchange
+			}
+			input := RewardInput{
+				Symbol:     "INFY",
+				Exchange:   exchange,
+				AmountInr:  decimal.RequireFromString(tt.amount),
+				RewardedAt: time.Date(2024, 5, 10, 0, 0, 0, 0, time.UTC),
+			}
+
+			got, err := s.resolveShares(input, decimal.RequireFromString(tt.price))
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("err = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("resolveShares: %v", err)
+			}
+			if !got.Shares.Equal(decimal.RequireFromString(tt.want)) {
+				t.Errorf("shares = %s, want %s", got.Shares, tt.want)
+			}
+		})
+	}
+}
+
+func TestResolveSharesKeepsShares(t *testing.T) {
+	s := &RewardService{rc: config.RewardConfig{ShareRounding: config.RoundDown}}
+	input := RewardInput{Symbol: "INFY", Shares: decimal.RequireFromString("1.5")}
+	got, err := s.resolveShares(input, decimal.Zero)
+	if err != nil {
+		t.Fatalf("resolveShares: %v", err)
+	}
+	if !got.Shares.Equal(input.Shares) {
+		t.Errorf("shares = %s, want %s unchanged", got.Shares, input.Shares)
+	}
+}
@@ -2,6 +2,7 @@ package service
 
 import (
 	"context"
+	"errors"
 	"time"
 
 	"github.com/google/uuid"
@@ -13,11 +14,11 @@ import (
 )
 
 type StatsService struct {
-	repo     *repository.Repository
+	repo     repository.Store
 	priceSvc *price.Service
 }
 
-func NewStatsService(repo *repository.Repository, priceSvc *price.Service) *StatsService {
+func NewStatsService(repo repository.Store, priceSvc *price.Service) *StatsService {
 	return &StatsService{repo: repo, priceSvc: priceSvc}
 }
 
@@ -56,29 +57,37 @@ func (s *StatsService) UserStats(ctx context.Context, userID uuid.UUID) (*models
 	var (
 		portfolioValue decimal.Decimal
 		priceAsOf      time.Time
+		unpriced       []string
 	)
 	if len(symbols) > 0 {
+		// Symbols that cannot be quoted are reported instead of failing the
+		// summary; the value covers the rest.
 		quotes, err := s.priceSvc.QuotesFor(ctx, symbols)
-		if err != nil {
+		var failed price.FetchErrors
+		if err != nil && !errors.As(err, &failed) {
 			return nil, err
 		}
 		for _, pos := range positions {
 			quote, ok := quotes[pos.Symbol]
 			if !ok {
+				if pos.Shares.IsPositive() {
+					unpriced = append(unpriced, pos.Symbol)
+				}
 				continue
 			}
 			value := quote.Price.Mul(pos.Shares)
 			portfolioValue = portfolioValue.Add(value)
-			if quote.FetchedAt.After(priceAsOf) {
-				priceAsOf = quote.FetchedAt
+			if quote.AsOf.After(priceAsOf) {
+				priceAsOf = quote.AsOf
 			}
 		}
 	}
 
 	return &models.StatsSummary{
-		TotalsToday:    totals,
-		PortfolioValue: portfolioValue.Round(2),
-		PriceAsOf:      priceAsOf,
+		TotalsToday:     totals,
+		PortfolioValue:  portfolioValue.Round(2),
+		PriceAsOf:       priceAsOf,
+		UnpricedSymbols: unpriced,
 	}, nil
 }
 
@@ -0,0 +1,138 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+
+	"github.com/stocky/backend/internal/config"
+	"github.com/stocky/backend/internal/models"
+	"github.com/stocky/backend/internal/repository"
+)
+
+// Treasury alert page sizes.
+const (
+	DefaultAlertLimit = 50
+	MaxAlertLimit     = 500
+)
+
+// FundingInput pays cash into the reward account. Reference identifies the
+// transfer and makes the request idempotent.
+type FundingInput struct {
+	AmountInr decimal.Decimal
+	Reference string
+	Memo      string
+}
+
+// FundingResult is the funding recorded for a request. Replayed is set when
+// the reference had already been used for the same amount and the original
+// funding is returned instead of a new one.
+type FundingResult struct {
+	Funding    *models.TreasuryFunding `json:"funding"`
+	Replayed   bool                    `json:"replayed"`
+	BalanceInr decimal.Decimal         `json:"balanceInr"`
+}
+
+// TreasuryStatus is the available cash and the policy applied to it.
+type TreasuryStatus struct {
+	AsOf            time.Time       `json:"asOf"`
+	BalanceInr      decimal.Decimal `json:"balanceInr"`
+	LowBalanceInr   decimal.Decimal `json:"lowBalanceInr"`
+	BelowThreshold  bool            `json:"belowThreshold"`
+	OverdraftPolicy string          `json:"overdraftPolicy"`
+}
+
+// TreasuryService funds the cash account rewards are paid from and watches
+// its balance.
+type TreasuryService struct {
+	repo repository.Store
+	tc   config.TreasuryConfig
+}
+
+func NewTreasuryService(repo repository.Store, tc config.TreasuryConfig) *TreasuryService {
+	return &TreasuryService{repo: repo, tc: tc}
+}
+
+// Fund debits cash against treasury_funding for a transfer.
+func (s *TreasuryService) Fund(ctx context.Context, input FundingInput) (*FundingResult, error) {
+	input.Reference = strings.TrimSpace(input.Reference)
+	if input.Reference == "" {
+		return nil, fmt.Errorf("%w: reference is required", ErrInvalidInput)
+	}
+	if !input.AmountInr.IsPositive() {
+		return nil, fmt.Errorf("%w: amountInr must be positive", ErrInvalidInput)
+	}
+	if !input.AmountInr.Equal(input.AmountInr.Round(2)) {
+		return nil, fmt.Errorf("%w: amountInr must be in whole paise", ErrInvalidInput)
+	}
+
+	funding := models.TreasuryFunding{
+		ID:        uuid.New(),
+		AmountInr: input.AmountInr,
+		Reference: input.Reference,
+		Memo:      strings.TrimSpace(input.Memo),
+		CreatedAt: time.Now().UTC(),
+	}
+	result := &FundingResult{Funding: &funding}
+	err := s.repo.FundTreasury(ctx, funding)
+	if errors.Is(err, repository.ErrDuplicateFunding) {
+		existing, err := s.repo.FundingByReference(ctx, input.Reference)
+		if err != nil {
+			return nil, err
+		}
+		if !existing.AmountInr.Equal(input.AmountInr) {
+			return nil, fmt.Errorf("%w: reference %s was used for %s INR", ErrConflict, input.Reference, existing.AmountInr.StringFixed(2))
+		}
+		result = &FundingResult{Funding: existing, Replayed: true}
+	} else if err != nil {
+		return nil, postingRejected(err)
+	}
+
+	if result.BalanceInr, err = s.repo.CashBalance(ctx); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// Status returns the available cash balance.
+func (s *TreasuryService) Status(ctx context.Context) (*TreasuryStatus, error) {
+	balance, err := s.repo.CashBalance(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &TreasuryStatus{
+		AsOf:            time.Now().UTC(),
+		BalanceInr:      balance,
+		LowBalanceInr:   s.tc.LowBalanceInr,
+		BelowThreshold:  s.tc.LowBalanceInr.IsPositive() && balance.LessThan(s.tc.LowBalanceInr),
+		OverdraftPolicy: s.tc.OverdraftPolicy,
+	}, nil
+}
+
+// Alerts returns the most recent low-balance alerts, newest first.
+func (s *TreasuryService) Alerts(ctx context.Context, limit int) ([]models.TreasuryAlert, error) {
+	switch {
+	case limit == 0:
+		limit = DefaultAlertLimit
+	case limit < 0 || limit > MaxAlertLimit:
+		return nil, fmt.Errorf("%w: limit must be between 1 and %d", ErrInvalidInput, MaxAlertLimit)
+	}
+	items, err := s.repo.ListTreasuryAlerts(ctx, limit)
+	if err != nil {
+		return nil, err
+	}
+	if items == nil {
+		items = []models.TreasuryAlert{}
+	}
+	return items, nil
+}
+
+// requireCash reports whether rewards must be covered by the cash balance.
+func (s *TreasuryService) requireCash() bool {
+	return s.tc.OverdraftPolicy == config.OverdraftReject
+}
@@ -0,0 +1,7 @@
+ALTER TABLE user_positions
+    ADD COLUMN total_cost_inr NUMERIC(18,4) NOT NULL DEFAULT 0,
+    ADD COLUMN first_acquired_at TIMESTAMPTZ;
+
+UPDATE user_positions
+SET total_cost_inr = ROUND(net_shares * avg_cost_inr, 4)
+WHERE total_cost_inr = 0;
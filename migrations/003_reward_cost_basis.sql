@@ -0,0 +1,10 @@
+ALTER TABLE reward_events
+    ADD COLUMN cost_basis_inr NUMERIC(18,4);
+
+UPDATE reward_events
+SET cost_basis_inr = ROUND(shares * granted_price_inr, 4)
+WHERE cost_basis_inr IS NULL;
+
+ALTER TABLE reward_events
+    ALTER COLUMN cost_basis_inr SET NOT NULL,
+    ALTER COLUMN cost_basis_inr SET DEFAULT 0;
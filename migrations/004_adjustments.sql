@@ -0,0 +1,17 @@
+-- Ledger entries now belong to either a reward event or an adjustment.
+ALTER TABLE ledger_entries
+    DROP CONSTRAINT IF EXISTS ledger_entries_event_id_fkey;
+
+ALTER TABLE adjustments
+    ADD COLUMN kind TEXT NOT NULL DEFAULT 'manual',
+    ADD COLUMN cost_inr NUMERIC(18,4) NOT NULL DEFAULT 0,
+    ADD COLUMN cash_inr NUMERIC(18,4) NOT NULL DEFAULT 0,
+    ADD COLUMN fee_treatment TEXT,
+    ADD COLUMN idempotency_key TEXT UNIQUE;
+
+CREATE INDEX idx_adjustments_reference ON adjustments (reference_event);
+CREATE INDEX idx_adjustments_user_symbol ON adjustments (user_id, symbol, created_at);
+
+INSERT INTO ledger_accounts (code, type)
+VALUES ('reversal_loss', 'expense')
+ON CONFLICT (code) DO NOTHING;
@@ -0,0 +1,12 @@
+-- Itemised charges computed from the fee schedule; NULL for rewards booked
+-- with the flat brokerage/tax split.
+ALTER TABLE reward_events
+    ADD COLUMN fees JSONB;
+
+INSERT INTO ledger_accounts (code, type)
+VALUES ('gst_expense', 'expense'),
+       ('stt_expense', 'expense'),
+       ('exchange_charges_expense', 'expense'),
+       ('sebi_fees_expense', 'expense'),
+       ('stamp_duty_expense', 'expense')
+ON CONFLICT (code) DO NOTHING;
@@ -0,0 +1,3 @@
+-- Support reading postings back per event and paging through an account.
+CREATE INDEX idx_ledger_entries_event ON ledger_entries (event_id);
+CREATE INDEX idx_ledger_entries_account_time ON ledger_entries (account_id, created_at, id);
@@ -0,0 +1,10 @@
+-- Reports written by the ledger invariant checker; the newest is served by
+-- GET /admin/ledger/trial-balance.
+CREATE TABLE trial_balances (
+    id BIGSERIAL PRIMARY KEY,
+    checked_at TIMESTAMPTZ NOT NULL,
+    balanced BOOLEAN NOT NULL,
+    report JSONB NOT NULL
+);
+
+CREATE INDEX idx_trial_balances_checked_at ON trial_balances (checked_at);
@@ -0,0 +1,21 @@
+-- Tamper-evident hash chains over reward_events and ledger_entries. Rows
+-- written before this migration stay unchained (NULL chain_seq).
+ALTER TABLE reward_events
+    ADD COLUMN chain_seq BIGINT UNIQUE,
+    ADD COLUMN prev_hash TEXT,
+    ADD COLUMN hash TEXT;
+
+ALTER TABLE ledger_entries
+    ADD COLUMN chain_seq BIGINT UNIQUE,
+    ADD COLUMN prev_hash TEXT,
+    ADD COLUMN hash TEXT;
+
+CREATE TABLE chain_heads (
+    chain TEXT PRIMARY KEY,
+    chain_seq BIGINT NOT NULL,
+    hash TEXT NOT NULL
+);
+
+INSERT INTO chain_heads (chain, chain_seq, hash)
+VALUES ('reward_events', 0, ''),
+       ('ledger_entries', 0, '');
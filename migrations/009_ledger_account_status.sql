@@ -0,0 +1,10 @@
+-- Closed accounts accept no further postings. Accounts opened by the
+-- application are INR.
+ALTER TABLE ledger_accounts
+    ADD COLUMN closed_at TIMESTAMPTZ;
+
+UPDATE ledger_accounts SET currency = 'INR' WHERE currency IS NULL;
+
+ALTER TABLE ledger_accounts
+    ALTER COLUMN currency SET DEFAULT 'INR',
+    ALTER COLUMN currency SET NOT NULL;
@@ -0,0 +1,23 @@
+-- Cash paid into the reward account, one row per transfer reference.
+CREATE TABLE treasury_fundings (
+    id UUID PRIMARY KEY,
+    amount_inr NUMERIC(18,4) NOT NULL CHECK (amount_inr > 0),
+    reference TEXT NOT NULL UNIQUE,
+    memo TEXT,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- Low-balance alerts raised after a reward takes cash under the threshold.
+CREATE TABLE treasury_alerts (
+    id UUID PRIMARY KEY,
+    event_id UUID NOT NULL,
+    balance_inr NUMERIC(18,4) NOT NULL,
+    threshold_inr NUMERIC(18,4) NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_treasury_alerts_created ON treasury_alerts (created_at);
+
+INSERT INTO ledger_accounts (code, type)
+VALUES ('treasury_funding', 'equity')
+ON CONFLICT (code) DO NOTHING;
@@ -0,0 +1,12 @@
+-- Month-end closes. A period row exists once it has been closed; balances is
+-- the closing snapshot and history every close and reopen, oldest first.
+CREATE TABLE ledger_periods (
+    period TEXT PRIMARY KEY,
+    starts_at TIMESTAMPTZ NOT NULL,
+    ends_at TIMESTAMPTZ NOT NULL,
+    status TEXT NOT NULL CHECK (status IN ('open', 'closed')),
+    closed_at TIMESTAMPTZ,
+    closed_by TEXT,
+    balances JSONB NOT NULL DEFAULT '[]',
+    history JSONB NOT NULL DEFAULT '[]'
+);
@@ -0,0 +1,21 @@
+-- Splits and bonus issues. An action is applied once, on or after its
+-- ex-date; holders and shares_issued record what the application did.
+CREATE TABLE corporate_actions (
+    id UUID PRIMARY KEY,
+    symbol TEXT NOT NULL,
+    kind TEXT NOT NULL CHECK (kind IN ('split', 'bonus')),
+    ratio_new BIGINT NOT NULL CHECK (ratio_new > 0),
+    ratio_held BIGINT NOT NULL CHECK (ratio_held > 0),
+    factor NUMERIC(18,6) NOT NULL,
+    record_date TIMESTAMPTZ NOT NULL,
+    ex_date TIMESTAMPTZ NOT NULL,
+    status TEXT NOT NULL CHECK (status IN ('pending', 'applied')),
+    note TEXT,
+    created_at TIMESTAMPTZ NOT NULL,
+    applied_at TIMESTAMPTZ,
+    holders INT NOT NULL DEFAULT 0,
+    shares_issued NUMERIC(18,6) NOT NULL DEFAULT 0,
+    UNIQUE (symbol, ex_date)
+);
+
+CREATE INDEX idx_corporate_actions_status ON corporate_actions (status, ex_date);
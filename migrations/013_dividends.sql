@@ -0,0 +1,52 @@
+-- Cash dividends. A dividend is paid once, on or after its pay date, to the
+-- users holding the symbol on the record date.
+CREATE TABLE dividends (
+    id UUID PRIMARY KEY,
+    symbol TEXT NOT NULL,
+    amount_per_share_inr NUMERIC(18,4) NOT NULL CHECK (amount_per_share_inr > 0),
+    record_date TIMESTAMPTZ NOT NULL,
+    pay_date TIMESTAMPTZ NOT NULL,
+    tds_rate_pct NUMERIC(7,4) NOT NULL CHECK (tds_rate_pct >= 0 AND tds_rate_pct <= 100),
+    status TEXT NOT NULL CHECK (status IN ('announced', 'paid')),
+    note TEXT,
+    created_at TIMESTAMPTZ NOT NULL,
+    paid_at TIMESTAMPTZ,
+    holders INT NOT NULL DEFAULT 0,
+    gross_inr NUMERIC(18,4) NOT NULL DEFAULT 0,
+    tds_inr NUMERIC(18,4) NOT NULL DEFAULT 0,
+    net_inr NUMERIC(18,4) NOT NULL DEFAULT 0,
+    UNIQUE (symbol, record_date)
+);
+
+CREATE INDEX idx_dividends_status ON dividends (status, pay_date);
+
+CREATE TABLE dividend_payments (
+    id UUID PRIMARY KEY,
+    dividend_id UUID NOT NULL REFERENCES dividends(id),
+    user_id UUID NOT NULL REFERENCES users(id),
+    symbol TEXT NOT NULL,
+    shares NUMERIC(18,6) NOT NULL,
+    amount_per_share_inr NUMERIC(18,4) NOT NULL,
+    gross_inr NUMERIC(18,4) NOT NULL,
+    tds_inr NUMERIC(18,4) NOT NULL,
+    net_inr NUMERIC(18,4) NOT NULL,
+    record_date TIMESTAMPTZ NOT NULL,
+    paid_at TIMESTAMPTZ NOT NULL,
+    UNIQUE (dividend_id, user_id)
+);
+
+CREATE INDEX idx_dividend_payments_user ON dividend_payments (user_id, paid_at);
+
+-- INR owed to each user; the sum of balances is the dividend_payable
+-- account's credit balance.
+CREATE TABLE user_wallets (
+    user_id UUID PRIMARY KEY REFERENCES users(id),
+    balance_inr NUMERIC(18,4) NOT NULL,
+    updated_at TIMESTAMPTZ NOT NULL
+);
+
+INSERT INTO ledger_accounts (code, type)
+VALUES ('dividend_receivable', 'asset'),
+       ('dividend_payable', 'liability'),
+       ('tds_payable', 'liability')
+ON CONFLICT (code) DO NOTHING;
@@ -0,0 +1,28 @@
+-- Renames, mergers and delistings. Positions in a symbol that stops trading
+-- move to the symbol it became or stay frozen at the last price, and the old
+-- symbol resolves through symbol_aliases.
+ALTER TABLE corporate_actions
+    DROP CONSTRAINT corporate_actions_kind_check,
+    ADD CONSTRAINT corporate_actions_kind_check
+        CHECK (kind IN ('split', 'bonus', 'rename', 'merger', 'delisting')),
+    ADD COLUMN new_symbol TEXT,
+    ADD COLUMN cash_price_inr NUMERIC(18,4) NOT NULL DEFAULT 0 CHECK (cash_price_inr >= 0),
+    ADD COLUMN cash_paid_inr NUMERIC(18,4) NOT NULL DEFAULT 0,
+    ADD COLUMN frozen_price_inr NUMERIC(18,4) NOT NULL DEFAULT 0;
+
+-- target is NULL for a delisting.
+CREATE TABLE symbol_aliases (
+    symbol TEXT PRIMARY KEY,
+    target TEXT,
+    kind TEXT NOT NULL CHECK (kind IN ('rename', 'merger', 'delisting')),
+    action_id UUID NOT NULL REFERENCES corporate_actions(id),
+    effective_at TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX idx_symbol_aliases_target ON symbol_aliases (target);
+
+INSERT INTO ledger_accounts (code, type)
+VALUES ('corporate_action_receivable', 'asset'),
+       ('cash_in_lieu_payable', 'liability'),
+       ('corporate_action_loss', 'expense')
+ON CONFLICT (code) DO NOTHING;
@@ -0,0 +1,4 @@
+-- Symbols the price job valued at a cached quote because the provider could
+-- not refresh them.
+ALTER TABLE daily_holdings
+    ADD COLUMN stale_symbols TEXT[] NOT NULL DEFAULT '{}';
@@ -0,0 +1,7 @@
+-- The provider's timestamp for a cached quote, kept apart from fetched_at so
+-- an older quote cannot replace a newer one while staleness is still
+-- measured from the last fetch. Existing quotes stored the provider's time
+-- in fetched_at.
+ALTER TABLE price_quotes ADD COLUMN as_of TIMESTAMPTZ;
+UPDATE price_quotes SET as_of = fetched_at;
+ALTER TABLE price_quotes ALTER COLUMN as_of SET NOT NULL;
@@ -0,0 +1,19 @@
+-- How many rows of each chain predate 008_hash_chain. verify-chain accepts
+-- only that many unchained rows, so a row inserted later without chain
+-- columns is reported as a break rather than as legacy. Rows created after
+-- the first chained row are not counted.
+ALTER TABLE chain_heads ADD COLUMN legacy_records BIGINT NOT NULL DEFAULT 0;
+
+UPDATE chain_heads SET legacy_records = (
+    SELECT COUNT(*) FROM reward_events
+    WHERE chain_seq IS NULL
+      AND created_at < COALESCE((SELECT MIN(created_at) FROM reward_events WHERE chain_seq IS NOT NULL), 'infinity')
+)
+WHERE chain = 'reward_events';
+
+UPDATE chain_heads SET legacy_records = (
+    SELECT COUNT(*) FROM ledger_entries
+    WHERE chain_seq IS NULL
+      AND created_at < COALESCE((SELECT MIN(created_at) FROM ledger_entries WHERE chain_seq IS NOT NULL), 'infinity')
+)
+WHERE chain = 'ledger_entries';
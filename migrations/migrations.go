@@ -0,0 +1,9 @@
+// Package migrations embeds the versioned PostgreSQL schema files so the
+// migrate tool and the server can apply them without a checkout on disk.
+// Files are named NNN_description.sql and applied in version order.
+package migrations
+
+import "embed"
+
+//go:embed *.sql
+var FS embed.FS